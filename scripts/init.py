@@ -16,7 +16,7 @@ def generate_config_file(
         rd_server_v, db_name_v, redis_ip_v, redis_port_v, redis_user_v,
         redis_pass_v, mongo_ip_v, mongo_port_v, mongo_user_v, mongo_pass_v,
         cc_url_v, paas_url_v, auth_address, auth_app_code,
-        auth_app_secret, auth_enabled, auth_scheme
+        auth_app_secret, auth_enabled, auth_scheme, auth_mode, auth_admins
 ):
     output = os.getcwd() + "/cmdb_adminserver/configures/"
     context = dict(
@@ -39,14 +39,50 @@ def generate_config_file(
         auth_app_code=auth_app_code,
         auth_app_secret=auth_app_secret,
         auth_enabled=auth_enabled,
-        auth_scheme=auth_scheme
+        auth_scheme=auth_scheme,
+        auth_mode=auth_mode,
+        auth_admins=auth_admins
     )
     if not os.path.exists(output):
         os.mkdir(output)
 
     # apiserver.conf
     apiserver_file_template_str = '''
-    '''
+[mongodb]
+host = $mongo_host
+usr = $mongo_user
+pwd = $mongo_pass
+database = $db
+port = $mongo_port
+maxOpenConns = 3000
+maxIDleConns = 1000
+mechanism = SCRAM-SHA-1
+
+[redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+maxOpenConns = 3000
+maxIDleConns = 1000
+
+[auth]
+address = $auth_address
+appCode = $auth_app_code
+appSecret = $auth_app_secret
+enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
+cacheEnable = false
+cacheTTL = 30
+cacheMaxSize = 10000
+cacheBackend = memory
+decisionLogEnable = false
+decisionLogGrantedSampleRate = 0.01
+decisionLogDeniedSampleRate = 1
+decisionLogRetentionDays = 30
+'''
 
     template = FileTemplate(apiserver_file_template_str)
     result = template.substitute(**context)
@@ -65,11 +101,26 @@ maxOpenConns = 3000
 maxIdleConns = 1000
 mechanism=SCRAM-SHA-1
 
+[redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+maxOpenConns = 3000
+maxIDleConns = 1000
+
 [auth]
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
+cacheEnable = false
+cacheTTL = 30
+cacheMaxSize = 10000
+cacheBackend = memory
 '''
     template = FileTemplate(auditcontroller_file_template_str)
     result = template.substitute(**context)
@@ -143,6 +194,22 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+
+[event]
+secretKey =
+historyRetention = 72
+deliveryRetention = 168
+sinkFileDir =
+sinkFileMaxSize = 100
+sinkFileMaxBackups = 5
+
+[auth]
+address = $auth_address
+appCode = $auth_app_code
+appSecret = $auth_app_secret
+enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
 '''
 
     template = FileTemplate(eventserver_file_template_str)
@@ -167,11 +234,27 @@ port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
 
+[mongodb]
+host = $mongo_host
+usr = $mongo_user
+pwd = $mongo_pass
+database = $db
+port = $mongo_port
+maxOpenConns = 3000
+maxIDleConns = 1000
+mechanism = SCRAM-SHA-1
+
 [auth]
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
+cacheEnable = false
+cacheTTL = 30
+cacheMaxSize = 10000
+cacheBackend = memory
 '''
     template = FileTemplate(host_file_template_str)
     result = template.substitute(**context)
@@ -237,11 +320,26 @@ res = conf/errors
 [language]
 res = conf/language
 
+[redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+maxOpenConns = 3000
+maxIDleConns = 1000
+
 [auth]
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
+cacheEnable = false
+cacheTTL = 30
+cacheMaxSize = 10000
+cacheBackend = memory
 enableSync = false
     '''
 
@@ -299,6 +397,9 @@ database = 0
 port = $redis_port
 maxOpenConns = 3000
 maxIDleConns = 1000
+
+[recycle]
+retentionDays = 30
 '''
 
     template = FileTemplate(coreservice_file_template_str)
@@ -308,6 +409,16 @@ maxIDleConns = 1000
 
     # proc.conf
     proc_file_template_str = '''
+[mongodb]
+host = $mongo_host
+usr = $mongo_user
+pwd = $mongo_pass
+database = $db
+port = $mongo_port
+maxOpenConns = 3000
+maxIDleConns = 1000
+mechanism = SCRAM-SHA-1
+
 [redis]
 host = $redis_host
 port = $redis_port
@@ -315,6 +426,14 @@ usr = $redis_user
 pwd = $redis_pass
 port = $redis_port
 database = 0
+
+[auth]
+address = $auth_address
+appCode = $auth_app_code
+appSecret = $auth_app_secret
+enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
 '''
     template = FileTemplate(proc_file_template_str)
     result = template.substitute(**context)
@@ -395,11 +514,26 @@ mechanism = SCRAM-SHA-1
 [level]
 businessTopoMax = 7
 
+[redis]
+host = $redis_host
+port = $redis_port
+usr = $redis_user
+pwd = $redis_pass
+database = 0
+maxOpenConns = 3000
+maxIDleConns = 1000
+
 [auth]
 address = $auth_address
 appCode = $auth_app_code
 appSecret = $auth_app_secret
 enable = $auth_enabled
+mode = $auth_mode
+admins = $auth_admins
+cacheEnable = false
+cacheTTL = 30
+cacheMaxSize = 10000
+cacheBackend = memory
 '''
 
     template = FileTemplate(topo_file_template_str)
@@ -492,6 +626,9 @@ def main(argv):
         "auth_enabled": "false",
         "auth_app_code": "bk_cmdb",
         "auth_app_secret": "",
+        # local auth options
+        "auth_mode": "iam",
+        "auth_admins": "admin",
     }
 
     server_ports = {
@@ -517,7 +654,7 @@ def main(argv):
         "mongo_user=", "mongo_pass=", "blueking_cmdb_url=",
         "blueking_paas_url=", "listen_port=", "auth_address=",
         "auth_app_code=", "auth_app_secret=", "auth_enabled=",
-        "auth_scheme="
+        "auth_scheme=", "auth_mode=", "auth_admins="
     ]
     usage = '''
     usage:
@@ -538,6 +675,8 @@ def main(argv):
       --auth_address       <auth_address>         iam address
       --auth_app_code      <auth_app_code>        app code for iam, default bk_cmdb
       --auth_app_secret    <auth_app_secret>      app code for iam
+      --auth_mode          <auth_mode>            the authorizer, iam or local, default iam
+      --auth_admins        <auth_admins>          the administrators of local auth mode, separated by comma, default admin
     '''
     try:
         opts, _ = getopt.getopt(argv, "hd:D:r:p:x:s:m:P:X:S:u:U:a:l:", arr)
@@ -606,6 +745,12 @@ def main(argv):
         elif opt in ("--auth_app_secret",):
             auth["auth_app_secret"] = arg
             print("auth_app_secret:", auth["auth_app_secret"])
+        elif opt in ("--auth_mode",):
+            auth["auth_mode"] = arg
+            print("auth_mode:", auth["auth_mode"])
+        elif opt in ("--auth_admins",):
+            auth["auth_admins"] = arg
+            print("auth_admins:", auth["auth_admins"])
 
     if 0 == len(rd_server):
         print('please input the ZooKeeper address, eg:127.0.0.1:2181')
@@ -652,7 +797,11 @@ def main(argv):
         print('auth_enabled value invalid, can only be `true` or `false`')
         sys.exit()
 
-    if auth["auth_scheme"] == "iam" and auth["auth_enabled"] == 'true':
+    if auth["auth_mode"] not in ["iam", "local"]:
+        print('auth_mode can only be iam or local')
+        sys.exit()
+
+    if auth["auth_scheme"] == "iam" and auth["auth_enabled"] == 'true' and auth["auth_mode"] == "iam":
         if not auth["auth_address"]:
             print("auth_address can't be empty when iam auth enabled")
             sys.exit()
@@ -285,6 +285,20 @@ func (asst *association) ReadInstAssociation(ctx context.Context, h http.Header,
 	return
 }
 
+func (asst *association) ReadInstAssociationMappingViolation(ctx context.Context, h http.Header, input *metadata.SearchAsstMappingViolationRequest) (resp *metadata.SearchAsstMappingViolationResult, err error) {
+	resp = new(metadata.SearchAsstMappingViolationResult)
+	subPath := "/read/instanceassociation/mapping_violation"
+
+	err = asst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
 func (asst *association) DeleteInstAssociation(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error) {
 	resp = new(metadata.DeletedOptionResult)
 	subPath := "/delete/instanceassociation"
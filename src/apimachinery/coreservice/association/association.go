@@ -41,6 +41,7 @@ type AssociationClientInterface interface {
 	SetInstAssociation(ctx context.Context, h http.Header, input *metadata.SetOneInstanceAssociation) (resp *metadata.SetOptionResult, err error)
 	UpdateInstAssociation(ctx context.Context, h http.Header, input *metadata.UpdateOption) (resp *metadata.UpdatedOptionResult, err error)
 	ReadInstAssociation(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.ReadInstAssociationResult, err error)
+	ReadInstAssociationMappingViolation(ctx context.Context, h http.Header, input *metadata.SearchAsstMappingViolationRequest) (resp *metadata.SearchAsstMappingViolationResult, err error)
 	DeleteInstAssociation(ctx context.Context, h http.Header, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
 }
 
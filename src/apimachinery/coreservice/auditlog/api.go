@@ -14,6 +14,7 @@ package auditlog
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 
 	"configcenter/src/common/metadata"
@@ -49,3 +50,17 @@ func (inst *auditlog) SearchAuditLog(ctx context.Context, h http.Header, param m
 		Into(resp)
 	return
 }
+
+func (inst *auditlog) SearchInstanceHistory(ctx context.Context, h http.Header, objID string, param metadata.InstanceHistoryOption) (resp *metadata.InstanceHistoryResult, err error) {
+	resp = new(metadata.InstanceHistoryResult)
+	subPath := fmt.Sprintf("/read/auditlog/history/object/%s", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(param).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -23,6 +23,7 @@ import (
 type AuditClientInterface interface {
 	SaveAuditLog(ctx context.Context, h http.Header, logs ...metadata.SaveAuditLogParams) (*metadata.Response, error)
 	SearchAuditLog(ctx context.Context, h http.Header, param metadata.QueryInput) (*metadata.AuditQueryResult, error)
+	SearchInstanceHistory(ctx context.Context, h http.Header, objID string, param metadata.InstanceHistoryOption) (*metadata.InstanceHistoryResult, error)
 }
 
 func NewAuditClientInterface(client rest.ClientInterface) AuditClientInterface {
@@ -117,3 +117,31 @@ func (inst *instance) DeleteInstanceCascade(ctx context.Context, h http.Header,
 		Into(resp)
 	return
 }
+
+func (inst *instance) DeleteInstanceImpact(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeleteImpactResult, err error) {
+	resp = new(metadata.DeleteImpactResult)
+	subPath := fmt.Sprintf("/read/model/%s/instance/delete_impact", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (inst *instance) RecomputeInstance(ctx context.Context, h http.Header, objID string, input *metadata.RecomputeOption) (resp *metadata.UpdatedOptionResult, err error) {
+	resp = new(metadata.UpdatedOptionResult)
+	subPath := fmt.Sprintf("/update/model/%s/instance/computed", objID)
+
+	err = inst.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
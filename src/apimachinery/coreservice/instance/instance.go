@@ -28,6 +28,8 @@ type InstanceClientInterface interface {
 	ReadInstance(ctx context.Context, h http.Header, objID string, input *metadata.QueryCondition) (resp *metadata.QueryConditionResult, err error)
 	DeleteInstance(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
 	DeleteInstanceCascade(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeletedOptionResult, err error)
+	DeleteInstanceImpact(ctx context.Context, h http.Header, objID string, input *metadata.DeleteOption) (resp *metadata.DeleteImpactResult, err error)
+	RecomputeInstance(ctx context.Context, h http.Header, objID string, input *metadata.RecomputeOption) (resp *metadata.UpdatedOptionResult, err error)
 }
 
 func NewInstanceClientInterface(client rest.ClientInterface) InstanceClientInterface {
@@ -468,3 +468,100 @@ func (m *model) ReadModelAttrUnique(ctx context.Context, h http.Header, inputPar
 		Into(&resp)
 	return
 }
+
+func (m *model) ReadModelVersion(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (resp *metadata.ReadModelVersionResult, err error) {
+	resp = new(metadata.ReadModelVersionResult)
+	subPath := fmt.Sprintf("/read/model/%s/versions", objID)
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) DiffModelVersion(ctx context.Context, h http.Header, objID string, input metadata.DiffModelVersionOption) (resp *metadata.DiffModelVersionResult, err error) {
+	resp = new(metadata.DiffModelVersionResult)
+	subPath := fmt.Sprintf("/read/model/%s/versions/diff", objID)
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) ExportModelVersion(ctx context.Context, h http.Header, objID string, version int64) (resp *metadata.ExportModelVersionResult, err error) {
+	resp = new(metadata.ExportModelVersionResult)
+	subPath := fmt.Sprintf("/read/model/%s/versions/%d/export", objID, version)
+
+	err = m.client.Post().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) RollbackModelVersion(ctx context.Context, h http.Header, objID string, input metadata.RollbackModelVersionOption) (resp *metadata.RollbackModelVersionResult, err error) {
+	resp = new(metadata.RollbackModelVersionResult)
+	subPath := fmt.Sprintf("/update/model/%s/versions/rollback", objID)
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) ExportModelBundle(ctx context.Context, h http.Header, input metadata.ExportModelBundleOption) (resp *metadata.ExportModelBundleResult, err error) {
+	resp = new(metadata.ExportModelBundleResult)
+	subPath := "/read/model/bundle/export"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) PlanModelBundle(ctx context.Context, h http.Header, input metadata.ModelBundleOption) (resp *metadata.PlanModelBundleResult, err error) {
+	resp = new(metadata.PlanModelBundleResult)
+	subPath := "/read/model/bundle/plan"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (m *model) ApplyModelBundle(ctx context.Context, h http.Header, input metadata.ModelBundleOption) (resp *metadata.ApplyModelBundleResult, err error) {
+	resp = new(metadata.ApplyModelBundleResult)
+	subPath := "/update/model/bundle/apply"
+
+	err = m.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
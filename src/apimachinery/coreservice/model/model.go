@@ -58,6 +58,15 @@ type ModelClientInterface interface {
 	UpdateModelAttrUnique(ctx context.Context, h http.Header, objID string, id uint64, data metadata.UpdateModelAttrUnique) (*metadata.UpdatedOptionResult, error)
 	DeleteModelAttrUnique(ctx context.Context, h http.Header, objID string, id uint64, data metadata.DeleteModelAttrUnique) (*metadata.DeletedOptionResult, error)
 	ReadModelAttrUnique(ctx context.Context, h http.Header, inputParam metadata.QueryCondition) (*metadata.ReadModelUniqueResult, error)
+
+	ReadModelVersion(ctx context.Context, h http.Header, objID string, input metadata.QueryCondition) (*metadata.ReadModelVersionResult, error)
+	DiffModelVersion(ctx context.Context, h http.Header, objID string, input metadata.DiffModelVersionOption) (*metadata.DiffModelVersionResult, error)
+	ExportModelVersion(ctx context.Context, h http.Header, objID string, version int64) (*metadata.ExportModelVersionResult, error)
+	RollbackModelVersion(ctx context.Context, h http.Header, objID string, input metadata.RollbackModelVersionOption) (*metadata.RollbackModelVersionResult, error)
+
+	ExportModelBundle(ctx context.Context, h http.Header, input metadata.ExportModelBundleOption) (*metadata.ExportModelBundleResult, error)
+	PlanModelBundle(ctx context.Context, h http.Header, input metadata.ModelBundleOption) (*metadata.PlanModelBundleResult, error)
+	ApplyModelBundle(ctx context.Context, h http.Header, input metadata.ModelBundleOption) (*metadata.ApplyModelBundleResult, error)
 }
 
 func NewModelClientInterface(client rest.ClientInterface) ModelClientInterface {
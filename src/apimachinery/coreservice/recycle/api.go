@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"context"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (r *recycle) SearchRecycleItem(ctx context.Context, h http.Header, input *metadata.QueryCondition) (resp *metadata.SearchRecycleItemResult, err error) {
+	resp = new(metadata.SearchRecycleItemResult)
+	subPath := "/read/recycle/item"
+
+	err = r.client.Post().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycle) RestoreRecycleItem(ctx context.Context, h http.Header, input *metadata.RecycleOption) (resp *metadata.RestoreRecycleItemResult, err error) {
+	resp = new(metadata.RestoreRecycleItemResult)
+	subPath := "/update/recycle/restore"
+
+	err = r.client.Put().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (r *recycle) PurgeRecycleItem(ctx context.Context, h http.Header, input *metadata.RecycleOption) (resp *metadata.DeletedOptionResult, err error) {
+	resp = new(metadata.DeletedOptionResult)
+	subPath := "/delete/recycle/purge"
+
+	err = r.client.Delete().
+		WithContext(ctx).
+		Body(input).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
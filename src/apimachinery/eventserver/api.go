@@ -103,3 +103,31 @@ func (e *eventServer) Rebook(ctx context.Context, ownerID string, appID string,
 		Into(resp)
 	return
 }
+
+func (e *eventServer) RotateSecret(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamSubscriptionSecret) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/subscribe/secret/%s/%s", ownerID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) Watch(ctx context.Context, ownerID string, h http.Header, dat metadata.ParamEventWatch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/watch/%s", ownerID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package eventserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"configcenter/src/common/metadata"
+)
+
+func (e *eventServer) SearchDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterSearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/search/%s/%s", ownerID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) GetDeadLetter(ctx context.Context, ownerID string, subscribeID string, deadLetterID string, h http.Header) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/%s/%s/%s", ownerID, subscribeID, deadLetterID)
+
+	err = e.client.Get().
+		WithContext(ctx).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) ReplayDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterIDs) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/replay/%s/%s", ownerID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) PurgeDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterIDs) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/deadletter/%s/%s", ownerID, subscribeID)
+
+	err = e.client.Delete().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
+
+func (e *eventServer) SearchDelivery(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeliverySearch) (resp *metadata.Response, err error) {
+	resp = new(metadata.Response)
+	subPath := fmt.Sprintf("/delivery/search/%s/%s", ownerID, subscribeID)
+
+	err = e.client.Post().
+		WithContext(ctx).
+		Body(dat).
+		SubResource(subPath).
+		WithHeaders(h).
+		Do().
+		Into(resp)
+	return
+}
@@ -29,6 +29,16 @@ type EventServerClientInterface interface {
 	Subscribe(ctx context.Context, ownerID string, appID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
 	UnSubscribe(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header) (resp *metadata.Response, err error)
 	Rebook(ctx context.Context, ownerID string, appID string, subscribeID string, h http.Header, subscription *metadata.Subscription) (resp *metadata.Response, err error)
+	RotateSecret(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamSubscriptionSecret) (resp *metadata.Response, err error)
+
+	SearchDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterSearch) (resp *metadata.Response, err error)
+	GetDeadLetter(ctx context.Context, ownerID string, subscribeID string, deadLetterID string, h http.Header) (resp *metadata.Response, err error)
+	ReplayDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterIDs) (resp *metadata.Response, err error)
+	PurgeDeadLetter(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeadLetterIDs) (resp *metadata.Response, err error)
+
+	SearchDelivery(ctx context.Context, ownerID string, subscribeID string, h http.Header, dat metadata.ParamDeliverySearch) (resp *metadata.Response, err error)
+
+	Watch(ctx context.Context, ownerID string, h http.Header, dat metadata.ParamEventWatch) (resp *metadata.Response, err error)
 }
 
 func NewEventServerClientInterface(c *util.Capability, version string) EventServerClientInterface {
@@ -24,11 +24,14 @@ import (
 	"configcenter/src/apiserver/service"
 	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/decisionlog"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/mongo/local"
 
 	"github.com/emicklei/go-restful"
 )
@@ -78,6 +81,19 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 
 	svc.SetConfig(authConf.Enable, engine, client, engine.Discovery(), authorize)
 
+	decisionLogConf, err := decisionlog.ParseConfigFromKV("auth", apiSvr.Config)
+	if err != nil {
+		return err
+	}
+	if authConf.Enable && decisionLogConf.Enable {
+		db, err := local.NewMgo(mongo.ParseConfigFromKV("mongodb", apiSvr.Config).BuildURI(), time.Minute)
+		if err != nil {
+			return fmt.Errorf("connect mongo server failed, err: %v", err)
+		}
+		svc.SetDecisionRecorder(decisionlog.NewRecorder(ctx, db, decisionLogConf))
+		blog.Infof("enable auth decision log, config: %+v", decisionLogConf)
+	}
+
 	ctnr := restful.NewContainer()
 	ctnr.Router(restful.CurlyRouter{})
 	ctnr.Router(restful.CurlyRouter{})
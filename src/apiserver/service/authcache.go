@@ -0,0 +1,64 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"net/http"
+
+	"configcenter/src/auth"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// FlushAuthCache removes all the cached authorize decisions, it's used after the policies are
+// changed in the auth center, whose changes can not be noticed by cmdb. the decisions cached in
+// the memory of the other processes are not removed, they expire after the ttl.
+func (s *service) FlushAuthCache(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	cache, ok := s.authorizer.(*auth.CachedAuthorize)
+	if !ok {
+		blog.Errorf("flush auth cache, but the cache is not enabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIAuthCacheIsNotEnabled)})
+		return
+	}
+
+	if err := cache.Flush(); err != nil {
+		blog.Errorf("flush auth cache failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIFlushAuthCacheFailed)})
+		return
+	}
+	blog.Infof("user %s flushed the auth cache, rid: %s", util.GetUser(pheader), rid)
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// GetAuthCacheStatistics returns the hit ratio of the authorize decision cache of this api server
+func (s *service) GetAuthCacheStatistics(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	cache, ok := s.authorizer.(*auth.CachedAuthorize)
+	if !ok {
+		blog.Errorf("get auth cache statistics, but the cache is not enabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIAuthCacheIsNotEnabled)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(cache.Statistics()))
+}
@@ -0,0 +1,53 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"configcenter/src/auth/decisionlog"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// SearchDecisionLogs finds the sampled authorize decisions by user, resource and time
+func (s *service) SearchDecisionLogs(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+
+	if s.decisionLog == nil {
+		blog.Errorf("search decision logs, but the decision log is not enabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPIDecisionLogIsNotEnabled)})
+		return
+	}
+
+	param := decisionlog.SearchParam{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&param); err != nil {
+		blog.Errorf("search decision logs, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.decisionLog.Search(req.Request.Context(), util.GetOwnerID(pheader), param)
+	if err != nil {
+		blog.Errorf("search decision logs failed, param: %+v, err: %v, rid: %s", param, err, rid)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
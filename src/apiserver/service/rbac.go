@@ -0,0 +1,403 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/auth/rbac"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// the apis to manage the roles, role bindings and groups of the local authorizer,
+// they are only allowed for the administrators.
+func (s *service) rbacRoutes(ws *restful.WebService) {
+	ws.Route(ws.POST("/auth/rbac/role").To(s.CreateRole))
+	ws.Route(ws.PUT("/auth/rbac/role/{id}").To(s.UpdateRole))
+	ws.Route(ws.DELETE("/auth/rbac/role/{id}").To(s.DeleteRole))
+	ws.Route(ws.POST("/auth/rbac/role/search").To(s.SearchRoles))
+	ws.Route(ws.POST("/auth/rbac/rolebinding").To(s.CreateRoleBinding))
+	ws.Route(ws.PUT("/auth/rbac/rolebinding/{id}").To(s.UpdateRoleBinding))
+	ws.Route(ws.DELETE("/auth/rbac/rolebinding/{id}").To(s.DeleteRoleBinding))
+	ws.Route(ws.POST("/auth/rbac/rolebinding/search").To(s.SearchRoleBindings))
+	ws.Route(ws.POST("/auth/rbac/group").To(s.CreateGroup))
+	ws.Route(ws.PUT("/auth/rbac/group/{id}").To(s.UpdateGroup))
+	ws.Route(ws.DELETE("/auth/rbac/group/{id}").To(s.DeleteGroup))
+	ws.Route(ws.POST("/auth/rbac/group/search").To(s.SearchGroups))
+}
+
+// checkRBAC checks the local authorizer is enabled and the user is an administrator
+func (s *service) checkRBAC(req *restful.Request, resp *restful.Response, defErr errors.DefaultCCErrorIf) bool {
+	rid := util.GetHTTPCCRequestID(req.Request.Header)
+	if s.rbac == nil || !s.rbac.Enabled() {
+		blog.Errorf("inappropriate calling, local auth is disabled, rid: %s", rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrAPILocalAuthIsNotEnabled)})
+		return false
+	}
+
+	user := util.GetUser(req.Request.Header)
+	if !s.rbac.IsAdmin(user) {
+		blog.Errorf("user %s is not an administrator of the local auth, rid: %s", user, rid)
+		resp.WriteError(http.StatusForbidden, &metadata.RespError{Msg: defErr.Error(common.CCErrCommAuthNotHavePermission)})
+		return false
+	}
+	return true
+}
+
+func (s *service) writeRBACError(resp *restful.Response, defErr errors.DefaultCCErrorIf, err error, dbErrCode int) {
+	switch err {
+	case rbac.ErrNotFound:
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+	case rbac.ErrDuplicated:
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommDuplicateItem, common.BKFieldName)})
+	default:
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(dbErrCode)})
+	}
+}
+
+func (s *service) CreateRole(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	role := rbac.Role{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&role); err != nil {
+		blog.Errorf("create role, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := role.Validate(); err != nil {
+		blog.Errorf("create role, but the role is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	result, err := s.rbac.CreateRole(req.Request.Context(), util.GetOwnerID(pheader), role)
+	if err != nil {
+		blog.Errorf("create role %s failed, err: %v, rid: %s", role.Name, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBInsertFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+func (s *service) UpdateRole(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update role, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+	role := rbac.Role{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&role); err != nil {
+		blog.Errorf("update role, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := role.Validate(); err != nil {
+		blog.Errorf("update role, but the role is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if err := s.rbac.UpdateRole(req.Request.Context(), util.GetOwnerID(pheader), id, role); err != nil {
+		blog.Errorf("update role %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBUpdateFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) DeleteRole(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete role, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	if err := s.rbac.DeleteRole(req.Request.Context(), util.GetOwnerID(pheader), id); err != nil {
+		blog.Errorf("delete role %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBDeleteFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) SearchRoles(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	param := rbac.SearchParam{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&param); err != nil {
+		blog.Errorf("search roles, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.rbac.SearchRoles(req.Request.Context(), util.GetOwnerID(pheader), param)
+	if err != nil {
+		blog.Errorf("search roles failed, param: %+v, err: %v, rid: %s", param, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBSelectFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+func (s *service) CreateRoleBinding(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	binding := rbac.RoleBinding{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&binding); err != nil {
+		blog.Errorf("create role binding, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := binding.Validate(); err != nil {
+		blog.Errorf("create role binding, but the binding is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	result, err := s.rbac.CreateRoleBinding(req.Request.Context(), util.GetOwnerID(pheader), binding)
+	if err != nil {
+		blog.Errorf("create binding of role %d failed, err: %v, rid: %s", binding.RoleID, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBInsertFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+func (s *service) UpdateRoleBinding(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update role binding, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+	binding := rbac.RoleBinding{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&binding); err != nil {
+		blog.Errorf("update role binding, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := binding.Validate(); err != nil {
+		blog.Errorf("update role binding, but the binding is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if err := s.rbac.UpdateRoleBinding(req.Request.Context(), util.GetOwnerID(pheader), id, binding); err != nil {
+		blog.Errorf("update role binding %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBUpdateFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) DeleteRoleBinding(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete role binding, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	if err := s.rbac.DeleteRoleBinding(req.Request.Context(), util.GetOwnerID(pheader), id); err != nil {
+		blog.Errorf("delete role binding %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBDeleteFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) SearchRoleBindings(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	param := rbac.SearchParam{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&param); err != nil {
+		blog.Errorf("search role bindings, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.rbac.SearchRoleBindings(req.Request.Context(), util.GetOwnerID(pheader), param)
+	if err != nil {
+		blog.Errorf("search role bindings failed, param: %+v, err: %v, rid: %s", param, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBSelectFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+func (s *service) CreateGroup(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	group := rbac.Group{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&group); err != nil {
+		blog.Errorf("create group, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := group.Validate(); err != nil {
+		blog.Errorf("create group, but the group is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	result, err := s.rbac.CreateGroup(req.Request.Context(), util.GetOwnerID(pheader), group)
+	if err != nil {
+		blog.Errorf("create group %s failed, err: %v, rid: %s", group.Name, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBInsertFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+func (s *service) UpdateGroup(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("update group, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+	group := rbac.Group{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&group); err != nil {
+		blog.Errorf("update group, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if err := group.Validate(); err != nil {
+		blog.Errorf("update group, but the group is invalid, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, err.Error())})
+		return
+	}
+
+	if err := s.rbac.UpdateGroup(req.Request.Context(), util.GetOwnerID(pheader), id, group); err != nil {
+		blog.Errorf("update group %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBUpdateFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) DeleteGroup(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	id, err := strconv.ParseInt(req.PathParameter("id"), 10, 64)
+	if err != nil {
+		blog.Errorf("delete group, but got invalid id %s, rid: %s", req.PathParameter("id"), rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	if err := s.rbac.DeleteGroup(req.Request.Context(), util.GetOwnerID(pheader), id); err != nil {
+		blog.Errorf("delete group %d failed, err: %v, rid: %s", id, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBDeleteFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+func (s *service) SearchGroups(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.engine.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	rid := util.GetHTTPCCRequestID(pheader)
+	if !s.checkRBAC(req, resp, defErr) {
+		return
+	}
+
+	param := rbac.SearchParam{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&param); err != nil {
+		blog.Errorf("search groups, but decode body failed, err: %v, rid: %s", err, rid)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	result, err := s.rbac.SearchGroups(req.Request.Context(), util.GetOwnerID(pheader), param)
+	if err != nil {
+		blog.Errorf("search groups failed, param: %+v, err: %v, rid: %s", param, err, rid)
+		s.writeRBACError(resp, defErr, err, common.CCErrCommDBSelectFailed)
+		return
+	}
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
@@ -13,12 +13,18 @@
 package service
 
 import (
+	"strings"
+	"time"
+
 	"configcenter/src/apimachinery/discovery"
 	"configcenter/src/apiserver/core"
 	compatiblev2 "configcenter/src/apiserver/core/compatiblev2/service"
 	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/decisionlog"
+	"configcenter/src/auth/meta"
 	"configcenter/src/auth/parser"
+	"configcenter/src/auth/rbac"
 	"configcenter/src/common"
 	"configcenter/src/common/backbone"
 	"configcenter/src/common/blog"
@@ -34,6 +40,7 @@ import (
 type Service interface {
 	WebServices(auth authcenter.AuthConfig) []*restful.WebService
 	SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize)
+	SetDecisionRecorder(recorder *decisionlog.Recorder)
 }
 
 // NewService create a new service instance
@@ -50,6 +57,10 @@ type service struct {
 	core       core.Core
 	discovery  discovery.DiscoveryInterface
 	authorizer auth.Authorizer
+	// rbac is the local authorizer, nil if the auth center is used
+	rbac *rbac.Authorizer
+	// decisionLog records the decisions of the auth filter, nil if it's disabled
+	decisionLog *decisionlog.Recorder
 }
 
 func (s *service) SetConfig(enableAuth bool, engine *backbone.Engine, httpClient HTTPClient, discovery discovery.DiscoveryInterface, authorize auth.Authorize) {
@@ -59,6 +70,11 @@ func (s *service) SetConfig(enableAuth bool, engine *backbone.Engine, httpClient
 	s.discovery = discovery
 	s.core.CompatibleV2Operation().SetConfig(engine)
 	s.authorizer = authorize
+	s.rbac, _ = auth.Origin(authorize).(*rbac.Authorizer)
+}
+
+func (s *service) SetDecisionRecorder(recorder *decisionlog.Recorder) {
+	s.decisionLog = recorder
 }
 
 func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService {
@@ -74,6 +90,10 @@ func (s *service) WebServices(auth authcenter.AuthConfig) []*restful.WebService
 	ws.Route(ws.POST("/auth/verify").To(s.AuthVerify))
 	ws.Route(ws.GET("/auth/business-list").To(s.GetAuthorizedAppList))
 	ws.Route(ws.GET("/auth/admin-entrance").To(s.GetAdminEntrance))
+	ws.Route(ws.POST("/auth/cache/flush").To(s.FlushAuthCache))
+	ws.Route(ws.GET("/auth/cache/statistics").To(s.GetAuthCacheStatistics))
+	ws.Route(ws.POST("/auth/decision/search").To(s.SearchDecisionLogs))
+	s.rbacRoutes(ws)
 	ws.Route(ws.GET("{.*}").Filter(s.URLFilterChan).To(s.Get))
 	ws.Route(ws.POST("{.*}").Filter(s.URLFilterChan).To(s.Post))
 	ws.Route(ws.PUT("{.*}").Filter(s.URLFilterChan).To(s.Put))
@@ -110,6 +130,11 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 			fchain.ProcessFilter(req, resp)
 			return
 		}
+		// the local auth apis check the administrators by themselves
+		if strings.HasPrefix(path, "/api/v3/auth/rbac/") {
+			fchain.ProcessFilter(req, resp)
+			return
+		}
 
 		// if common.BKSuperOwnerID == util.GetOwnerID(req.Request.Header) {
 		// 	blog.Errorf("authFilter failed, can not use super supplier account, rid: %s", rid)
@@ -149,7 +174,15 @@ func (s *service) authFilter(errFunc func() errors.CCErrorIf) func(req *restful.
 		}
 
 		blog.V(7).Infof("auth filter parse attribute result: %s, rid: %s", attribute, rid)
+		start := time.Now()
 		decision, err := s.authorizer.Authorize(req.Request.Context(), attribute)
+		if s.decisionLog != nil {
+			recorded := decision
+			if err != nil {
+				recorded = meta.Decision{Authorized: false, Reason: err.Error()}
+			}
+			s.decisionLog.Record(req.Request, attribute, recorded, time.Since(start))
+		}
 		if err != nil {
 			blog.Errorf("authFilter failed, authorized request failed, url: %s, err: %v, rid: %s", path, err, rid)
 			rsp := metadata.BaseResp{
@@ -19,6 +19,7 @@ import (
 	"configcenter/src/apimachinery/util"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/meta"
+	"configcenter/src/auth/rbac"
 )
 
 type Authorize interface {
@@ -63,7 +64,19 @@ type ResourceHandler interface {
 // which is used for request authorize and resource handle.
 // This allows bk-cmdb to support other kind of auth center.
 // tls can be nil if it is not care.
-// authConfig is a way to parse configuration info for the connection to a auth center.
+// authConfig is a way to parse configuration info for the connection to a auth center,
+// and its mode selects the auth center or the local authorizer stored in cmdb.
+// the decisions are cached if the cache is enabled.
 func NewAuthorize(tls *util.TLSClientConfig, authConfig authcenter.AuthConfig) (Authorize, error) {
-	return authcenter.NewAuthCenter(tls, authConfig)
+	var authorize Authorize
+	var err error
+	if authConfig.Mode == authcenter.ModeLocal {
+		authorize, err = rbac.NewAuthorizer(authConfig)
+	} else {
+		authorize, err = authcenter.NewAuthCenter(tls, authConfig)
+	}
+	if err != nil || !authConfig.Cache.Enable {
+		return authorize, err
+	}
+	return NewCachedAuthorize(authorize, authConfig.Cache)
 }
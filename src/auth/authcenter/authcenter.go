@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"configcenter/src/apimachinery/flowctrl"
 	"configcenter/src/apimachinery/rest"
@@ -28,6 +29,8 @@ import (
 	"configcenter/src/auth/meta"
 	"configcenter/src/common/blog"
 	commonutil "configcenter/src/common/util"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/redis"
 )
 
 const (
@@ -35,6 +38,9 @@ const (
 	authAppSecretHeaderKey string = "X-BK-APP-SECRET"
 	cmdbUser               string = "user"
 	cmdbUserID             string = "system"
+
+	defaultCacheTTL     = 30 * time.Second
+	defaultCacheMaxSize = 10000
 )
 
 // ParseConfigFromKV returns a new config
@@ -55,6 +61,29 @@ func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig,
 		return AuthConfig{}, nil
 	}
 
+	cfg.Cache, err = parseCacheConfig(prefix, configmap)
+	if err != nil {
+		return AuthConfig{}, err
+	}
+
+	cfg.Mode = ModeIAM
+	if mode, exist := configmap[prefix+".mode"]; exist && len(mode) > 0 {
+		cfg.Mode = mode
+	}
+	switch cfg.Mode {
+	case ModeIAM:
+	case ModeLocal:
+		// the local authorizer does not need the auth center
+		if admins := strings.Replace(configmap[prefix+".admins"], " ", "", -1); len(admins) > 0 {
+			cfg.Admins = strings.Split(admins, ",")
+		}
+		cfg.Mongo = mongo.ParseConfigFromKV("mongodb", configmap)
+		cfg.SystemID = SystemIDCMDB
+		return cfg, nil
+	default:
+		return AuthConfig{}, fmt.Errorf(`invalid auth "mode" value %s`, cfg.Mode)
+	}
+
 	enableSync, exist := configmap[prefix+".enableSync"]
 	if exist && len(enableSync) > 0 {
 		cfg.EnableSync, err = strconv.ParseBool(enableSync)
@@ -101,6 +130,52 @@ func ParseConfigFromKV(prefix string, configmap map[string]string) (AuthConfig,
 	return cfg, nil
 }
 
+// the cache is disabled by default, the decisions are cached in process unless the backend is redis.
+func parseCacheConfig(prefix string, configmap map[string]string) (CacheConfig, error) {
+	cfg := CacheConfig{TTL: defaultCacheTTL, MaxSize: defaultCacheMaxSize}
+	if enable, exist := configmap[prefix+".cacheEnable"]; exist && len(enable) > 0 {
+		var err error
+		cfg.Enable, err = strconv.ParseBool(enable)
+		if err != nil {
+			return CacheConfig{}, errors.New(`invalid auth "cacheEnable" value`)
+		}
+	}
+	if !cfg.Enable {
+		return cfg, nil
+	}
+
+	if ttl, exist := configmap[prefix+".cacheTTL"]; exist && len(ttl) > 0 {
+		seconds, err := strconv.Atoi(ttl)
+		if err != nil || seconds <= 0 {
+			return CacheConfig{}, errors.New(`invalid auth "cacheTTL" value`)
+		}
+		cfg.TTL = time.Duration(seconds) * time.Second
+	}
+
+	if maxSize, exist := configmap[prefix+".cacheMaxSize"]; exist && len(maxSize) > 0 {
+		size, err := strconv.Atoi(maxSize)
+		if err != nil || size <= 0 {
+			return CacheConfig{}, errors.New(`invalid auth "cacheMaxSize" value`)
+		}
+		cfg.MaxSize = size
+	}
+
+	cfg.Backend = CacheBackendMemory
+	if backend, exist := configmap[prefix+".cacheBackend"]; exist && len(backend) > 0 {
+		cfg.Backend = backend
+	}
+	if cfg.Backend != CacheBackendMemory && cfg.Backend != CacheBackendRedis {
+		return CacheConfig{}, fmt.Errorf(`invalid auth "cacheBackend" value %s`, cfg.Backend)
+	}
+	// the redis is required by both of the backends, the memory one broadcasts the invalidations by it
+	redisConf := redis.ParseConfigFromKV("redis", configmap)
+	if len(redisConf.Address) == 0 {
+		return CacheConfig{}, errors.New(`the auth cache requires the "redis" config`)
+	}
+	cfg.Redis = &redisConf
+	return cfg, nil
+}
+
 // NewAuthCenter create a instance to handle resources with blueking's AuthCenter.
 func NewAuthCenter(tls *util.TLSClientConfig, cfg AuthConfig) (*AuthCenter, error) {
 	blog.V(5).Infof("new auth center client with parameters tls: %+v, cfg: %+v", tls, cfg)
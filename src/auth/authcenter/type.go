@@ -2,8 +2,11 @@ package authcenter
 
 import (
 	"fmt"
+	"time"
 
 	"configcenter/src/auth/meta"
+	"configcenter/src/storage/dal/mongo"
+	"configcenter/src/storage/dal/redis"
 )
 
 // system constant
@@ -34,8 +37,46 @@ type AuthConfig struct {
 	Enable bool
 	// enable sync auth data to iam
 	EnableSync bool
+	// Mode the authorizer to use, ModeIAM by default
+	Mode string
+	// Admins the users who have all the permissions in ModeLocal
+	Admins []string
+	// Mongo the db which stores the roles in ModeLocal
+	Mongo mongo.Config
+	// Cache the config of the authorize decision cache
+	Cache CacheConfig
 }
 
+// CacheConfig the config of the authorize decision cache
+type CacheConfig struct {
+	Enable bool
+	// TTL how long a decision is cached
+	TTL time.Duration
+	// MaxSize the max count of the decisions cached in process, the ones in redis are limited by the TTL
+	MaxSize int
+	// Backend where the decisions are cached, CacheBackendMemory by default
+	Backend string
+	// Redis shares the decisions in CacheBackendRedis, and broadcasts the invalidations in CacheBackendMemory,
+	// so that the resources changed in a process are not authorized by the stale decisions in the others.
+	Redis *redis.Config
+}
+
+// the backends of the authorize decision cache
+const (
+	// CacheBackendMemory caches the decisions in process
+	CacheBackendMemory = "memory"
+	// CacheBackendRedis caches the decisions in redis
+	CacheBackendRedis = "redis"
+)
+
+// the modes of the authorizer
+const (
+	// ModeIAM authorize by blueking's auth center
+	ModeIAM = "iam"
+	// ModeLocal authorize by the roles stored in cmdb
+	ModeLocal = "local"
+)
+
 type RegisterInfo struct {
 	CreatorType string           `json:"creator_type"`
 	CreatorID   string           `json:"creator_id"`
@@ -0,0 +1,247 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/rbac"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal/redis"
+)
+
+// CacheStatistics the statistics of the authorize decision cache
+type CacheStatistics struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	HitRatio float64 `json:"hit_ratio"`
+	Size     int64   `json:"size"`
+}
+
+// NewCachedAuthorize caches the decisions of the authorize, the cached decisions are invalidated
+// when the resources are registered, updated or deregistered, when the local policies are changed,
+// and when they are flushed. the invalidations take effect in all the processes sharing the redis.
+func NewCachedAuthorize(authorize Authorize, cfg authcenter.CacheConfig) (*CachedAuthorize, error) {
+	var store decisionStore = newMemoryStore(cfg.MaxSize, cfg.TTL)
+	if cfg.Redis != nil {
+		client, err := redis.NewFromConfig(*cfg.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("connect redis server failed %v", err)
+		}
+		if cfg.Backend == authcenter.CacheBackendRedis {
+			store = newRedisStore(client, cfg.TTL)
+		} else {
+			store = newBroadcastStore(store, client)
+		}
+	} else if cfg.Backend == authcenter.CacheBackendRedis {
+		return nil, fmt.Errorf("the redis cache backend requires the redis config")
+	}
+
+	c := &CachedAuthorize{origin: authorize, store: store}
+	if local, ok := authorize.(*rbac.Authorizer); ok {
+		local.OnPolicyChange(func() {
+			if err := c.Flush(); err != nil {
+				blog.Errorf("flush the authorize decision cache after the policy changed failed, err: %v", err)
+			}
+		})
+	}
+	return c, nil
+}
+
+// CachedAuthorize the Authorize with the decisions cached
+type CachedAuthorize struct {
+	// hits and misses are accessed atomically, keep them 64-bit aligned
+	hits   int64
+	misses int64
+	origin Authorize
+	store  decisionStore
+}
+
+// Origin returns the authorize whose decisions are cached
+func Origin(authorize Authorize) Authorize {
+	if c, ok := authorize.(*CachedAuthorize); ok {
+		return c.origin
+	}
+	return authorize
+}
+
+func (c *CachedAuthorize) Enabled() bool {
+	return c.origin.Enabled()
+}
+
+func (c *CachedAuthorize) Authorize(ctx context.Context, a *meta.AuthAttribute) (decision meta.Decision, err error) {
+	if !c.Enabled() {
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	// filter out SkipAction, which set by api server to skip authorization
+	noSkipResources := make([]meta.ResourceAttribute, 0)
+	for _, resource := range a.Resources {
+		if resource.Action == meta.SkipAction {
+			continue
+		}
+		noSkipResources = append(noSkipResources, resource)
+	}
+	a.Resources = noSkipResources
+	if len(noSkipResources) == 0 {
+		return meta.Decision{Authorized: true}, nil
+	}
+
+	decisions, err := c.AuthorizeBatch(ctx, a.User, a.Resources...)
+	if err != nil {
+		return meta.Decision{}, err
+	}
+	noAuth := make([]string, 0)
+	for i, item := range decisions {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", a.Resources[i].Type, item.Reason))
+		}
+	}
+	if len(noAuth) > 0 {
+		return meta.Decision{Authorized: false, Reason: fmt.Sprintf("%v", noAuth)}, nil
+	}
+
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (c *CachedAuthorize) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	if !c.Enabled() {
+		return c.origin.AuthorizeBatch(ctx, user, resources...)
+	}
+
+	rid := util.ExtractRequestIDFromContext(ctx)
+	decisions = make([]meta.Decision, len(resources))
+	keys := make([]decisionKey, len(resources))
+	missIndexes := make([]int, 0)
+	missResources := make([]meta.ResourceAttribute, 0)
+	for i := range resources {
+		keys[i] = newDecisionKey(user, &resources[i])
+		decision, hit, err := c.store.get(keys[i])
+		if err != nil {
+			blog.Warnf("get the authorize decision from cache failed, err: %v, rid: %s", err, rid)
+		}
+		if hit {
+			decisions[i] = decision
+			continue
+		}
+		missIndexes = append(missIndexes, i)
+		missResources = append(missResources, resources[i])
+	}
+	atomic.AddInt64(&c.hits, int64(len(resources)-len(missIndexes)))
+	atomic.AddInt64(&c.misses, int64(len(missIndexes)))
+	if len(missIndexes) == 0 {
+		return decisions, nil
+	}
+
+	missDecisions, err := c.origin.AuthorizeBatch(ctx, user, missResources...)
+	if err != nil {
+		return nil, err
+	}
+	if len(missDecisions) != len(missResources) {
+		return nil, fmt.Errorf("got %d decisions for %d resources", len(missDecisions), len(missResources))
+	}
+	for i, index := range missIndexes {
+		decisions[index] = missDecisions[i]
+		if err := c.store.set(keys[index], missDecisions[i]); err != nil {
+			blog.Warnf("save the authorize decision to cache failed, err: %v, rid: %s", err, rid)
+		}
+	}
+	return decisions, nil
+}
+
+func (c *CachedAuthorize) GetAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	return c.origin.GetAuthorizedBusinessList(ctx, user)
+}
+
+func (c *CachedAuthorize) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	return c.origin.AdminEntrance(ctx, user)
+}
+
+func (c *CachedAuthorize) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	return c.origin.GetAuthorizedAuditList(ctx, user, businessID)
+}
+
+func (c *CachedAuthorize) RegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	defer c.invalidateResources(rs...)
+	return c.origin.RegisterResource(ctx, rs...)
+}
+
+func (c *CachedAuthorize) DeregisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	defer c.invalidateResources(rs...)
+	return c.origin.DeregisterResource(ctx, rs...)
+}
+
+func (c *CachedAuthorize) RawDeregisterResource(ctx context.Context, scope authcenter.ScopeInfo, rs ...meta.BackendResource) error {
+	// the backend resources can not be mapped to the resource types, so all the decisions are flushed.
+	defer func() {
+		if err := c.Flush(); err != nil {
+			blog.Errorf("flush the authorize decision cache failed, err: %v", err)
+		}
+	}()
+	return c.origin.RawDeregisterResource(ctx, scope, rs...)
+}
+
+func (c *CachedAuthorize) UpdateResource(ctx context.Context, rs *meta.ResourceAttribute) error {
+	defer c.invalidateResources(*rs)
+	return c.origin.UpdateResource(ctx, rs)
+}
+
+func (c *CachedAuthorize) DryRunRegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) (*authcenter.RegisterInfo, error) {
+	return c.origin.DryRunRegisterResource(ctx, rs...)
+}
+
+func (c *CachedAuthorize) Get(ctx context.Context) error {
+	return c.origin.Get(ctx)
+}
+
+func (c *CachedAuthorize) ListResources(ctx context.Context, r *meta.ResourceAttribute) ([]meta.BackendResource, error) {
+	return c.origin.ListResources(ctx, r)
+}
+
+func (c *CachedAuthorize) Init(ctx context.Context, config meta.InitConfig) error {
+	return c.origin.Init(ctx, config)
+}
+
+func (c *CachedAuthorize) invalidateResources(rs ...meta.ResourceAttribute) {
+	types := make(map[meta.ResourceType]bool)
+	for _, r := range rs {
+		types[r.Type] = true
+	}
+	for resourceType := range types {
+		if err := c.store.invalidate(resourceType); err != nil {
+			blog.Errorf("invalidate the authorize decisions of resource type %s failed, err: %v", resourceType, err)
+		}
+	}
+}
+
+// Flush removes all the cached decisions
+func (c *CachedAuthorize) Flush() error {
+	return c.store.flush()
+}
+
+// Statistics returns the hits and misses of the cache since the process started
+func (c *CachedAuthorize) Statistics() CacheStatistics {
+	stat := CacheStatistics{
+		Hits:   atomic.LoadInt64(&c.hits),
+		Misses: atomic.LoadInt64(&c.misses),
+		Size:   c.store.size(),
+	}
+	if total := stat.Hits + stat.Misses; total > 0 {
+		stat.HitRatio = float64(stat.Hits) / float64(total)
+	}
+	return stat
+}
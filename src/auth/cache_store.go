@@ -0,0 +1,334 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package auth
+
+import (
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+)
+
+// decisionKey identifies the decision of a user on a resource
+type decisionKey struct {
+	resourceType meta.ResourceType
+	field        string
+}
+
+func newDecisionKey(user meta.UserInfo, r *meta.ResourceAttribute) decisionKey {
+	layers := ""
+	for _, layer := range r.Layers {
+		layers += fmt.Sprintf("%s:%d:%s/", layer.Type, layer.InstanceID, layer.InstanceIDEx)
+	}
+	return decisionKey{
+		resourceType: r.Type,
+		field: fmt.Sprintf("%s|%s|%s|%d|%s|%d|%s|%s", user.SupplierAccount, user.UserName, r.Action,
+			r.BusinessID, r.Name, r.InstanceID, r.InstanceIDEx, layers),
+	}
+}
+
+// decisionStore saves the decisions until they are expired
+type decisionStore interface {
+	get(key decisionKey) (meta.Decision, bool, error)
+	set(key decisionKey, decision meta.Decision) error
+	// invalidate removes the decisions on the resource type
+	invalidate(resourceType meta.ResourceType) error
+	flush() error
+	size() int64
+}
+
+type cachedDecision struct {
+	Authorized bool   `json:"authorized"`
+	Reason     string `json:"reason"`
+	Expire     int64  `json:"expire"`
+}
+
+type memoryEntry struct {
+	key      decisionKey
+	decision cachedDecision
+}
+
+// memoryStore is a LRU cache of the decisions in process
+type memoryStore struct {
+	sync.Mutex
+	maxSize int
+	ttl     time.Duration
+	entries map[decisionKey]*list.Element
+	lru     *list.List
+}
+
+func newMemoryStore(maxSize int, ttl time.Duration) *memoryStore {
+	return &memoryStore{
+		maxSize: maxSize,
+		ttl:     ttl,
+		entries: make(map[decisionKey]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+func (m *memoryStore) get(key decisionKey) (meta.Decision, bool, error) {
+	m.Lock()
+	defer m.Unlock()
+	elem, ok := m.entries[key]
+	if !ok {
+		return meta.Decision{}, false, nil
+	}
+	entry := elem.Value.(*memoryEntry)
+	if time.Now().Unix() >= entry.decision.Expire {
+		m.remove(elem)
+		return meta.Decision{}, false, nil
+	}
+	m.lru.MoveToFront(elem)
+	return meta.Decision{Authorized: entry.decision.Authorized, Reason: entry.decision.Reason}, true, nil
+}
+
+func (m *memoryStore) set(key decisionKey, decision meta.Decision) error {
+	m.Lock()
+	defer m.Unlock()
+	cached := cachedDecision{Authorized: decision.Authorized, Reason: decision.Reason, Expire: time.Now().Add(m.ttl).Unix()}
+	if elem, ok := m.entries[key]; ok {
+		elem.Value.(*memoryEntry).decision = cached
+		m.lru.MoveToFront(elem)
+		return nil
+	}
+
+	m.entries[key] = m.lru.PushFront(&memoryEntry{key: key, decision: cached})
+	for m.lru.Len() > m.maxSize {
+		m.remove(m.lru.Back())
+	}
+	return nil
+}
+
+func (m *memoryStore) invalidate(resourceType meta.ResourceType) error {
+	m.Lock()
+	defer m.Unlock()
+	for elem := m.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*memoryEntry).key.resourceType == resourceType {
+			m.remove(elem)
+		}
+		elem = next
+	}
+	return nil
+}
+
+func (m *memoryStore) flush() error {
+	m.Lock()
+	defer m.Unlock()
+	m.entries = make(map[decisionKey]*list.Element)
+	m.lru.Init()
+	return nil
+}
+
+func (m *memoryStore) size() int64 {
+	m.Lock()
+	defer m.Unlock()
+	return int64(m.lru.Len())
+}
+
+func (m *memoryStore) remove(elem *list.Element) {
+	m.lru.Remove(elem)
+	delete(m.entries, elem.Value.(*memoryEntry).key)
+}
+
+// broadcastStore caches the decisions in process, and broadcasts the invalidations to the other processes by redis
+type broadcastStore struct {
+	decisionStore
+	client *redis.Client
+}
+
+// the messages of the invalidations
+const (
+	flushMessage            = "flush"
+	invalidateMessagePrefix = "invalidate:"
+)
+
+func newBroadcastStore(store decisionStore, client *redis.Client) *broadcastStore {
+	b := &broadcastStore{decisionStore: store, client: client}
+	go b.receive()
+	return b
+}
+
+func (b *broadcastStore) invalidate(resourceType meta.ResourceType) error {
+	if err := b.decisionStore.invalidate(resourceType); err != nil {
+		return err
+	}
+	return b.client.Publish(common.AuthCacheInvalidateChannel, invalidateMessagePrefix+string(resourceType)).Err()
+}
+
+func (b *broadcastStore) flush() error {
+	if err := b.decisionStore.flush(); err != nil {
+		return err
+	}
+	return b.client.Publish(common.AuthCacheInvalidateChannel, flushMessage).Err()
+}
+
+// receive applies the invalidations of the other processes, all the decisions are flushed
+// if the channel is broken, because the invalidations may be missed before it recovers.
+func (b *broadcastStore) receive() {
+	for {
+		pubsub, err := b.client.Subscribe(common.AuthCacheInvalidateChannel)
+		if err != nil {
+			blog.Errorf("subscribe the authorize decision invalidations failed, err: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+		for {
+			msg, err := pubsub.ReceiveMessage()
+			if err != nil {
+				blog.Errorf("receive the authorize decision invalidations failed, flush the cache, err: %v", err)
+				break
+			}
+			if msg.Payload == flushMessage {
+				err = b.decisionStore.flush()
+			} else if strings.HasPrefix(msg.Payload, invalidateMessagePrefix) {
+				err = b.decisionStore.invalidate(meta.ResourceType(strings.TrimPrefix(msg.Payload, invalidateMessagePrefix)))
+			}
+			if err != nil {
+				blog.Errorf("apply the authorize decision invalidation %s failed, err: %v", msg.Payload, err)
+			}
+		}
+		pubsub.Close()
+		if err := b.decisionStore.flush(); err != nil {
+			blog.Errorf("flush the authorize decision cache failed, err: %v", err)
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// redisStore saves each decision in a redis key with its own ttl, so they are shared by all the processes.
+// the keys of a resource type are prefixed by its generation, the invalidation starts a new generation,
+// then the decisions of the previous generations are never read again and expire by themselves.
+type redisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// getDecisionScript gets the decision of the current generation
+var getDecisionScript = redis.NewScript(`
+local generation = redis.call('get', KEYS[1]) or '0'
+return redis.call('get', ARGV[1] .. generation .. ':' .. ARGV[2])
+`)
+
+// setDecisionScript sets the decision in the current generation with the ttl
+var setDecisionScript = redis.NewScript(`
+local generation = redis.call('get', KEYS[1]) or '0'
+redis.call('set', ARGV[1] .. generation .. ':' .. ARGV[2], ARGV[3], 'EX', ARGV[4])
+redis.call('sadd', KEYS[2], ARGV[5])
+return 1
+`)
+
+func newRedisStore(client *redis.Client, ttl time.Duration) *redisStore {
+	return &redisStore{client: client, ttl: ttl}
+}
+
+func generationKey(resourceType string) string {
+	return common.AuthCacheDecisionGenerationPrefix + resourceType
+}
+
+func decisionPrefix(resourceType string) string {
+	return common.AuthCacheDecisionPrefix + resourceType + ":"
+}
+
+func (r *redisStore) get(key decisionKey) (meta.Decision, bool, error) {
+	resourceType := string(key.resourceType)
+	val, err := getDecisionScript.Run(r.client, []string{generationKey(resourceType)}, decisionPrefix(resourceType), key.field).Result()
+	if err == redis.Nil {
+		return meta.Decision{}, false, nil
+	}
+	if err != nil {
+		return meta.Decision{}, false, err
+	}
+	str, ok := val.(string)
+	if !ok {
+		return meta.Decision{}, false, fmt.Errorf("unexpected cached decision %v", val)
+	}
+
+	cached := cachedDecision{}
+	if err := json.Unmarshal([]byte(str), &cached); err != nil {
+		return meta.Decision{}, false, err
+	}
+	if time.Now().Unix() >= cached.Expire {
+		return meta.Decision{}, false, nil
+	}
+	return meta.Decision{Authorized: cached.Authorized, Reason: cached.Reason}, true, nil
+}
+
+func (r *redisStore) set(key decisionKey, decision meta.Decision) error {
+	val, err := json.Marshal(cachedDecision{Authorized: decision.Authorized, Reason: decision.Reason, Expire: time.Now().Add(r.ttl).Unix()})
+	if err != nil {
+		return err
+	}
+
+	ttl := int64(r.ttl / time.Second)
+	if ttl <= 0 {
+		ttl = 1
+	}
+	resourceType := string(key.resourceType)
+	keys := []string{generationKey(resourceType), common.AuthCacheDecisionTypesKey}
+	return setDecisionScript.Run(r.client, keys, decisionPrefix(resourceType), key.field, string(val), ttl, resourceType).Err()
+}
+
+func (r *redisStore) invalidate(resourceType meta.ResourceType) error {
+	return r.client.Incr(generationKey(string(resourceType))).Err()
+}
+
+func (r *redisStore) flush() error {
+	types, err := r.client.SMembers(common.AuthCacheDecisionTypesKey).Result()
+	if err != nil {
+		return err
+	}
+	for _, resourceType := range types {
+		if err := r.client.Incr(generationKey(resourceType)).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// size counts the decisions of the current generations
+func (r *redisStore) size() int64 {
+	types, err := r.client.SMembers(common.AuthCacheDecisionTypesKey).Result()
+	if err != nil {
+		return 0
+	}
+	var size int64
+	for _, resourceType := range types {
+		generation, err := r.client.Get(generationKey(resourceType)).Result()
+		if err == redis.Nil {
+			generation = "0"
+		} else if err != nil {
+			continue
+		}
+		match := decisionPrefix(resourceType) + generation + ":*"
+		var cursor uint64
+		for {
+			keys, next, err := r.client.Scan(cursor, match, 1000).Result()
+			if err != nil {
+				break
+			}
+			size += int64(len(keys))
+			if cursor = next; cursor == 0 {
+				break
+			}
+		}
+	}
+	return size
+}
@@ -0,0 +1,151 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/meta"
+	"configcenter/src/auth/rbac"
+)
+
+// countAuthorize allows the resources whose instance id is even, and counts the authorized resources
+type countAuthorize struct {
+	*rbac.Authorizer
+	count int
+}
+
+func (c *countAuthorize) Enabled() bool {
+	return true
+}
+
+func (c *countAuthorize) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error) {
+	decisions := make([]meta.Decision, len(resources))
+	for i, r := range resources {
+		decisions[i].Authorized = r.InstanceID%2 == 0
+	}
+	c.count += len(resources)
+	return decisions, nil
+}
+
+func hostResource(instID int64) meta.ResourceAttribute {
+	return meta.ResourceAttribute{Basic: meta.Basic{Type: meta.HostInstance, Action: meta.Update, InstanceID: instID}}
+}
+
+func TestCachedAuthorize(t *testing.T) {
+	origin := &countAuthorize{Authorizer: &rbac.Authorizer{}}
+	cache, err := NewCachedAuthorize(origin, authcenter.CacheConfig{Enable: true, TTL: time.Minute, MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	user := meta.UserInfo{UserName: "admin", SupplierAccount: "0"}
+
+	decisions, err := cache.AuthorizeBatch(ctx, user, hostResource(1), hostResource(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decisions[0].Authorized || !decisions[1].Authorized {
+		t.Errorf("unexpected decisions %+v", decisions)
+	}
+
+	decisions, _ = cache.AuthorizeBatch(ctx, user, hostResource(2), hostResource(3), hostResource(1))
+	if !decisions[0].Authorized || decisions[1].Authorized || decisions[2].Authorized {
+		t.Errorf("unexpected decisions %+v", decisions)
+	}
+	if origin.count != 3 {
+		t.Errorf("expect 3 resources authorized by the origin, but got %d", origin.count)
+	}
+	if stat := cache.Statistics(); stat.Hits != 2 || stat.Misses != 3 || stat.Size != 3 {
+		t.Errorf("unexpected statistics %+v", stat)
+	}
+
+	// the decisions of the other users are not shared
+	cache.AuthorizeBatch(ctx, meta.UserInfo{UserName: "guest", SupplierAccount: "0"}, hostResource(2))
+	if origin.count != 4 {
+		t.Errorf("expect 4 resources authorized by the origin, but got %d", origin.count)
+	}
+
+	cache.DeregisterResource(ctx, hostResource(2))
+	cache.AuthorizeBatch(ctx, user, hostResource(2))
+	if origin.count != 5 {
+		t.Errorf("expect the decision is invalidated after deregistration, but got count %d", origin.count)
+	}
+
+	cache.Flush()
+	if stat := cache.Statistics(); stat.Size != 0 {
+		t.Errorf("expect empty cache after flush, but got %+v", stat)
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	store := newMemoryStore(2, time.Minute)
+	user := meta.UserInfo{UserName: "admin"}
+	keys := make([]decisionKey, 3)
+	for i := range keys {
+		r := hostResource(int64(i))
+		keys[i] = newDecisionKey(user, &r)
+	}
+
+	store.set(keys[0], meta.Decision{Authorized: true})
+	store.set(keys[1], meta.Decision{Authorized: true})
+	// key 0 is used recently, so key 1 is evicted
+	store.get(keys[0])
+	store.set(keys[2], meta.Decision{Authorized: true})
+	if _, hit, _ := store.get(keys[1]); hit {
+		t.Errorf("expect the least recently used decision is evicted")
+	}
+	if _, hit, _ := store.get(keys[0]); !hit {
+		t.Errorf("expect the recently used decision is kept")
+	}
+
+	expired := newMemoryStore(2, -time.Second)
+	expired.set(keys[0], meta.Decision{Authorized: true})
+	if _, hit, _ := expired.get(keys[0]); hit {
+		t.Errorf("expect the expired decision is not returned")
+	}
+}
+
+// shortAuthorize returns less decisions than the resources
+type shortAuthorize struct {
+	countAuthorize
+}
+
+func (s *shortAuthorize) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) ([]meta.Decision, error) {
+	decisions, _ := s.countAuthorize.AuthorizeBatch(ctx, user, resources...)
+	return decisions[:len(decisions)-1], nil
+}
+
+func TestCachedAuthorizeShortDecisions(t *testing.T) {
+	origin := &shortAuthorize{countAuthorize{Authorizer: &rbac.Authorizer{}}}
+	cache, err := NewCachedAuthorize(origin, authcenter.CacheConfig{Enable: true, TTL: time.Minute, MaxSize: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := meta.UserInfo{UserName: "admin", SupplierAccount: "0"}
+	if _, err := cache.AuthorizeBatch(context.Background(), user, hostResource(1), hostResource(2)); err == nil {
+		t.Errorf("expect error when the decisions do not match the resources")
+	}
+	if stat := cache.Statistics(); stat.Size != 0 {
+		t.Errorf("expect nothing cached, but got %+v", stat)
+	}
+}
+
+func TestNewCachedAuthorizeRedisBackend(t *testing.T) {
+	cfg := authcenter.CacheConfig{Enable: true, TTL: time.Minute, MaxSize: 10, Backend: authcenter.CacheBackendRedis}
+	if _, err := NewCachedAuthorize(&countAuthorize{Authorizer: &rbac.Authorizer{}}, cfg); err == nil {
+		t.Errorf("expect error when the redis backend has no redis config")
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package decisionlog
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	bufferSize    = 1000
+	batchSize     = 100
+	flushInterval = time.Second
+
+	defaultSearchLimit = 100
+	maxSearchLimit     = 1000
+)
+
+// NewRecorder create a recorder which saves the sampled decision logs to the db in background
+func NewRecorder(ctx context.Context, db dal.RDB, cfg Config) *Recorder {
+	r := &Recorder{ctx: ctx, db: db, cfg: cfg, logs: make(chan DecisionLog, bufferSize)}
+	go r.save()
+	go r.clean()
+	return r
+}
+
+// Recorder saves the authorize decisions
+type Recorder struct {
+	ctx  context.Context
+	db   dal.RDB
+	cfg  Config
+	logs chan DecisionLog
+}
+
+// Record saves the decision on the request if it's sampled, the decision is dropped
+// if the logs can not be saved in time, so that the request is not blocked.
+func (r *Recorder) Record(req *http.Request, attr *meta.AuthAttribute, decision meta.Decision, latency time.Duration) {
+	if !r.sampled(decision.Authorized) {
+		return
+	}
+
+	log := DecisionLog{
+		User:       attr.User.UserName,
+		OwnerID:    attr.User.SupplierAccount,
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Resources:  newResources(attr.Resources),
+		Authorized: decision.Authorized,
+		Reason:     decision.Reason,
+		RequestID:  util.GetHTTPCCRequestID(req.Header),
+		Latency:    int64(latency / time.Millisecond),
+		CreateTime: metadata.Now(),
+	}
+	select {
+	case r.logs <- log:
+	default:
+		blog.Warnf("the decision log buffer is full, drop the decision log, rid: %s", log.RequestID)
+	}
+}
+
+func (r *Recorder) sampled(authorized bool) bool {
+	rate := r.cfg.DeniedSampleRate
+	if authorized {
+		rate = r.cfg.GrantedSampleRate
+	}
+	return rate >= 1 || rand.Float64() < rate
+}
+
+// save inserts the decision logs in batch
+func (r *Recorder) save() {
+	batch := make([]DecisionLog, 0, batchSize)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case log := <-r.logs:
+			batch = append(batch, log)
+			if len(batch) < batchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if err := r.db.Table(common.BKTableNameAuthDecisionLog).Insert(r.ctx, batch); err != nil {
+			blog.Errorf("save %d decision logs failed, err: %v", len(batch), err)
+		}
+		batch = make([]DecisionLog, 0, batchSize)
+	}
+}
+
+// clean removes the decision logs which are older than the retention
+func (r *Recorder) clean() {
+	tick := util.NewTicker(time.Hour)
+	tick.Tick()
+	for range tick.C {
+		expire := time.Now().Add(-r.cfg.Retention)
+		cond := condition.CreateCondition().Field(common.CreateTimeField).Lt(expire)
+		if err := r.db.Table(common.BKTableNameAuthDecisionLog).Delete(r.ctx, cond.ToMapStr()); err != nil {
+			blog.Errorf("clean the decision logs before %v failed: %v", expire, err)
+		}
+	}
+}
+
+// Search finds the decision logs of the supplier account, the latest first by default
+func (r *Recorder) Search(ctx context.Context, ownerID string, param SearchParam) (*SearchResult, error) {
+	cond := mapstr.MapStr{common.BKOwnerIDField: ownerID}
+	if len(param.User) > 0 {
+		cond["user"] = param.User
+	}
+	if param.Authorized != nil {
+		cond["authorized"] = *param.Authorized
+	}
+
+	resourceCond := mapstr.MapStr{}
+	if len(param.ResourceType) > 0 {
+		resourceCond["type"] = param.ResourceType
+	}
+	if param.BusinessID > 0 {
+		resourceCond[common.BKAppIDField] = param.BusinessID
+	}
+	if param.InstanceID > 0 {
+		resourceCond["instance_id"] = param.InstanceID
+	}
+	if len(resourceCond) > 0 {
+		cond["resources"] = mapstr.MapStr{"$elemMatch": resourceCond}
+	}
+
+	timeCond := mapstr.MapStr{}
+	if param.StartTime != nil {
+		timeCond[common.BKDBGTE] = param.StartTime.Time
+	}
+	if param.EndTime != nil {
+		timeCond[common.BKDBLTE] = param.EndTime.Time
+	}
+	if len(timeCond) > 0 {
+		cond[common.CreateTimeField] = timeCond
+	}
+
+	count, err := r.db.Table(common.BKTableNameAuthDecisionLog).Find(cond).Count(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sort := param.Page.Sort
+	if len(sort) == 0 {
+		sort = "-" + common.CreateTimeField
+	}
+	limit := param.Page.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	logs := make([]DecisionLog, 0)
+	err = r.db.Table(common.BKTableNameAuthDecisionLog).Find(cond).Sort(sort).Start(uint64(param.Page.Start)).Limit(uint64(limit)).All(ctx, &logs)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Count: count, Info: logs}, nil
+}
@@ -0,0 +1,155 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package decisionlog
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/metadata"
+)
+
+const (
+	defaultGrantedSampleRate = 0.01
+	defaultDeniedSampleRate  = 1
+	defaultRetention         = 30 * 24 * time.Hour
+)
+
+// Config the config of the authorize decision logs
+type Config struct {
+	Enable bool
+	// GrantedSampleRate the ratio of the granted decisions to be saved, 0 to 1
+	GrantedSampleRate float64
+	// DeniedSampleRate the ratio of the denied decisions to be saved, 0 to 1
+	DeniedSampleRate float64
+	// Retention the decision logs older than it are cleaned
+	Retention time.Duration
+}
+
+// ParseConfigFromKV returns the decision log config, it's disabled by default.
+func ParseConfigFromKV(prefix string, configmap map[string]string) (Config, error) {
+	cfg := Config{
+		GrantedSampleRate: defaultGrantedSampleRate,
+		DeniedSampleRate:  defaultDeniedSampleRate,
+		Retention:         defaultRetention,
+	}
+
+	if enable, exist := configmap[prefix+".decisionLogEnable"]; exist && len(enable) > 0 {
+		var err error
+		cfg.Enable, err = strconv.ParseBool(enable)
+		if err != nil {
+			return Config{}, errors.New(`invalid auth "decisionLogEnable" value`)
+		}
+	}
+	if !cfg.Enable {
+		return cfg, nil
+	}
+
+	if rate, exist := configmap[prefix+".decisionLogGrantedSampleRate"]; exist && len(rate) > 0 {
+		var err error
+		cfg.GrantedSampleRate, err = strconv.ParseFloat(rate, 64)
+		if err != nil || cfg.GrantedSampleRate < 0 || cfg.GrantedSampleRate > 1 {
+			return Config{}, errors.New(`invalid auth "decisionLogGrantedSampleRate" value`)
+		}
+	}
+
+	if rate, exist := configmap[prefix+".decisionLogDeniedSampleRate"]; exist && len(rate) > 0 {
+		var err error
+		cfg.DeniedSampleRate, err = strconv.ParseFloat(rate, 64)
+		if err != nil || cfg.DeniedSampleRate < 0 || cfg.DeniedSampleRate > 1 {
+			return Config{}, errors.New(`invalid auth "decisionLogDeniedSampleRate" value`)
+		}
+	}
+
+	if days, exist := configmap[prefix+".decisionLogRetentionDays"]; exist && len(days) > 0 {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return Config{}, errors.New(`invalid auth "decisionLogRetentionDays" value`)
+		}
+		cfg.Retention = time.Duration(n) * 24 * time.Hour
+	}
+
+	return cfg, nil
+}
+
+// Layer a layer of the resource, like the business of a set
+type Layer struct {
+	Type         meta.ResourceType `bson:"type" json:"type"`
+	InstanceID   int64             `bson:"instance_id" json:"instance_id"`
+	InstanceIDEx string            `bson:"instance_id_ex,omitempty" json:"instance_id_ex,omitempty"`
+}
+
+// Resource the resource which is authorized
+type Resource struct {
+	Type         meta.ResourceType `bson:"type" json:"type"`
+	Action       meta.Action       `bson:"action" json:"action"`
+	Name         string            `bson:"name,omitempty" json:"name,omitempty"`
+	InstanceID   int64             `bson:"instance_id" json:"instance_id"`
+	InstanceIDEx string            `bson:"instance_id_ex,omitempty" json:"instance_id_ex,omitempty"`
+	BusinessID   int64             `bson:"bk_biz_id" json:"bk_biz_id"`
+	Layers       []Layer           `bson:"layers" json:"layers"`
+}
+
+// DecisionLog the authorize decision on a request
+type DecisionLog struct {
+	User       string     `bson:"user" json:"user"`
+	OwnerID    string     `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	Method     string     `bson:"method" json:"method"`
+	Path       string     `bson:"path" json:"path"`
+	Resources  []Resource `bson:"resources" json:"resources"`
+	Authorized bool       `bson:"authorized" json:"authorized"`
+	Reason     string     `bson:"reason" json:"reason"`
+	RequestID  string     `bson:"rid" json:"rid"`
+	// Latency the milliseconds used to make the decision
+	Latency    int64         `bson:"latency" json:"latency"`
+	CreateTime metadata.Time `bson:"create_time" json:"create_time"`
+}
+
+// SearchParam the condition to search the decision logs, the empty fields are ignored
+type SearchParam struct {
+	User         string            `json:"user"`
+	ResourceType meta.ResourceType `json:"resource_type"`
+	BusinessID   int64             `json:"bk_biz_id"`
+	InstanceID   int64             `json:"instance_id"`
+	Authorized   *bool             `json:"authorized"`
+	StartTime    *metadata.Time    `json:"start_time"`
+	EndTime      *metadata.Time    `json:"end_time"`
+	Page         metadata.BasePage `json:"page"`
+}
+
+// SearchResult the decision logs found
+type SearchResult struct {
+	Count uint64        `json:"count"`
+	Info  []DecisionLog `json:"info"`
+}
+
+func newResources(rs []meta.ResourceAttribute) []Resource {
+	resources := make([]Resource, 0, len(rs))
+	for _, r := range rs {
+		resource := Resource{
+			Type:         r.Type,
+			Action:       r.Action,
+			Name:         r.Name,
+			InstanceID:   r.InstanceID,
+			InstanceIDEx: r.InstanceIDEx,
+			BusinessID:   r.BusinessID,
+			Layers:       make([]Layer, 0, len(r.Layers)),
+		}
+		for _, layer := range r.Layers {
+			resource.Layers = append(resource.Layers, Layer{Type: layer.Type, InstanceID: layer.InstanceID, InstanceIDEx: layer.InstanceIDEx})
+		}
+		resources = append(resources, resource)
+	}
+	return resources
+}
@@ -0,0 +1,49 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package decisionlog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseConfigFromKV(t *testing.T) {
+	cfg, err := ParseConfigFromKV("auth", map[string]string{})
+	if err != nil || cfg.Enable {
+		t.Errorf("expect disabled by default, but got %+v, err: %v", cfg, err)
+	}
+
+	cfg, err = ParseConfigFromKV("auth", map[string]string{
+		"auth.decisionLogEnable":            "true",
+		"auth.decisionLogGrantedSampleRate": "0.5",
+		"auth.decisionLogRetentionDays":     "7",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.Enable || cfg.GrantedSampleRate != 0.5 || cfg.DeniedSampleRate != defaultDeniedSampleRate || cfg.Retention != 7*24*time.Hour {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+
+	if _, err := ParseConfigFromKV("auth", map[string]string{"auth.decisionLogEnable": "true", "auth.decisionLogDeniedSampleRate": "2"}); err == nil {
+		t.Errorf("expect error for the sample rate out of range")
+	}
+}
+
+func TestSampled(t *testing.T) {
+	r := &Recorder{cfg: Config{GrantedSampleRate: 0, DeniedSampleRate: 1}}
+	for i := 0; i < 100; i++ {
+		if r.sampled(true) || !r.sampled(false) {
+			t.Fatalf("expect the granted decisions are dropped and the denied ones are kept")
+		}
+	}
+}
@@ -0,0 +1,71 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package parser
+
+import (
+	"net/http"
+
+	"configcenter/src/auth/meta"
+)
+
+const (
+	flushAuthCachePattern         = "/api/v3/auth/cache/flush"
+	findAuthCacheStatisticPattern = "/api/v3/auth/cache/statistics"
+	findAuthDecisionLogPattern    = "/api/v3/auth/decision/search"
+)
+
+func (ps *parseStream) authRelated() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// flush the authorize decision cache
+	if ps.hitPattern(flushAuthCachePattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.SystemBase,
+					Action: meta.Update,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the statistics of the authorize decision cache
+	if ps.hitPattern(findAuthCacheStatisticPattern, http.MethodGet) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.SystemBase,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	// find the authorize decision logs, they are a kind of audit log.
+	if ps.hitPattern(findAuthDecisionLogPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.AuditLog,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
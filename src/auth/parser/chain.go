@@ -64,6 +64,7 @@ func (ps *parseStream) Parse() (*meta.AuthAttribute, error) {
 		netCollectorRelated().
 		processRelated().
 		eventRelated().
+		authRelated().
 		// finalizer must be at the end of the check chains.
 		finalizer()
 
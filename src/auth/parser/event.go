@@ -26,7 +26,11 @@ func (ps *parseStream) eventRelated() *parseStream {
 		return ps
 	}
 
-	ps.subscribe()
+	ps.subscriptionSecret().
+		subscribe().
+		deadLetter().
+		delivery().
+		watch()
 
 	return ps
 }
@@ -140,3 +144,129 @@ func (ps *parseStream) subscribe() *parseStream {
 
 	return ps
 }
+
+var rotateSubscriptionSecretRegexp = regexp.MustCompile(`^/api/v3/event/subscribe/secret/[^\s/]+/\d+/?$`)
+
+// subscriptionSecret must be parsed before subscribe, because the create subscription rule matches it too.
+func (ps *parseStream) subscriptionSecret() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// rotate the secret of a subscription
+	if ps.hitRegexp(rotateSubscriptionSecretRegexp, http.MethodPost) {
+		subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[6], 10, 64)
+		if err != nil {
+			ps.err = fmt.Errorf("rotate subscription secret, but got invalid subscription id: %s", ps.RequestCtx.Elements[6])
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:       meta.EventPushing,
+					Action:     meta.Update,
+					InstanceID: subscribeID,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+var (
+	findDeadLetterRegexp   = regexp.MustCompile(`^/api/v3/event/deadletter/search/[^\s/]+/\d+/?$`)
+	replayDeadLetterRegexp = regexp.MustCompile(`^/api/v3/event/deadletter/replay/[^\s/]+/\d+/?$`)
+	getDeadLetterRegexp    = regexp.MustCompile(`^/api/v3/event/deadletter/[^\s/]+/\d+/\d+/?$`)
+	purgeDeadLetterRegexp  = regexp.MustCompile(`^/api/v3/event/deadletter/[^\s/]+/\d+/?$`)
+)
+
+func (ps *parseStream) deadLetter() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// find the dead letters of a subscription
+	if ps.hitRegexp(findDeadLetterRegexp, http.MethodPost) {
+		ps.subscriptionResource(6, meta.Find)
+		return ps
+	}
+
+	// replay the dead letters of a subscription
+	if ps.hitRegexp(replayDeadLetterRegexp, http.MethodPost) {
+		ps.subscriptionResource(6, meta.Update)
+		return ps
+	}
+
+	// inspect a dead letter of a subscription
+	if ps.hitRegexp(getDeadLetterRegexp, http.MethodGet) {
+		ps.subscriptionResource(5, meta.Find)
+		return ps
+	}
+
+	// purge the dead letters of a subscription
+	if ps.hitRegexp(purgeDeadLetterRegexp, http.MethodDelete) {
+		ps.subscriptionResource(5, meta.Update)
+		return ps
+	}
+
+	return ps
+}
+
+var findDeliveryRegexp = regexp.MustCompile(`^/api/v3/event/delivery/search/[^\s/]+/\d+/?$`)
+
+func (ps *parseStream) delivery() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// find the delivery logs of a subscription
+	if ps.hitRegexp(findDeliveryRegexp, http.MethodPost) {
+		ps.subscriptionResource(6, meta.Find)
+		return ps
+	}
+
+	return ps
+}
+
+// the dead letters and the delivery logs belong to the subscription, so they are authorized as the subscription.
+func (ps *parseStream) subscriptionResource(subscribeIDIndex int, action meta.Action) {
+	subscribeID, err := strconv.ParseInt(ps.RequestCtx.Elements[subscribeIDIndex], 10, 64)
+	if err != nil {
+		ps.err = fmt.Errorf("operate the resource of subscription, but got invalid subscription id: %s", ps.RequestCtx.Elements[subscribeIDIndex])
+		return
+	}
+	ps.Attribute.Resources = []meta.ResourceAttribute{
+		meta.ResourceAttribute{
+			Basic: meta.Basic{
+				Type:       meta.EventPushing,
+				Action:     action,
+				InstanceID: subscribeID,
+			},
+		},
+	}
+}
+
+var watchEventRegexp = regexp.MustCompile(`^/api/v3/event/watch/[^\s/]+/?$`)
+
+func (ps *parseStream) watch() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// watch the events
+	if ps.hitRegexp(watchEventRegexp, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			meta.ResourceAttribute{
+				Basic: meta.Basic{
+					Type:   meta.EventPushing,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
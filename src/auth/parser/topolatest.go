@@ -39,6 +39,9 @@ func (ps *parseStream) topologyLatest() *parseStream {
 	}
 
 	ps.objectUniqueLatest().
+		objectVersionLatest().
+		objectBundleLatest().
+		recycleLatest().
 		associationTypeLatest().
 		objectAssociationLatest().
 		objectInstanceAssociationLatest().
@@ -158,6 +161,167 @@ func (ps *parseStream) objectUniqueLatest() *parseStream {
 	return ps
 }
 
+var (
+	findObjectVersionLatestRegexp     = regexp.MustCompile(`^/api/v3/find/objectversion/object/[^\s/]+/?$`)
+	diffObjectVersionLatestRegexp     = regexp.MustCompile(`^/api/v3/find/objectversion/object/[^\s/]+/diff/?$`)
+	exportObjectVersionLatestRegexp   = regexp.MustCompile(`^/api/v3/find/objectversion/object/[^\s/]+/version/[0-9]+/export/?$`)
+	rollbackObjectVersionLatestRegexp = regexp.MustCompile(`^/api/v3/update/objectversion/object/[^\s/]+/rollback/?$`)
+)
+
+func (ps *parseStream) objectVersionLatest() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// find, compare or export the versions of the object's schema operation.
+	if ps.hitRegexp(findObjectVersionLatestRegexp, http.MethodPost) ||
+		ps.hitRegexp(diffObjectVersionLatestRegexp, http.MethodPost) ||
+		ps.hitRegexp(exportObjectVersionLatestRegexp, http.MethodPost) {
+		model, err := ps.getModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[5]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.Model,
+					Action:     meta.Find,
+					InstanceID: model[0].ID,
+				},
+			},
+		}
+		return ps
+	}
+
+	// rollback the attributes of the object to a version operation.
+	if ps.hitRegexp(rollbackObjectVersionLatestRegexp, http.MethodPost) {
+		model, err := ps.getModel(mapstr.MapStr{common.BKObjIDField: ps.RequestCtx.Elements[5]})
+		if err != nil {
+			ps.err = err
+			return ps
+		}
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:       meta.Model,
+					Action:     meta.Update,
+					InstanceID: model[0].ID,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	exportObjectBundleLatestPattern = "/api/v3/find/objectbundle/export"
+	planObjectBundleLatestPattern   = "/api/v3/find/objectbundle/plan"
+	applyObjectBundleLatestPattern  = "/api/v3/update/objectbundle/apply"
+)
+
+func (ps *parseStream) objectBundleLatest() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// export the objects as a bundle, or compare a bundle with the objects operation.
+	if ps.hitPattern(exportObjectBundleLatestPattern, http.MethodPost) ||
+		ps.hitPattern(planObjectBundleLatestPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// apply a bundle, which may create and update objects operation.
+	if ps.hitPattern(applyObjectBundleLatestPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.Create,
+				},
+			},
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.UpdateMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
+const (
+	findRecycleLatestPattern    = "/api/v3/find/recycle"
+	restoreRecycleLatestPattern = "/api/v3/update/recycle/restore"
+	purgeRecycleLatestPattern   = "/api/v3/delete/recycle/purge"
+)
+
+func (ps *parseStream) recycleLatest() *parseStream {
+	if ps.shouldReturn() {
+		return ps
+	}
+
+	// find the deleted objects and instances in the recycle bin operation.
+	if ps.hitPattern(findRecycleLatestPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	// restore the deleted objects and instances, which creates them again operation.
+	if ps.hitPattern(restoreRecycleLatestPattern, http.MethodPut) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.Create,
+				},
+			},
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelInstance,
+					Action: meta.Create,
+				},
+			},
+		}
+		return ps
+	}
+
+	// purge the deleted objects and instances permanently operation.
+	if ps.hitPattern(purgeRecycleLatestPattern, http.MethodDelete) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.Model,
+					Action: meta.DeleteMany,
+				},
+			},
+		}
+		return ps
+	}
+
+	return ps
+}
+
 const (
 	findManyAssociationKindLatestPattern = "/api/v3/find/associationtype"
 	createAssociationKindLatestPattern   = "/api/v3/create/associationtype"
@@ -414,6 +578,8 @@ func (ps *parseStream) objectAssociationLatest() *parseStream {
 const (
 	findObjectInstanceAssociationLatestPattern   = "/api/v3/find/instassociation"
 	createObjectInstanceAssociationLatestPattern = "/api/v3/create/instassociation"
+
+	findObjectInstanceAssociationMappingViolationLatestPattern = "/api/v3/find/instassociation/mapping_violation"
 )
 
 var (
@@ -444,6 +610,19 @@ func (ps *parseStream) objectInstanceAssociationLatest() *parseStream {
 		return ps
 	}
 
+	// find the instance associations which exceed the mapping of the object association.
+	if ps.hitPattern(findObjectInstanceAssociationMappingViolationLatestPattern, http.MethodPost) {
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelInstanceAssociation,
+					Action: meta.FindMany,
+				},
+			},
+		}
+		return ps
+	}
+
 	// create object's instance association operation.
 	if ps.hitPattern(createObjectInstanceAssociationLatestPattern, http.MethodPost) {
 		bizID, err := metadata.BizIDFromMetadata(ps.RequestCtx.Metadata)
@@ -550,6 +729,7 @@ var (
 	deleteObjectInstanceLatestRegexp          = regexp.MustCompile(`^/api/v3/delete/instance/object/[^\s/]+/inst/[0-9]+/?$`)
 	findObjectInstanceSubTopologyLatestRegexp = regexp.MustCompile(`^/api/v3/find/insttopo/object/[^\s/]+/inst/[0-9]+/?$`)
 	findObjectInstanceTopologyLatestRegexp    = regexp.MustCompile(`^/api/v3/find/instassttopo/object/[^\s/]+/inst/[0-9]+/?$`)
+	findObjectInstanceGraphLatestRegexp       = regexp.MustCompile(`^/api/v3/find/instgraph/object/[^\s/]+/inst/[0-9]+/?$`)
 	findBusinessInstanceTopologyLatestRegexp  = regexp.MustCompile(`^/api/v3/find/topoinst/biz/[0-9]+/?$`)
 	findObjectInstancesLatestRegexp           = regexp.MustCompile(`^/api/v3/find/instance/object/[^\s/]+/?$`)
 )
@@ -890,6 +1070,24 @@ func (ps *parseStream) objectInstanceLatest() *parseStream {
 		return ps
 	}
 
+	// find object instance association graph operation.
+	if ps.hitRegexp(findObjectInstanceGraphLatestRegexp, http.MethodPost) {
+		if len(ps.RequestCtx.Elements) != 8 {
+			ps.err = errors.New("find object instance graph, but got invalid url")
+			return ps
+		}
+
+		ps.Attribute.Resources = []meta.ResourceAttribute{
+			{
+				Basic: meta.Basic{
+					Type:   meta.ModelInstanceTopology,
+					Action: meta.Find,
+				},
+			},
+		}
+		return ps
+	}
+
 	// find business instance topology operation.
 	if ps.hitRegexp(findBusinessInstanceTopologyLatestRegexp, http.MethodPost) {
 		if len(ps.RequestCtx.Elements) != 6 {
@@ -1857,6 +1857,7 @@ func (ps *parseStream) objectUnique() *parseStream {
 var (
 	searchAuditlog               = `/api/v3/audit/search`
 	searchInstanceAuditlogRegexp = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/search/?$`)
+	searchInstanceHistoryRegexp  = regexp.MustCompile(`^/api/v3/object/[^\s/]+/audit/history/?$`)
 )
 
 func (ps *parseStream) audit() *parseStream {
@@ -1887,7 +1888,8 @@ func (ps *parseStream) instanceAudit() *parseStream {
 	}
 
 	// add object unique operation.
-	if ps.hitRegexp(searchInstanceAuditlogRegexp, http.MethodPost) {
+	if ps.hitRegexp(searchInstanceAuditlogRegexp, http.MethodPost) ||
+		ps.hitRegexp(searchInstanceHistoryRegexp, http.MethodPost) {
 		ps.Attribute.Resources = []meta.ResourceAttribute{
 			{
 				Basic: meta.Basic{
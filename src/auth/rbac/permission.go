@@ -0,0 +1,103 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"strconv"
+
+	"configcenter/src/auth/meta"
+)
+
+// the batch actions are allowed by the permission of the single ones.
+var batchActions = map[meta.Action]meta.Action{
+	meta.CreateMany: meta.Create,
+	meta.UpdateMany: meta.Update,
+	meta.DeleteMany: meta.Delete,
+	meta.FindMany:   meta.Find,
+}
+
+func singleAction(action meta.Action) meta.Action {
+	if single, ok := batchActions[action]; ok {
+		return single
+	}
+	return action
+}
+
+// Allow returns whether the permission allows the action on the resource
+func (p Permission) Allow(rsc *meta.ResourceAttribute) bool {
+	if p.ResourceType != Any && p.ResourceType != rsc.Type {
+		return false
+	}
+	if !p.allowAction(rsc.Action) {
+		return false
+	}
+	if len(p.BusinessIDs) > 0 && !p.inBusiness(rsc.BusinessID) {
+		return false
+	}
+	if len(p.InstanceIDs) > 0 && !p.hasInstance(instanceID(rsc)) {
+		return false
+	}
+	return true
+}
+
+func (p Permission) allowAction(action meta.Action) bool {
+	action = singleAction(action)
+	for _, allowed := range p.Actions {
+		if allowed == Any || singleAction(allowed) == action {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Permission) inBusiness(businessID int64) bool {
+	for _, id := range p.BusinessIDs {
+		if id == businessID {
+			return true
+		}
+	}
+	return false
+}
+
+// hasInstance returns whether the instance is in the scope, the resources without instance id
+// are not in the scope of the instances, because they are the operations on all the instances.
+func (p Permission) hasInstance(id string) bool {
+	for _, instID := range p.InstanceIDs {
+		if instID == Any || (len(id) > 0 && instID == id) {
+			return true
+		}
+	}
+	return false
+}
+
+func instanceID(rsc *meta.ResourceAttribute) string {
+	if len(rsc.InstanceIDEx) > 0 {
+		return rsc.InstanceIDEx
+	}
+	if rsc.InstanceID > 0 {
+		return strconv.FormatInt(rsc.InstanceID, 10)
+	}
+	return ""
+}
+
+// Permissions the permissions of a user
+type Permissions []Permission
+
+// Allow returns whether any of the permissions allows the action on the resource
+func (ps Permissions) Allow(rsc *meta.ResourceAttribute) bool {
+	for _, p := range ps {
+		if p.Allow(rsc) {
+			return true
+		}
+	}
+	return false
+}
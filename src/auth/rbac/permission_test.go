@@ -0,0 +1,52 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"testing"
+
+	"configcenter/src/auth/meta"
+)
+
+func TestPermissionAllow(t *testing.T) {
+	host := func(action meta.Action, bizID int64, instID int64) *meta.ResourceAttribute {
+		return &meta.ResourceAttribute{
+			Basic:      meta.Basic{Type: meta.HostInstance, Action: action, InstanceID: instID},
+			BusinessID: bizID,
+		}
+	}
+
+	cases := []struct {
+		name       string
+		permission Permission
+		resource   *meta.ResourceAttribute
+		allowed    bool
+	}{
+		{"same type and action", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Update}}, host(meta.Update, 1, 2), true},
+		{"other type", Permission{ResourceType: meta.Business, Actions: []meta.Action{meta.Update}}, host(meta.Update, 1, 2), false},
+		{"other action", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Find}}, host(meta.Update, 1, 2), false},
+		{"batch action", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Find}}, host(meta.FindMany, 1, 0), true},
+		{"any type and action", Permission{ResourceType: Any, Actions: []meta.Action{Any}}, host(meta.Delete, 1, 2), true},
+		{"in business", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Update}, BusinessIDs: []int64{1}}, host(meta.Update, 1, 2), true},
+		{"out of business", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Update}, BusinessIDs: []int64{3}}, host(meta.Update, 1, 2), false},
+		{"in instances", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Update}, InstanceIDs: []string{"2"}}, host(meta.Update, 1, 2), true},
+		{"out of instances", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Update}, InstanceIDs: []string{"3"}}, host(meta.Update, 1, 2), false},
+		{"no instance", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Find}, InstanceIDs: []string{"2"}}, host(meta.FindMany, 1, 0), false},
+		{"any instance", Permission{ResourceType: meta.HostInstance, Actions: []meta.Action{meta.Find}, InstanceIDs: []string{Any}}, host(meta.FindMany, 1, 0), true},
+	}
+
+	for _, c := range cases {
+		if allowed := c.permission.Allow(c.resource); allowed != c.allowed {
+			t.Errorf("%s: expect allowed %v, but got %v", c.name, c.allowed, allowed)
+		}
+	}
+}
@@ -0,0 +1,360 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"configcenter/src/auth/authcenter"
+	"configcenter/src/auth/authcenter/permit"
+	"configcenter/src/auth/meta"
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+	"configcenter/src/storage/dal/mongo/local"
+)
+
+// NewAuthorizer create a instance to authorize with the roles stored in cmdb's db.
+func NewAuthorizer(cfg authcenter.AuthConfig) (*Authorizer, error) {
+	blog.V(5).Infof("new local authorizer with admins: %v", cfg.Admins)
+	if !cfg.Enable {
+		return &Authorizer{Config: cfg}, nil
+	}
+
+	db, err := local.NewMgo(cfg.Mongo.BuildURI(), time.Minute)
+	if err != nil {
+		return nil, fmt.Errorf("connect mongo server failed %v", err)
+	}
+	return NewAuthorizerWithDB(cfg, db), nil
+}
+
+// NewAuthorizerWithDB create a local authorizer on the db
+func NewAuthorizerWithDB(cfg authcenter.AuthConfig, db dal.RDB) *Authorizer {
+	admins := make(map[string]bool)
+	for _, admin := range cfg.Admins {
+		admins[admin] = true
+	}
+	return &Authorizer{Config: cfg, db: db, admins: admins}
+}
+
+// Authorizer authorize the users with the roles bound to them,
+// the resources do not need to be registered to it.
+type Authorizer struct {
+	Config authcenter.AuthConfig
+	db     dal.RDB
+	admins map[string]bool
+	// policyChanged is called after the roles, role bindings or groups are changed
+	policyChanged func()
+}
+
+func (a *Authorizer) Enabled() bool {
+	return a.Config.Enable
+}
+
+// OnPolicyChange registers the function to be called after the roles, role bindings or groups are changed
+func (a *Authorizer) OnPolicyChange(f func()) {
+	a.policyChanged = f
+}
+
+func (a *Authorizer) notifyPolicyChange() {
+	if a.policyChanged != nil {
+		a.policyChanged()
+	}
+}
+
+// IsAdmin returns whether the user is the administrator, who has all the permissions
+func (a *Authorizer) IsAdmin(userName string) bool {
+	return a.admins[userName]
+}
+
+func (a *Authorizer) Authorize(ctx context.Context, attr *meta.AuthAttribute) (decision meta.Decision, err error) {
+	decisions, err := a.AuthorizeBatch(ctx, attr.User, attr.Resources...)
+	if err != nil {
+		return meta.Decision{}, err
+	}
+
+	noAuth := make([]string, 0)
+	for i, item := range decisions {
+		if !item.Authorized {
+			noAuth = append(noAuth, fmt.Sprintf("resource [%v] permission deny by reason: %s", attr.Resources[i].Type, item.Reason))
+		}
+	}
+	if len(noAuth) > 0 {
+		return meta.Decision{Authorized: false, Reason: fmt.Sprintf("%v", noAuth)}, nil
+	}
+
+	return meta.Decision{Authorized: true}, nil
+}
+
+func (a *Authorizer) AuthorizeBatch(ctx context.Context, user meta.UserInfo, resources ...meta.ResourceAttribute) (decisions []meta.Decision, err error) {
+	rid := util.ExtractRequestIDFromContext(ctx)
+	decisions = make([]meta.Decision, len(resources))
+	if !a.Config.Enable || a.IsAdmin(user.UserName) {
+		for i := range decisions {
+			decisions[i].Authorized = true
+		}
+		return decisions, nil
+	}
+
+	var permissions Permissions
+	for i := range resources {
+		rsc := &resources[i]
+		if rsc.Action == meta.SkipAction || permit.ShouldSkipAuthorize(rsc) {
+			decisions[i].Authorized = true
+			blog.V(5).Infof("skip authorization for resource: %+v, rid: %s", rsc, rid)
+			continue
+		}
+
+		// the permissions are only loaded when there are resources to be authorized
+		if permissions == nil {
+			permissions, err = a.UserPermissions(ctx, user)
+			if err != nil {
+				blog.Errorf("auth batch, but get the permissions of user %s failed, err: %v, rid: %s", user.UserName, err, rid)
+				return nil, err
+			}
+		}
+
+		decisions[i].Authorized = permissions.Allow(rsc)
+		if !decisions[i].Authorized {
+			decisions[i].Reason = fmt.Sprintf("user %s has no role which allows %s on %s", user.UserName, rsc.Action, rsc.Type)
+		}
+	}
+
+	return decisions, nil
+}
+
+// UserPermissions returns the permissions of the roles bound to the user and the groups of the user
+func (a *Authorizer) UserPermissions(ctx context.Context, user meta.UserInfo) (Permissions, error) {
+	roles, err := a.userRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make(Permissions, 0)
+	for _, role := range roles {
+		permissions = append(permissions, role.Permissions...)
+	}
+	return permissions, nil
+}
+
+func (a *Authorizer) userRoles(ctx context.Context, user meta.UserInfo) ([]Role, error) {
+	groups := make([]Group, 0)
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(user.SupplierAccount)
+	cond.Field("members").Eq(user.UserName)
+	if err := a.db.Table(common.BKTableNameAuthGroup).Find(cond.ToMapStr()).Fields("name").All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	subjects := []mapstr.MapStr{subjectCond(SubjectUser, user.UserName)}
+	for _, group := range groups {
+		subjects = append(subjects, subjectCond(SubjectGroup, group.Name))
+	}
+	bindingCond := mapstr.MapStr{
+		common.BKOwnerIDField: user.SupplierAccount,
+		common.BKDBOR:         subjects,
+	}
+	bindings := make([]RoleBinding, 0)
+	if err := a.db.Table(common.BKTableNameAuthRoleBinding).Find(bindingCond).All(ctx, &bindings); err != nil {
+		return nil, err
+	}
+	if len(bindings) == 0 {
+		return []Role{}, nil
+	}
+
+	roleIDs := make([]int64, 0, len(bindings))
+	for _, binding := range bindings {
+		roleIDs = append(roleIDs, binding.RoleID)
+	}
+	roles := make([]Role, 0)
+	roleCond := condition.CreateCondition()
+	roleCond.Field(common.BKOwnerIDField).Eq(user.SupplierAccount)
+	roleCond.Field(common.BKFieldID).In(roleIDs)
+	if err := a.db.Table(common.BKTableNameAuthRole).Find(roleCond.ToMapStr()).All(ctx, &roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func subjectCond(subjectType, name string) mapstr.MapStr {
+	return mapstr.MapStr{"subjects": mapstr.MapStr{"$elemMatch": mapstr.MapStr{"type": subjectType, "name": name}}}
+}
+
+func (a *Authorizer) GetAuthorizedBusinessList(ctx context.Context, user meta.UserInfo) ([]int64, error) {
+	if !a.Config.Enable {
+		return []int64{}, nil
+	}
+
+	allBusiness := a.IsAdmin(user.UserName)
+	businessIDs := make([]int64, 0)
+	if !allBusiness {
+		permissions, err := a.UserPermissions(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range permissions {
+			isBusiness := p.ResourceType == Any || p.ResourceType == meta.Business
+			if isBusiness && len(p.BusinessIDs) == 0 && (len(p.InstanceIDs) == 0 || p.hasInstance(Any)) {
+				allBusiness = true
+				break
+			}
+			businessIDs = append(businessIDs, p.BusinessIDs...)
+			if !isBusiness {
+				continue
+			}
+			for _, instID := range p.InstanceIDs {
+				if id, err := strconv.ParseInt(instID, 10, 64); err == nil {
+					businessIDs = append(businessIDs, id)
+				}
+			}
+		}
+	}
+
+	if allBusiness {
+		apps := make([]mapstr.MapStr, 0)
+		cond := condition.CreateCondition().Field(common.BKOwnerIDField).Eq(user.SupplierAccount)
+		if err := a.db.Table(common.BKTableNameBaseApp).Find(cond.ToMapStr()).Fields(common.BKAppIDField).All(ctx, &apps); err != nil {
+			return nil, err
+		}
+		businessIDs = make([]int64, 0, len(apps))
+		for _, app := range apps {
+			id, err := app.Int64(common.BKAppIDField)
+			if err != nil {
+				return nil, err
+			}
+			businessIDs = append(businessIDs, id)
+		}
+	}
+
+	return util.IntArrayUnique(businessIDs), nil
+}
+
+func (a *Authorizer) AdminEntrance(ctx context.Context, user meta.UserInfo) ([]string, error) {
+	if !a.Config.Enable {
+		return []string{}, nil
+	}
+	if a.IsAdmin(user.UserName) {
+		return []string{authcenter.SystemIDCMDB}, nil
+	}
+
+	roles, err := a.userRoles(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) == 0 {
+		return []string{}, nil
+	}
+	return []string{authcenter.SystemIDCMDB}, nil
+}
+
+// GetAuthorizedAuditList returns the models whose audit logs can be read by the user,
+// the instances of the audit log permission are the model ids.
+func (a *Authorizer) GetAuthorizedAuditList(ctx context.Context, user meta.UserInfo, businessID int64) ([]authcenter.AuthorizedResource, error) {
+	if !a.Config.Enable {
+		return []authcenter.AuthorizedResource{}, nil
+	}
+
+	allModels := a.IsAdmin(user.UserName)
+	modelIDs := make([]string, 0)
+	if !allModels {
+		permissions, err := a.UserPermissions(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range permissions {
+			if p.ResourceType != Any && p.ResourceType != meta.AuditLog || !p.allowAction(meta.Find) {
+				continue
+			}
+			// business 0 means any business, so only the permissions without business scope match it.
+			if businessID == 0 && len(p.BusinessIDs) > 0 || businessID > 0 && len(p.BusinessIDs) > 0 && !p.inBusiness(businessID) {
+				continue
+			}
+			if len(p.InstanceIDs) == 0 || p.hasInstance(Any) {
+				allModels = true
+				break
+			}
+			modelIDs = append(modelIDs, p.InstanceIDs...)
+		}
+	}
+
+	if allModels {
+		models := make([]mapstr.MapStr, 0)
+		cond := condition.CreateCondition().Field(common.BKOwnerIDField).Eq(user.SupplierAccount)
+		if err := a.db.Table(common.BKTableNameObjDes).Find(cond.ToMapStr()).Fields(common.BKObjIDField).All(ctx, &models); err != nil {
+			return nil, err
+		}
+		modelIDs = make([]string, 0, len(models))
+		for _, model := range models {
+			modelID, err := model.String(common.BKObjIDField)
+			if err != nil {
+				return nil, err
+			}
+			modelIDs = append(modelIDs, modelID)
+		}
+	}
+	if len(modelIDs) == 0 {
+		return []authcenter.AuthorizedResource{}, nil
+	}
+
+	resource := authcenter.AuthorizedResource{
+		ActionID:     authcenter.Get,
+		ResourceType: authcenter.SysAuditLog,
+		ResourceIDs:  make([][]authcenter.RscTypeAndID, 0, len(modelIDs)),
+	}
+	if businessID > 0 {
+		resource.ResourceType = authcenter.BizAuditLog
+	}
+	for _, modelID := range util.StrArrayUnique(modelIDs) {
+		resource.ResourceIDs = append(resource.ResourceIDs, []authcenter.RscTypeAndID{{ResourceType: authcenter.SysModel, ResourceID: modelID}})
+	}
+	return []authcenter.AuthorizedResource{resource}, nil
+}
+
+// the resources need not to be registered to the local authorizer, so the resource handler does nothing.
+
+func (a *Authorizer) RegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) DryRunRegisterResource(ctx context.Context, rs ...meta.ResourceAttribute) (*authcenter.RegisterInfo, error) {
+	return &authcenter.RegisterInfo{}, nil
+}
+
+func (a *Authorizer) DeregisterResource(ctx context.Context, rs ...meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) RawDeregisterResource(ctx context.Context, scope authcenter.ScopeInfo, rs ...meta.BackendResource) error {
+	return nil
+}
+
+func (a *Authorizer) UpdateResource(ctx context.Context, rs *meta.ResourceAttribute) error {
+	return nil
+}
+
+func (a *Authorizer) Get(ctx context.Context) error {
+	return nil
+}
+
+func (a *Authorizer) ListResources(ctx context.Context, r *meta.ResourceAttribute) ([]meta.BackendResource, error) {
+	return []meta.BackendResource{}, nil
+}
+
+func (a *Authorizer) Init(ctx context.Context, config meta.InitConfig) error {
+	return nil
+}
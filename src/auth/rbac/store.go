@@ -0,0 +1,385 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// CreateRole creates the role, and returns it with the id
+func (a *Authorizer) CreateRole(ctx context.Context, ownerID string, role Role) (*Role, error) {
+	id, err := a.newID(ctx, common.BKTableNameAuthRole, ownerID, role.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := metadata.Now()
+	role.ID, role.OwnerID, role.CreateTime, role.LastTime = id, ownerID, now, now
+	if role.Permissions == nil {
+		role.Permissions = make([]Permission, 0)
+	}
+	if err := a.insert(ctx, common.BKTableNameAuthRole, role); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+// UpdateRole replaces the name, description and permissions of the role
+func (a *Authorizer) UpdateRole(ctx context.Context, ownerID string, id int64, role Role) error {
+	if role.Permissions == nil {
+		role.Permissions = make([]Permission, 0)
+	}
+	data := mapstr.MapStr{
+		"name":        role.Name,
+		"description": role.Description,
+		"permissions": role.Permissions,
+	}
+	return a.update(ctx, common.BKTableNameAuthRole, ownerID, id, role.Name, data)
+}
+
+// DeleteRole deletes the role and its bindings
+func (a *Authorizer) DeleteRole(ctx context.Context, ownerID string, id int64) error {
+	if err := a.delete(ctx, common.BKTableNameAuthRole, ownerID, id); err != nil {
+		return err
+	}
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field("role_id").Eq(id)
+	if err := a.db.Table(common.BKTableNameAuthRoleBinding).Delete(ctx, cond.ToMapStr()); err != nil {
+		return err
+	}
+	a.notifyPolicyChange()
+	return nil
+}
+
+// SearchRoles finds the roles matching the condition
+func (a *Authorizer) SearchRoles(ctx context.Context, ownerID string, param SearchParam) (*SearchResult, error) {
+	roles := make([]Role, 0)
+	count, err := a.search(ctx, common.BKTableNameAuthRole, ownerID, param, &roles)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Count: count, Info: roles}, nil
+}
+
+// CreateRoleBinding binds the role to the subjects, and returns the binding with the id
+func (a *Authorizer) CreateRoleBinding(ctx context.Context, ownerID string, binding RoleBinding) (*RoleBinding, error) {
+	if err := a.checkRole(ctx, ownerID, binding.RoleID); err != nil {
+		return nil, err
+	}
+	id, err := a.newID(ctx, common.BKTableNameAuthRoleBinding, ownerID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := metadata.Now()
+	binding.ID, binding.OwnerID, binding.CreateTime, binding.LastTime = id, ownerID, now, now
+	if err := a.insert(ctx, common.BKTableNameAuthRoleBinding, binding); err != nil {
+		return nil, err
+	}
+	return &binding, nil
+}
+
+// UpdateRoleBinding replaces the role and the subjects of the binding
+func (a *Authorizer) UpdateRoleBinding(ctx context.Context, ownerID string, id int64, binding RoleBinding) error {
+	if err := a.checkRole(ctx, ownerID, binding.RoleID); err != nil {
+		return err
+	}
+	data := mapstr.MapStr{
+		"role_id":  binding.RoleID,
+		"subjects": binding.Subjects,
+	}
+	return a.update(ctx, common.BKTableNameAuthRoleBinding, ownerID, id, "", data)
+}
+
+// DeleteRoleBinding deletes the role binding
+func (a *Authorizer) DeleteRoleBinding(ctx context.Context, ownerID string, id int64) error {
+	return a.delete(ctx, common.BKTableNameAuthRoleBinding, ownerID, id)
+}
+
+// SearchRoleBindings finds the role bindings matching the condition
+func (a *Authorizer) SearchRoleBindings(ctx context.Context, ownerID string, param SearchParam) (*SearchResult, error) {
+	bindings := make([]RoleBinding, 0)
+	count, err := a.search(ctx, common.BKTableNameAuthRoleBinding, ownerID, param, &bindings)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Count: count, Info: bindings}, nil
+}
+
+// CreateGroup creates the user group, and returns it with the id
+func (a *Authorizer) CreateGroup(ctx context.Context, ownerID string, group Group) (*Group, error) {
+	id, err := a.newID(ctx, common.BKTableNameAuthGroup, ownerID, group.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := metadata.Now()
+	group.ID, group.OwnerID, group.CreateTime, group.LastTime = id, ownerID, now, now
+	if group.Members == nil {
+		group.Members = make([]string, 0)
+	}
+	if err := a.insert(ctx, common.BKTableNameAuthGroup, group); err != nil {
+		return nil, err
+	}
+	return &group, nil
+}
+
+// UpdateGroup replaces the name, description and members of the group,
+// the bindings of the group follow the new name.
+func (a *Authorizer) UpdateGroup(ctx context.Context, ownerID string, id int64, group Group) error {
+	old, err := a.getGroup(ctx, ownerID, id)
+	if err != nil {
+		return err
+	}
+
+	if group.Members == nil {
+		group.Members = make([]string, 0)
+	}
+	data := mapstr.MapStr{
+		"name":        group.Name,
+		"description": group.Description,
+		"members":     group.Members,
+	}
+	if err := a.update(ctx, common.BKTableNameAuthGroup, ownerID, id, group.Name, data); err != nil {
+		return err
+	}
+	if old.Name == group.Name {
+		return nil
+	}
+	if err := a.renameSubject(ctx, ownerID, SubjectGroup, old.Name, group.Name); err != nil {
+		return err
+	}
+	a.notifyPolicyChange()
+	return nil
+}
+
+// DeleteGroup deletes the group and removes it from the role bindings, so that a new group
+// with the same name gains nothing. the bindings left without any subject are deleted too.
+func (a *Authorizer) DeleteGroup(ctx context.Context, ownerID string, id int64) error {
+	group, err := a.getGroup(ctx, ownerID, id)
+	if err != nil {
+		return err
+	}
+	// the bindings are removed first, so a failure leaves the group with less permissions rather than more
+	if err := a.removeSubject(ctx, ownerID, SubjectGroup, group.Name); err != nil {
+		return err
+	}
+	return a.delete(ctx, common.BKTableNameAuthGroup, ownerID, id)
+}
+
+func (a *Authorizer) getGroup(ctx context.Context, ownerID string, id int64) (*Group, error) {
+	groups := make([]Group, 0)
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKFieldID).Eq(id)
+	if err := a.db.Table(common.BKTableNameAuthGroup).Find(cond.ToMapStr()).Limit(1).All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 {
+		return nil, ErrNotFound
+	}
+	return &groups[0], nil
+}
+
+// SearchGroups finds the groups matching the condition
+func (a *Authorizer) SearchGroups(ctx context.Context, ownerID string, param SearchParam) (*SearchResult, error) {
+	groups := make([]Group, 0)
+	count, err := a.search(ctx, common.BKTableNameAuthGroup, ownerID, param, &groups)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchResult{Count: count, Info: groups}, nil
+}
+
+// newID returns the id of the new document, and checks the name is not used if it is not empty
+func (a *Authorizer) newID(ctx context.Context, table, ownerID, name string) (int64, error) {
+	if len(name) > 0 {
+		if err := a.checkName(ctx, table, ownerID, name, 0); err != nil {
+			return 0, err
+		}
+	}
+	id, err := a.db.NextSequence(ctx, table)
+	if err != nil {
+		return 0, err
+	}
+	return int64(id), nil
+}
+
+func (a *Authorizer) checkName(ctx context.Context, table, ownerID, name string, id int64) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKFieldName).Eq(name)
+	cond.Field(common.BKFieldID).NotEq(id)
+	count, err := a.db.Table(table).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicated
+	}
+	return nil
+}
+
+func (a *Authorizer) checkRole(ctx context.Context, ownerID string, roleID int64) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKFieldID).Eq(roleID)
+	count, err := a.db.Table(common.BKTableNameAuthRole).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (a *Authorizer) insert(ctx context.Context, table string, doc interface{}) error {
+	err := a.db.Table(table).Insert(ctx, doc)
+	if err != nil && a.db.IsDuplicatedError(err) {
+		return ErrDuplicated
+	}
+	if err != nil {
+		return err
+	}
+	a.notifyPolicyChange()
+	return nil
+}
+
+func (a *Authorizer) update(ctx context.Context, table, ownerID string, id int64, name string, data mapstr.MapStr) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKFieldID).Eq(id)
+	count, err := a.db.Table(table).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	if len(name) > 0 {
+		if err := a.checkName(ctx, table, ownerID, name, id); err != nil {
+			return err
+		}
+	}
+
+	data[common.LastTimeField] = time.Now()
+	err = a.db.Table(table).Update(ctx, cond.ToMapStr(), data)
+	if err != nil && a.db.IsDuplicatedError(err) {
+		return ErrDuplicated
+	}
+	if err != nil {
+		return err
+	}
+	a.notifyPolicyChange()
+	return nil
+}
+
+func (a *Authorizer) delete(ctx context.Context, table, ownerID string, id int64) error {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	cond.Field(common.BKFieldID).Eq(id)
+	count, err := a.db.Table(table).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	if err := a.db.Table(table).Delete(ctx, cond.ToMapStr()); err != nil {
+		return err
+	}
+	a.notifyPolicyChange()
+	return nil
+}
+
+func (a *Authorizer) search(ctx context.Context, table, ownerID string, param SearchParam, result interface{}) (uint64, error) {
+	cond := param.Condition
+	if cond == nil {
+		cond = mapstr.MapStr{}
+	}
+	cond[common.BKOwnerIDField] = ownerID
+
+	count, err := a.db.Table(table).Find(cond).Count(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sort := param.Page.Sort
+	if len(sort) == 0 {
+		sort = common.BKFieldID
+	}
+	find := a.db.Table(table).Find(cond).Sort(sort).Start(uint64(param.Page.Start))
+	if param.Page.Limit > 0 {
+		find = find.Limit(uint64(param.Page.Limit))
+	}
+	return count, find.All(ctx, result)
+}
+
+// renameSubject updates the subject name in the role bindings
+func (a *Authorizer) renameSubject(ctx context.Context, ownerID, subjectType, oldName, newName string) error {
+	bindings := make([]RoleBinding, 0)
+	cond := subjectCond(subjectType, oldName)
+	cond[common.BKOwnerIDField] = ownerID
+	if err := a.db.Table(common.BKTableNameAuthRoleBinding).Find(cond).All(ctx, &bindings); err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		for i := range binding.Subjects {
+			if binding.Subjects[i].Type == subjectType && binding.Subjects[i].Name == oldName {
+				binding.Subjects[i].Name = newName
+			}
+		}
+		idCond := condition.CreateCondition().Field(common.BKFieldID).Eq(binding.ID)
+		if err := a.db.Table(common.BKTableNameAuthRoleBinding).Update(ctx, idCond.ToMapStr(), mapstr.MapStr{"subjects": binding.Subjects}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeSubject removes the subject from the role bindings, and deletes the bindings without any subject left
+func (a *Authorizer) removeSubject(ctx context.Context, ownerID, subjectType, name string) error {
+	bindings := make([]RoleBinding, 0)
+	cond := subjectCond(subjectType, name)
+	cond[common.BKOwnerIDField] = ownerID
+	if err := a.db.Table(common.BKTableNameAuthRoleBinding).Find(cond).All(ctx, &bindings); err != nil {
+		return err
+	}
+
+	for _, binding := range bindings {
+		subjects := make([]Subject, 0, len(binding.Subjects))
+		for _, subject := range binding.Subjects {
+			if subject.Type != subjectType || subject.Name != name {
+				subjects = append(subjects, subject)
+			}
+		}
+		idCond := condition.CreateCondition().Field(common.BKFieldID).Eq(binding.ID)
+		if len(subjects) == 0 {
+			if err := a.db.Table(common.BKTableNameAuthRoleBinding).Delete(ctx, idCond.ToMapStr()); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := a.db.Table(common.BKTableNameAuthRoleBinding).Update(ctx, idCond.ToMapStr(), mapstr.MapStr{"subjects": subjects}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
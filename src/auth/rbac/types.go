@@ -0,0 +1,153 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package rbac
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"configcenter/src/auth/meta"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// the wildcard matches any resource type, action, business or instance
+const Any = "*"
+
+// the types of the subjects which a role can be bound to
+const (
+	SubjectUser  = "user"
+	SubjectGroup = "group"
+)
+
+var (
+	// ErrNotFound the role, role binding or group does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrDuplicated the name of the role or group is used by the other one
+	ErrDuplicated = errors.New("duplicated")
+)
+
+// Permission allows the actions on the resources of a type, in the scope of the businesses and instances.
+// empty BusinessIDs or InstanceIDs means any business or instance.
+type Permission struct {
+	ResourceType meta.ResourceType `bson:"resource_type" json:"resource_type"`
+	Actions      []meta.Action     `bson:"actions" json:"actions"`
+	BusinessIDs  []int64           `bson:"business_ids" json:"business_ids"`
+	InstanceIDs  []string          `bson:"instance_ids" json:"instance_ids"`
+}
+
+// Role a set of permissions
+type Role struct {
+	ID          int64         `bson:"id" json:"id"`
+	Name        string        `bson:"name" json:"name"`
+	Description string        `bson:"description" json:"description"`
+	Permissions []Permission  `bson:"permissions" json:"permissions"`
+	OwnerID     string        `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime  metadata.Time `bson:"create_time" json:"create_time"`
+	LastTime    metadata.Time `bson:"last_time" json:"last_time"`
+}
+
+// Subject a user or a group which a role is bound to
+type Subject struct {
+	Type string `bson:"type" json:"type"`
+	Name string `bson:"name" json:"name"`
+}
+
+// RoleBinding grants the permissions of a role to the subjects
+type RoleBinding struct {
+	ID         int64         `bson:"id" json:"id"`
+	RoleID     int64         `bson:"role_id" json:"role_id"`
+	Subjects   []Subject     `bson:"subjects" json:"subjects"`
+	OwnerID    string        `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime metadata.Time `bson:"create_time" json:"create_time"`
+	LastTime   metadata.Time `bson:"last_time" json:"last_time"`
+}
+
+// Group a set of users
+type Group struct {
+	ID          int64         `bson:"id" json:"id"`
+	Name        string        `bson:"name" json:"name"`
+	Description string        `bson:"description" json:"description"`
+	Members     []string      `bson:"members" json:"members"`
+	OwnerID     string        `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime  metadata.Time `bson:"create_time" json:"create_time"`
+	LastTime    metadata.Time `bson:"last_time" json:"last_time"`
+}
+
+// SearchParam the condition to search the roles, role bindings or groups
+type SearchParam struct {
+	Condition mapstr.MapStr     `json:"condition"`
+	Page      metadata.BasePage `json:"page"`
+}
+
+// SearchResult the result of the search
+type SearchResult struct {
+	Count uint64      `json:"count"`
+	Info  interface{} `json:"info"`
+}
+
+// Validate checks the permission is complete
+func (p Permission) Validate() error {
+	if len(p.ResourceType) == 0 {
+		return errors.New("resource_type is required")
+	}
+	if len(p.Actions) == 0 {
+		return fmt.Errorf("actions of resource_type %s is required", p.ResourceType)
+	}
+	for _, action := range p.Actions {
+		if len(action) == 0 {
+			return fmt.Errorf("actions of resource_type %s contains empty action", p.ResourceType)
+		}
+	}
+	return nil
+}
+
+// Validate checks the role is complete
+func (r Role) Validate() error {
+	if len(strings.TrimSpace(r.Name)) == 0 {
+		return errors.New("name is required")
+	}
+	for _, permission := range r.Permissions {
+		if err := permission.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks the role binding is complete
+func (b RoleBinding) Validate() error {
+	if b.RoleID <= 0 {
+		return errors.New("role_id is required")
+	}
+	if len(b.Subjects) == 0 {
+		return errors.New("subjects is required")
+	}
+	for _, subject := range b.Subjects {
+		if subject.Type != SubjectUser && subject.Type != SubjectGroup {
+			return fmt.Errorf("invalid subject type %s", subject.Type)
+		}
+		if len(subject.Name) == 0 {
+			return errors.New("subject name is required")
+		}
+	}
+	return nil
+}
+
+// Validate checks the group is complete
+func (g Group) Validate() error {
+	if len(strings.TrimSpace(g.Name)) == 0 {
+		return errors.New("name is required")
+	}
+	return nil
+}
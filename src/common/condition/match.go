@@ -0,0 +1,187 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	types "configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// Match check whether the data matches all the condition items in memory,
+// it works as the mongodb query does, the array value matches if any of its elements matches.
+func Match(items []metadata.ConditionItem, data types.MapStr) (bool, error) {
+	for _, item := range items {
+		val, exists := data[item.Field]
+		matched, err := matchItem(item, val, exists)
+		if err != nil {
+			return false, err
+		}
+		if !matched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ValidateConditionItems check whether the condition items can be used by Match
+func ValidateConditionItems(items []metadata.ConditionItem) error {
+	for _, item := range items {
+		if item.Field == "" {
+			return fmt.Errorf("condition field is empty")
+		}
+		switch item.Operator {
+		case BKDBEQ, BKDBNE, BKDBGT, BKDBGTE, BKDBLT, BKDBLTE, BKDBEXISTS:
+		case BKDBIN, BKDBNIN:
+			if item.Value != nil && reflect.TypeOf(item.Value).Kind() != reflect.Slice {
+				return fmt.Errorf("the value of %s %s must be an array", item.Field, item.Operator)
+			}
+		case BKDBLIKE:
+			if _, err := regexp.Compile(util.GetStrByInterface(item.Value)); err != nil {
+				return fmt.Errorf("the value of %s %s is not a valid regular expression, %v", item.Field, item.Operator, err)
+			}
+		default:
+			return fmt.Errorf("unsupported operator %s of field %s", item.Operator, item.Field)
+		}
+	}
+	return nil
+}
+
+func matchItem(item metadata.ConditionItem, val interface{}, exists bool) (bool, error) {
+	switch item.Operator {
+	case BKDBEXISTS:
+		want, ok := item.Value.(bool)
+		if !ok {
+			want = true
+		}
+		return exists == want, nil
+	case BKDBNE:
+		matched, err := matchAny(val, func(v interface{}) (bool, error) { return equal(v, item.Value), nil })
+		return !matched, err
+	case BKDBNIN:
+		matched, err := matchAny(val, func(v interface{}) (bool, error) { return in(v, item.Value), nil })
+		return !matched, err
+	}
+
+	if !exists {
+		return false, nil
+	}
+	return matchAny(val, func(v interface{}) (bool, error) {
+		switch item.Operator {
+		case BKDBEQ:
+			return equal(v, item.Value), nil
+		case BKDBIN:
+			return in(v, item.Value), nil
+		case BKDBGT, BKDBGTE, BKDBLT, BKDBLTE:
+			return compare(item.Operator, v, item.Value), nil
+		case BKDBLIKE:
+			reg, err := regexp.Compile(util.GetStrByInterface(item.Value))
+			if err != nil {
+				return false, err
+			}
+			s, ok := v.(string)
+			return ok && reg.MatchString(s), nil
+		default:
+			return false, fmt.Errorf("unsupported operator %s", item.Operator)
+		}
+	})
+}
+
+// matchAny apply fn on each element if val is an array, otherwise apply fn on val directly
+func matchAny(val interface{}, fn func(v interface{}) (bool, error)) (bool, error) {
+	if val != nil && reflect.TypeOf(val).Kind() == reflect.Slice {
+		for _, v := range util.ConverToInterfaceSlice(val) {
+			matched, err := fn(v)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	return fn(val)
+}
+
+func in(val interface{}, values interface{}) bool {
+	for _, v := range util.ConverToInterfaceSlice(values) {
+		if equal(val, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		fa, _ := util.GetFloat64ByInterface(a)
+		fb, _ := util.GetFloat64ByInterface(b)
+		return fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+func compare(operator string, a, b interface{}) bool {
+	var result int
+	switch {
+	case isNumber(a) && isNumber(b):
+		fa, _ := util.GetFloat64ByInterface(a)
+		fb, _ := util.GetFloat64ByInterface(b)
+		switch {
+		case fa < fb:
+			result = -1
+		case fa > fb:
+			result = 1
+		}
+	default:
+		sa, aok := a.(string)
+		sb, bok := b.(string)
+		if !aok || !bok {
+			return false
+		}
+		switch {
+		case sa < sb:
+			result = -1
+		case sa > sb:
+			result = 1
+		}
+	}
+
+	switch operator {
+	case BKDBGT:
+		return result > 0
+	case BKDBGTE:
+		return result >= 0
+	case BKDBLT:
+		return result < 0
+	case BKDBLTE:
+		return result <= 0
+	}
+	return false
+}
+
+func isNumber(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+	switch reflect.TypeOf(val).Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	_, ok := val.(interface{ Float64() (float64, error) })
+	return ok
+}
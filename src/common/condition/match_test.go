@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package condition
+
+import (
+	"encoding/json"
+	"testing"
+
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestMatch(t *testing.T) {
+	data := mapstr.MapStr{}
+	if err := json.Unmarshal([]byte(`{"bk_host_innerip":"10.0.0.1","bk_cpu":8,"bk_biz_id":[2,3],"operator":"admin"}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		items []metadata.ConditionItem
+		want  bool
+	}{
+		{"empty", nil, true},
+		{"eq", []metadata.ConditionItem{{Field: "operator", Operator: BKDBEQ, Value: "admin"}}, true},
+		{"eq number", []metadata.ConditionItem{{Field: "bk_cpu", Operator: BKDBEQ, Value: int64(8)}}, true},
+		{"eq array", []metadata.ConditionItem{{Field: "bk_biz_id", Operator: BKDBEQ, Value: 3}}, true},
+		{"ne", []metadata.ConditionItem{{Field: "operator", Operator: BKDBNE, Value: "admin"}}, false},
+		{"ne not exists", []metadata.ConditionItem{{Field: "bk_os_type", Operator: BKDBNE, Value: "1"}}, true},
+		{"in", []metadata.ConditionItem{{Field: "bk_biz_id", Operator: BKDBIN, Value: []int64{1, 2}}}, true},
+		{"nin", []metadata.ConditionItem{{Field: "bk_biz_id", Operator: BKDBNIN, Value: []int64{1, 2}}}, false},
+		{"gt", []metadata.ConditionItem{{Field: "bk_cpu", Operator: BKDBGT, Value: 4}}, true},
+		{"lte", []metadata.ConditionItem{{Field: "bk_cpu", Operator: BKDBLTE, Value: 4}}, false},
+		{"regex", []metadata.ConditionItem{{Field: "bk_host_innerip", Operator: BKDBLIKE, Value: `^10\.`}}, true},
+		{"exists", []metadata.ConditionItem{{Field: "bk_os_type", Operator: BKDBEXISTS, Value: false}}, true},
+		{"and", []metadata.ConditionItem{
+			{Field: "operator", Operator: BKDBEQ, Value: "admin"},
+			{Field: "bk_cpu", Operator: BKDBLT, Value: 8},
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Match(tt.items, data)
+			if err != nil {
+				t.Fatalf("Match() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConditionItems(t *testing.T) {
+	if err := ValidateConditionItems([]metadata.ConditionItem{{Field: "a", Operator: BKDBIN, Value: []string{"x"}}}); err != nil {
+		t.Errorf("ValidateConditionItems() error = %v", err)
+	}
+	if err := ValidateConditionItems([]metadata.ConditionItem{{Field: "a", Operator: "$where", Value: "1"}}); err == nil {
+		t.Errorf("ValidateConditionItems() should fail with unsupported operator")
+	}
+	if err := ValidateConditionItems([]metadata.ConditionItem{{Field: "a", Operator: BKDBIN, Value: "x"}}); err == nil {
+		t.Errorf("ValidateConditionItems() should fail with non-array value")
+	}
+}
@@ -169,6 +169,9 @@ const (
 	// BKDBNot the db opeartor
 	BKDBNot = "$not"
 
+	// BKDBElemMatch the db opeartor
+	BKDBElemMatch = "$elemMatch"
+
 	// BKDBCount the db opeartor
 	BKDBCount = "$count"
 
@@ -187,6 +190,12 @@ const (
 	// BKDBUNSET the db opeartor
 	BKDBUNSET = "$unset"
 
+	// BKDBIPIn the ip operator, matches the ip attributes whose value is within the ip or cidr
+	BKDBIPIn = "$ipin"
+
+	// BKDBIPContains the ip operator, matches the ip attributes whose value contains the ip or cidr
+	BKDBIPContains = "$ipcontains"
+
 	// BKDBSortFieldSep the db sort field split char
 	BKDBSortFieldSep = ","
 )
@@ -248,6 +257,18 @@ const (
 	// BKAttrChangedHost the cloud sync attr changed hosts
 	BKAttrChangedHost = "attr_changed"
 
+	// BKTerminatedHost the cloud sync hosts whose cloud instances are terminated
+	BKTerminatedHost = "terminated"
+
+	// BKSyncedInstances the cloud instances returned by the last cloud sync
+	BKSyncedInstances = "bk_synced_instances"
+
+	// BKTerminatedPolicy the cloud sync policy on the hosts whose cloud instances are terminated
+	BKTerminatedPolicy = "bk_terminated_policy"
+
+	// BKMappingRules the cloud sync rules to map the cloud instances to the hosts
+	BKMappingRules = "bk_mapping_rules"
+
 	// BKCloudConfirm whether new add cloud hosts need confirm
 	BKCloudConfirm = "bk_confirm"
 
@@ -692,6 +713,18 @@ const (
 	// FieldTypeBool the bool type
 	FieldTypeBool string = "bool"
 
+	// FieldTypeComputed the computed field type, the value is evaluated by the expression in the option
+	FieldTypeComputed string = "computed"
+
+	// FieldTypeList the list field type, the value is an array of the items with the type in the option
+	FieldTypeList string = "list"
+
+	// FieldTypeTable the table field type, the value is an array of the rows with the columns in the option
+	FieldTypeTable string = "table"
+
+	// FieldTypeIP the ip address or cidr field type
+	FieldTypeIP string = "ip"
+
 	// FieldTypeSingleLenChar the single char length limit
 	FieldTypeSingleLenChar int = 256
 
@@ -835,6 +868,8 @@ const (
 	BKHTTPOtherRequestID  = "X-Bkapi-Request-Id"
 	BKHTTPCCRequestTime   = "Cc_Request_Time"
 	BKHTTPCCTransactionID = "Cc_Txn_Id"
+	// BKHTTPEventSignature the signature of the event callback body
+	BKHTTPEventSignature = "X-Bk-Cmdb-Signature"
 )
 
 type CCContextKey string
@@ -898,6 +933,16 @@ const (
 	BKDataRecoverSuffix = "(recover)"
 )
 
+const (
+	// BKIPRangeField the field holds the address ranges of the ip attributes of the instance,
+	// which are keyed by the property id and searched by the ip operators.
+	BKIPRangeField = "bk_ip_range"
+
+	// ListValueSeparator the separator of the items of the list attribute in the text form,
+	// such as the excel cell and the legacy long char value.
+	ListValueSeparator = ","
+)
+
 const (
 	// period default value
 	Infinite = "∞"
@@ -973,6 +1018,19 @@ const (
 	RedisCloudSyncStartLockKey                = BKCacheKeyV3Prefix + "lock:cloudsyncstart"
 )
 
+// auth cache keys
+const (
+	// AuthCacheDecisionPrefix the decisions on a resource type, each decision has its own key and ttl
+	AuthCacheDecisionPrefix = BKCacheKeyV3Prefix + "auth:decision:"
+	// AuthCacheDecisionGenerationPrefix the generation of the decisions on a resource type,
+	// the decisions of the previous generations are invalidated
+	AuthCacheDecisionGenerationPrefix = BKCacheKeyV3Prefix + "auth:decision_generation:"
+	// AuthCacheDecisionTypesKey the set of the resource types which have decisions cached
+	AuthCacheDecisionTypesKey = BKCacheKeyV3Prefix + "auth:decision_types"
+	// AuthCacheInvalidateChannel the channel to broadcast the invalidations of the decisions cached in process
+	AuthCacheInvalidateChannel = BKCacheKeyV3Prefix + "auth:decision_invalidate"
+)
+
 // association fields
 const (
 	// the id of the association kind
@@ -165,6 +165,9 @@ const (
 	CCErrCommGetMultipleObject      = 1199052
 	CCErrCommAuthCenterIsNotEnabled = 1199053
 
+	// CCErrCommQueryInvalid the query statement is invalid, %s
+	CCErrCommQueryInvalid = 1199054
+
 	// CCErrCommInternalServerError %s Internal Server Error
 	CCErrCommInternalServerError = 1199999
 
@@ -172,6 +175,10 @@ const (
 	CCErrAPIGetAuthorizedAppListFromAuthFailed = 1100001
 	CCErrAPIGetUserResourceAuthStatusFailed    = 1100002
 	CCErrAPINoObjectInstancesIsFound           = 1100003
+	CCErrAPILocalAuthIsNotEnabled              = 1100004
+	CCErrAPIAuthCacheIsNotEnabled              = 1100005
+	CCErrAPIFlushAuthCacheFailed               = 1100006
+	CCErrAPIDecisionLogIsNotEnabled            = 1100007
 
 	// toposerver 1101XXX
 	// CCErrTopoInstCreateFailed unable to create the instance
@@ -384,6 +391,10 @@ const (
 	CCErrorTopoModleStopped = 1101084
 	// mainline's object unique can not be updated, deleted or create new rules.
 	CCErrorTopoMainlineObjectCanNotBeChanged = 1101085
+	// CCErrTopoInstDeleteRestricted the instance can not be deleted, because the associations [%s] restrict it
+	CCErrTopoInstDeleteRestricted = 1101086
+	// CCErrTopoModelBundleApplyFailed the bundle is failed to apply by the change [%s], the applied changes are rolled back
+	CCErrTopoModelBundleApplyFailed = 1101087
 
 	// objectcontroller 1102XXX
 
@@ -424,6 +435,20 @@ const (
 	CCErrEventSubscribeTelnetFailed = 1103005
 	// CCErrEventOperateSuccessBUtSentEventFailed failed to sent event
 	CCErrEventPushEventFailed = 1103006
+	// CCErrEventDeadLetterSelectFailed failed to select the dead letter events
+	CCErrEventDeadLetterSelectFailed = 1103007
+	// CCErrEventDeadLetterReplayFailed failed to replay the dead letter events
+	CCErrEventDeadLetterReplayFailed = 1103008
+	// CCErrEventDeadLetterDeleteFailed failed to purge the dead letter events
+	CCErrEventDeadLetterDeleteFailed = 1103009
+	// CCErrEventSubscribeSecretFailed failed to save the secret of the subscription
+	CCErrEventSubscribeSecretFailed = 1103010
+	// CCErrEventWatchCursorExpired the events after the cursor are cleaned
+	CCErrEventWatchCursorExpired = 1103011
+	// CCErrEventWatchFailed failed to watch the events
+	CCErrEventWatchFailed = 1103012
+	// CCErrEventDeliverySelectFailed failed to get the delivery logs
+	CCErrEventDeliverySelectFailed = 1103013
 
 	// host 1104XXX
 	CCErrHostModuleRelationAddFailed = 1104000
@@ -574,6 +599,8 @@ const (
 	// CCErrHostMulueIDNotFoundORHasMutliInnerModuleIDFailed Module does not exist or there are multiple built-in modules
 	CCErrHostMulueIDNotFoundORHasMutliInnerModuleIDFailed = 1110057
 	CCErrHostSearchNeedObjectInstIDErr                    = 1110058
+	// CCErrCloudObtainInstancesFail failed to obtain the instances of the cloud account
+	CCErrCloudObtainInstancesFail = 1110059
 
 	//web  1111XXX
 	CCErrWebFileNoFound                 = 1111001
@@ -633,6 +660,18 @@ const (
 	CCErrCoreServiceTransferHostModuleErr = 1113009
 	// CCErrCoreServiceEventPushEventFailed failed to sent event
 	CCErrCoreServiceEventPushEventFailed = 1113010
+	// CCErrCoreServiceInstDeleteRestricted the instance can not be deleted, because the associations [%s] restrict it
+	CCErrCoreServiceInstDeleteRestricted = 1113011
+	// CCErrCoreServiceComputedAttributeReadOnly the computed attribute [%s] can not be written
+	CCErrCoreServiceComputedAttributeReadOnly = 1113012
+	// CCErrCoreServiceModelVersionNotFound the version of the model schema is not found
+	CCErrCoreServiceModelVersionNotFound = 1113013
+	// CCErrCoreServiceAttributeRuleFailed the value of the attribute [%s] does not satisfy the rule [%s]
+	CCErrCoreServiceAttributeRuleFailed = 1113014
+	// CCErrCoreServiceAttributeRuleReferenceNotFound the value [%v] of the attribute [%s] is not found in the [%s] of the model [%s]
+	CCErrCoreServiceAttributeRuleReferenceNotFound = 1113015
+	// CCErrCoreServiceRecycleRestoreConflict the recycle item [%s] can not be restored, because [%s]
+	CCErrCoreServiceRecycleRestoreConflict = 1113016
 
 	// synchronize data coreservice  11139xx
 	CCErrCoreServiceSyncError = 1113900
@@ -69,6 +69,11 @@ func (c *ClientViaRedis) Push(ctx context.Context, events ...*metadata.EventInst
 	c.queueLock.Lock()
 	for i := range events {
 		if events[i] != nil {
+			// the address ranges of the ip attributes are only used to search the instances
+			for j := range events[i].Data {
+				events[i].Data[j].PreData = util.WithoutIPRange(events[i].Data[j].PreData)
+				events[i].Data[j].CurData = util.WithoutIPRange(events[i].Data[j].CurData)
+			}
 			var allEqual = true
 			for _, data := range events[i].Data {
 				equal, err := instEqual(data)
@@ -0,0 +1,309 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package expression implements the arithmetic expressions over the named numeric variables,
+// which are used by the computed attributes, e.g. "(bk_mem - used_mem) * 100 / bk_mem".
+package expression
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"unicode"
+)
+
+// ErrDivideByZero the expression divides by zero with the given variables
+var ErrDivideByZero = errors.New("divide by zero")
+
+// Expression a parsed arithmetic expression
+type Expression struct {
+	raw  string
+	root node
+}
+
+// Parse parse the arithmetic expression which supports the numbers, the variables,
+// the parentheses and the operators + - * / %.
+func Parse(raw string) (*Expression, error) {
+	p := &parser{raw: raw}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, errors.New("empty expression")
+	}
+
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return &Expression{raw: raw, root: root}, nil
+}
+
+// String returns the raw expression
+func (e *Expression) String() string {
+	return e.raw
+}
+
+// Variables returns the sorted names of the variables used by the expression
+func (e *Expression) Variables() []string {
+	names := make(map[string]bool)
+	e.root.variables(names)
+	result := make([]string, 0, len(names))
+	for name := range names {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Evaluate evaluate the expression with the values of the variables
+func (e *Expression) Evaluate(vars map[string]float64) (float64, error) {
+	return e.root.eval(vars)
+}
+
+type node interface {
+	eval(vars map[string]float64) (float64, error)
+	variables(names map[string]bool)
+}
+
+type number float64
+
+func (n number) eval(map[string]float64) (float64, error) {
+	return float64(n), nil
+}
+
+func (n number) variables(map[string]bool) {}
+
+type variable string
+
+func (v variable) eval(vars map[string]float64) (float64, error) {
+	val, ok := vars[string(v)]
+	if !ok {
+		return 0, fmt.Errorf("variable %s is not set", string(v))
+	}
+	return val, nil
+}
+
+func (v variable) variables(names map[string]bool) {
+	names[string(v)] = true
+}
+
+type negative struct {
+	operand node
+}
+
+func (n negative) eval(vars map[string]float64) (float64, error) {
+	val, err := n.operand.eval(vars)
+	return -val, err
+}
+
+func (n negative) variables(names map[string]bool) {
+	n.operand.variables(names)
+}
+
+type binary struct {
+	op          byte
+	left, right node
+}
+
+func (b binary) eval(vars map[string]float64) (float64, error) {
+	left, err := b.left.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+	right, err := b.right.eval(vars)
+	if err != nil {
+		return 0, err
+	}
+
+	switch b.op {
+	case '+':
+		return left + right, nil
+	case '-':
+		return left - right, nil
+	case '*':
+		return left * right, nil
+	case '/':
+		if right == 0 {
+			return 0, ErrDivideByZero
+		}
+		return left / right, nil
+	case '%':
+		if right == 0 {
+			return 0, ErrDivideByZero
+		}
+		return math.Mod(left, right), nil
+	}
+	return 0, fmt.Errorf("unknown operator %c", b.op)
+}
+
+func (b binary) variables(names map[string]bool) {
+	b.left.variables(names)
+	b.right.variables(names)
+}
+
+type tokenKind int
+
+const (
+	tokenNumber tokenKind = iota
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+type parser struct {
+	raw    string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) tokenize() error {
+	runes := []rune(p.raw)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenNumber, text: string(runes[start:i]), offset: start})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokenIdent, text: string(runes[start:i]), offset: start})
+		case r == '+' || r == '-' || r == '*' || r == '/' || r == '%':
+			p.tokens = append(p.tokens, token{kind: tokenOperator, text: string(r), offset: i})
+			i++
+		case r == '(':
+			p.tokens = append(p.tokens, token{kind: tokenLeftParen, text: "(", offset: i})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{kind: tokenRightParen, text: ")", offset: i})
+			i++
+		default:
+			return fmt.Errorf("unexpected %q at %d", r, i)
+		}
+	}
+	return nil
+}
+
+func (p *parser) peekOperator(ops string) (byte, bool) {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenOperator {
+		return 0, false
+	}
+	op := p.tokens[p.pos].text[0]
+	for i := 0; i < len(ops); i++ {
+		if ops[i] == op {
+			return op, true
+		}
+	}
+	return 0, false
+}
+
+// expr := term { ("+" | "-") term }
+func (p *parser) parseExpr() (node, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOperator("+-")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+// term := factor { ("*" | "/" | "%") factor }
+func (p *parser) parseTerm() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.peekOperator("*/%")
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = binary{op: op, left: left, right: right}
+	}
+}
+
+// factor := number | variable | "(" expr ")" | ("-" | "+") factor
+func (p *parser) parseFactor() (node, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	tok := p.tokens[p.pos]
+	p.pos++
+	switch tok.kind {
+	case tokenNumber:
+		val, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", tok.text, tok.offset)
+		}
+		return number(val), nil
+	case tokenIdent:
+		return variable(tok.text), nil
+	case tokenLeftParen:
+		inner, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != tokenRightParen {
+			return nil, fmt.Errorf("missing ) for ( at %d", tok.offset)
+		}
+		p.pos++
+		return inner, nil
+	case tokenOperator:
+		if tok.text == "-" || tok.text == "+" {
+			operand, err := p.parseFactor()
+			if err != nil {
+				return nil, err
+			}
+			if tok.text == "-" {
+				return negative{operand: operand}, nil
+			}
+			return operand, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at %d", tok.text, tok.offset)
+}
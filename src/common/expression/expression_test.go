@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package expression
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	vars := map[string]float64{"bk_cpu": 8, "bk_mem": 16384, "used_mem": 4096, "host_count": 3}
+	tests := []struct {
+		expr string
+		want float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-bk_cpu + 10", 2},
+		{"(bk_mem - used_mem) * 100 / bk_mem", 75},
+		{"bk_cpu % 3", 2},
+		{"host_count * bk_cpu", 24},
+		{"1.5 * 2", 3},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.expr, err)
+			continue
+		}
+		got, err := expr.Evaluate(vars)
+		if err != nil || got != tt.want {
+			t.Errorf("Evaluate(%q) = %v, %v, want %v", tt.expr, got, err, tt.want)
+		}
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	expr, _ := Parse("bk_cpu / host_count")
+	if _, err := expr.Evaluate(map[string]float64{"bk_cpu": 1, "host_count": 0}); err != ErrDivideByZero {
+		t.Errorf("expect divide by zero, got %v", err)
+	}
+	if _, err := expr.Evaluate(map[string]float64{"bk_cpu": 1}); err == nil {
+		t.Errorf("expect error of the unset variable")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for _, raw := range []string{"", "1 +", "(1 + 2", "1 2", "bk_cpu $ 2", "1..2", "* 2"} {
+		if _, err := Parse(raw); err == nil {
+			t.Errorf("Parse(%q) should fail", raw)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	expr, err := Parse("(bk_mem - used_mem) * 100 / bk_mem + host_count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"bk_mem", "host_count", "used_mem"}
+	if got := expr.Variables(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Variables() = %v, want %v", got, want)
+	}
+}
@@ -13,6 +13,8 @@
 package metadata
 
 import (
+	"fmt"
+
 	"configcenter/src/common/mapstr"
 )
 
@@ -34,6 +36,10 @@ const (
 	// AssociationFieldAssociationId auto incr id
 	AssociationFieldAssociationId   = "id"
 	AssociationFieldAssociationKind = "bk_asst_id"
+	// AssociationFieldOnDelete the association data field on_delete
+	AssociationFieldOnDelete = "on_delete"
+	// AssociationFieldMapping the association data field mapping
+	AssociationFieldMapping = "mapping"
 )
 
 type SearchAssociationTypeRequest struct {
@@ -210,6 +216,8 @@ const (
 	DeleteSource AssociationOnDeleteAction = "delete_src"
 	// delete related destination object instances when the association is deleted.
 	DeleteDestinatioin AssociationOnDeleteAction = "delete_dest"
+	// refuse to delete the instances while they are related with other instances.
+	Restrict AssociationOnDeleteAction = "restrict"
 
 	// the source object can be related with only one destination object
 	OneToOneMapping AssociationMapping = "1:1"
@@ -219,6 +227,16 @@ const (
 	ManyToManyMapping AssociationMapping = "n:n"
 )
 
+// IsValid check whether the action is a supported one.
+func (a AssociationOnDeleteAction) IsValid() bool {
+	switch a {
+	case NoAction, DeleteSource, DeleteDestinatioin, Restrict:
+		return true
+	default:
+		return false
+	}
+}
+
 // Association defines the association between two objects.
 type Association struct {
 	ID      int64  `field:"id" json:"id" bson:"id"`
@@ -337,6 +355,66 @@ func (asst InstAsst) GetInstID(objID string) (instID int64, ok bool) {
 	}
 }
 
+// the fields of the instance association which keep the mapping of the model association,
+// they are unique indexed, so the mapping can not be exceeded by the concurrent creations.
+const (
+	InstAsstFieldSrcMappingKey  = "src_mapping_key"
+	InstAsstFieldDestMappingKey = "dest_mapping_key"
+)
+
+// InstAsstWithMappingKey the instance association stored in db
+type InstAsstWithMappingKey struct {
+	InstAsst `bson:",inline"`
+	// SrcMappingKey is the same for all the associations of a source instance if it can be associated with
+	// only one destination instance, otherwise it's unique for every association.
+	SrcMappingKey string `bson:"src_mapping_key"`
+	// DestMappingKey is the same for all the associations of a destination instance if it can be associated
+	// with only one source instance, otherwise it's unique for every association.
+	DestMappingKey string `bson:"dest_mapping_key"`
+}
+
+// NewInstAsstWithMappingKey make the mapping keys of the instance association, the id of the association
+// must be set before, because the key is made from it when the mapping does not limit the side.
+func NewInstAsstWithMappingKey(asst InstAsst, mapping AssociationMapping) InstAsstWithMappingKey {
+	unlimited := fmt.Sprintf("#%d", asst.ID)
+	record := InstAsstWithMappingKey{
+		InstAsst:       asst,
+		SrcMappingKey:  unlimited,
+		DestMappingKey: unlimited,
+	}
+
+	switch mapping {
+	case OneToOneMapping:
+		record.SrcMappingKey = fmt.Sprintf("%s|%s|%d", asst.OwnerID, asst.ObjectAsstID, asst.InstID)
+		record.DestMappingKey = fmt.Sprintf("%s|%s|%d", asst.OwnerID, asst.ObjectAsstID, asst.AsstInstID)
+	case OneToManyMapping:
+		record.DestMappingKey = fmt.Sprintf("%s|%s|%d", asst.OwnerID, asst.ObjectAsstID, asst.AsstInstID)
+	}
+	return record
+}
+
+// AsstMappingViolation the instance which is associated with more instances than the mapping of the association allows
+type AsstMappingViolation struct {
+	ObjectAsstID string             `json:"bk_obj_asst_id"`
+	Mapping      AssociationMapping `json:"mapping"`
+	// the instance associated with too many instances
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	// AssociationIDs the instance associations of the instance
+	AssociationIDs []int64 `json:"association_ids"`
+}
+
+// SearchAsstMappingViolationRequest search the mapping violations of the associations,
+// all the associations are searched if ObjectAsstIDs is empty.
+type SearchAsstMappingViolationRequest struct {
+	ObjectAsstIDs []string `json:"bk_obj_asst_ids"`
+}
+
+type SearchAsstMappingViolationResult struct {
+	BaseResp `json:",inline"`
+	Data     []AsstMappingViolation `json:"data"`
+}
+
 type InstNameAsst struct {
 	ID         string `json:"id"`
 	ObjID      string `json:"bk_obj_id"`
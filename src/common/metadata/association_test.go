@@ -0,0 +1,36 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+)
+
+func TestNewInstAsstWithMappingKey(t *testing.T) {
+	asst := InstAsst{ID: 7, OwnerID: "0", ObjectAsstID: "a_connect_b", InstID: 1, AsstInstID: 2}
+	tests := []struct {
+		mapping  AssociationMapping
+		wantSrc  string
+		wantDest string
+	}{
+		{OneToOneMapping, "0|a_connect_b|1", "0|a_connect_b|2"},
+		{OneToManyMapping, "#7", "0|a_connect_b|2"},
+		{ManyToManyMapping, "#7", "#7"},
+	}
+	for _, tt := range tests {
+		got := NewInstAsstWithMappingKey(asst, tt.mapping)
+		if got.SrcMappingKey != tt.wantSrc || got.DestMappingKey != tt.wantDest {
+			t.Errorf("NewInstAsstWithMappingKey(%s) = %s, %s, want %s, %s", tt.mapping, got.SrcMappingKey, got.DestMappingKey, tt.wantSrc, tt.wantDest)
+		}
+	}
+}
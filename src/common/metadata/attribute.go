@@ -13,6 +13,14 @@
 package metadata
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/expression"
 	"configcenter/src/common/mapstr"
 )
 
@@ -35,6 +43,7 @@ const (
 	AttributeFieldIsAPI           = "bk_isapi"
 	AttributeFieldPropertyType    = "bk_property_type"
 	AttributeFieldOption          = "option"
+	AttributeFieldRules           = "rules"
 	AttributeFieldDescription     = "description"
 	AttributeFieldCreator         = "creator"
 	AttributeFieldCreateTime      = "create_time"
@@ -63,6 +72,7 @@ type Attribute struct {
 	IsAPI             bool        `field:"bk_isapi" json:"bk_isapi" bson:"bk_isapi"`
 	PropertyType      string      `field:"bk_property_type" json:"bk_property_type" bson:"bk_property_type"`
 	Option            interface{} `field:"option" json:"option" bson:"option"`
+	Rules             interface{} `field:"rules" json:"rules" bson:"rules"`
 	Description       string      `field:"description" json:"description" bson:"description"`
 
 	Creator    string `field:"creator" json:"creator" bson:"creator"`
@@ -103,3 +113,216 @@ type ObjAttDes struct {
 	Attribute         `json:",inline" bson:",inline"`
 	PropertyGroupName string `json:"bk_property_group_name"`
 }
+
+// the aggregate functions of the computed attribute
+const (
+	ComputedAggregateCount = "count"
+	ComputedAggregateSum   = "sum"
+	ComputedAggregateAvg   = "avg"
+	ComputedAggregateMin   = "min"
+	ComputedAggregateMax   = "max"
+)
+
+// ComputedOption the option of the computed attribute
+type ComputedOption struct {
+	// Expression the arithmetic expression over the attributes of the instance and the aggregates,
+	// such as "(bk_mem - used_mem) * 100 / bk_mem"
+	Expression string `json:"expression"`
+	// Aggregates the aggregates over the associated instances, which are referred by the name in the expression
+	Aggregates []ComputedAggregate `json:"aggregates"`
+
+	expr *expression.Expression
+}
+
+// Expr returns the parsed expression of the option
+func (c *ComputedOption) Expr() *expression.Expression {
+	return c.expr
+}
+
+// ComputedAggregate the aggregate over the instances associated with the instance.
+// the hosts are associated with the business, set and module by the host module relations,
+// and the other instances are associated by the instance associations.
+type ComputedAggregate struct {
+	Name string `json:"name"`
+	// Func one of count, sum, avg, min and max
+	Func string `json:"func"`
+	// ObjectID the model of the associated instances
+	ObjectID string `json:"bk_obj_id"`
+	// ObjectAsstID only aggregate the instances associated by the model association if it's set
+	ObjectAsstID string `json:"bk_obj_asst_id"`
+	// Field the attribute of the associated instances to aggregate, it's not needed by count
+	Field string `json:"field"`
+}
+
+// ParseComputedOption parse and check the option of the computed attribute
+func ParseComputedOption(option interface{}) (*ComputedOption, error) {
+	opt := new(ComputedOption)
+	if err := decodeOption(option, opt); err != nil {
+		return nil, err
+	}
+
+	expr, err := expression.Parse(opt.Expression)
+	if err != nil {
+		return nil, fmt.Errorf("invalid expression, %v", err)
+	}
+	opt.expr = expr
+
+	names := make(map[string]bool)
+	for _, agg := range opt.Aggregates {
+		if len(agg.Name) == 0 || len(agg.ObjectID) == 0 {
+			return nil, errors.New("the name and bk_obj_id of the aggregate are required")
+		}
+		if names[agg.Name] {
+			return nil, fmt.Errorf("the aggregate %s is duplicated", agg.Name)
+		}
+		names[agg.Name] = true
+
+		switch agg.Func {
+		case ComputedAggregateCount:
+		case ComputedAggregateSum, ComputedAggregateAvg, ComputedAggregateMin, ComputedAggregateMax:
+			if len(agg.Field) == 0 {
+				return nil, fmt.Errorf("the field of the aggregate %s is required", agg.Name)
+			}
+		default:
+			return nil, fmt.Errorf("the func %s of the aggregate %s is not supported", agg.Func, agg.Name)
+		}
+	}
+	return opt, nil
+}
+
+// ListOption the option of the list attribute, the value of the list attribute is an array of the items
+type ListOption struct {
+	// ItemType the type of the items, one of singlechar, int and enum
+	ItemType string `json:"item_type"`
+	// ItemOption the option of the items, which is the same as the option of the attribute with the item type,
+	// such as the regular expression of singlechar, the min and max of int and the values of enum
+	ItemOption interface{} `json:"item_option"`
+	// MaxItems the max count of the items, there is no limit if it's zero
+	MaxItems int `json:"max_items"`
+}
+
+// ParseListOption parse and check the option of the list attribute
+func ParseListOption(option interface{}) (*ListOption, error) {
+	opt := new(ListOption)
+	if err := decodeOption(option, opt); err != nil {
+		return nil, err
+	}
+	switch opt.ItemType {
+	case common.FieldTypeSingleChar, common.FieldTypeInt, common.FieldTypeEnum:
+	default:
+		return nil, fmt.Errorf("the item type %s of the list is not supported", opt.ItemType)
+	}
+	if opt.MaxItems < 0 {
+		return nil, errors.New("the max items of the list can not be negative")
+	}
+	return opt, nil
+}
+
+// ParseText split the text form of the list value, in which the items are separated by commas,
+// such as the excel cell and the legacy long char value, the int items are converted if they can be.
+func (l *ListOption) ParseText(value string) []interface{} {
+	items := make([]interface{}, 0)
+	for _, item := range strings.Split(value, common.ListValueSeparator) {
+		item = strings.TrimSpace(item)
+		if len(item) == 0 {
+			continue
+		}
+		if l.ItemType == common.FieldTypeInt {
+			if i, err := strconv.ParseInt(item, 10, 64); err == nil {
+				items = append(items, i)
+				continue
+			}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// TableColumn the column of the table attribute
+type TableColumn struct {
+	PropertyID   string      `json:"bk_property_id"`
+	PropertyName string      `json:"bk_property_name"`
+	PropertyType string      `json:"bk_property_type"`
+	Option       interface{} `json:"option"`
+	IsRequired   bool        `json:"isrequired"`
+}
+
+// TableOption the option of the table attribute, the value of the table attribute is an array of the rows,
+// and each row is an object keyed by the property id of the columns
+type TableOption struct {
+	Columns []TableColumn `json:"columns"`
+	// MaxRows the max count of the rows, there is no limit if it's zero
+	MaxRows int `json:"max_rows"`
+}
+
+// ParseTableOption parse and check the option of the table attribute
+func ParseTableOption(option interface{}) (*TableOption, error) {
+	opt := new(TableOption)
+	if err := decodeOption(option, opt); err != nil {
+		return nil, err
+	}
+	if len(opt.Columns) == 0 {
+		return nil, errors.New("the columns of the table are required")
+	}
+	if opt.MaxRows < 0 {
+		return nil, errors.New("the max rows of the table can not be negative")
+	}
+
+	ids := make(map[string]bool)
+	for _, column := range opt.Columns {
+		if len(column.PropertyID) == 0 {
+			return nil, errors.New("the bk_property_id of the column is required")
+		}
+		if ids[column.PropertyID] {
+			return nil, fmt.Errorf("the column %s is duplicated", column.PropertyID)
+		}
+		ids[column.PropertyID] = true
+
+		switch column.PropertyType {
+		case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeInt, common.FieldTypeFloat,
+			common.FieldTypeEnum, common.FieldTypeDate, common.FieldTypeTime, common.FieldTypeBool:
+		default:
+			return nil, fmt.Errorf("the type %s of the column %s is not supported", column.PropertyType, column.PropertyID)
+		}
+	}
+	return opt, nil
+}
+
+// IPOption the option of the ip attribute
+type IPOption struct {
+	// Version the ip version, 4 or 6, both of them are allowed if it's zero
+	Version int `json:"version"`
+	// AllowCIDR the value can be a cidr if it's true, otherwise it must be an ip address
+	AllowCIDR bool `json:"allow_cidr"`
+}
+
+// ParseIPOption parse and check the option of the ip attribute, the empty option allows any ip address
+func ParseIPOption(option interface{}) (*IPOption, error) {
+	opt := new(IPOption)
+	if nil == option || "" == option {
+		return opt, nil
+	}
+	if err := decodeOption(option, opt); err != nil {
+		return nil, err
+	}
+	switch opt.Version {
+	case 0, 4, 6:
+	default:
+		return nil, fmt.Errorf("the ip version %d is not supported", opt.Version)
+	}
+	return opt, nil
+}
+
+// decodeOption decode the option of the attribute, which is a json string or an object, into the result
+func decodeOption(option interface{}, result interface{}) error {
+	switch val := option.(type) {
+	case string:
+		return json.Unmarshal([]byte(val), result)
+	default:
+		js, err := json.Marshal(option)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(js, result)
+	}
+}
@@ -0,0 +1,233 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+)
+
+// the types of the attribute rule
+const (
+	// AttributeRuleRequired the attribute is required if the conditions of the rule are matched
+	AttributeRuleRequired = "required"
+	// AttributeRuleCompare the value of the attribute is compared with the value of another attribute or a constant
+	AttributeRuleCompare = "compare"
+	// AttributeRuleBizUnique the value of the attribute is unique among the instances of the same business
+	AttributeRuleBizUnique = "biz_unique"
+	// AttributeRuleReference the value of the attribute is one of the values of an attribute of another model
+	AttributeRuleReference = "reference"
+)
+
+// AttributeRule the validation rule of the attribute, which is checked besides the type and the option
+// of the attribute when the instances are created or updated. the rules of an attribute are stored in
+// its rules field as an array, such as:
+// [{"type": "required", "when": [{"field": "os_type", "operator": "$eq", "value": "linux"}]}]
+type AttributeRule struct {
+	// Type one of required, compare, biz_unique and reference
+	Type string `json:"type"`
+	// When the rule is only checked if all the conditions are matched by the instance,
+	// and it is always checked if there is no condition
+	When []RuleCondition `json:"when"`
+	// Operator the operator to compare the value with, used by compare
+	Operator string `json:"operator"`
+	// Field the attribute of the instance to compare the value with, used by compare,
+	// or the attribute of the referenced model which has the allowed values, used by reference
+	Field string `json:"field"`
+	// Value the constant to compare the value with when the field is not set, used by compare
+	Value interface{} `json:"value"`
+	// ObjectID the referenced model, used by reference
+	ObjectID string `json:"bk_obj_id"`
+	// Message the language key of the error message, which is formatted with the property name,
+	// and it's used as the message directly if the key is not found
+	Message string `json:"message"`
+}
+
+// RuleCondition the condition of the attribute rule on an attribute of the instance
+type RuleCondition struct {
+	Field    string      `json:"field"`
+	Operator string      `json:"operator"`
+	Value    interface{} `json:"value"`
+}
+
+// ParseAttributeRules parse and check the rules of the attribute, the empty rules are allowed
+func ParseAttributeRules(rules interface{}) ([]AttributeRule, error) {
+	result := make([]AttributeRule, 0)
+	if nil == rules || "" == rules {
+		return result, nil
+	}
+	if err := decodeOption(rules, &result); err != nil {
+		return nil, err
+	}
+
+	for _, rule := range result {
+		for _, cond := range rule.When {
+			if len(cond.Field) == 0 {
+				return nil, fmt.Errorf("the field of the condition of the %s rule is required", rule.Type)
+			}
+			if !isRuleOperator(cond.Operator) {
+				return nil, fmt.Errorf("the operator %s of the condition is not supported", cond.Operator)
+			}
+		}
+
+		switch rule.Type {
+		case AttributeRuleRequired, AttributeRuleBizUnique:
+		case AttributeRuleCompare:
+			if !isRuleOperator(rule.Operator) || rule.Operator == common.BKDBExists {
+				return nil, fmt.Errorf("the operator %s of the compare rule is not supported", rule.Operator)
+			}
+			if len(rule.Field) == 0 && nil == rule.Value {
+				return nil, errors.New("the field or value of the compare rule is required")
+			}
+		case AttributeRuleReference:
+			if len(rule.ObjectID) == 0 || len(rule.Field) == 0 {
+				return nil, errors.New("the bk_obj_id and field of the reference rule are required")
+			}
+		default:
+			return nil, fmt.Errorf("the rule type %s is not supported", rule.Type)
+		}
+	}
+	return result, nil
+}
+
+// Fields returns the other attributes of the instance which the rule depends on
+func (r AttributeRule) Fields() []string {
+	fields := make([]string, 0)
+	for _, cond := range r.When {
+		fields = append(fields, cond.Field)
+	}
+	if r.Type == AttributeRuleCompare && len(r.Field) != 0 {
+		fields = append(fields, r.Field)
+	}
+	return fields
+}
+
+// Matched returns whether all the conditions of the rule are matched by the instance
+func (r AttributeRule) Matched(data mapstr.MapStr) bool {
+	for _, cond := range r.When {
+		if !MatchRuleOperator(data[cond.Field], cond.Operator, cond.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the readable form of the compare rule, such as "$gte start_time"
+func (r AttributeRule) String() string {
+	if len(r.Field) != 0 {
+		return fmt.Sprintf("%s %s", r.Operator, r.Field)
+	}
+	return fmt.Sprintf("%s %v", r.Operator, r.Value)
+}
+
+func isRuleOperator(operator string) bool {
+	switch operator {
+	case common.BKDBEQ, common.BKDBNE, common.BKDBIN, common.BKDBNIN,
+		common.BKDBGT, common.BKDBGTE, common.BKDBLT, common.BKDBLTE, common.BKDBExists:
+		return true
+	}
+	return false
+}
+
+// MatchRuleOperator returns whether the value matches the operator with the target.
+// the numbers are compared by their values, and the strings are compared in lexical order,
+// so that the dates and times in the same format are compared correctly.
+func MatchRuleOperator(value interface{}, operator string, target interface{}) bool {
+	switch operator {
+	case common.BKDBEQ:
+		return equalRuleValue(value, target)
+	case common.BKDBNE:
+		return !equalRuleValue(value, target)
+	case common.BKDBIN, common.BKDBNIN:
+		in := false
+		for _, item := range ruleValueItems(target) {
+			if equalRuleValue(value, item) {
+				in = true
+				break
+			}
+		}
+		return in == (operator == common.BKDBIN)
+	case common.BKDBGT, common.BKDBGTE, common.BKDBLT, common.BKDBLTE:
+		result, ok := compareRuleValue(value, target)
+		if !ok {
+			return false
+		}
+		switch operator {
+		case common.BKDBGT:
+			return result > 0
+		case common.BKDBGTE:
+			return result >= 0
+		case common.BKDBLT:
+			return result < 0
+		default:
+			return result <= 0
+		}
+	case common.BKDBExists:
+		exists := nil != value && "" != value
+		expected, ok := target.(bool)
+		return exists == (expected || !ok)
+	}
+	return false
+}
+
+func equalRuleValue(value, target interface{}) bool {
+	if result, ok := compareRuleValue(value, target); ok {
+		return result == 0
+	}
+	return reflect.DeepEqual(value, target)
+}
+
+// compareRuleValue compare the numbers or the strings, the result is false if they can't be compared
+func compareRuleValue(value, target interface{}) (int, bool) {
+	valStr, valIsStr := value.(string)
+	targetStr, targetIsStr := target.(string)
+	if valIsStr && targetIsStr {
+		return strings.Compare(valStr, targetStr), true
+	}
+	if valIsStr || targetIsStr {
+		return 0, false
+	}
+
+	valNum, err := util.GetFloat64ByInterface(value)
+	if err != nil {
+		return 0, false
+	}
+	targetNum, err := util.GetFloat64ByInterface(target)
+	if err != nil {
+		return 0, false
+	}
+	switch {
+	case valNum < targetNum:
+		return -1, true
+	case valNum > targetNum:
+		return 1, true
+	}
+	return 0, true
+}
+
+func ruleValueItems(value interface{}) []interface{} {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return []interface{}{value}
+	}
+	items := make([]interface{}, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items = append(items, rv.Index(i).Interface())
+	}
+	return items
+}
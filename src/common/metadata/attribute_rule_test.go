@@ -0,0 +1,74 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+)
+
+func TestParseAttributeRules(t *testing.T) {
+	rules, err := ParseAttributeRules(`[{"type": "required", "when": [{"field": "os_type", "operator": "$eq", "value": "linux"}]},
+		{"type": "compare", "operator": "$gte", "field": "start_time"}]`)
+	if err != nil {
+		t.Fatalf("parse the rules failed, err: %v", err)
+	}
+	if len(rules) != 2 {
+		t.Fatalf("parse %d rules, want 2", len(rules))
+	}
+	if fields := rules[1].Fields(); len(fields) != 1 || fields[0] != "start_time" {
+		t.Errorf("fields of the compare rule = %v", fields)
+	}
+
+	invalids := []interface{}{
+		[]interface{}{map[string]interface{}{"type": "unknown"}},
+		[]interface{}{map[string]interface{}{"type": "compare", "operator": "$gt"}},
+		[]interface{}{map[string]interface{}{"type": "reference", "bk_obj_id": "vendor"}},
+		[]interface{}{map[string]interface{}{"type": "required", "when": []interface{}{map[string]interface{}{"field": "a", "operator": "$like"}}}},
+	}
+	for _, invalid := range invalids {
+		if _, err := ParseAttributeRules(invalid); err == nil {
+			t.Errorf("parse the invalid rules %v succeeded", invalid)
+		}
+	}
+}
+
+func TestMatchRuleOperator(t *testing.T) {
+	cases := []struct {
+		value    interface{}
+		operator string
+		target   interface{}
+		want     bool
+	}{
+		{"linux", "$eq", "linux", true},
+		{int64(3), "$eq", float64(3), true},
+		{"linux", "$ne", "windows", true},
+		{"linux", "$in", []interface{}{"linux", "aix"}, true},
+		{"linux", "$nin", []interface{}{"linux", "aix"}, false},
+		{10, "$gt", 9.5, true},
+		{"2019-05-01", "$lt", "2019-06-01", true},
+		{"10", "$gt", 9, false},
+		{"", "$exists", true, false},
+		{nil, "$exists", false, true},
+	}
+	for _, c := range cases {
+		if got := MatchRuleOperator(c.value, c.operator, c.target); got != c.want {
+			t.Errorf("MatchRuleOperator(%v, %s, %v) = %v, want %v", c.value, c.operator, c.target, got, c.want)
+		}
+	}
+
+	rule := AttributeRule{Type: AttributeRuleRequired, When: []RuleCondition{{Field: "os_type", Operator: "$eq", Value: "linux"}}}
+	if !rule.Matched(mapstr.MapStr{"os_type": "linux"}) || rule.Matched(mapstr.MapStr{"os_type": "windows"}) {
+		t.Errorf("the conditions of the rule are not matched correctly")
+	}
+}
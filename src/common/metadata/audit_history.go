@@ -0,0 +1,225 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// AuditTargetInstanceAssociation the op_target of the audit logs of the instance associations
+	AuditTargetInstanceAssociation = "instance_association"
+	// AuditFieldInstID the id of the resource which the audit log is about
+	AuditFieldInstID = "inst_id"
+)
+
+// InstanceHistoryOption the option to rebuild an instance at a moment
+type InstanceHistoryOption struct {
+	InstID int64 `json:"bk_inst_id"`
+	// Time the moment to rebuild the instance at, it's now if not set
+	Time time.Time `json:"time"`
+}
+
+// InstanceHistory the instance at a moment which is rebuilt from the audit logs
+type InstanceHistory struct {
+	ObjectID string    `json:"bk_obj_id"`
+	InstID   int64     `json:"bk_inst_id"`
+	Time     time.Time `json:"time"`
+	// Exists whether the instance exists at the moment, the data is the last one before it's deleted if not
+	Exists bool          `json:"exists"`
+	Data   mapstr.MapStr `json:"data"`
+	// Topology the business and modules of the host
+	Topology     mapstr.MapStr   `json:"topology,omitempty"`
+	Associations []InstAsst      `json:"associations"`
+	Timeline     []HistoryChange `json:"timeline"`
+}
+
+// HistoryChange a change of the instance or its associations
+type HistoryChange struct {
+	Time        time.Time     `json:"op_time"`
+	Operator    string        `json:"operator"`
+	OpType      int           `json:"op_type"`
+	OpDesc      string        `json:"op_desc"`
+	Fields      []FieldChange `json:"fields,omitempty"`
+	Association *InstAsst     `json:"association,omitempty"`
+}
+
+// FieldChange the change of a field
+type FieldChange struct {
+	PropertyID string      `json:"bk_property_id"`
+	PreValue   interface{} `json:"pre_value"`
+	CurValue   interface{} `json:"cur_value"`
+}
+
+// InstanceHistoryResult the result of rebuilding an instance
+type InstanceHistoryResult struct {
+	BaseResp `json:",inline"`
+	Data     InstanceHistory `json:"data"`
+}
+
+// ReplayInstanceHistory rebuild the instance by replaying the audit logs of it and its associations,
+// the logs should be sorted by the operation time and none of them is later than the moment.
+func ReplayInstanceHistory(objID string, instID int64, at time.Time, logs []OperationLog) *InstanceHistory {
+	history := &InstanceHistory{
+		ObjectID:     objID,
+		InstID:       instID,
+		Time:         at,
+		Data:         mapstr.New(),
+		Associations: make([]InstAsst, 0),
+		Timeline:     make([]HistoryChange, 0),
+	}
+
+	assts := make(map[int64]InstAsst)
+	for _, log := range logs {
+		change := HistoryChange{
+			Time:     log.CreateTime,
+			Operator: log.User,
+			OpType:   log.OpType,
+			OpDesc:   log.OpDesc,
+		}
+		pre, cur := auditData(log.Content)
+
+		if log.OpTarget == AuditTargetInstanceAssociation {
+			data := cur
+			if auditoplog.AuditOpType(log.OpType) == auditoplog.AuditOpTypeDel {
+				data = pre
+			}
+			asst, ok := auditAssociation(log.InstID, data)
+			if !ok || !asst.involve(objID, instID) {
+				continue
+			}
+			if auditoplog.AuditOpType(log.OpType) == auditoplog.AuditOpTypeDel {
+				delete(assts, asst.ID)
+			} else {
+				assts[asst.ID] = asst
+			}
+			change.Association = &asst
+			history.Timeline = append(history.Timeline, change)
+			continue
+		}
+
+		switch auditoplog.AuditOpType(log.OpType) {
+		case auditoplog.AuditOpTypeAdd:
+			change.Fields = diffFields(nil, cur)
+			history.Exists = true
+			history.Data = cur
+		case auditoplog.AuditOpTypeModify:
+			if nil == pre {
+				pre = history.Data
+			}
+			change.Fields = diffFields(pre, cur)
+			history.Exists = true
+			data := mapstr.New()
+			data.Merge(history.Data)
+			data.Merge(cur)
+			history.Data = data
+		case auditoplog.AuditOpTypeDel:
+			history.Exists = false
+			if nil != pre {
+				history.Data = pre
+			}
+		case auditoplog.AuditOpTypeHostModule:
+			if nil == pre {
+				pre = history.Topology
+			}
+			change.Fields = diffFields(pre, cur)
+			history.Topology = cur
+		}
+		history.Timeline = append(history.Timeline, change)
+	}
+
+	for _, asst := range assts {
+		history.Associations = append(history.Associations, asst)
+	}
+	sort.Slice(history.Associations, func(i, j int) bool {
+		return history.Associations[i].ID < history.Associations[j].ID
+	})
+	if nil == history.Data {
+		history.Data = mapstr.New()
+	}
+	return history
+}
+
+func (asst InstAsst) involve(objID string, instID int64) bool {
+	return (asst.ObjectID == objID && asst.InstID == instID) || (asst.AsstObjectID == objID && asst.AsstInstID == instID)
+}
+
+// auditData returns the data before and after the change in the content of the audit log
+func auditData(content interface{}) (pre, cur mapstr.MapStr) {
+	data := struct {
+		PreData mapstr.MapStr `json:"pre_data"`
+		CurData mapstr.MapStr `json:"cur_data"`
+	}{}
+	js, err := json.Marshal(content)
+	if nil != err {
+		return nil, nil
+	}
+	if err := json.Unmarshal(js, &data); nil != err {
+		return nil, nil
+	}
+	return data.PreData, data.CurData
+}
+
+// auditAssociation returns the instance association in the audit log, the data of the created
+// association is the request to create it, the association is in the data field of it.
+func auditAssociation(id int64, data mapstr.MapStr) (InstAsst, bool) {
+	asst := InstAsst{}
+	if nil == data {
+		return asst, false
+	}
+	if inner, exists := data["data"]; exists {
+		data = mapstr.MapStr{}
+		js, err := json.Marshal(inner)
+		if nil != err || nil != json.Unmarshal(js, &data) {
+			return asst, false
+		}
+	}
+	if err := data.MarshalJSONInto(&asst); nil != err {
+		return asst, false
+	}
+	if 0 == asst.ID {
+		asst.ID = id
+	}
+	return asst, true
+}
+
+func diffFields(pre, cur mapstr.MapStr) []FieldChange {
+	keys := make([]string, 0)
+	for key := range pre {
+		keys = append(keys, key)
+	}
+	for key := range cur {
+		if _, exists := pre[key]; !exists {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	changes := make([]FieldChange, 0)
+	for _, key := range keys {
+		if key == common.LastTimeField {
+			continue
+		}
+		if reflect.DeepEqual(pre[key], cur[key]) {
+			continue
+		}
+		changes = append(changes, FieldChange{PropertyID: key, PreValue: pre[key], CurValue: cur[key]})
+	}
+	return changes
+}
@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/auditoplog"
+)
+
+func TestReplayInstanceHistory(t *testing.T) {
+	now := time.Now()
+	logs := []OperationLog{
+		{
+			OpTarget:   "switch",
+			InstID:     1,
+			OpType:     int(auditoplog.AuditOpTypeAdd),
+			CreateTime: now.Add(-3 * time.Hour),
+			Content:    map[string]interface{}{"cur_data": map[string]interface{}{"bk_inst_name": "sw1", "port": 24}},
+		},
+		{
+			OpTarget:   AuditTargetInstanceAssociation,
+			InstID:     10,
+			OpType:     int(auditoplog.AuditOpTypeAdd),
+			CreateTime: now.Add(-2 * time.Hour),
+			Content: map[string]interface{}{"cur_data": map[string]interface{}{"data": map[string]interface{}{
+				"bk_obj_id": "switch", "bk_inst_id": 1, "bk_asst_obj_id": "host", "bk_asst_inst_id": 2,
+			}}},
+		},
+		{
+			OpTarget:   "switch",
+			InstID:     1,
+			OpType:     int(auditoplog.AuditOpTypeModify),
+			CreateTime: now.Add(-time.Hour),
+			Content: map[string]interface{}{
+				"pre_data": map[string]interface{}{"bk_inst_name": "sw1", "port": 24},
+				"cur_data": map[string]interface{}{"bk_inst_name": "sw1", "port": 48},
+			},
+		},
+		{
+			OpTarget:   AuditTargetInstanceAssociation,
+			InstID:     10,
+			OpType:     int(auditoplog.AuditOpTypeDel),
+			CreateTime: now,
+			Content: map[string]interface{}{"pre_data": map[string]interface{}{
+				"id": 10, "bk_obj_id": "switch", "bk_inst_id": 1, "bk_asst_obj_id": "host", "bk_asst_inst_id": 2,
+			}},
+		},
+	}
+
+	history := ReplayInstanceHistory("switch", 1, now.Add(-30*time.Minute), logs[:3])
+	if !history.Exists || history.Data["port"] != float64(48) {
+		t.Fatalf("the instance should exist with port 48, but got %v", history.Data)
+	}
+	if len(history.Associations) != 1 || history.Associations[0].ID != 10 {
+		t.Fatalf("the instance should have the association 10, but got %v", history.Associations)
+	}
+	if len(history.Timeline) != 3 {
+		t.Fatalf("the timeline should have 3 changes, but got %d", len(history.Timeline))
+	}
+	fields := history.Timeline[2].Fields
+	if len(fields) != 1 || fields[0].PropertyID != "port" || fields[0].PreValue != float64(24) {
+		t.Fatalf("the port should be changed from 24, but got %v", fields)
+	}
+
+	history = ReplayInstanceHistory("switch", 1, now, logs)
+	if len(history.Associations) != 0 {
+		t.Fatalf("the association should be deleted, but got %v", history.Associations)
+	}
+}
@@ -17,6 +17,15 @@ import "configcenter/src/common/mapstr"
 // DeleteOption common delete condition options
 type DeleteOption struct {
 	Condition mapstr.MapStr `json:"condition"`
+	// Along the instances of the other objects deleted together with the ones matched by the condition,
+	// all of them are planned as one deletion, so the associations between them do not restrict it.
+	Along []DeleteAlongInsts `json:"along,omitempty"`
+}
+
+// DeleteAlongInsts the instances of a object deleted along with the instances of the delete option
+type DeleteAlongInsts struct {
+	ObjectID string  `json:"bk_obj_id"`
+	InstIDs  []int64 `json:"bk_inst_ids"`
 }
 
 // DeletedCountResult delete  api http response return result struct
@@ -24,3 +33,29 @@ type DeletedOptionResult struct {
 	BaseResp `json:",inline"`
 	Data     DeletedCount `json:"data"`
 }
+
+// DeleteImpact the impact of deleting instances, which honors the OnDelete action of the model associations
+type DeleteImpact struct {
+	// Instances the instances to be deleted, including the ones deleted by the cascade of the associations
+	Instances []DeleteImpactInst `json:"instances"`
+	// Associations the instance associations to be deleted with the instances
+	Associations []InstAsst `json:"associations"`
+	// Restrictions the instance associations which refuse the deletion
+	Restrictions []InstAsst `json:"restrictions"`
+}
+
+// DeleteImpactInst a instance to be deleted
+type DeleteImpactInst struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	InstName string `json:"bk_inst_name"`
+	// CascadedBy the association which cascades the deletion to this instance,
+	// it's empty for the instances which are requested to delete.
+	CascadedBy string `json:"cascaded_by,omitempty"`
+}
+
+// DeleteImpactResult delete impact api http response return result struct
+type DeleteImpactResult struct {
+	BaseResp `json:",inline"`
+	Data     DeleteImpact `json:"data"`
+}
@@ -32,6 +32,9 @@ type ParamSubscriptionSearch struct {
 	Fields    []string               `json:"fields"`
 	Condition map[string]interface{} `json:"condition"`
 	Page      BasePage               `json:"page"`
+	// StatisticsWindows the time windows(second) to aggregate the delivery statistics, default 1 hour and 1 day,
+	// at most 5 distinct windows are aggregated, and the ones longer than the delivery retention are cut to it
+	StatisticsWindows []int64 `json:"statistics_windows"`
 }
 
 type RspSubscriptionSearch struct {
@@ -47,6 +50,15 @@ type ParamSubscriptionTestCallback struct {
 	Data                    string `json:"data"`
 }
 
+// ParamSubscriptionSecret rotate the secret of subscription,
+// the previous secret keeps valid in the grace period, empty secret means disable the signature
+type ParamSubscriptionSecret struct {
+	Secret string `json:"secret"`
+	// GracePeriod the seconds the previous secret keeps valid, it's 24 hours if omitted,
+	// 0 switches to the new secret immediately.
+	GracePeriod *int64 `json:"grace_period,omitempty"`
+}
+
 type RspSubscriptionTestCallback struct {
 	HttpStatus   int    `json:"http_status"`
 	ResponseBody string `json:"response_body"`
@@ -54,24 +66,90 @@ type RspSubscriptionTestCallback struct {
 
 // Subscription define
 type Subscription struct {
-	SubscriptionID   int64       `bson:"subscription_id" json:"subscription_id"`
-	SubscriptionName string      `bson:"subscription_name" json:"subscription_name"`
-	SystemName       string      `bson:"system_name" json:"system_name"`
-	CallbackURL      string      `bson:"callback_url" json:"callback_url"`
-	ConfirmMode      string      `bson:"confirm_mode" json:"confirm_mode"`
-	ConfirmPattern   string      `bson:"confirm_pattern" json:"confirm_pattern"`
-	TimeOut          int64       `bson:"time_out" json:"time_out"`                   // second
-	SubscriptionForm string      `bson:"subscription_form" json:"subscription_form"` // json format
-	Operator         string      `bson:"operator" json:"operator"`
-	OwnerID          string      `bson:"bk_supplier_account" json:"bk_supplier_account"`
-	LastTime         Time        `bson:"last_time" json:"last_time"`
-	Statistics       *Statistics `bson:"-" json:"statistics"`
+	SubscriptionID   int64               `bson:"subscription_id" json:"subscription_id"`
+	SubscriptionName string              `bson:"subscription_name" json:"subscription_name"`
+	SystemName       string              `bson:"system_name" json:"system_name"`
+	CallbackURL      string              `bson:"callback_url" json:"callback_url"`
+	ConfirmMode      string              `bson:"confirm_mode" json:"confirm_mode"`
+	ConfirmPattern   string              `bson:"confirm_pattern" json:"confirm_pattern"`
+	TimeOut          int64               `bson:"time_out" json:"time_out"`                   // second
+	SubscriptionForm string              `bson:"subscription_form" json:"subscription_form"` // json format
+	Operator         string              `bson:"operator" json:"operator"`
+	OwnerID          string              `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	LastTime         Time                `bson:"last_time" json:"last_time"`
+	RetryPolicy      *RetryPolicy        `bson:"retry_policy" json:"retry_policy,omitempty"`
+	OrderPolicy      *OrderPolicy        `bson:"order_policy" json:"order_policy,omitempty"`
+	Filter           *SubscriptionFilter `bson:"filter" json:"filter,omitempty"`
+	// SinkType where the events are sent to, http callback by default
+	SinkType string `bson:"sink_type" json:"sink_type"`
+	// SinkConfig the config of the sink, redis: key, mode(list/stream), max_length(10000 by default); file: name; mq: queue, topic
+	SinkConfig map[string]string `bson:"sink_config" json:"sink_config,omitempty"`
+	// Secret the key to sign the callback body, it's encrypted when persisted
+	Secret string `bson:"secret" json:"secret,omitempty"`
+	// PreviousSecret the rotated secret, which is still used to sign the callback until PreviousSecretExpire
+	PreviousSecret       string      `bson:"previous_secret" json:"previous_secret,omitempty"`
+	PreviousSecretExpire *Time       `bson:"previous_secret_expire" json:"previous_secret_expire,omitempty"`
+	SecretEnabled        bool        `bson:"-" json:"secret_enabled"`
+	Statistics           *Statistics `bson:"-" json:"statistics"`
+}
+
+// SubscriptionFilter filter the events by the instance data before they are pushed to the subscriber
+type SubscriptionFilter struct {
+	// Condition the instance data must match all the condition items, the current data is used
+	// except the delete events. the host data contains the bk_biz_id, bk_set_id, bk_module_id of it.
+	Condition []ConditionItem `bson:"condition" json:"condition"`
+	// ChangedFields the update events are pushed only when at least one of these fields changed
+	ChangedFields []string `bson:"changed_fields" json:"changed_fields"`
 }
 
+// RetryPolicy define how the event server retries a failed callback
+type RetryPolicy struct {
+	// MaxAttempts the max times a event will be sent, include the first one
+	MaxAttempts int `bson:"max_attempts" json:"max_attempts"`
+	// Interval the backoff before the first retry, millisecond
+	Interval int64 `bson:"interval" json:"interval"`
+	// MaxInterval the upper limit of the backoff, millisecond
+	MaxInterval int64 `bson:"max_interval" json:"max_interval"`
+	// Jitter the random factor of the backoff, range [0, 1]
+	Jitter float64 `bson:"jitter" json:"jitter"`
+}
+
+// OrderPolicy define what to do when the events of an instance are blocked by the previous one.
+// the events of the same instance are delivered in order, and the ones of different instances in parallel.
+type OrderPolicy struct {
+	// BlockTimeout how long to wait for the previous event, second
+	BlockTimeout int64 `bson:"block_timeout" json:"block_timeout"`
+	// OnBlocked the action after the block timeout, OrderBlockedWait or OrderBlockedSkip
+	OnBlocked string `bson:"on_blocked" json:"on_blocked"`
+}
+
+// the actions when the event is blocked by the previous one of the same instance
+const (
+	// OrderBlockedWait keep waiting while the previous event is being sent, and skip it only when it's lost
+	OrderBlockedWait = "wait"
+	// OrderBlockedSkip send the event without waiting for the previous one any more
+	OrderBlockedSkip = "skip"
+)
+
 // Report define sending statistic
 type Statistics struct {
-	Total   int64 `json:"total"`
-	Failure int64 `json:"failure"`
+	Total   int64                      `json:"total"`
+	Failure int64                      `json:"failure"`
+	Windows []DeliveryWindowStatistics `json:"windows"`
+	// Blocked the count of the events sent before the previous ones of the same instance by the order policy
+	Blocked int64 `json:"blocked"`
+}
+
+// DeliveryWindowStatistics the statistics of the callback attempts in the recent time window
+type DeliveryWindowStatistics struct {
+	// Window second
+	Window      int64   `json:"window"`
+	Total       int64   `json:"total"`
+	Failure     int64   `json:"failure"`
+	SuccessRate float64 `json:"success_rate"`
+	// P50Latency P99Latency millisecond
+	P50Latency int64 `json:"p50_latency"`
+	P99Latency int64 `json:"p99_latency"`
 }
 
 func (Subscription) TableName() string {
@@ -83,17 +161,33 @@ func (s Subscription) GetCacheKey() string {
 	sort.Strings(eventnames)
 	s.SubscriptionForm = strings.Join(eventnames, ",")
 	ns := &Subscription{
-		SubscriptionID:   s.SubscriptionID,
-		CallbackURL:      s.CallbackURL,
-		ConfirmMode:      s.ConfirmMode,
-		ConfirmPattern:   s.ConfirmPattern,
-		SubscriptionForm: s.SubscriptionForm,
-		TimeOut:          s.TimeOut,
+		SubscriptionID:       s.SubscriptionID,
+		CallbackURL:          s.CallbackURL,
+		ConfirmMode:          s.ConfirmMode,
+		ConfirmPattern:       s.ConfirmPattern,
+		SubscriptionForm:     s.SubscriptionForm,
+		TimeOut:              s.TimeOut,
+		RetryPolicy:          s.RetryPolicy,
+		OrderPolicy:          s.OrderPolicy,
+		SinkType:             s.SinkType,
+		SinkConfig:           s.SinkConfig,
+		OwnerID:              s.OwnerID,
+		Secret:               s.Secret,
+		PreviousSecret:       s.PreviousSecret,
+		PreviousSecretExpire: s.PreviousSecretExpire,
 	}
 	b, _ := json.Marshal(ns)
 	return string(b)
 }
 
+// GetSinkType returns the sink type, the subscriptions created before the sinks are supported use http
+func (s Subscription) GetSinkType() string {
+	if s.SinkType == "" {
+		return SinkTypeHTTP
+	}
+	return s.SinkType
+}
+
 func (s Subscription) GetTimeout() time.Duration {
 	return time.Second * time.Duration(s.TimeOut)
 }
@@ -139,6 +233,11 @@ type DistInst struct {
 	EventInst
 	DstbID         int64 `json:"distribution_id"`
 	SubscriptionID int64 `json:"subscription_id"`
+	// OrderKey the object type and the instance id, the events with the same key are delivered by the OrderSeq
+	OrderKey string `json:"order_key,omitempty"`
+	OrderSeq int64  `json:"order_seq,omitempty"`
+	// Attempts the times the dist has been sent, the dist is scheduled to be sent again if it's less than the max attempts
+	Attempts int `json:"attempts,omitempty"`
 }
 
 type DistInstCtx struct {
@@ -146,6 +245,122 @@ type DistInstCtx struct {
 	Raw string
 }
 
+// EventHistory the persisted event, the ID is the cursor to watch the events
+type EventHistory struct {
+	ID         int64  `bson:"id" json:"id"`
+	EventID    int64  `bson:"event_id" json:"event_id"`
+	EventType  string `bson:"event_type" json:"event_type"` // the same as the subscription form, such as hostcreate
+	OwnerID    string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	Raw        string `bson:"raw" json:"raw"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+}
+
+// EventWatermark the max id of the cleaned events of the owner, the watch cursors before it are expired
+type EventWatermark struct {
+	OwnerID   string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CleanedID int64  `bson:"cleaned_id" json:"cleaned_id"`
+	LastTime  Time   `bson:"last_time" json:"last_time"`
+}
+
+// ParamEventWatch watch the events after the cursor, the event id is used to locate
+// the cursor if the cursor is not set, and it starts from the latest event if neither is set.
+type ParamEventWatch struct {
+	Cursor     int64    `json:"cursor"`
+	EventID    int64    `json:"event_id"`
+	EventTypes []string `json:"event_types"`
+	Limit      int64    `json:"limit"`
+	Timeout    int64    `json:"timeout"` // second, the max time to wait for the new events
+}
+
+type RspEventWatch struct {
+	Cursor int64        `json:"cursor"`
+	Events []WatchEvent `json:"events"`
+}
+
+type WatchEvent struct {
+	Cursor    int64 `json:"cursor"`
+	EventInst `json:",inline"`
+}
+
+// EventDeadLetter define the event which failed to be sent after all the retries
+type EventDeadLetter struct {
+	ID             int64  `bson:"id" json:"id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	DstbID         int64  `bson:"distribution_id" json:"distribution_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	Action         string `bson:"action" json:"action"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	Attempts       int    `bson:"attempts" json:"attempts"`
+	LastError      string `bson:"last_error" json:"last_error"`
+	Raw            string `bson:"raw" json:"raw"`
+	OwnerID        string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime     Time   `bson:"create_time" json:"create_time"`
+}
+
+type ParamDeadLetterSearch struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type RspDeadLetterSearch struct {
+	Count uint64            `json:"count"`
+	Info  []EventDeadLetter `json:"info"`
+}
+
+// ParamDeadLetterIDs the dead letters to be replayed or purged, empty means all of the subscription
+type ParamDeadLetterIDs struct {
+	IDs []int64 `json:"ids"`
+}
+
+type RspDeadLetterOperate struct {
+	Count int64 `json:"count"`
+	// Invalid the ids of the dead letters which can not be replayed because the raw event is broken, they are kept
+	Invalid []int64 `json:"invalid,omitempty"`
+}
+
+// EventDelivery the log of a callback attempt
+type EventDelivery struct {
+	ID             int64  `bson:"id" json:"id"`
+	SubscriptionID int64  `bson:"subscription_id" json:"subscription_id"`
+	DstbID         int64  `bson:"distribution_id" json:"distribution_id"`
+	EventType      string `bson:"event_type" json:"event_type"`
+	Action         string `bson:"action" json:"action"`
+	ObjType        string `bson:"obj_type" json:"obj_type"`
+	RequestID      string `bson:"request_id" json:"request_id"`
+	Attempt        int    `bson:"attempt" json:"attempt"`
+	Success        bool   `bson:"success" json:"success"`
+	StatusCode     int    `bson:"status_code" json:"status_code"`
+	// Latency millisecond
+	Latency int64 `bson:"latency" json:"latency"`
+	// Response the response body, it's truncated if too long
+	Response   string `bson:"response" json:"response"`
+	Error      string `bson:"error" json:"error"`
+	OwnerID    string `bson:"bk_supplier_account" json:"bk_supplier_account"`
+	CreateTime Time   `bson:"create_time" json:"create_time"`
+}
+
+type ParamDeliverySearch struct {
+	Condition map[string]interface{} `json:"condition"`
+	Page      BasePage               `json:"page"`
+}
+
+type RspDeliverySearch struct {
+	Count uint64          `json:"count"`
+	Info  []EventDelivery `json:"info"`
+}
+
+// the sink types of the subscription
+const (
+	// SinkTypeHTTP post the events to the callback url
+	SinkTypeHTTP = "http"
+	// SinkTypeRedis push the events to a redis list or stream
+	SinkTypeRedis = "redis"
+	// SinkTypeFile append the events to a local file, one json per line
+	SinkTypeFile = "file"
+	// SinkTypeMQ publish the events to a registered message queue
+	SinkTypeMQ = "mq"
+)
+
 // EventAction
 const (
 	EventActionCreate = "create"
@@ -225,10 +225,24 @@ type CloudTaskInfo struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm" bson:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id" bson:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key" bson:"bk_secret_key"`
+	Endpoint        string `json:"bk_endpoint" bson:"bk_endpoint"`
 	SyncStatus      string `json:"bk_sync_status" bson:"bk_sync_status"`
 	NewAdd          int64  `json:"new_add" bson:"new_add"`
 	AttrChanged     int64  `json:"attr_changed" bson:"attr_changed"`
+	Terminated      int64  `json:"terminated" bson:"terminated"`
 	OwnerID         string `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	// TerminatedPolicy how to handle the hosts whose cloud instances are terminated
+	TerminatedPolicy CloudTerminatedPolicy `json:"bk_terminated_policy" bson:"bk_terminated_policy"`
+	// SyncedInstances the cloud instances returned by the last sync
+	SyncedInstances []CloudSyncedInstance `json:"bk_synced_instances" bson:"bk_synced_instances"`
+	// MappingRules the rules to map the cloud instances to the hosts
+	MappingRules CloudMappingRules `json:"bk_mapping_rules" bson:"bk_mapping_rules"`
+}
+
+// CloudSyncedInstance the cloud instance synced by the cloud sync task
+type CloudSyncedInstance struct {
+	InstanceID string `json:"instance_id" bson:"instance_id"`
+	InnerIP    string `json:"bk_host_innerip" bson:"bk_host_innerip"`
 }
 
 // TransferHostToInnerModule transfer host to inner module eg:idle module ,fault module
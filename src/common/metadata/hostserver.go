@@ -98,6 +98,8 @@ type HostCommonSearch struct {
 	Condition []SearchCondition `json:"condition"`
 	Page      BasePage          `json:"page"`
 	Pattern   string            `json:"pattern,omitempty"`
+	// Query the query statement such as biz="pay" and os_type=linux, which is compiled into the condition
+	Query string `json:"query,omitempty"`
 }
 
 type HostModuleFind struct {
@@ -111,6 +113,10 @@ type IPInfo struct {
 	Data  []string `json:"data"`
 	Exact int64    `json:"exact"`
 	Flag  string   `json:"flag"`
+	// Ranges the ips, cidr blocks and ip ranges like 10.0.0.1-10.0.0.100, one of the ips of the host is in one of them
+	Ranges []string `json:"ranges"`
+	// Excludes the ips, cidr blocks and ip ranges, none of the ips of the host is in them
+	Excludes []string `json:"excludes"`
 }
 
 //search condition
@@ -187,6 +193,101 @@ type CloudTaskList struct {
 	AttrConfirm     bool   `json:"bk_attr_confirm"`
 	SecretID        string `json:"bk_secret_id"`
 	SecretKey       string `json:"bk_secret_key"`
+	Endpoint        string `json:"bk_endpoint"`
+	// TerminatedPolicy how to handle the hosts whose cloud instances are terminated
+	TerminatedPolicy CloudTerminatedPolicy `json:"bk_terminated_policy"`
+	// MappingRules the rules to map the cloud instances to the hosts
+	MappingRules CloudMappingRules `json:"bk_mapping_rules"`
+}
+
+const (
+	// CloudTerminatedNone only records the hosts whose cloud instances are terminated
+	CloudTerminatedNone = "none"
+	// CloudTerminatedStatus sets a host attribute to mark the host as terminated
+	CloudTerminatedStatus = "status"
+	// CloudTerminatedFault moves the terminated hosts to the fault module of their business
+	CloudTerminatedFault = "fault"
+	// CloudTerminatedConfirm queues the terminated hosts for the resource confirm
+	CloudTerminatedConfirm = "confirm"
+)
+
+// CloudTerminatedPolicy the policy of a cloud sync task on the hosts whose cloud instances are terminated
+type CloudTerminatedPolicy struct {
+	Action     string      `json:"action" bson:"action"`
+	PropertyID string      `json:"bk_property_id" bson:"bk_property_id"`
+	Value      interface{} `json:"value" bson:"value"`
+}
+
+// Validate checks the terminated policy, the empty action is the same as none
+func (p CloudTerminatedPolicy) Validate() (string, bool) {
+	switch p.Action {
+	case "", CloudTerminatedNone, CloudTerminatedFault, CloudTerminatedConfirm:
+		return "", true
+	case CloudTerminatedStatus:
+		if p.PropertyID == "" {
+			return "bk_terminated_policy.bk_property_id", false
+		}
+		return "", true
+	default:
+		return "bk_terminated_policy.action", false
+	}
+}
+
+// CloudMappingRules the rules to map the cloud instances to the hosts besides the attributes
+// which the cloud provider maps, such as the inner ip, outer ip and os name.
+type CloudMappingRules struct {
+	Attributes []CloudAttributeRule `json:"attributes" bson:"attributes"`
+	// Topology the first matched rule decides where the new host is added,
+	// the new host is added to the resource pool if none of the rules is matched.
+	Topology []CloudTopologyRule `json:"topology" bson:"topology"`
+}
+
+// CloudAttributeRule maps a field of the cloud instance to a host attribute
+type CloudAttributeRule struct {
+	// Field the field of the instance, eg: instance_id, region, tag.owner
+	Field      string `json:"field" bson:"field"`
+	PropertyID string `json:"bk_property_id" bson:"bk_property_id"`
+	// Transforms transform the value in order, eg: trim, lower, upper
+	Transforms []string `json:"transforms" bson:"transforms"`
+	// Mapping replaces the transformed value, the value not in it is kept
+	Mapping map[string]string `json:"mapping" bson:"mapping"`
+	// Default the value when the field of the instance is empty
+	Default string `json:"default" bson:"default"`
+}
+
+// CloudTopologyRule adds the new hosts whose instance tag has the value to the business module,
+// the host is added to the idle module of the business if the module is not specified.
+type CloudTopologyRule struct {
+	Tag      string `json:"tag" bson:"tag"`
+	Value    string `json:"value" bson:"value"`
+	BizID    int64  `json:"bk_biz_id" bson:"bk_biz_id"`
+	ModuleID int64  `json:"bk_module_id" bson:"bk_module_id"`
+}
+
+// CloudMappingPreviewRequest preview the mapping rules with the account of the task or the request,
+// the rules of the task are used if the rules are not specified.
+type CloudMappingPreviewRequest struct {
+	TaskID       int64              `json:"bk_task_id"`
+	AccountType  string             `json:"bk_account_type"`
+	SecretID     string             `json:"bk_secret_id"`
+	SecretKey    string             `json:"bk_secret_key"`
+	Endpoint     string             `json:"bk_endpoint"`
+	MappingRules *CloudMappingRules `json:"bk_mapping_rules"`
+}
+
+// CloudMappingPreview the host which the cloud instance is mapped to
+type CloudMappingPreview struct {
+	InstanceID string            `json:"instance_id"`
+	Tags       map[string]string `json:"tags"`
+	Host       mapstr.MapStr     `json:"host"`
+	BizID      int64             `json:"bk_biz_id"`
+	ModuleID   int64             `json:"bk_module_id"`
+	Exists     bool              `json:"exists"`
+}
+
+type CloudMappingPreviewResult struct {
+	BaseResp `json:",inline"`
+	Data     []CloudMappingPreview `json:"data"`
 }
 
 type ResourceConfirm struct {
@@ -202,15 +303,17 @@ type ResourceConfirm struct {
 }
 
 type CloudHistory struct {
-	ObjID       string `json:"bk_obj_id"`
-	Status      string `json:"bk_status"`
-	TimeConsume string `json:"bk_time_consume"`
-	NewAdd      int    `json:"new_add"`
-	AttrChanged int    `json:"attr_changed"`
-	StartTime   string `json:"bk_start_time"`
-	TaskID      int64  `json:"bk_task_id"`
-	HistoryID   int64  `json:"bk_history_id"`
-	FailReason  string `json:"fail_reason"`
+	ObjID           string   `json:"bk_obj_id"`
+	Status          string   `json:"bk_status"`
+	TimeConsume     string   `json:"bk_time_consume"`
+	NewAdd          int      `json:"new_add"`
+	AttrChanged     int      `json:"attr_changed"`
+	Terminated      int      `json:"terminated"`
+	TerminatedHosts []string `json:"bk_terminated_hosts"`
+	StartTime       string   `json:"bk_start_time"`
+	TaskID          int64    `json:"bk_task_id"`
+	HistoryID       int64    `json:"bk_history_id"`
+	FailReason      string   `json:"fail_reason"`
 }
 
 type DeleteCloudTask struct {
@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import "configcenter/src/common/mapstr"
+
+// InstGraphDirection the direction to walk along the instance associations
+type InstGraphDirection string
+
+const (
+	// InstGraphOutbound walk from the source instance to the destination instance of the associations
+	InstGraphOutbound InstGraphDirection = "out"
+	// InstGraphInbound walk from the destination instance to the source instance of the associations
+	InstGraphInbound InstGraphDirection = "in"
+	// InstGraphBoth walk along the associations in both directions
+	InstGraphBoth InstGraphDirection = "both"
+)
+
+// the limits of the instance graph query
+const (
+	InstGraphDefaultMaxDepth = 3
+	InstGraphMaxDepthLimit   = 10
+	InstGraphDefaultMaxNodes = 1000
+	InstGraphMaxNodesLimit   = 10000
+)
+
+// IsValid check whether the direction is supported
+func (d InstGraphDirection) IsValid() bool {
+	switch d {
+	case InstGraphOutbound, InstGraphInbound, InstGraphBoth:
+		return true
+	}
+	return false
+}
+
+// SearchInstGraphRequest walk the instance associations from a instance
+type SearchInstGraphRequest struct {
+	// AssociationKinds only walk along the associations of these kinds, all the kinds are allowed if it's empty
+	AssociationKinds []string `json:"bk_asst_ids"`
+	// Objects only walk to the instances of these models, all the models are allowed if it's empty
+	Objects []string `json:"bk_obj_ids"`
+	// Direction the direction to walk, both by default
+	Direction InstGraphDirection `json:"direction"`
+	// MaxDepth the max hops from the start instance
+	MaxDepth int `json:"max_depth"`
+	// MaxNodes the max count of the nodes returned, the walk stops when it's reached
+	MaxNodes int `json:"max_nodes"`
+	// Filters the attribute filters of the instances on each level
+	Filters []InstGraphFilter `json:"filters"`
+}
+
+// InstGraphFilter the attribute filter of the instances on a level, the instances
+// which don't match the filter are neither returned nor walked through.
+type InstGraphFilter struct {
+	// Depth the level the filter applies to, it applies to all the levels if it's 0
+	Depth int `json:"depth"`
+	// ObjectID the model the filter applies to
+	ObjectID  string        `json:"bk_obj_id"`
+	Condition mapstr.MapStr `json:"condition"`
+}
+
+// Normalize set the default values and cap the limits of the request
+func (r *SearchInstGraphRequest) Normalize() {
+	if len(r.Direction) == 0 {
+		r.Direction = InstGraphBoth
+	}
+	if r.MaxDepth <= 0 {
+		r.MaxDepth = InstGraphDefaultMaxDepth
+	}
+	if r.MaxDepth > InstGraphMaxDepthLimit {
+		r.MaxDepth = InstGraphMaxDepthLimit
+	}
+	if r.MaxNodes <= 0 {
+		r.MaxNodes = InstGraphDefaultMaxNodes
+	}
+	if r.MaxNodes > InstGraphMaxNodesLimit {
+		r.MaxNodes = InstGraphMaxNodesLimit
+	}
+}
+
+// FilterOf returns the conditions that the instances of the model on the level must match
+func (r *SearchInstGraphRequest) FilterOf(depth int, objID string) []mapstr.MapStr {
+	conds := make([]mapstr.MapStr, 0)
+	for _, filter := range r.Filters {
+		if filter.ObjectID != objID || (filter.Depth != 0 && filter.Depth != depth) {
+			continue
+		}
+		if len(filter.Condition) != 0 {
+			conds = append(conds, filter.Condition)
+		}
+	}
+	return conds
+}
+
+// InstGraphNode a instance in the graph
+type InstGraphNode struct {
+	ObjectID string `json:"bk_obj_id"`
+	InstID   int64  `json:"bk_inst_id"`
+	InstName string `json:"bk_inst_name"`
+	// Depth the hops from the start instance
+	Depth int `json:"depth"`
+}
+
+// InstGraphEdge a instance association in the graph
+type InstGraphEdge struct {
+	// ID the id of the instance association
+	ID                int64  `json:"id"`
+	ObjectAsstID      string `json:"bk_obj_asst_id"`
+	AssociationKindID string `json:"bk_asst_id"`
+	ObjectID          string `json:"bk_obj_id"`
+	InstID            int64  `json:"bk_inst_id"`
+	AsstObjectID      string `json:"bk_asst_obj_id"`
+	AsstInstID        int64  `json:"bk_asst_inst_id"`
+}
+
+// InstGraph the instances and the associations reached from the start instance
+type InstGraph struct {
+	Nodes []InstGraphNode `json:"nodes"`
+	Edges []InstGraphEdge `json:"edges"`
+	// Cyclic whether the associations in the graph form a directed cycle, the associations
+	// between the instances on the last level are not walked, so the cycles closed by them are not found
+	Cyclic bool `json:"cyclic"`
+	// Truncated whether the walk stopped because of the max nodes limit
+	Truncated bool `json:"truncated"`
+}
+
+// SearchInstGraphResult the instance graph api http response return result struct
+type SearchInstGraphResult struct {
+	BaseResp `json:",inline"`
+	Data     InstGraph `json:"data"`
+}
+
+// instGraphNodeKey identify a instance in the instance graph
+type instGraphNodeKey struct {
+	objID  string
+	instID int64
+}
+
+// InstGraphCyclic returns whether the edges form a directed cycle, the converging edges
+// such as a diamond are not cycles, unless they lead back to a instance on the same path.
+func InstGraphCyclic(edges []InstGraphEdge) bool {
+	successors := make(map[instGraphNodeKey][]instGraphNodeKey)
+	for _, edge := range edges {
+		from := instGraphNodeKey{objID: edge.ObjectID, instID: edge.InstID}
+		successors[from] = append(successors[from], instGraphNodeKey{objID: edge.AsstObjectID, instID: edge.AsstInstID})
+	}
+
+	// onPath the instances on the current path, done the instances whose successors are all walked without a cycle
+	onPath := make(map[instGraphNodeKey]bool)
+	done := make(map[instGraphNodeKey]bool)
+	var walk func(key instGraphNodeKey) bool
+	walk = func(key instGraphNodeKey) bool {
+		if onPath[key] {
+			return true
+		}
+		if done[key] {
+			return false
+		}
+		onPath[key] = true
+		for _, next := range successors[key] {
+			if walk(next) {
+				return true
+			}
+		}
+		onPath[key] = false
+		done[key] = true
+		return false
+	}
+
+	for key := range successors {
+		if walk(key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package metadata
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+)
+
+func TestSearchInstGraphRequestNormalize(t *testing.T) {
+	req := &SearchInstGraphRequest{MaxDepth: 100, MaxNodes: -1}
+	req.Normalize()
+	if req.Direction != InstGraphBoth || req.MaxDepth != InstGraphMaxDepthLimit || req.MaxNodes != InstGraphDefaultMaxNodes {
+		t.Errorf("unexpected normalized request: %+v", req)
+	}
+}
+
+func TestSearchInstGraphRequestFilterOf(t *testing.T) {
+	req := &SearchInstGraphRequest{
+		Filters: []InstGraphFilter{
+			{Depth: 0, ObjectID: "host", Condition: mapstr.MapStr{"bk_os_type": "1"}},
+			{Depth: 2, ObjectID: "host", Condition: mapstr.MapStr{"bk_cloud_id": 0}},
+			{Depth: 2, ObjectID: "switch", Condition: mapstr.MapStr{"bk_inst_name": "s1"}},
+		},
+	}
+	tests := []struct {
+		depth int
+		objID string
+		want  int
+	}{
+		{1, "host", 1},
+		{2, "host", 2},
+		{2, "switch", 1},
+		{1, "switch", 0},
+	}
+	for _, tt := range tests {
+		if got := req.FilterOf(tt.depth, tt.objID); len(got) != tt.want {
+			t.Errorf("FilterOf(%d, %s) got %d filters, want %d", tt.depth, tt.objID, len(got), tt.want)
+		}
+	}
+}
+
+func instGraphEdges(pairs ...[2]int64) []InstGraphEdge {
+	edges := make([]InstGraphEdge, 0, len(pairs))
+	for index, pair := range pairs {
+		edges = append(edges, InstGraphEdge{
+			ID:           int64(index + 1),
+			ObjectID:     "obj",
+			InstID:       pair[0],
+			AsstObjectID: "obj",
+			AsstInstID:   pair[1],
+		})
+	}
+	return edges
+}
+
+func TestInstGraphCyclic(t *testing.T) {
+	tests := []struct {
+		name  string
+		edges []InstGraphEdge
+		want  bool
+	}{
+		{name: "empty", edges: nil, want: false},
+		{name: "chain", edges: instGraphEdges([2]int64{1, 2}, [2]int64{2, 3}), want: false},
+		// A->B, A->C, B->C, C is reached on the first level and again from B
+		{name: "diamond", edges: instGraphEdges([2]int64{1, 2}, [2]int64{1, 3}, [2]int64{2, 3}), want: false},
+		// A->B, A->C, B->D, C->D, both parents on the same level point to D
+		{name: "same level convergence", edges: instGraphEdges([2]int64{1, 2}, [2]int64{1, 3}, [2]int64{2, 4}, [2]int64{3, 4}), want: false},
+		// A->B, A->C, B->D, D->C, C->B, the cycle closes among the instances on the same and the next level
+		{name: "same level cycle", edges: instGraphEdges([2]int64{1, 2}, [2]int64{1, 3}, [2]int64{2, 4}, [2]int64{4, 3}, [2]int64{3, 2}), want: true},
+		{name: "back to start", edges: instGraphEdges([2]int64{1, 2}, [2]int64{2, 3}, [2]int64{3, 1}), want: true},
+		{name: "self association", edges: instGraphEdges([2]int64{1, 1}), want: true},
+	}
+	for _, tt := range tests {
+		if got := InstGraphCyclic(tt.edges); got != tt.want {
+			t.Errorf("%s: InstGraphCyclic() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// the instances of the different objects with the same id are different nodes
+	edges := []InstGraphEdge{
+		{ID: 1, ObjectID: "set", InstID: 1, AsstObjectID: "module", AsstInstID: 1},
+		{ID: 2, ObjectID: "module", InstID: 1, AsstObjectID: "host", AsstInstID: 1},
+	}
+	if InstGraphCyclic(edges) {
+		t.Errorf("the associations between the instances of the different objects are not cyclic")
+	}
+}
@@ -0,0 +1,391 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// the formats of the model bundle
+const (
+	ModelBundleFormatJSON = "json"
+	ModelBundleFormatYAML = "yaml"
+)
+
+// ModelBundle the declarative definition of the models, which can be exported from a cmdb and applied to another one
+type ModelBundle struct {
+	Classifications []Classification `json:"classifications"`
+	Models          []BundleModel    `json:"models"`
+	// Associations the associations between the models of the bundle
+	Associations []Association `json:"associations"`
+}
+
+// BundleModel the model of the bundle
+type BundleModel struct {
+	Object     Object         `json:"object"`
+	Attributes []Attribute    `json:"attributes"`
+	Groups     []Group        `json:"groups"`
+	Uniques    []SchemaUnique `json:"uniques"`
+}
+
+// EncodeModelBundle encode the bundle in the format
+func EncodeModelBundle(bundle *ModelBundle, format string) ([]byte, error) {
+	js, err := json.Marshal(bundle)
+	if nil != err {
+		return nil, err
+	}
+
+	switch format {
+	case "", ModelBundleFormatJSON:
+		return js, nil
+	case ModelBundleFormatYAML:
+		// the bundle is converted by json, so that the fields are named by the json tags
+		var data interface{}
+		if err := json.Unmarshal(js, &data); nil != err {
+			return nil, err
+		}
+		return yaml.Marshal(data)
+	default:
+		return nil, fmt.Errorf("unknown bundle format %s", format)
+	}
+}
+
+// DecodeModelBundle decode the bundle in the format
+func DecodeModelBundle(content []byte, format string) (*ModelBundle, error) {
+	js := content
+	switch format {
+	case "", ModelBundleFormatJSON:
+	case ModelBundleFormatYAML:
+		var data interface{}
+		if err := yaml.Unmarshal(content, &data); nil != err {
+			return nil, err
+		}
+		var err error
+		if js, err = json.Marshal(yamlToJSONValue(data)); nil != err {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown bundle format %s", format)
+	}
+
+	bundle := new(ModelBundle)
+	if err := json.Unmarshal(js, bundle); nil != err {
+		return nil, err
+	}
+	return bundle, nil
+}
+
+// yamlToJSONValue convert the maps decoded from yaml, whose keys are interface{}, to the ones that json supports
+func yamlToJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = yamlToJSONValue(item)
+		}
+		return result
+	case []interface{}:
+		for idx, item := range v {
+			v[idx] = yamlToJSONValue(item)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// ExportModelBundleOption the models to export, all the models are exported if it's empty
+type ExportModelBundleOption struct {
+	ObjectIDs []string `json:"bk_obj_ids"`
+}
+
+// ModelBundleOption the bundle to plan or apply
+type ModelBundleOption struct {
+	Bundle ModelBundle `json:"bundle"`
+	// Prune deletes the attributes, groups and uniques of the models of the bundle, and the associations between them,
+	// which are not defined by the bundle, and the models in the classifications of the bundle which are not defined by
+	// the bundle, the pre-defined and mainline models and the classifications are never deleted.
+	Prune bool `json:"prune"`
+}
+
+// ModelBundleRequest the bundle of the request, it's decoded from the content if the bundle is not set
+type ModelBundleRequest struct {
+	Bundle  *ModelBundle `json:"bundle"`
+	Format  string       `json:"format"`
+	Content string       `json:"content"`
+	Prune   bool         `json:"prune"`
+}
+
+// Option returns the option to plan or apply the bundle of the request
+func (r ModelBundleRequest) Option() (*ModelBundleOption, error) {
+	if nil != r.Bundle {
+		return &ModelBundleOption{Bundle: *r.Bundle, Prune: r.Prune}, nil
+	}
+
+	bundle, err := DecodeModelBundle([]byte(r.Content), r.Format)
+	if nil != err {
+		return nil, err
+	}
+	return &ModelBundleOption{Bundle: *bundle, Prune: r.Prune}, nil
+}
+
+// the actions of the changes planned for the bundle
+const (
+	BundleActionCreate = "create"
+	BundleActionUpdate = "update"
+	BundleActionDelete = "delete"
+	// BundleActionConflict the item can't be changed in place, such as the ends of the association, it's skipped
+	BundleActionConflict = "conflict"
+)
+
+// AuditTargetModelBundle the op_target of the audit logs of the changes applied by the bundles
+const AuditTargetModelBundle = "model_bundle"
+
+// the kinds of the items of the bundle
+const (
+	BundleKindClassification = "classification"
+	BundleKindModel          = "model"
+	BundleKindGroup          = "group"
+	BundleKindAttribute      = "attribute"
+	BundleKindUnique         = "unique"
+	BundleKindAssociation    = "association"
+)
+
+// BundleChange the change planned to make the cmdb match the bundle
+type BundleChange struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// ObjectID the model of the item, it's empty for the classifications and the associations
+	ObjectID string      `json:"bk_obj_id"`
+	Key      string      `json:"key"`
+	Fields   []FieldDiff `json:"fields"`
+	// Error the reason why the change is failed to apply
+	Error string `json:"error,omitempty"`
+}
+
+// ModelBundlePlan the changes planned for the bundle, they are applied in order
+type ModelBundlePlan struct {
+	Changes []BundleChange `json:"changes"`
+}
+
+// ModelBundleApplyResult the result of applying the bundle, it stops at the first failed change, and the applied
+// changes are rolled back if there is a failed one
+type ModelBundleApplyResult struct {
+	Changes []BundleChange `json:"changes"`
+	Applied int            `json:"applied"`
+	Failed  int            `json:"failed"`
+	Skipped int            `json:"skipped"`
+}
+
+// associationUpdatableFields the fields of the association which can be changed in place
+var associationUpdatableFields = map[string]bool{
+	"bk_obj_asst_name": true,
+}
+
+// PlanModelBundle plan the changes to make the current definitions match the desired bundle, the current bundle
+// should contain all the classifications, the models of the desired bundle and the associations of them, and the
+// models which can be pruned if prune is set.
+func PlanModelBundle(current, desired *ModelBundle, prune bool) *ModelBundlePlan {
+	plan := &ModelBundlePlan{Changes: make([]BundleChange, 0)}
+	add := func(action, kind, objID, key string, fields []FieldDiff) {
+		if nil == fields {
+			fields = make([]FieldDiff, 0)
+		}
+		plan.Changes = append(plan.Changes, BundleChange{Action: action, Kind: kind, ObjectID: objID, Key: key, Fields: fields})
+	}
+
+	clsKey := func(item mapstr.MapStr) string { return toString(item[ClassFieldClassificationID]) }
+	clsDiff := diffSchemaItems(schemaItems(current.Classifications, clsKey), schemaItems(desired.Classifications, clsKey))
+	for _, key := range clsDiff.Added {
+		add(BundleActionCreate, BundleKindClassification, "", key, nil)
+	}
+	for _, change := range clsDiff.Changed {
+		add(BundleActionUpdate, BundleKindClassification, "", change.Key, change.Fields)
+	}
+
+	currentModels := make(map[string]BundleModel)
+	for _, model := range current.Models {
+		currentModels[model.Object.ObjectID] = model
+	}
+	desiredModels := make([]BundleModel, len(desired.Models))
+	copy(desiredModels, desired.Models)
+	sort.Slice(desiredModels, func(i, j int) bool { return desiredModels[i].Object.ObjectID < desiredModels[j].Object.ObjectID })
+
+	for _, model := range desiredModels {
+		objID := model.Object.ObjectID
+		origin, exists := currentModels[objID]
+		if !exists {
+			add(BundleActionCreate, BundleKindModel, objID, objID, nil)
+		} else if fields := diffSchemaFields(schemaItem(origin.Object), schemaItem(model.Object)); 0 != len(fields) {
+			add(BundleActionUpdate, BundleKindModel, objID, objID, fields)
+		}
+
+		diff := DiffModelSchema(
+			ModelSchema{Attributes: origin.Attributes, Groups: origin.Groups, Uniques: origin.Uniques},
+			ModelSchema{Attributes: model.Attributes, Groups: model.Groups, Uniques: model.Uniques},
+		)
+
+		// the groups are created before the attributes which are in them, and deleted after the attributes
+		for _, key := range diff.Groups.Added {
+			add(BundleActionCreate, BundleKindGroup, objID, key, nil)
+		}
+		for _, change := range diff.Groups.Changed {
+			add(BundleActionUpdate, BundleKindGroup, objID, change.Key, change.Fields)
+		}
+		for _, key := range diff.Attributes.Added {
+			add(BundleActionCreate, BundleKindAttribute, objID, key, nil)
+		}
+		for _, change := range diff.Attributes.Changed {
+			add(BundleActionUpdate, BundleKindAttribute, objID, change.Key, change.Fields)
+		}
+		for _, key := range diff.Uniques.Added {
+			add(BundleActionCreate, BundleKindUnique, objID, key, nil)
+		}
+		for _, change := range diff.Uniques.Changed {
+			add(BundleActionUpdate, BundleKindUnique, objID, change.Key, change.Fields)
+		}
+
+		if !prune {
+			continue
+		}
+		// the uniques are deleted before the attributes in them
+		preUniques := make(map[string]bool)
+		for _, unique := range origin.Uniques {
+			preUniques[unique.Key()] = unique.IsPre
+		}
+		for _, key := range diff.Uniques.Removed {
+			if !preUniques[key] {
+				add(BundleActionDelete, BundleKindUnique, objID, key, nil)
+			}
+		}
+		preAttrs := make(map[string]bool)
+		for _, attr := range origin.Attributes {
+			preAttrs[attr.PropertyID] = attr.IsPre
+		}
+		for _, key := range diff.Attributes.Removed {
+			// the pre-defined attributes are used by cmdb itself
+			if !preAttrs[key] {
+				add(BundleActionDelete, BundleKindAttribute, objID, key, nil)
+			}
+		}
+		preGroups := make(map[string]bool)
+		for _, group := range origin.Groups {
+			preGroups[group.GroupID] = group.IsPre || group.IsDefault
+		}
+		for _, key := range diff.Groups.Removed {
+			if !preGroups[key] {
+				add(BundleActionDelete, BundleKindGroup, objID, key, nil)
+			}
+		}
+	}
+
+	// the models out of the bundle are pruned, the associations of them are deleted before them
+	bundleModels := make(map[string]bool)
+	for _, model := range desired.Models {
+		bundleModels[model.Object.ObjectID] = true
+	}
+	prunedModels := make([]string, 0)
+	currentModelIDs := make(map[string]bool)
+	for _, model := range current.Models {
+		objID := model.Object.ObjectID
+		currentModelIDs[objID] = bundleModels[objID]
+		if prune && !bundleModels[objID] && !model.Object.IsPre {
+			currentModelIDs[objID] = true
+			prunedModels = append(prunedModels, objID)
+		}
+	}
+	sort.Strings(prunedModels)
+
+	// only the associations between the models of the bundle are planned
+	inModels := func(assts []Association, models map[string]bool) []Association {
+		results := make([]Association, 0)
+		for _, asst := range assts {
+			if models[asst.ObjectID] && models[asst.AsstObjID] && common.AssociationKindMainline != asst.AsstKindID {
+				results = append(results, asst)
+			}
+		}
+		return results
+	}
+	asstKey := func(item mapstr.MapStr) string { return toString(item[AssociationFieldAsstID]) }
+	asstDiff := diffSchemaItems(schemaItems(inModels(current.Associations, currentModelIDs), asstKey), schemaItems(inModels(desired.Associations, bundleModels), asstKey))
+	for _, key := range asstDiff.Added {
+		add(BundleActionCreate, BundleKindAssociation, "", key, nil)
+	}
+	for _, change := range asstDiff.Changed {
+		action := BundleActionUpdate
+		for _, field := range change.Fields {
+			if !associationUpdatableFields[field.Field] {
+				action = BundleActionConflict
+			}
+		}
+		add(action, BundleKindAssociation, "", change.Key, change.Fields)
+	}
+	if prune {
+		for _, key := range asstDiff.Removed {
+			add(BundleActionDelete, BundleKindAssociation, "", key, nil)
+		}
+		for _, objID := range prunedModels {
+			add(BundleActionDelete, BundleKindModel, objID, objID, nil)
+		}
+	}
+
+	return plan
+}
+
+// Portable returns the bundle without the ids, owners and times, so that it can be applied to another cmdb
+func (b ModelBundle) Portable() ModelBundle {
+	result := ModelBundle{
+		Classifications: make([]Classification, 0),
+		Models:          make([]BundleModel, 0),
+		Associations:    make([]Association, 0),
+	}
+	for _, cls := range b.Classifications {
+		cls.ID, cls.OwnerID = 0, ""
+		result.Classifications = append(result.Classifications, cls)
+	}
+	for _, model := range b.Models {
+		schema := ModelSchema{Object: &model.Object, Attributes: model.Attributes, Groups: model.Groups, Uniques: model.Uniques}.Portable()
+		result.Models = append(result.Models, BundleModel{
+			Object:     *schema.Object,
+			Attributes: schema.Attributes,
+			Groups:     schema.Groups,
+			Uniques:    model.Uniques,
+		})
+	}
+	result.Associations = ModelSchema{Associations: b.Associations}.Portable().Associations
+	return result
+}
+
+// ExportModelBundleResult the result of exporting the bundle
+type ExportModelBundleResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelBundle `json:"data"`
+}
+
+// PlanModelBundleResult the result of planning the bundle
+type PlanModelBundleResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelBundlePlan `json:"data"`
+}
+
+// ApplyModelBundleResult the result of applying the bundle
+type ApplyModelBundleResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelBundleApplyResult `json:"data"`
+}
@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"testing"
+)
+
+func TestPlanModelBundle(t *testing.T) {
+	current := &ModelBundle{
+		Models: []BundleModel{{
+			Object: Object{ObjectID: "switch", ObjectName: "switch"},
+			Attributes: []Attribute{
+				{ObjectID: "switch", PropertyID: "name", PropertyName: "name"},
+				{ObjectID: "switch", PropertyID: "port", PropertyName: "port"},
+			},
+		}},
+	}
+	desired := &ModelBundle{
+		Models: []BundleModel{{
+			Object: Object{ObjectID: "switch", ObjectName: "switch"},
+			Attributes: []Attribute{
+				{ObjectID: "switch", PropertyID: "name", PropertyName: "switch name"},
+				{ObjectID: "switch", PropertyID: "vendor", PropertyName: "vendor"},
+			},
+		}},
+	}
+
+	count := func(plan *ModelBundlePlan) map[string]int {
+		actions := make(map[string]int)
+		for _, change := range plan.Changes {
+			actions[change.Action+"/"+change.Kind]++
+		}
+		return actions
+	}
+
+	actions := count(PlanModelBundle(current, desired, false))
+	if len(actions) != 2 || actions["update/attribute"] != 1 || actions["create/attribute"] != 1 {
+		t.Errorf("plan without prune = %v", actions)
+	}
+
+	actions = count(PlanModelBundle(current, desired, true))
+	if actions["delete/attribute"] != 1 {
+		t.Errorf("plan with prune = %v", actions)
+	}
+
+	pruned := *current
+	pruned.Models = append(pruned.Models,
+		BundleModel{Object: Object{ObjectID: "router", ObjectName: "router"}},
+		BundleModel{Object: Object{ObjectID: "host", ObjectName: "host", IsPre: true}},
+	)
+	pruned.Associations = []Association{{AssociationName: "switch_connect_router", ObjectID: "switch", AsstObjID: "router", AsstKindID: "connect"}}
+	plan := PlanModelBundle(&pruned, desired, true)
+	actions = count(plan)
+	if actions["delete/model"] != 1 || actions["delete/association"] != 1 {
+		t.Errorf("plan with prune of the models = %v", actions)
+	}
+	if last := plan.Changes[len(plan.Changes)-1]; last.Kind != BundleKindModel || last.ObjectID != "router" {
+		t.Errorf("the last change of the plan = %v, want to delete the model router", last)
+	}
+	if actions = count(PlanModelBundle(&pruned, desired, false)); actions["delete/model"] != 0 {
+		t.Errorf("plan without prune of the models = %v", actions)
+	}
+
+	if plan := PlanModelBundle(current, current, true); len(plan.Changes) != 0 {
+		t.Errorf("plan of the same bundle = %v, want no changes", plan.Changes)
+	}
+}
+
+func TestModelBundleYAML(t *testing.T) {
+	bundle := &ModelBundle{
+		Models: []BundleModel{{
+			Object:     Object{ObjectID: "switch", ObjectName: "switch"},
+			Attributes: []Attribute{{ObjectID: "switch", PropertyID: "name", IsRequired: true}},
+			Uniques:    []SchemaUnique{{Keys: []string{"name"}, MustCheck: true}},
+		}},
+	}
+
+	content, err := EncodeModelBundle(bundle, ModelBundleFormatYAML)
+	if err != nil {
+		t.Fatalf("encode yaml failed, err: %v", err)
+	}
+	decoded, err := DecodeModelBundle(content, ModelBundleFormatYAML)
+	if err != nil {
+		t.Fatalf("decode yaml failed, err: %v", err)
+	}
+
+	if plan := PlanModelBundle(bundle, decoded, true); len(plan.Changes) != 0 {
+		t.Errorf("yaml round trip changed the bundle: %v", plan.Changes)
+	}
+}
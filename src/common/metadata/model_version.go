@@ -0,0 +1,387 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	ModelVersionFieldID       = "id"
+	ModelVersionFieldObjectID = "bk_obj_id"
+	ModelVersionFieldVersion  = "version"
+	ModelVersionFieldOwnerID  = "bk_supplier_account"
+)
+
+// the changes that the versions of the model schema are recorded for
+const (
+	ModelVersionActionInit        = "init"
+	ModelVersionActionModel       = "model"
+	ModelVersionActionAttribute   = "attribute"
+	ModelVersionActionGroup       = "group"
+	ModelVersionActionUnique      = "unique"
+	ModelVersionActionAssociation = "association"
+	ModelVersionActionRollback    = "rollback"
+)
+
+// ModelVersion the immutable version of the model schema, a new version is recorded on every change of the schema
+type ModelVersion struct {
+	ID       int64  `json:"id" bson:"id"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// Version the sequence number of the version in the model, starts from 1
+	Version int64 `json:"version" bson:"version"`
+	// Action the kind of the change that produced the version
+	Action     string      `json:"action" bson:"action"`
+	Operator   string      `json:"operator" bson:"operator"`
+	OwnerID    string      `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	CreateTime time.Time   `json:"create_time" bson:"create_time"`
+	Schema     ModelSchema `json:"schema" bson:"schema"`
+}
+
+// ModelSchema the definition of the model, it's nil object if the model has been deleted
+type ModelSchema struct {
+	Object       *Object        `json:"object" bson:"object"`
+	Attributes   []Attribute    `json:"attributes" bson:"attributes"`
+	Groups       []Group        `json:"groups" bson:"groups"`
+	Uniques      []SchemaUnique `json:"uniques" bson:"uniques"`
+	Associations []Association  `json:"associations" bson:"associations"`
+}
+
+// SchemaUnique the unique of the model schema, the keys are the property ids rather than the ids of the attributes,
+// which are changed when the attributes are created again.
+type SchemaUnique struct {
+	Keys      []string `json:"keys" bson:"keys"`
+	MustCheck bool     `json:"must_check" bson:"must_check"`
+	IsPre     bool     `json:"ispre" bson:"ispre"`
+}
+
+// Key returns the key of the unique, which is made of its sorted property ids
+func (u SchemaUnique) Key() string {
+	keys := make([]string, len(u.Keys))
+	copy(keys, u.Keys)
+	sort.Strings(keys)
+	return strings.Join(keys, "+")
+}
+
+// NewSchemaUnique convert the unique to the one of the model schema, attrs are used to find the property ids of the keys
+func NewSchemaUnique(unique ObjectUnique, attrs []Attribute) SchemaUnique {
+	propertyIDs := make(map[int64]string)
+	for _, attr := range attrs {
+		propertyIDs[attr.ID] = attr.PropertyID
+	}
+	result := SchemaUnique{Keys: make([]string, 0), MustCheck: unique.MustCheck, IsPre: unique.Ispre}
+	for _, key := range unique.Keys {
+		result.Keys = append(result.Keys, propertyIDs[int64(key.ID)])
+	}
+	return result
+}
+
+// Portable returns the schema without the ids, owners and times, so that it can be carried to another cmdb
+func (s ModelSchema) Portable() ModelSchema {
+	result := ModelSchema{
+		Attributes:   make([]Attribute, 0),
+		Groups:       make([]Group, 0),
+		Uniques:      s.Uniques,
+		Associations: make([]Association, 0),
+	}
+	if nil != s.Object {
+		object := *s.Object
+		object.ID, object.OwnerID, object.CreateTime, object.LastTime = 0, "", nil, nil
+		result.Object = &object
+	}
+	for _, attr := range s.Attributes {
+		attr.ID, attr.OwnerID, attr.CreateTime, attr.LastTime = 0, "", nil, nil
+		result.Attributes = append(result.Attributes, attr)
+	}
+	for _, group := range s.Groups {
+		group.ID, group.OwnerID = 0, ""
+		result.Groups = append(result.Groups, group)
+	}
+	for _, asst := range s.Associations {
+		asst.ID, asst.OwnerID = 0, ""
+		result.Associations = append(result.Associations, asst)
+	}
+	return result
+}
+
+// ModelVersionDocument the portable document of the model version
+type ModelVersionDocument struct {
+	ObjectID   string      `json:"bk_obj_id"`
+	Version    int64       `json:"version"`
+	Action     string      `json:"action"`
+	Operator   string      `json:"operator"`
+	CreateTime time.Time   `json:"create_time"`
+	Schema     ModelSchema `json:"schema"`
+}
+
+// Document returns the portable document of the version
+func (v ModelVersion) Document() ModelVersionDocument {
+	return ModelVersionDocument{
+		ObjectID:   v.ObjectID,
+		Version:    v.Version,
+		Action:     v.Action,
+		Operator:   v.Operator,
+		CreateTime: v.CreateTime,
+		Schema:     v.Schema.Portable(),
+	}
+}
+
+// QueryModelVersionResult the result of searching the versions of the model
+type QueryModelVersionResult struct {
+	Count int64          `json:"count"`
+	Info  []ModelVersion `json:"info"`
+}
+
+// DiffModelVersionOption the versions to compare, To is compared with From
+type DiffModelVersionOption struct {
+	From int64 `json:"from"`
+	To   int64 `json:"to"`
+}
+
+// FieldDiff the change of a field
+type FieldDiff struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// SchemaItemChange the changed fields of the item of the schema
+type SchemaItemChange struct {
+	Key    string      `json:"key"`
+	Fields []FieldDiff `json:"fields"`
+}
+
+// SchemaItemsDiff the difference of the items of the schema, such as the attributes, which are identified by the keys
+type SchemaItemsDiff struct {
+	Added   []string           `json:"added"`
+	Removed []string           `json:"removed"`
+	Changed []SchemaItemChange `json:"changed"`
+}
+
+// IsEmpty returns whether there is no difference
+func (d SchemaItemsDiff) IsEmpty() bool {
+	return 0 == len(d.Added) && 0 == len(d.Removed) && 0 == len(d.Changed)
+}
+
+// ModelSchemaDiff the difference between two model schemas
+type ModelSchemaDiff struct {
+	Object       []FieldDiff     `json:"object"`
+	Attributes   SchemaItemsDiff `json:"attributes"`
+	Groups       SchemaItemsDiff `json:"groups"`
+	Uniques      SchemaItemsDiff `json:"uniques"`
+	Associations SchemaItemsDiff `json:"associations"`
+}
+
+// IsEmpty returns whether there is no difference
+func (d ModelSchemaDiff) IsEmpty() bool {
+	return 0 == len(d.Object) && d.Attributes.IsEmpty() && d.Groups.IsEmpty() && d.Uniques.IsEmpty() && d.Associations.IsEmpty()
+}
+
+// schemaIgnoreFields the fields which are changed by the storage or the operators rather than the definition
+var schemaIgnoreFields = map[string]bool{
+	"id":                     true,
+	"bk_supplier_account":    true,
+	"create_time":            true,
+	"last_time":              true,
+	"creator":                true,
+	"modifier":               true,
+	"bk_property_group_name": true,
+}
+
+// DiffModelSchema compare the schema to with the schema from, the attributes are identified by the property id,
+// the groups by the group id, the uniques by the keys and the associations by the association id.
+func DiffModelSchema(from, to ModelSchema) ModelSchemaDiff {
+	diff := ModelSchemaDiff{}
+
+	var fromObject, toObject mapstr.MapStr
+	if nil != from.Object {
+		fromObject = schemaItem(from.Object)
+	}
+	if nil != to.Object {
+		toObject = schemaItem(to.Object)
+	}
+	diff.Object = diffSchemaFields(fromObject, toObject)
+
+	attrKey := func(item mapstr.MapStr) string { return toString(item["bk_property_id"]) }
+	diff.Attributes = diffSchemaItems(schemaItems(from.Attributes, attrKey), schemaItems(to.Attributes, attrKey))
+
+	groupKey := func(item mapstr.MapStr) string { return toString(item["bk_group_id"]) }
+	diff.Groups = diffSchemaItems(schemaItems(from.Groups, groupKey), schemaItems(to.Groups, groupKey))
+
+	// the keys of the uniques are compared by the key of the item, which ignores the order of them
+	fromUniques, toUniques := make(map[string]mapstr.MapStr), make(map[string]mapstr.MapStr)
+	for _, unique := range from.Uniques {
+		fromUniques[unique.Key()] = schemaItem(unique)
+		delete(fromUniques[unique.Key()], "keys")
+	}
+	for _, unique := range to.Uniques {
+		toUniques[unique.Key()] = schemaItem(unique)
+		delete(toUniques[unique.Key()], "keys")
+	}
+	diff.Uniques = diffSchemaItems(fromUniques, toUniques)
+
+	asstKey := func(item mapstr.MapStr) string { return toString(item["bk_obj_asst_id"]) }
+	diff.Associations = diffSchemaItems(schemaItems(from.Associations, asstKey), schemaItems(to.Associations, asstKey))
+
+	return diff
+}
+
+func toString(val interface{}) string {
+	str, _ := val.(string)
+	return str
+}
+
+// schemaItem convert the item to its json form, so that the fields are compared as they are stored
+func schemaItem(item interface{}) mapstr.MapStr {
+	result := mapstr.New()
+	js, err := json.Marshal(item)
+	if nil != err {
+		return result
+	}
+	json.Unmarshal(js, &result)
+	for field := range schemaIgnoreFields {
+		delete(result, field)
+	}
+	return result
+}
+
+func schemaItems(items interface{}, key func(mapstr.MapStr) string) map[string]mapstr.MapStr {
+	results := make(map[string]mapstr.MapStr)
+	values := reflect.ValueOf(items)
+	for i := 0; i < values.Len(); i++ {
+		item := schemaItem(values.Index(i).Interface())
+		results[key(item)] = item
+	}
+	return results
+}
+
+func diffSchemaItems(from, to map[string]mapstr.MapStr) SchemaItemsDiff {
+	diff := SchemaItemsDiff{Added: make([]string, 0), Removed: make([]string, 0), Changed: make([]SchemaItemChange, 0)}
+	for key, toItem := range to {
+		fromItem, exists := from[key]
+		if !exists {
+			diff.Added = append(diff.Added, key)
+			continue
+		}
+		if fields := diffSchemaFields(fromItem, toItem); 0 != len(fields) {
+			diff.Changed = append(diff.Changed, SchemaItemChange{Key: key, Fields: fields})
+		}
+	}
+	for key := range from {
+		if _, exists := to[key]; !exists {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key < diff.Changed[j].Key })
+	return diff
+}
+
+func diffSchemaFields(from, to mapstr.MapStr) []FieldDiff {
+	fields := make([]string, 0)
+	for field := range from {
+		fields = append(fields, field)
+	}
+	for field := range to {
+		if _, exists := from[field]; !exists {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	diffs := make([]FieldDiff, 0)
+	for _, field := range fields {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			diffs = append(diffs, FieldDiff{Field: field, From: from[field], To: to[field]})
+		}
+	}
+	return diffs
+}
+
+// RollbackModelVersionOption the option to restore the schema of the model to the version
+type RollbackModelVersionOption struct {
+	Version int64 `json:"version"`
+	// DryRun only reports the changes and the affected instances without applying them
+	DryRun bool `json:"dry_run"`
+	// Force applies the rollback even if it's unsafe, such as the values of the removed attributes are lost
+	Force bool `json:"force"`
+}
+
+// the reasons why the attributes and their instances are affected by the rollback
+const (
+	RollbackReasonRemoved     = "removed"
+	RollbackReasonTypeChanged = "type_changed"
+	RollbackReasonRequired    = "required"
+	RollbackReasonPredefined  = "predefined"
+	RollbackReasonUnique      = "unique"
+)
+
+// RollbackMaxAffectedInstances the max count of the ids of the affected instances which are reported
+const RollbackMaxAffectedInstances = 100
+
+// RollbackAffected the attribute affected by the rollback and the instances having the values of it
+type RollbackAffected struct {
+	PropertyID string `json:"bk_property_id"`
+	Reason     string `json:"reason"`
+	// Unsafe the rollback loses the data or breaks the model if it's applied
+	Unsafe        bool    `json:"unsafe"`
+	InstanceCount uint64  `json:"instance_count"`
+	InstanceIDs   []int64 `json:"inst_ids"`
+}
+
+// ModelVersionRollbackResult the result of the rollback, the object, attributes, groups and uniques are restored,
+// the associations are shared with the other models and not restored.
+type ModelVersionRollbackResult struct {
+	// Object the changes of the object fields from the current schema to the version
+	Object []FieldDiff `json:"object"`
+	// Attributes the changes of the attributes from the current schema to the version
+	Attributes SchemaItemsDiff    `json:"attributes"`
+	Groups     SchemaItemsDiff    `json:"groups"`
+	Uniques    SchemaItemsDiff    `json:"uniques"`
+	Affected   []RollbackAffected `json:"affected"`
+	Safe       bool               `json:"safe"`
+	// Applied is false if it's a dry run or it's unsafe and not forced
+	Applied bool `json:"applied"`
+	// Version the version recorded for the rollback if it's applied
+	Version int64 `json:"version"`
+}
+
+// ReadModelVersionResult the result of searching the versions of the model
+type ReadModelVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryModelVersionResult `json:"data"`
+}
+
+// DiffModelVersionResult the result of comparing the versions of the model
+type DiffModelVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelSchemaDiff `json:"data"`
+}
+
+// ExportModelVersionResult the result of exporting the version of the model
+type ExportModelVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelVersionDocument `json:"data"`
+}
+
+// RollbackModelVersionResult the result of rolling back the model to the version
+type RollbackModelVersionResult struct {
+	BaseResp `json:",inline"`
+	Data     ModelVersionRollbackResult `json:"data"`
+}
@@ -0,0 +1,54 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffModelSchema(t *testing.T) {
+	from := ModelSchema{
+		Object: &Object{ID: 1, ObjectID: "switch", ObjectName: "switch"},
+		Attributes: []Attribute{
+			{ID: 1, PropertyID: "name", PropertyType: "singlechar"},
+			{ID: 2, PropertyID: "port", PropertyType: "int"},
+		},
+		Uniques: []SchemaUnique{{Keys: []string{"name", "port"}}},
+	}
+	to := ModelSchema{
+		Object: &Object{ID: 1, ObjectID: "switch", ObjectName: "core switch"},
+		Attributes: []Attribute{
+			{ID: 1, PropertyID: "name", PropertyType: "longchar"},
+			{ID: 3, PropertyID: "vendor", PropertyType: "singlechar"},
+		},
+		Uniques: []SchemaUnique{{Keys: []string{"port", "name"}}},
+	}
+
+	diff := DiffModelSchema(from, to)
+	if want := []FieldDiff{{Field: "bk_obj_name", From: "switch", To: "core switch"}}; !reflect.DeepEqual(diff.Object, want) {
+		t.Errorf("object diff = %v, want %v", diff.Object, want)
+	}
+	if !reflect.DeepEqual(diff.Attributes.Added, []string{"vendor"}) || !reflect.DeepEqual(diff.Attributes.Removed, []string{"port"}) {
+		t.Errorf("attributes diff = %+v, want vendor added and port removed", diff.Attributes)
+	}
+	want := []SchemaItemChange{{Key: "name", Fields: []FieldDiff{{Field: "bk_property_type", From: "singlechar", To: "longchar"}}}}
+	if !reflect.DeepEqual(diff.Attributes.Changed, want) {
+		t.Errorf("attributes changed = %+v, want %+v", diff.Attributes.Changed, want)
+	}
+	if !diff.Uniques.IsEmpty() {
+		t.Errorf("uniques diff = %+v, the order of the keys should be ignored", diff.Uniques)
+	}
+	if diff.IsEmpty() || !DiffModelSchema(from, from).IsEmpty() {
+		t.Errorf("IsEmpty is wrong")
+	}
+}
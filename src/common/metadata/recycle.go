@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package metadata
+
+import (
+	"time"
+
+	"configcenter/src/common/mapstr"
+)
+
+// the kinds of the deleted resources in the recycle bin
+const (
+	RecycleKindInstance = "instance"
+	RecycleKindHost     = "host"
+	RecycleKindModel    = "model"
+)
+
+// the fields of the recycle item
+const (
+	RecycleFieldID         = "id"
+	RecycleFieldKind       = "bk_recycle_kind"
+	RecycleFieldObjectID   = "bk_obj_id"
+	RecycleFieldInstID     = "bk_inst_id"
+	RecycleFieldBatchID    = "batch_id"
+	RecycleFieldOwnerID    = "bk_supplier_account"
+	RecycleFieldDeleteTime = "delete_time"
+)
+
+// RecycleItem a deleted instance, host or model in the recycle bin. the documents deleted with it,
+// such as the instance associations and the host module relations, are archived along with it,
+// and all of them are inserted back when it's restored.
+type RecycleItem struct {
+	ID       int64  `json:"id" bson:"id"`
+	Kind     string `json:"bk_recycle_kind" bson:"bk_recycle_kind"`
+	ObjectID string `json:"bk_obj_id" bson:"bk_obj_id"`
+	// InstID the id of the instance or host, it's the id of the model for the model
+	InstID   int64  `json:"bk_inst_id" bson:"bk_inst_id"`
+	InstName string `json:"bk_inst_name" bson:"bk_inst_name"`
+	BizID    int64  `json:"bk_biz_id" bson:"bk_biz_id"`
+	// BatchID the items deleted by the same request, such as a set and its modules, have the same batch id,
+	// which is the request id, so that they can be restored together.
+	BatchID    string            `json:"batch_id" bson:"batch_id"`
+	Documents  []RecycleDocument `json:"documents" bson:"documents"`
+	OwnerID    string            `json:"bk_supplier_account" bson:"bk_supplier_account"`
+	Operator   string            `json:"operator" bson:"operator"`
+	DeleteTime time.Time         `json:"delete_time" bson:"delete_time"`
+}
+
+// RecycleDocument a document deleted from the table, the first document of the item is the resource itself
+type RecycleDocument struct {
+	Table string        `json:"table" bson:"table"`
+	Data  mapstr.MapStr `json:"data" bson:"data"`
+}
+
+// NewRecycleItem create a recycle item of the resource with its document
+func NewRecycleItem(kind, objID string, instID int64, instName string, table string, data mapstr.MapStr) RecycleItem {
+	return RecycleItem{
+		Kind:      kind,
+		ObjectID:  objID,
+		InstID:    instID,
+		InstName:  instName,
+		Documents: []RecycleDocument{{Table: table, Data: data}},
+	}
+}
+
+// Archive add the documents deleted with the resource to the item
+func (r *RecycleItem) Archive(table string, datas ...mapstr.MapStr) {
+	for _, data := range datas {
+		r.Documents = append(r.Documents, RecycleDocument{Table: table, Data: data})
+	}
+}
+
+// RecycleOption the recycle items to restore or purge, by their ids or the batch id
+type RecycleOption struct {
+	IDs     []int64 `json:"ids"`
+	BatchID string  `json:"batch_id"`
+}
+
+// QueryRecycleItemResult the result of searching the recycle items
+type QueryRecycleItemResult struct {
+	Count int64         `json:"count"`
+	Info  []RecycleItem `json:"info"`
+}
+
+// RecycleRestoreResult the restored recycle items
+type RecycleRestoreResult struct {
+	Restored []RecycleItem `json:"restored"`
+}
+
+// SearchRecycleItemResult the result of searching the recycle items
+type SearchRecycleItemResult struct {
+	BaseResp `json:",inline"`
+	Data     QueryRecycleItemResult `json:"data"`
+}
+
+// RestoreRecycleItemResult the result of restoring the recycle items
+type RestoreRecycleItemResult struct {
+	BaseResp `json:",inline"`
+	Data     RecycleRestoreResult `json:"data"`
+}
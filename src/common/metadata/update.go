@@ -24,3 +24,8 @@ type UpdatedOptionResult struct {
 	BaseResp `json:",inline"`
 	Data     UpdatedCount `json:"data"`
 }
+
+// RecomputeOption recompute the computed attributes of the instances matching the condition
+type RecomputeOption struct {
+	Condition mapstr.MapStr `json:"condition"`
+}
@@ -28,6 +28,8 @@ type SearchParams struct {
 	Page      map[string]interface{} `json:"page,omitempty"`
 	Fields    []string               `json:"fields,omitempty"`
 	Native    int                    `json:"native,omitempty"`
+	// Query the query statement on the fields of the object, which is compiled into the condition
+	Query string `json:"query,omitempty"`
 }
 
 // common result struct
@@ -13,6 +13,8 @@
 package params
 
 import (
+	"fmt"
+
 	"configcenter/src/common"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
@@ -145,3 +147,62 @@ func ParseHostIPParams(ipCond metadata.IPInfo, output map[string]interface{}) er
 	}
 	return nil
 }
+
+// ParseHostIPRangeParams converts the ranges and the excludes of the ip condition to the conditions on the addresses
+// of the host ips, which are appended to the $and of the output. the ranges are compared with the addresses stored
+// in the bk_ip_range of the host, so that the cidr blocks and ip ranges of both ipv4 and ipv6 can be searched by index.
+func ParseHostIPRangeParams(ipCond metadata.IPInfo, output map[string]interface{}) error {
+	if 0 == len(ipCond.Ranges) && 0 == len(ipCond.Excludes) {
+		return nil
+	}
+
+	var fields []string
+	switch ipCond.Flag {
+	case INNERONLY:
+		fields = []string{common.BKHostInnerIPField}
+	case OUTERONLY:
+		fields = []string{common.BKHostOuterIPField}
+	default:
+		fields = []string{common.BKHostInnerIPField, common.BKHostOuterIPField}
+	}
+
+	andCond, _ := output[common.BKDBAND].([]interface{})
+	if 0 != len(ipCond.Ranges) {
+		orCond := make([]interface{}, 0)
+		for _, value := range ipCond.Ranges {
+			match, err := ipAddressMatch(value)
+			if nil != err {
+				return err
+			}
+			for _, field := range fields {
+				orCond = append(orCond, map[string]interface{}{common.BKIPRangeField + "." + field: match})
+			}
+		}
+		andCond = append(andCond, map[string]interface{}{common.BKDBOR: orCond})
+	}
+
+	for _, value := range ipCond.Excludes {
+		match, err := ipAddressMatch(value)
+		if nil != err {
+			return err
+		}
+		for _, field := range fields {
+			andCond = append(andCond, map[string]interface{}{common.BKIPRangeField + "." + field: map[string]interface{}{common.BKDBNot: match}})
+		}
+	}
+	output[common.BKDBAND] = andCond
+	return nil
+}
+
+// ipAddressMatch returns the condition which matches the addresses in the ip, cidr block or ip range
+func ipAddressMatch(value string) (map[string]interface{}, error) {
+	r, err := util.ParseIPSpan(value)
+	if nil != err {
+		return nil, fmt.Errorf("invalid ip condition %s, %v", value, err)
+	}
+	return map[string]interface{}{
+		common.BKDBElemMatch: map[string]interface{}{
+			"start": map[string]interface{}{common.BKDBGTE: r.Start, common.BKDBLTE: r.End},
+		},
+	}, nil
+}
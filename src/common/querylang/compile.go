@@ -0,0 +1,324 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querylang
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// AttributeGetter returns the attributes of the object, the fields of the query are resolved with them.
+type AttributeGetter func(objID string) ([]metadata.Attribute, error)
+
+// objectAliases are the short names of the objects which can qualify the fields, such as biz.bk_biz_maintainer
+var objectAliases = map[string]string{
+	"app":      common.BKInnerObjIDApp,
+	"business": common.BKInnerObjIDApp,
+	"cloud":    common.BKInnerObjIDPlat,
+}
+
+// topologyFields are the fields which refer to the name of the topology the instance is in, such as biz="pay"
+var topologyFields = map[string][2]string{
+	"biz":    {common.BKInnerObjIDApp, common.BKAppNameField},
+	"app":    {common.BKInnerObjIDApp, common.BKAppNameField},
+	"set":    {common.BKInnerObjIDSet, common.BKSetNameField},
+	"module": {common.BKInnerObjIDModule, common.BKModuleNameField},
+	"cloud":  {common.BKInnerObjIDPlat, common.BKCloudNameField},
+	"plat":   {common.BKInnerObjIDPlat, common.BKCloudNameField},
+}
+
+// idFields are the inner id fields which may not be the attributes of the objects
+var idFields = map[string]bool{
+	common.BKAppIDField:    true,
+	common.BKSetIDField:    true,
+	common.BKModuleIDField: true,
+	common.BKHostIDField:   true,
+	common.BKCloudIDField:  true,
+	common.BKInstIDField:   true,
+}
+
+var mongoOperators = map[string]string{
+	OperatorEqual:        common.BKDBEQ,
+	OperatorNotEqual:     common.BKDBNE,
+	OperatorGreater:      common.BKDBGT,
+	OperatorGreaterEqual: common.BKDBGTE,
+	OperatorLess:         common.BKDBLT,
+	OperatorLessEqual:    common.BKDBLTE,
+	OperatorMatch:        common.BKDBLIKE,
+	OperatorIn:           common.BKDBIN,
+	OperatorNotIn:        common.BKDBNIN,
+}
+
+// Compile resolves the fields of the query and converts the values with the types of the attributes, the result
+// is the conditions of the objects which the host search and the association search accept. the unqualified fields
+// are the fields of the object objID except the topology fields biz, set, module and cloud, which refer to the names.
+// a field is resolved by its id, its id without the bk_ prefix, its id without the bk_<object>_ prefix or its name.
+func (q *Query) Compile(objID string, getAttributes AttributeGetter) ([]metadata.SearchCondition, error) {
+	c := &compiler{
+		objID:         objID,
+		getAttributes: getAttributes,
+		attributes:    make(map[string][]metadata.Attribute),
+		operators:     make(map[string]map[string]map[string]interface{}),
+	}
+	for _, term := range q.Terms {
+		if err := c.compile(term); err != nil {
+			return nil, err
+		}
+	}
+	return c.conditions(), nil
+}
+
+type compiler struct {
+	objID         string
+	getAttributes AttributeGetter
+	attributes    map[string][]metadata.Attribute
+	// objects and fields keep the order of the terms
+	objects   []string
+	fields    map[string][]string
+	operators map[string]map[string]map[string]interface{}
+}
+
+func (c *compiler) compile(term Term) error {
+	objID, name := c.objID, term.Field
+	if term.Object != "" {
+		objID = strings.ToLower(term.Object)
+		if alias, ok := objectAliases[objID]; ok {
+			objID = alias
+		}
+	} else if topo, ok := topologyFields[strings.ToLower(name)]; ok {
+		objID, name = topo[0], topo[1]
+	}
+
+	attr, err := c.resolve(objID, name)
+	if err != nil {
+		return err
+	}
+	if attr == nil {
+		return fmt.Errorf("column %d: unknown field %s of the object %s", term.Pos, name, objID)
+	}
+
+	switch term.Operator {
+	case OperatorMatch:
+		if !matchable(attr.PropertyType) {
+			return fmt.Errorf("column %d: the field %s of the type %s can not be matched with ~", term.Pos, attr.PropertyID, attr.PropertyType)
+		}
+	case OperatorGreater, OperatorGreaterEqual, OperatorLess, OperatorLessEqual:
+		if attr.PropertyType == common.FieldTypeEnum || attr.PropertyType == common.FieldTypeBool {
+			return fmt.Errorf("column %d: the field %s of the type %s can not be compared with %s", term.Pos, attr.PropertyID, attr.PropertyType, term.Operator)
+		}
+	}
+
+	values := make([]interface{}, 0)
+	for _, raw := range term.Values {
+		value, err := convertValue(attr, term.Operator, raw)
+		if err != nil {
+			return fmt.Errorf("column %d: %v", term.Pos, err)
+		}
+		values = append(values, value)
+	}
+
+	var value interface{} = values
+	if term.Operator != OperatorIn && term.Operator != OperatorNotIn {
+		value = values[0]
+	}
+	return c.add(term, objID, attr.PropertyID, mongoOperators[term.Operator], value)
+}
+
+// resolve returns the attribute of the field, nil if the object has no such field.
+func (c *compiler) resolve(objID, name string) (*metadata.Attribute, error) {
+	attrs, ok := c.attributes[objID]
+	if !ok {
+		var err error
+		attrs, err = c.getAttributes(objID)
+		if err != nil {
+			return nil, err
+		}
+		c.attributes[objID] = attrs
+	}
+
+	for _, id := range []string{name, "bk_" + name, "bk_" + objID + "_" + name} {
+		for idx := range attrs {
+			if attrs[idx].PropertyID == id {
+				return &attrs[idx], nil
+			}
+		}
+	}
+	for idx := range attrs {
+		if strings.EqualFold(attrs[idx].PropertyName, name) {
+			return &attrs[idx], nil
+		}
+	}
+	if idFields[name] || name == common.GetInstIDField(objID) {
+		return &metadata.Attribute{ObjectID: objID, PropertyID: name, PropertyType: common.FieldTypeInt}, nil
+	}
+	return nil, nil
+}
+
+func (c *compiler) add(term Term, objID, field, operator string, value interface{}) error {
+	if c.operators[objID] == nil {
+		c.objects = append(c.objects, objID)
+		c.operators[objID] = make(map[string]map[string]interface{})
+		if c.fields == nil {
+			c.fields = make(map[string][]string)
+		}
+	}
+	ops := c.operators[objID][field]
+	if ops == nil {
+		ops = make(map[string]interface{})
+		c.operators[objID][field] = ops
+		c.fields[objID] = append(c.fields[objID], field)
+	}
+	if _, exist := ops[operator]; exist {
+		return fmt.Errorf("column %d: the field %s is compared with %s more than once", term.Pos, field, term.Operator)
+	}
+	ops[operator] = value
+	return nil
+}
+
+// conditions converts the operators of the fields to the condition items. the string values of = and != are
+// turned to $in and $nin, which are not escaped as the regular expressions by the searches, and the operators on
+// the same field are merged into the value of an $eq item, just as what the host search does with the host ids.
+// the string values of the comparisons are put into the value of an $eq item too for the same reason.
+func (c *compiler) conditions() []metadata.SearchCondition {
+	conds := make([]metadata.SearchCondition, 0)
+	for _, objID := range c.objects {
+		items := make([]metadata.ConditionItem, 0)
+		for _, field := range c.fields[objID] {
+			ops := c.operators[objID][field]
+			if len(ops) > 1 {
+				items = append(items, metadata.ConditionItem{Field: field, Operator: common.BKDBEQ, Value: ops})
+				continue
+			}
+			for op, value := range ops {
+				str, ok := value.(string)
+				switch {
+				case ok && op == common.BKDBEQ:
+					op, value = common.BKDBIN, []interface{}{str}
+				case ok && op == common.BKDBNE:
+					op, value = common.BKDBNIN, []interface{}{str}
+				case ok && op != common.BKDBLIKE:
+					op, value = common.BKDBEQ, ops
+				}
+				items = append(items, metadata.ConditionItem{Field: field, Operator: op, Value: value})
+			}
+		}
+		conds = append(conds, metadata.SearchCondition{ObjectID: objID, Condition: items})
+	}
+	return conds
+}
+
+func matchable(propertyType string) bool {
+	switch propertyType {
+	case common.FieldTypeSingleChar, common.FieldTypeLongChar, common.FieldTypeUser, common.FieldTypeList, "":
+		return true
+	}
+	return false
+}
+
+// convertValue converts the text value to the type of the attribute, the value of ~ is converted to the pattern.
+func convertValue(attr *metadata.Attribute, operator, raw string) (interface{}, error) {
+	if operator == OperatorMatch {
+		return wildcardPattern(raw), nil
+	}
+
+	switch attr.PropertyType {
+	case common.FieldTypeInt:
+		value, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the value %s of the field %s should be an integer", raw, attr.PropertyID)
+		}
+		return value, nil
+	case common.FieldTypeFloat:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("the value %s of the field %s should be a number", raw, attr.PropertyID)
+		}
+		return value, nil
+	case common.FieldTypeBool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("the value %s of the field %s should be true or false", raw, attr.PropertyID)
+		}
+		return value, nil
+	case common.FieldTypeTime:
+		// the time values are saved as the times, just as what the host search does
+		if util.IsTime(raw) {
+			return util.Str2Time(raw), nil
+		}
+		return raw, nil
+	case common.FieldTypeEnum:
+		options := make([]struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}, 0)
+		if js, err := json.Marshal(attr.Option); err == nil {
+			_ = json.Unmarshal(js, &options)
+		}
+		names := make([]string, 0)
+		for _, option := range options {
+			if option.ID == raw || strings.EqualFold(option.Name, raw) {
+				return option.ID, nil
+			}
+			names = append(names, option.Name)
+		}
+		return nil, fmt.Errorf("%s is not an option of the field %s, the options are %s", raw, attr.PropertyID, strings.Join(names, ", "))
+	}
+	return raw, nil
+}
+
+// wildcardPattern converts the value with the wildcards * and ? to the regular expression which matches the whole
+// value, the value without the wildcards matches the values which contain it.
+func wildcardPattern(value string) string {
+	if !strings.ContainsAny(value, "*?") {
+		return regexp.QuoteMeta(value)
+	}
+	pattern := regexp.QuoteMeta(value)
+	pattern = strings.Replace(pattern, `\*`, ".*", -1)
+	pattern = strings.Replace(pattern, `\?`, ".", -1)
+	return "^" + pattern + "$"
+}
+
+// MergeSearchConditions appends the condition items of the compiled query to the conditions of the same objects,
+// the host search takes only one condition of each object. the searches keep only one item of each field, so the
+// query on the field which the conditions have is rejected rather than overwrites the condition.
+func MergeSearchConditions(conds []metadata.SearchCondition, compiled []metadata.SearchCondition) ([]metadata.SearchCondition, error) {
+	for _, cond := range compiled {
+		merged := false
+		for idx := range conds {
+			if conds[idx].ObjectID != cond.ObjectID {
+				continue
+			}
+			for _, item := range cond.Condition {
+				for _, exist := range conds[idx].Condition {
+					if exist.Field == item.Field {
+						return nil, fmt.Errorf("the field %s of the object %s is in both the condition and the query", item.Field, cond.ObjectID)
+					}
+				}
+			}
+			conds[idx].Condition = append(conds[idx].Condition, cond.Condition...)
+			merged = true
+			break
+		}
+		if !merged {
+			conds = append(conds, cond)
+		}
+	}
+	return conds, nil
+}
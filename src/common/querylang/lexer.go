@@ -0,0 +1,134 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querylang
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexical element of the query, pos is the column where it starts, counted from 1.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keyword returns the lower case text of the word, so that the keywords are case insensitive.
+func (t token) keyword() string {
+	if t.kind != tokenWord {
+		return ""
+	}
+	return strings.ToLower(t.text)
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end of the query"
+	case tokenString:
+		return fmt.Sprintf("string %q", t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+// SyntaxError is returned when the query can not be parsed, Pos is the column of the error, counted from 1.
+type SyntaxError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("syntax error at column %d: %s", e.Pos, e.Msg)
+}
+
+// isWordRune reports whether the rune can be part of a bare word, which is a field reference, a keyword or an
+// unquoted value such as linux, 64000, 10.0.0.1 or db-*.
+func isWordRune(r rune) bool {
+	if unicode.IsSpace(r) {
+		return false
+	}
+	return !strings.ContainsRune(`=!<>~(),"'`, r)
+}
+
+// lex splits the query into tokens.
+func lex(query string) ([]token, error) {
+	runes := []rune(query)
+	tokens := make([]token, 0)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		pos := i + 1
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: pos})
+			i++
+		case r == '=' || r == '~':
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), pos: pos})
+			i++
+		case r == '!' || r == '>' || r == '<':
+			if i+1 < len(runes) && runes[i+1] == '=' {
+				tokens = append(tokens, token{kind: tokenOperator, text: string(r) + "=", pos: pos})
+				i += 2
+				continue
+			}
+			if r == '!' {
+				return nil, &SyntaxError{Pos: pos, Msg: "unexpected '!', use '!=' for not equal or 'not in' to exclude the values"}
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: string(r), pos: pos})
+			i++
+		case r == '"' || r == '\'':
+			text := make([]rune, 0)
+			j := i + 1
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				text = append(text, runes[j])
+			}
+			if j >= len(runes) {
+				return nil, &SyntaxError{Pos: pos, Msg: "the string is not terminated"}
+			}
+			tokens = append(tokens, token{kind: tokenString, text: string(text), pos: pos})
+			i = j + 1
+		default:
+			j := i
+			for ; j < len(runes) && isWordRune(runes[j]); j++ {
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: string(runes[i:j]), pos: pos})
+			i = j
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(runes) + 1}), nil
+}
@@ -0,0 +1,195 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querylang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// the operators of the query terms
+const (
+	OperatorEqual        = "="
+	OperatorNotEqual     = "!="
+	OperatorGreater      = ">"
+	OperatorGreaterEqual = ">="
+	OperatorLess         = "<"
+	OperatorLessEqual    = "<="
+	OperatorMatch        = "~"
+	OperatorIn           = "in"
+	OperatorNotIn        = "not in"
+)
+
+// Query is a parsed query, the terms are joined with and.
+type Query struct {
+	Terms []Term
+}
+
+// Term compares a field with the values, the field is referred as object.field or field of the searched object,
+// Object is empty when the field is not qualified.
+type Term struct {
+	Pos      int
+	Object   string
+	Field    string
+	Operator string
+	Values   []string
+}
+
+func (t Term) String() string {
+	ref := t.Field
+	if t.Object != "" {
+		ref = t.Object + "." + t.Field
+	}
+	if t.Operator == OperatorIn || t.Operator == OperatorNotIn {
+		return fmt.Sprintf("%s %s (%s)", ref, t.Operator, strings.Join(t.Values, ", "))
+	}
+	return fmt.Sprintf("%s %s %s", ref, t.Operator, t.Values[0])
+}
+
+// Parse parses the query such as biz="pay" and set~"db-*" and os_type=linux and mem>=64000. a term is either
+// field op value, where op is one of = != > >= < <= ~, or field in (value, ...) or field not in (value, ...).
+// the field is field, object.field or the quoted name of the attribute, the value is a quoted string or a bare word,
+// ~ matches the value with the wildcards * and ?, the keywords and, in and not are case insensitive.
+// the returned error is a *SyntaxError which tells the column of the error.
+func Parse(query string) (*Query, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	if p.peek().kind == tokenEOF {
+		return nil, &SyntaxError{Pos: 1, Msg: "the query is empty"}
+	}
+
+	q := &Query{Terms: make([]Term, 0)}
+	for {
+		term, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		q.Terms = append(q.Terms, *term)
+
+		tok := p.next()
+		switch {
+		case tok.kind == tokenEOF:
+			return q, nil
+		case tok.keyword() == "and":
+			continue
+		case tok.keyword() == "or":
+			return nil, &SyntaxError{Pos: tok.pos, Msg: "'or' is not supported, use 'field in (a, b)' to match any of the values"}
+		default:
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected 'and' after %s but found %s", term, tok)}
+		}
+	}
+}
+
+type parser struct {
+	tokens []token
+	cur    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.cur]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.cur]
+	if tok.kind != tokenEOF {
+		p.cur++
+	}
+	return tok
+}
+
+func (p *parser) term() (*Term, error) {
+	tok := p.next()
+	if tok.kind != tokenString && (tok.kind != tokenWord || isKeyword(tok.keyword())) {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a field but found %s", tok)}
+	}
+	// the quoted field is the name of the attribute which may contain the spaces and the dots
+	term := &Term{Pos: tok.pos, Field: tok.text}
+	if idx := strings.Index(tok.text, "."); tok.kind == tokenWord && idx >= 0 {
+		term.Object, term.Field = tok.text[:idx], tok.text[idx+1:]
+		if term.Object == "" || term.Field == "" || strings.Contains(term.Field, ".") {
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("invalid field %s, refer to the field as field or object.field", tok)}
+		}
+	}
+
+	op := p.next()
+	switch {
+	case op.kind == tokenOperator:
+		term.Operator = op.text
+		value, err := p.value(term.Field)
+		if err != nil {
+			return nil, err
+		}
+		term.Values = []string{value}
+		return term, nil
+	case op.keyword() == "in":
+		term.Operator = OperatorIn
+	case op.keyword() == "not":
+		if in := p.next(); in.keyword() != "in" {
+			return nil, &SyntaxError{Pos: in.pos, Msg: fmt.Sprintf("expected 'in' after 'not' but found %s", in)}
+		}
+		term.Operator = OperatorNotIn
+	default:
+		return nil, &SyntaxError{Pos: op.pos, Msg: fmt.Sprintf("expected an operator after the field %s but found %s", term.Field, op)}
+	}
+
+	values, err := p.list(term.Field)
+	if err != nil {
+		return nil, err
+	}
+	term.Values = values
+	return term, nil
+}
+
+// list parses the values of in and not in, which are (value, ...)
+func (p *parser) list(field string) ([]string, error) {
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected '(' before the values of the field %s but found %s", field, tok)}
+	}
+	values := make([]string, 0)
+	for {
+		value, err := p.value(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		tok := p.next()
+		switch tok.kind {
+		case tokenComma:
+			continue
+		case tokenRParen:
+			return values, nil
+		default:
+			return nil, &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected ',' or ')' after the value %s but found %s", value, tok)}
+		}
+	}
+}
+
+func (p *parser) value(field string) (string, error) {
+	tok := p.next()
+	if tok.kind == tokenString || (tok.kind == tokenWord && !isKeyword(tok.keyword())) {
+		return tok.text, nil
+	}
+	return "", &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("expected a value of the field %s but found %s", field, tok)}
+}
+
+func isKeyword(word string) bool {
+	switch word {
+	case "and", "or", "not", "in":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,161 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package querylang
+
+import (
+	"reflect"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+)
+
+func TestParse(t *testing.T) {
+	q, err := Parse(`biz="pay" and set~"db-*" AND os_type=linux and mem>=64000 and bk_host_innerip not in (10.0.0.1, '10.0.0.2')`)
+	if err != nil {
+		t.Fatalf("parse failed, err: %v", err)
+	}
+	expect := []Term{
+		{Pos: 1, Field: "biz", Operator: OperatorEqual, Values: []string{"pay"}},
+		{Pos: 15, Field: "set", Operator: OperatorMatch, Values: []string{"db-*"}},
+		{Pos: 30, Field: "os_type", Operator: OperatorEqual, Values: []string{"linux"}},
+		{Pos: 48, Field: "mem", Operator: OperatorGreaterEqual, Values: []string{"64000"}},
+		{Pos: 63, Field: "bk_host_innerip", Operator: OperatorNotIn, Values: []string{"10.0.0.1", "10.0.0.2"}},
+	}
+	if !reflect.DeepEqual(q.Terms, expect) {
+		t.Errorf("unexpected terms %+v", q.Terms)
+	}
+
+	q, err = Parse(`switch.bk_inst_name = "sw 1"`)
+	if err != nil || q.Terms[0].Object != "switch" || q.Terms[0].Field != "bk_inst_name" || q.Terms[0].Values[0] != "sw 1" {
+		t.Errorf("parse qualified field failed, query: %+v, err: %v", q, err)
+	}
+
+	invalid := map[string]int{
+		``:                     1,
+		`biz`:                  4,
+		`biz = `:               7,
+		`biz = "pay`:           7,
+		`biz = pay or set = a`: 11,
+		`biz = pay set = a`:    11,
+		`mem ! 1`:              5,
+		`os in linux`:          7,
+		`os not linux`:         8,
+		`os in (a, b`:          12,
+		`and = 1`:              1,
+	}
+	for query, pos := range invalid {
+		_, err := Parse(query)
+		syntaxErr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("parse %s should fail with syntax error, but got %v", query, err)
+			continue
+		}
+		if syntaxErr.Pos != pos {
+			t.Errorf("parse %s should fail at column %d, but got %v", query, pos, err)
+		}
+	}
+}
+
+func TestCompile(t *testing.T) {
+	attrs := map[string][]metadata.Attribute{
+		common.BKInnerObjIDHost: {
+			{PropertyID: "bk_os_type", PropertyName: "OS Type", PropertyType: common.FieldTypeEnum,
+				Option: []interface{}{map[string]interface{}{"id": "1", "name": "Linux"}, map[string]interface{}{"id": "2", "name": "Windows"}}},
+			{PropertyID: "bk_mem", PropertyType: common.FieldTypeInt},
+			{PropertyID: "bk_host_name", PropertyName: "Host Name", PropertyType: common.FieldTypeSingleChar},
+		},
+		common.BKInnerObjIDApp: {{PropertyID: common.BKAppNameField, PropertyType: common.FieldTypeSingleChar}},
+		common.BKInnerObjIDSet: {{PropertyID: common.BKSetNameField, PropertyType: common.FieldTypeSingleChar}},
+	}
+	getter := func(objID string) ([]metadata.Attribute, error) {
+		return attrs[objID], nil
+	}
+
+	q, err := Parse(`biz="pay" and set~"db-*" and os_type=linux and mem>=64000 and mem<128000 and "host name"~web and set.name != test`)
+	if err != nil {
+		t.Fatalf("parse failed, err: %v", err)
+	}
+	conds, err := q.Compile(common.BKInnerObjIDHost, getter)
+	if err != nil {
+		t.Fatalf("compile failed, err: %v", err)
+	}
+	expect := []metadata.SearchCondition{
+		{ObjectID: common.BKInnerObjIDApp, Condition: []metadata.ConditionItem{
+			{Field: common.BKAppNameField, Operator: common.BKDBIN, Value: []interface{}{"pay"}},
+		}},
+		{ObjectID: common.BKInnerObjIDSet, Condition: []metadata.ConditionItem{
+			{Field: common.BKSetNameField, Operator: common.BKDBEQ, Value: map[string]interface{}{common.BKDBLIKE: `^db-.*$`, common.BKDBNE: "test"}},
+		}},
+		{ObjectID: common.BKInnerObjIDHost, Condition: []metadata.ConditionItem{
+			{Field: "bk_os_type", Operator: common.BKDBIN, Value: []interface{}{"1"}},
+			{Field: "bk_mem", Operator: common.BKDBEQ, Value: map[string]interface{}{common.BKDBGTE: int64(64000), common.BKDBLT: int64(128000)}},
+			{Field: "bk_host_name", Operator: common.BKDBLIKE, Value: "web"},
+		}},
+	}
+	if !reflect.DeepEqual(conds, expect) {
+		t.Errorf("unexpected conditions %+v", conds)
+	}
+
+	q, err = Parse(`"host name">"a.b" and mem<10`)
+	if err != nil {
+		t.Fatalf("parse failed, err: %v", err)
+	}
+	conds, err = q.Compile(common.BKInnerObjIDHost, getter)
+	if err != nil {
+		t.Fatalf("compile failed, err: %v", err)
+	}
+	expect = []metadata.SearchCondition{
+		{ObjectID: common.BKInnerObjIDHost, Condition: []metadata.ConditionItem{
+			{Field: "bk_host_name", Operator: common.BKDBEQ, Value: map[string]interface{}{common.BKDBGT: "a.b"}},
+			{Field: "bk_mem", Operator: common.BKDBLT, Value: int64(10)},
+		}},
+	}
+	if !reflect.DeepEqual(conds, expect) {
+		t.Errorf("unexpected conditions %+v", conds)
+	}
+
+	for _, query := range []string{`cpu=1`, `mem=lots`, `os_type=mac`, `os_type~lin`, `mem>1 and mem>2`, `switch.name=a`} {
+		q, err := Parse(query)
+		if err != nil {
+			t.Fatalf("parse %s failed, err: %v", query, err)
+		}
+		if _, err := q.Compile(common.BKInnerObjIDHost, getter); err == nil {
+			t.Errorf("compile %s should fail", query)
+		}
+	}
+}
+
+func TestMergeSearchConditions(t *testing.T) {
+	compiled := []metadata.SearchCondition{
+		{ObjectID: common.BKInnerObjIDHost, Condition: []metadata.ConditionItem{{Field: "bk_mem", Operator: common.BKDBGT, Value: 1}}},
+		{ObjectID: common.BKInnerObjIDSet, Condition: []metadata.ConditionItem{{Field: common.BKSetNameField, Operator: common.BKDBIN, Value: []interface{}{"db"}}}},
+	}
+	conds := []metadata.SearchCondition{
+		{ObjectID: common.BKInnerObjIDHost, Condition: []metadata.ConditionItem{{Field: "bk_cpu", Operator: common.BKDBEQ, Value: 8}}},
+	}
+	merged, err := MergeSearchConditions(conds, compiled)
+	if err != nil {
+		t.Fatalf("merge failed, err: %v", err)
+	}
+	if len(merged) != 2 || len(merged[0].Condition) != 2 || merged[1].ObjectID != common.BKInnerObjIDSet {
+		t.Errorf("unexpected conditions %+v", merged)
+	}
+
+	conds = []metadata.SearchCondition{
+		{ObjectID: common.BKInnerObjIDHost, Condition: []metadata.ConditionItem{{Field: "bk_mem", Operator: common.BKDBLT, Value: 8}}},
+	}
+	if _, err := MergeSearchConditions(conds, compiled); err == nil {
+		t.Error("the query on the field of the condition should be rejected")
+	}
+}
@@ -94,6 +94,30 @@ const (
 	BKTableNameCloudSyncHistory       = "cc_CloudSyncHistory"
 	BKTableNameCloudResourceConfirm   = "cc_CloudResourceConfirm"
 	BKTableNameResourceConfirmHistory = "cc_ResourceConfirmHistory"
+
+	// BKTableNameEventDeadLetter the table name of the events which failed to be pushed
+	BKTableNameEventDeadLetter = "cc_EventDeadLetter"
+	// BKTableNameEventHistory the table name of the events which can be watched
+	BKTableNameEventHistory = "cc_EventHistory"
+	// BKTableNameEventDelivery the table name of the callback logs
+	BKTableNameEventDelivery = "cc_EventDelivery"
+	// BKTableNameEventWatermark the table name of the max ids of the cleaned events of the owners
+	BKTableNameEventWatermark = "cc_EventWatermark"
+
+	// BKTableNameAuthRole the table name of the roles of the local authorizer
+	BKTableNameAuthRole = "cc_AuthRole"
+	// BKTableNameAuthRoleBinding the table name of the role bindings of the local authorizer
+	BKTableNameAuthRoleBinding = "cc_AuthRoleBinding"
+	// BKTableNameAuthGroup the table name of the user groups of the local authorizer
+	BKTableNameAuthGroup = "cc_AuthGroup"
+	// BKTableNameAuthDecisionLog the table name of the authorize decision logs
+	BKTableNameAuthDecisionLog = "cc_AuthDecisionLog"
+
+	// BKTableNameObjVersion the table name of the versions of the model schemas
+	BKTableNameObjVersion = "cc_ObjVersion"
+
+	// BKTableNameRecycleBin the table name of the deleted instances, hosts and models which can be restored
+	BKTableNameRecycleBin = "cc_RecycleBin"
 )
 
 // AllTables alltables
@@ -142,6 +166,16 @@ var AllTables = []string{
 	BKTableNameResourceConfirmHistory,
 	BKTableNameObjUnique,
 	BKTableNameAsstDes,
+	BKTableNameEventDeadLetter,
+	BKTableNameEventHistory,
+	BKTableNameEventDelivery,
+	BKTableNameEventWatermark,
+	BKTableNameAuthRole,
+	BKTableNameAuthRoleBinding,
+	BKTableNameAuthGroup,
+	BKTableNameAuthDecisionLog,
+	BKTableNameObjVersion,
+	BKTableNameRecycleBin,
 }
 
 // GetInstTableName returns inst data table name
@@ -0,0 +1,229 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package util
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+// IPRange the range of the addresses of an ip or a cidr, Start and End are the hex strings of
+// the addresses in the 16 bytes form, so that the ranges can be compared as strings in db.
+type IPRange struct {
+	Start string `json:"start" bson:"start"`
+	End   string `json:"end" bson:"end"`
+}
+
+// ParseIPRange parse the ip address or the cidr to the range of the addresses
+func ParseIPRange(value string) (*IPRange, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "/") {
+		ip := net.ParseIP(value)
+		if nil == ip {
+			return nil, fmt.Errorf("invalid ip address %s", value)
+		}
+		return &IPRange{Start: hex.EncodeToString(ip.To16()), End: hex.EncodeToString(ip.To16())}, nil
+	}
+
+	_, ipNet, err := net.ParseCIDR(value)
+	if nil != err {
+		return nil, err
+	}
+	start := ipNet.IP.Mask(ipNet.Mask)
+	end := make(net.IP, len(start))
+	for i := range start {
+		end[i] = start[i] | ^ipNet.Mask[i]
+	}
+	return &IPRange{Start: hex.EncodeToString(start.To16()), End: hex.EncodeToString(end.To16())}, nil
+}
+
+// ParseIPSpan parse the ip address, the cidr or the range of the addresses like 10.0.0.1-10.0.0.100,
+// both ends of the range should be of the same family.
+func ParseIPSpan(value string) (*IPRange, error) {
+	value = strings.TrimSpace(value)
+	if !strings.Contains(value, "-") {
+		return ParseIPRange(value)
+	}
+
+	parts := strings.SplitN(value, "-", 2)
+	start := net.ParseIP(strings.TrimSpace(parts[0]))
+	end := net.ParseIP(strings.TrimSpace(parts[1]))
+	if nil == start || nil == end {
+		return nil, fmt.Errorf("invalid ip range %s", value)
+	}
+	if (nil == start.To4()) != (nil == end.To4()) {
+		return nil, fmt.Errorf("the ends of the ip range %s are of different families", value)
+	}
+	r := &IPRange{Start: hex.EncodeToString(start.To16()), End: hex.EncodeToString(end.To16())}
+	if r.Start > r.End {
+		return nil, fmt.Errorf("the start of the ip range %s is greater than the end", value)
+	}
+	return r, nil
+}
+
+// RemoveIPRange removes the address ranges stored along with the ip attributes from the instances read from the db,
+// they're only used to search the instances, and never returned to the callers.
+func RemoveIPRange(insts ...map[string]interface{}) {
+	for _, inst := range insts {
+		delete(inst, common.BKIPRangeField)
+	}
+}
+
+// WithoutIPRange returns the instance data without the address ranges stored along with the ip attributes,
+// the data is copied if the ranges are there, so that the data kept by the caller is not changed.
+func WithoutIPRange(data interface{}) interface{} {
+	var inst map[string]interface{}
+	switch d := data.(type) {
+	case map[string]interface{}:
+		inst = d
+	case mapstr.MapStr:
+		inst = d
+	default:
+		return data
+	}
+	if _, ok := inst[common.BKIPRangeField]; !ok {
+		return data
+	}
+	result := make(map[string]interface{}, len(inst))
+	for key, val := range inst {
+		if key != common.BKIPRangeField {
+			result[key] = val
+		}
+	}
+	return result
+}
+
+// HostIPRanges returns the addresses of the comma separated ips of the host ip fields in the data, keyed by the field.
+// the addresses are the ranges whose start and end are the same, the invalid ips are skipped.
+func HostIPRanges(data map[string]interface{}) map[string][]IPRange {
+	ranges := make(map[string][]IPRange)
+	for _, field := range []string{common.BKHostInnerIPField, common.BKHostOuterIPField} {
+		val, exist := data[field]
+		if !exist {
+			continue
+		}
+		value, _ := val.(string)
+		addresses := make([]IPRange, 0)
+		for _, ip := range strings.Split(value, ",") {
+			if "" == strings.TrimSpace(ip) || strings.Contains(ip, "/") {
+				continue
+			}
+			r, err := ParseIPRange(ip)
+			if nil != err {
+				continue
+			}
+			addresses = append(addresses, *r)
+		}
+		ranges[field] = addresses
+	}
+	return ranges
+}
+
+// ConvertIPRangeCondition replace the ip operators in the condition with the comparisons of the address ranges, e.g.
+// {"bk_ip": {"$ipin": "10.0.0.0/8"}} is converted to the condition on the bk_ip_range.bk_ip.start and end fields.
+// the condition is converted in place, and the conditions in $and, $or and $nor are converted too.
+func ConvertIPRangeCondition(cond mapstr.MapStr) error {
+	return convertIPRangeCondition(cond)
+}
+
+func convertIPRangeCondition(cond map[string]interface{}) error {
+	converted := make(map[string]map[string]interface{})
+	for key, val := range cond {
+		switch key {
+		case common.BKDBAND, common.BKDBOR, "$nor":
+			items, ok := val.([]interface{})
+			if !ok {
+				if maps, ok := val.([]mapstr.MapStr); ok {
+					for _, item := range maps {
+						if err := convertIPRangeCondition(item); nil != err {
+							return err
+						}
+					}
+				}
+				continue
+			}
+			for _, item := range items {
+				sub, ok := toConditionMap(item)
+				if !ok {
+					continue
+				}
+				if err := convertIPRangeCondition(sub); nil != err {
+					return err
+				}
+			}
+			continue
+		}
+
+		ops, ok := toConditionMap(val)
+		if !ok {
+			continue
+		}
+		removed := false
+		for _, op := range []string{common.BKDBIPIn, common.BKDBIPContains} {
+			target, exist := ops[op]
+			if !exist {
+				continue
+			}
+			targetStr, ok := target.(string)
+			if !ok {
+				return fmt.Errorf("the value of %s on %s should be an ip address or cidr", op, key)
+			}
+			r, err := ParseIPRange(targetStr)
+			if nil != err {
+				return fmt.Errorf("the value of %s on %s is invalid, %v", op, key, err)
+			}
+
+			startKey := common.BKIPRangeField + "." + key + ".start"
+			endKey := common.BKIPRangeField + "." + key + ".end"
+			for _, k := range []string{startKey, endKey} {
+				if _, ok := converted[k]; !ok {
+					converted[k] = make(map[string]interface{})
+				}
+			}
+			if op == common.BKDBIPIn {
+				// the range of the value is within the target range
+				converted[startKey][common.BKDBGTE] = r.Start
+				converted[endKey][common.BKDBLTE] = r.End
+			} else {
+				// the range of the value covers the target range
+				converted[startKey][common.BKDBLTE] = r.Start
+				converted[endKey][common.BKDBGTE] = r.End
+			}
+			delete(ops, op)
+			removed = true
+		}
+		if removed && 0 == len(ops) {
+			delete(cond, key)
+		}
+	}
+
+	for key, ops := range converted {
+		cond[key] = ops
+	}
+	return nil
+}
+
+func toConditionMap(val interface{}) (map[string]interface{}, bool) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		return v, true
+	case mapstr.MapStr:
+		return v, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package util
+
+import (
+	"testing"
+
+	"configcenter/src/common/mapstr"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseIPRange(t *testing.T) {
+	r, err := ParseIPRange("10.0.0.1")
+	require.NoError(t, err)
+	require.Equal(t, "00000000000000000000ffff0a000001", r.Start)
+	require.Equal(t, r.Start, r.End)
+
+	r, err = ParseIPRange("10.1.2.3/16")
+	require.NoError(t, err)
+	require.Equal(t, "00000000000000000000ffff0a010000", r.Start)
+	require.Equal(t, "00000000000000000000ffff0a01ffff", r.End)
+
+	r, err = ParseIPRange("fe80::/64")
+	require.NoError(t, err)
+	require.Equal(t, "fe800000000000000000000000000000", r.Start)
+	require.Equal(t, "fe80000000000000ffffffffffffffff", r.End)
+
+	_, err = ParseIPRange("10.0.0.256")
+	require.Error(t, err)
+	_, err = ParseIPRange("10.0.0.0/33")
+	require.Error(t, err)
+}
+
+func TestConvertIPRangeCondition(t *testing.T) {
+	cond := mapstr.MapStr{
+		"bk_ip":   map[string]interface{}{"$ipin": "10.0.0.0/8"},
+		"bk_name": "test",
+		"$or": []interface{}{
+			map[string]interface{}{"bk_net": map[string]interface{}{"$ipcontains": "192.168.1.1", "$ne": nil}},
+		},
+	}
+	require.NoError(t, ConvertIPRangeCondition(cond))
+
+	require.NotContains(t, cond, "bk_ip")
+	require.Equal(t, "test", cond["bk_name"])
+	require.Equal(t, map[string]interface{}{"$gte": "00000000000000000000ffff0a000000"}, cond["bk_ip_range.bk_ip.start"])
+	require.Equal(t, map[string]interface{}{"$lte": "00000000000000000000ffff0affffff"}, cond["bk_ip_range.bk_ip.end"])
+
+	or := cond["$or"].([]interface{})[0].(map[string]interface{})
+	require.Equal(t, map[string]interface{}{"$ne": nil}, or["bk_net"])
+	require.Equal(t, map[string]interface{}{"$lte": "00000000000000000000ffffc0a80101"}, or["bk_ip_range.bk_net.start"])
+	require.Equal(t, map[string]interface{}{"$gte": "00000000000000000000ffffc0a80101"}, or["bk_ip_range.bk_net.end"])
+
+	require.Error(t, ConvertIPRangeCondition(mapstr.MapStr{"bk_ip": map[string]interface{}{"$ipin": "x"}}))
+}
+
+func TestParseIPSpan(t *testing.T) {
+	r, err := ParseIPSpan("10.0.0.1 - 10.0.0.100")
+	require.NoError(t, err)
+	require.Equal(t, "00000000000000000000ffff0a000001", r.Start)
+	require.Equal(t, "00000000000000000000ffff0a000064", r.End)
+
+	r, err = ParseIPSpan("10.12.0.0/16")
+	require.NoError(t, err)
+	require.Equal(t, "00000000000000000000ffff0a0cffff", r.End)
+
+	_, err = ParseIPSpan("10.0.0.100-10.0.0.1")
+	require.Error(t, err)
+	_, err = ParseIPSpan("10.0.0.1-fe80::1")
+	require.Error(t, err)
+}
+
+func TestHostIPRanges(t *testing.T) {
+	ranges := HostIPRanges(map[string]interface{}{
+		"bk_host_innerip": "10.0.0.1, fe80::1,invalid",
+		"bk_host_outerip": "",
+	})
+	require.Len(t, ranges, 2)
+	require.Equal(t, []IPRange{
+		{Start: "00000000000000000000ffff0a000001", End: "00000000000000000000ffff0a000001"},
+		{Start: "fe800000000000000000000000000001", End: "fe800000000000000000000000000001"},
+	}, ranges["bk_host_innerip"])
+	require.Empty(t, ranges["bk_host_outerip"])
+	require.Empty(t, HostIPRanges(map[string]interface{}{"bk_host_name": "test"}))
+}
+
+func TestWithoutIPRange(t *testing.T) {
+	inst := mapstr.MapStr{"bk_host_innerip": "10.0.0.1", "bk_ip_range": mapstr.MapStr{}}
+	result := WithoutIPRange(inst)
+	require.Equal(t, map[string]interface{}{"bk_host_innerip": "10.0.0.1"}, result)
+	require.Contains(t, inst, "bk_ip_range")
+
+	noRange := map[string]interface{}{"bk_host_innerip": "10.0.0.1"}
+	require.Equal(t, noRange, WithoutIPRange(noRange))
+	require.Equal(t, "data", WithoutIPRange("data"))
+
+	RemoveIPRange(inst)
+	require.NotContains(t, inst, "bk_ip_range")
+}
@@ -30,6 +30,14 @@ func (ei errif) Errorf(errCode int, args ...interface{}) error {
 	return nil
 }
 
+func (ei errif) CCError(errCode int) errors.CCErrorCoder {
+	return nil
+}
+
+func (ei errif) CCErrorf(errCode int, args ...interface{}) errors.CCErrorCoder {
+	return nil
+}
+
 func (ei errif) New(errCode int, msg string) error {
 	return nil
 }
@@ -86,7 +86,10 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			return err
 		}
 
-		if process.Config.AuthCenter.Enable {
+		if process.Config.AuthCenter.Enable && process.Config.AuthCenter.Mode == authcenter.ModeLocal {
+			// the local authorizer need not to init or sync the resources
+			blog.Info("enable local authorizer, skip auth center access.")
+		} else if process.Config.AuthCenter.Enable {
 			blog.Info("enable auth center access.")
 			authcli, err := authcenter.NewAuthCenter(nil, process.Config.AuthCenter)
 			if err != nil {
@@ -41,4 +41,16 @@ import (
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.01"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.02"
 	_ "configcenter/src/scene_server/admin_server/upgrader/x19.04.16.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.01"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.02"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.03"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.04"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.05"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.06"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.07"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.08"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.09"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.10"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.11"
+	_ "configcenter/src/scene_server/admin_server/upgrader/x19.05.08.12"
 )
@@ -0,0 +1,49 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_08_03
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+func createTable(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	for tablename, indexs := range tables {
+		exists, err := db.HasTable(tablename)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if err = db.CreateTable(tablename); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+		for index := range indexs {
+			if err = db.Table(tablename).CreateIndex(ctx, indexs[index]); err != nil && !db.IsDuplicatedError(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+var tables = map[string][]dal.Index{
+	common.BKTableNameEventDelivery: []dal.Index{
+		{Name: "idx_id", Keys: map[string]int32{"id": 1}, Unique: true, Background: true},
+		{Name: "idx_subscriptionID_createTime", Keys: map[string]int32{"subscription_id": 1, "create_time": 1}, Background: true},
+		{Name: "idx_createTime", Keys: map[string]int32{"create_time": 1}, Background: true},
+	},
+}
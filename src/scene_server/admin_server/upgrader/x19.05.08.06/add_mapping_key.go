@@ -0,0 +1,66 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_08_06
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addMappingKey set the mapping keys of the existing instance associations, the associations which have
+// exceeded the mapping get the unlimited keys, so that the unique indexes can be created, and they are
+// listed by the mapping violation search to be cleaned up.
+func addMappingKey(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	objAssts := []metadata.Association{}
+	if err := db.Table(common.BKTableNameObjAsst).Find(nil).All(ctx, &objAssts); err != nil {
+		return err
+	}
+	mappings := map[string]metadata.AssociationMapping{}
+	for _, objAsst := range objAssts {
+		mappings[objAsst.OwnerID+"|"+objAsst.AssociationName] = objAsst.Mapping
+	}
+
+	instAssts := []metadata.InstAsst{}
+	if err := db.Table(common.BKTableNameInstAsst).Find(nil).Sort(common.BKFieldID).All(ctx, &instAssts); err != nil {
+		return err
+	}
+
+	srcKeys := map[string]bool{}
+	destKeys := map[string]bool{}
+	for _, instAsst := range instAssts {
+		record := metadata.NewInstAsstWithMappingKey(instAsst, mappings[instAsst.OwnerID+"|"+instAsst.ObjectAsstID])
+		if srcKeys[record.SrcMappingKey] {
+			record.SrcMappingKey = fmt.Sprintf("#%d", instAsst.ID)
+		}
+		if destKeys[record.DestMappingKey] {
+			record.DestMappingKey = fmt.Sprintf("#%d", instAsst.ID)
+		}
+		srcKeys[record.SrcMappingKey] = true
+		destKeys[record.DestMappingKey] = true
+
+		data := mapstr.MapStr{
+			metadata.InstAsstFieldSrcMappingKey:  record.SrcMappingKey,
+			metadata.InstAsstFieldDestMappingKey: record.DestMappingKey,
+		}
+		if err := db.Table(common.BKTableNameInstAsst).Update(ctx, mapstr.MapStr{common.BKFieldID: instAsst.ID}, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package x19_05_08_07
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// convertAttributeValue convert the values of the list attributes which are still the comma separated text,
+// the attributes used to be long char, into the arrays of the items, and store the address ranges of the values
+// of the ip attributes, so that both of them can be searched.
+func convertAttributeValue(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	attrs := []metadata.Attribute{}
+	cond := mapstr.MapStr{
+		common.BKPropertyTypeField: mapstr.MapStr{common.BKDBIN: []string{common.FieldTypeList, common.FieldTypeIP}},
+	}
+	if err := db.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); err != nil {
+		return err
+	}
+
+	for _, attr := range attrs {
+		tableName := common.GetInstTableName(attr.ObjectID)
+		instIDField := common.GetInstIDField(attr.ObjectID)
+		filter := mapstr.MapStr{attr.PropertyID: mapstr.MapStr{"$type": "string"}}
+		if tableName == common.BKTableNameBaseInst {
+			filter[common.BKObjIDField] = attr.ObjectID
+		}
+		insts := []mapstr.MapStr{}
+		if err := db.Table(tableName).Find(filter).Fields(instIDField, attr.PropertyID).All(ctx, &insts); err != nil {
+			return err
+		}
+
+		var listOption *metadata.ListOption
+		if attr.PropertyType == common.FieldTypeList {
+			option, err := metadata.ParseListOption(attr.Option)
+			if err != nil {
+				blog.Warnf("the option of the list attribute %s.%s is invalid, skip it, err: %v", attr.ObjectID, attr.PropertyID, err)
+				continue
+			}
+			listOption = option
+		}
+
+		for _, inst := range insts {
+			value, _ := inst[attr.PropertyID].(string)
+			data := mapstr.MapStr{}
+			switch attr.PropertyType {
+			case common.FieldTypeList:
+				data[attr.PropertyID] = listOption.ParseText(value)
+			case common.FieldTypeIP:
+				if len(value) == 0 {
+					continue
+				}
+				r, err := util.ParseIPRange(value)
+				if err != nil {
+					blog.Warnf("the value %s of the ip attribute %s.%s is invalid, skip it, err: %v", value, attr.ObjectID, attr.PropertyID, err)
+					continue
+				}
+				data[common.BKIPRangeField+"."+attr.PropertyID] = r
+			}
+
+			if err := db.Table(tableName).Update(ctx, mapstr.MapStr{instIDField: inst[instIDField]}, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
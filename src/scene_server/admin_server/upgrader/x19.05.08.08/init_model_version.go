@@ -0,0 +1,95 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package x19_05_08_08
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// initModelVersion records the schemas of the existing models as their first versions
+func initModelVersion(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	objects := make([]metadata.Object, 0)
+	if err := db.Table(common.BKTableNameObjDes).Find(nil).All(ctx, &objects); err != nil {
+		return err
+	}
+
+	for index := range objects {
+		object := objects[index]
+		cond := mapstr.MapStr{common.BKObjIDField: object.ObjectID, common.BKOwnerIDField: object.OwnerID}
+
+		cnt, err := db.Table(common.BKTableNameObjVersion).Find(cond).Count(ctx)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+
+		schema := metadata.ModelSchema{
+			Object:       &object,
+			Attributes:   make([]metadata.Attribute, 0),
+			Groups:       make([]metadata.Group, 0),
+			Uniques:      make([]metadata.SchemaUnique, 0),
+			Associations: make([]metadata.Association, 0),
+		}
+		if err := db.Table(common.BKTableNameObjAttDes).Find(cond).Sort(metadata.AttributeFieldID).All(ctx, &schema.Attributes); err != nil {
+			return err
+		}
+		if err := db.Table(common.BKTableNamePropertyGroup).Find(cond).All(ctx, &schema.Groups); err != nil {
+			return err
+		}
+		uniques := make([]metadata.ObjectUnique, 0)
+		if err := db.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques); err != nil {
+			return err
+		}
+		for _, unique := range uniques {
+			schema.Uniques = append(schema.Uniques, metadata.NewSchemaUnique(unique, schema.Attributes))
+		}
+		asstCond := mapstr.MapStr{
+			common.BKOwnerIDField: object.OwnerID,
+			common.BKDBOR: []mapstr.MapStr{
+				{metadata.AssociationFieldObjectID: object.ObjectID},
+				{metadata.AssociationFieldAssociationObjectID: object.ObjectID},
+			},
+		}
+		if err := db.Table(common.BKTableNameObjAsst).Find(asstCond).All(ctx, &schema.Associations); err != nil {
+			return err
+		}
+
+		id, err := db.NextSequence(ctx, common.BKTableNameObjVersion)
+		if err != nil {
+			return err
+		}
+		version := metadata.ModelVersion{
+			ID:         int64(id),
+			ObjectID:   object.ObjectID,
+			Version:    1,
+			Action:     metadata.ModelVersionActionInit,
+			Operator:   conf.User,
+			OwnerID:    object.OwnerID,
+			CreateTime: time.Now(),
+			Schema:     schema,
+		}
+		if err := db.Table(common.BKTableNameObjVersion).Insert(ctx, version); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+
+	return nil
+}
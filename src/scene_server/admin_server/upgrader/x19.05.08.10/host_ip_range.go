@@ -0,0 +1,56 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package x19_05_08_10
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addHostIPRange stores the addresses of the inner and outer ips of the hosts, so that the hosts can be searched
+// by the cidr blocks and the ip ranges with the indexes.
+func addHostIPRange(ctx context.Context, db dal.RDB, conf *upgrader.Config) error {
+	hosts := []mapstr.MapStr{}
+	fields := []string{common.BKHostIDField, common.BKHostInnerIPField, common.BKHostOuterIPField}
+	if err := db.Table(common.BKTableNameBaseHost).Find(mapstr.MapStr{}).Fields(fields...).All(ctx, &hosts); err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		data := mapstr.MapStr{}
+		for field, addresses := range util.HostIPRanges(host) {
+			data[common.BKIPRangeField+"."+field] = addresses
+		}
+		if len(data) == 0 {
+			continue
+		}
+		if err := db.Table(common.BKTableNameBaseHost).Update(ctx, mapstr.MapStr{common.BKHostIDField: host[common.BKHostIDField]}, data); err != nil {
+			return err
+		}
+	}
+
+	indexs := []dal.Index{
+		dal.Index{Name: "", Keys: map[string]int32{common.BKIPRangeField + "." + common.BKHostInnerIPField + ".start": 1}, Background: true},
+		dal.Index{Name: "", Keys: map[string]int32{common.BKIPRangeField + "." + common.BKHostOuterIPField + ".start": 1}, Background: true},
+	}
+	for _, index := range indexs {
+		if err := db.Table(common.BKTableNameBaseHost).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package x19_05_08_12
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/scene_server/admin_server/upgrader"
+	"configcenter/src/storage/dal"
+)
+
+// addOperationLogIndex index the instances of both sides of the instance association audit logs,
+// which are searched to rebuild the associations of an instance at a moment
+func addOperationLogIndex(ctx context.Context, db dal.RDB, conf *upgrader.Config) (err error) {
+	for _, index := range operationLogIndexes {
+		if err = db.Table(common.BKTableNameOperationLog).CreateIndex(ctx, index); err != nil && !db.IsDuplicatedError(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+var operationLogIndexes = []dal.Index{
+	// the content of the created association is the request to create it
+	{Name: "content.cur_data.data.bk_inst_id_1", Keys: map[string]int32{"content.cur_data.data.bk_inst_id": 1}, Background: true},
+	{Name: "content.cur_data.data.bk_asst_inst_id_1", Keys: map[string]int32{"content.cur_data.data.bk_asst_inst_id": 1}, Background: true},
+	{Name: "content.pre_data.bk_inst_id_1", Keys: map[string]int32{"content.pre_data.bk_inst_id": 1}, Background: true},
+	{Name: "content.pre_data.bk_asst_inst_id_1", Keys: map[string]int32{"content.pre_data.bk_asst_inst_id": 1}, Background: true},
+}
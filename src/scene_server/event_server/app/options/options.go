@@ -13,6 +13,8 @@
 package options
 
 import (
+	"time"
+
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/core/cc/config"
 	"configcenter/src/storage/dal/mongo"
@@ -49,4 +51,28 @@ type Config struct {
 	Redis   redis.Config
 	RPC     rpc.ClientConfig
 	Auth    authcenter.AuthConfig
+	// SecretKey the key to encrypt the secret of subscriptions
+	SecretKey string
+	// HistoryRetention how long the events can be watched
+	HistoryRetention time.Duration
+	// DeliveryRetention how long the delivery logs of the subscriptions are kept
+	DeliveryRetention time.Duration
+	// SinkFileDir the directory of the file sinks, the file sink is disabled if it's empty
+	SinkFileDir string
+	// SinkFileMaxSize the sink file is rotated when it's larger than it, MB
+	SinkFileMaxSize int64
+	// SinkFileMaxBackups the count of the rotated sink files to keep
+	SinkFileMaxBackups int
 }
+
+// DefaultHistoryRetention the default retention of the event history
+const DefaultHistoryRetention = time.Hour * 72
+
+// DefaultDeliveryRetention the default retention of the delivery logs
+const DefaultDeliveryRetention = time.Hour * 24 * 7
+
+// the default rotation of the sink files
+const (
+	DefaultSinkFileMaxSize    = 100
+	DefaultSinkFileMaxBackups = 5
+)
@@ -17,9 +17,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/common/backbone"
 	cc "configcenter/src/common/backbone/configcenter"
@@ -84,15 +86,18 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 			return fmt.Errorf("connect redis server failed %s", err.Error())
 		}
 		process.Service.SetCache(cache)
+		process.Service.SetSecretKey(process.Config.SecretKey)
+		process.Service.SetSinkFileDir(process.Config.SinkFileDir)
+		process.Service.SetDeliveryRetention(process.Config.DeliveryRetention)
 
 		subcli, err := redis.NewFromConfig(process.Config.Redis)
 		if err != nil {
 			return fmt.Errorf("connect subcli redis server failed %s", err.Error())
 		}
 
-		authcli, err := authcenter.NewAuthCenter(nil, process.Config.Auth)
+		authcli, err := auth.NewAuthorize(nil, process.Config.Auth)
 		if err != nil {
-			return fmt.Errorf("new authcenter failed: %v, config: %+v", err, process.Config.Auth)
+			return fmt.Errorf("new authorize failed: %v, config: %+v", err, process.Config.Auth)
 		}
 		process.Service.SetAuth(authcli)
 		blog.Infof("enable authcenter: %v", process.Config.Auth.Enable)
@@ -102,7 +107,11 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		}()
 
 		go func() {
-			errCh <- distribution.Start(ctx, cache, db, rpccli)
+			errCh <- distribution.Start(ctx, cache, db, rpccli, process.Config.SecretKey, process.Config.HistoryRetention, process.Config.DeliveryRetention, distribution.FileSinkConfig{
+				Dir:        process.Config.SinkFileDir,
+				MaxSize:    process.Config.SinkFileMaxSize * 1024 * 1024,
+				MaxBackups: process.Config.SinkFileMaxBackups,
+			})
 		}()
 
 		break
@@ -145,6 +154,44 @@ func (h *EventServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 		h.Config.Redis = redisConf
 
 		h.Config.RPC.Address = current.ConfigMap["rpc.address"]
+		h.Config.SecretKey = current.ConfigMap["event.secretKey"]
+		h.Config.HistoryRetention = options.DefaultHistoryRetention
+		if retention, ok := current.ConfigMap["event.historyRetention"]; ok && retention != "" {
+			hours, err := strconv.ParseInt(retention, 10, 64)
+			if err != nil || hours <= 0 {
+				blog.Warnf("invalid event.historyRetention %s, the default %v is used", retention, options.DefaultHistoryRetention)
+			} else {
+				h.Config.HistoryRetention = time.Duration(hours) * time.Hour
+			}
+		}
+		h.Config.DeliveryRetention = options.DefaultDeliveryRetention
+		if retention, ok := current.ConfigMap["event.deliveryRetention"]; ok && retention != "" {
+			hours, err := strconv.ParseInt(retention, 10, 64)
+			if err != nil || hours <= 0 {
+				blog.Warnf("invalid event.deliveryRetention %s, the default %v is used", retention, options.DefaultDeliveryRetention)
+			} else {
+				h.Config.DeliveryRetention = time.Duration(hours) * time.Hour
+			}
+		}
+		h.Config.SinkFileDir = current.ConfigMap["event.sinkFileDir"]
+		h.Config.SinkFileMaxSize = options.DefaultSinkFileMaxSize
+		if size, ok := current.ConfigMap["event.sinkFileMaxSize"]; ok && size != "" {
+			mb, err := strconv.ParseInt(size, 10, 64)
+			if err != nil || mb <= 0 {
+				blog.Warnf("invalid event.sinkFileMaxSize %s, the default %d is used", size, options.DefaultSinkFileMaxSize)
+			} else {
+				h.Config.SinkFileMaxSize = mb
+			}
+		}
+		h.Config.SinkFileMaxBackups = options.DefaultSinkFileMaxBackups
+		if backups, ok := current.ConfigMap["event.sinkFileMaxBackups"]; ok && backups != "" {
+			count, err := strconv.Atoi(backups)
+			if err != nil || count < 0 {
+				blog.Warnf("invalid event.sinkFileMaxBackups %s, the default %d is used", backups, options.DefaultSinkFileMaxBackups)
+			} else {
+				h.Config.SinkFileMaxBackups = count
+			}
+		}
 
 		h.Config.Auth, err = authcenter.ParseConfigFromKV("auth", current.ConfigMap)
 		if err != nil {
@@ -29,14 +29,20 @@ import (
 	"configcenter/src/scene_server/event_server/types"
 )
 
-func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event string) (err error) {
-	increaseTotal(dh.cache, receiver.SubscriptionID)
+// httpSink post the events to the callback url of the subscription
+type httpSink struct {
+	secretKey string
+}
 
+func (s *httpSink) Send(receiver *metadata.Subscription, dist *metadata.DistInstCtx) (*SinkResponse, error) {
+	event := dist.Raw
 	body := bytes.NewBufferString(event)
 	req, err := http.NewRequest("POST", receiver.CallbackURL, body)
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, build request error: %v, date=[%s]", err, event)
+		return nil, fmt.Errorf("event distribute fail, build request error: %v, date=[%s]", err, event)
+	}
+	if err = signRequest(s.secretKey, receiver, req, event); err != nil {
+		return nil, fmt.Errorf("event distribute fail, sign request error: %v, date=[%s]", err, event)
 	}
 	var duration time.Duration
 	if receiver.TimeOut == 0 {
@@ -46,33 +52,30 @@ func (dh *DistHandler) SendCallback(receiver *metadata.Subscription, event strin
 	}
 	resp, err := httpCli.DoWithTimeout(duration, req)
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, send request error: %v, date=[%s]", err, event)
+		return nil, fmt.Errorf("event distribute fail, send request error: %v, date=[%s]", err, event)
 	}
 	defer resp.Body.Close()
+	result := &SinkResponse{StatusCode: resp.StatusCode}
 	respdata, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		increaseFailue(dh.cache, receiver.SubscriptionID)
-		return fmt.Errorf("event distribute fail, read response error: %v, date=[%s]", err, event)
+		return result, fmt.Errorf("event distribute fail, read response error: %v, date=[%s]", err, event)
 	}
+	result.Body = respdata
 	if receiver.ConfirmMode == metadata.ConfirmmodeHttpstatus {
 		if strconv.Itoa(resp.StatusCode) != receiver.ConfirmPattern {
-			increaseFailue(dh.cache, receiver.SubscriptionID)
-			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
+			return result, fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
 		}
 	} else if receiver.ConfirmMode == metadata.ConfirmmodeRegular {
 		pattern, err := regexp.Compile(receiver.ConfirmPattern)
 		if err != nil {
-			return fmt.Errorf("event distribute fail, build regexp error: %v", err)
+			return result, fmt.Errorf("event distribute fail, build regexp error: %v", err)
 		}
 		if !pattern.Match(respdata) {
-			increaseFailue(dh.cache, receiver.SubscriptionID)
-			return fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
+			return result, fmt.Errorf("event distribute fail, received response %s, date=[%s]", respdata, event)
 		}
-		return nil
 	}
 
-	return
+	return result, nil
 }
 
 var httpCli = httpclient.NewHttpClient()
@@ -85,6 +88,10 @@ func increaseFailue(cache *redis.Client, subscriptionID int64) error {
 	return increase(cache, subscriptionID, "failue")
 }
 
+func increaseBlocked(cache *redis.Client, subscriptionID int64) error {
+	return increase(cache, subscriptionID, "blocked")
+}
+
 func increase(cache *redis.Client, subscriptionID int64, key string) error {
 	err := cache.HIncrBy(types.EventCacheDistCallBackCountPrefix+strconv.FormatInt(subscriptionID, 10), key, 1).Err()
 	if err != nil {
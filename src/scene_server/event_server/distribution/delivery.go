@@ -0,0 +1,61 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+// maxDeliveryResponseLength the response body longer than it is truncated in the delivery log
+const maxDeliveryResponseLength = 1024
+
+func newDelivery(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempt int) *metadata.EventDelivery {
+	return &metadata.EventDelivery{
+		SubscriptionID: sub.SubscriptionID,
+		DstbID:         dist.DstbID,
+		EventType:      dist.EventType,
+		Action:         dist.Action,
+		ObjType:        dist.ObjType,
+		RequestID:      dist.RequestID,
+		Attempt:        attempt,
+		OwnerID:        sub.OwnerID,
+	}
+}
+
+// saveDelivery records the result of the callback, failing to record doesn't affect the distribution
+func (dh *DistHandler) saveDelivery(delivery *metadata.EventDelivery, sendErr error) {
+	delivery.Success = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+	delivery.CreateTime = metadata.Now()
+
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDelivery)
+	if err != nil {
+		blog.Errorf("save delivery of dist %d for subscription %d, but generate id failed: %v", delivery.DstbID, delivery.SubscriptionID, err)
+		return
+	}
+	delivery.ID = int64(id)
+	if err := dh.db.Table(common.BKTableNameEventDelivery).Insert(dh.ctx, delivery); err != nil {
+		blog.Errorf("save delivery of dist %d for subscription %d failed: %v", delivery.DstbID, delivery.SubscriptionID, err)
+	}
+}
+
+func truncateResponse(data []byte) string {
+	if len(data) <= maxDeliveryResponseLength {
+		return string(data)
+	}
+	return string(data[:maxDeliveryResponseLength]) + "..."
+}
@@ -17,6 +17,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"runtime/debug"
+	"sync"
 	"time"
 
 	"configcenter/src/common"
@@ -142,12 +143,37 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 	}()
 	sub := param
 	ticker := time.NewTicker(time.Minute)
+	retryTicker := time.NewTicker(requeueInterval)
+	defer retryTicker.Stop()
+	defer dh.holds.clear(sub.SubscriptionID)
+	defer dh.releaseSinks(sub.SubscriptionID)
 	defer blog.Infof("ended handle dist %v", sub.SubscriptionID)
+
+	// the dists are delivered by the lanes in parallel, the ones of the same instance go to the same lane
+	lanes := make([]chan distTask, distLanes)
+	wg := sync.WaitGroup{}
+	for i := range lanes {
+		lanes[i] = make(chan distTask, distLaneBuffer)
+		wg.Add(1)
+		go func(lane chan distTask) {
+			defer wg.Done()
+			dh.runLane(lane)
+		}(lanes[i])
+	}
+	// the popped dists are sent before exit
+	defer func() {
+		for _, lane := range lanes {
+			close(lane)
+		}
+		wg.Wait()
+	}()
 	for {
 		select {
 		case nsub := <-chNew:
 			if nsub.GetCacheKey() != sub.GetCacheKey() {
 				sub = nsub
+				// the sink may be changed, the resources are held again by the next send
+				dh.releaseSinks(sub.SubscriptionID)
 				blog.Infof("refreshed subcriber %v", sub.GetCacheKey())
 			} else {
 				blog.Infof("refresh ignore, subcriber cache key not change\nold:%s\nnew:%s ", sub.GetCacheKey(), nsub.GetCacheKey())
@@ -162,6 +188,10 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 				ticker.Stop()
 				return
 			}
+		case <-retryTicker.C:
+			if err := dh.requeueRetries(sub.SubscriptionID); err != nil {
+				blog.Errorf("move the dists to be sent again of subscription %d back to the queue failed: %v", sub.SubscriptionID, err)
+			}
 		case <-done:
 			return
 		default:
@@ -169,18 +199,60 @@ func (dh *DistHandler) distToSubscribe(param metadata.Subscription, chNew chan m
 			if dist == nil {
 				continue
 			}
-			if err = dh.handleDist(&sub, dist); err != nil {
-				blog.Errorf("error handle dist: %v, %v", err, dist)
-			}
+			dh.dispatch(sub, dist, lanes)
 		}
 	}
 }
 
+// dispatch hands the dist to its lane without blocking the pop loop. the dist is deferred by the retry set if the lane
+// is full or an earlier dist of the same instance is deferred, so that a slow instance doesn't stall the others.
+func (dh *DistHandler) dispatch(sub metadata.Subscription, dist *metadata.DistInstCtx, lanes []chan distTask) {
+	lane := lanes[laneIndex(&dist.DistInst, len(lanes))]
+	task := distTask{sub: sub, dist: dist}
+	if !dh.holds.held(&dist.DistInst) {
+		select {
+		case lane <- task:
+			dh.holds.release(&dist.DistInst)
+			return
+		default:
+		}
+	}
+
+	dh.holds.hold(&dist.DistInst)
+	if err := dh.scheduleRetry(&sub, dist, dist.Attempts, laneOverflowDelay); err != nil {
+		blog.Errorf("defer dist %d of subscription %d failed, wait for the lane, err: %v", dist.DstbID, dist.SubscriptionID, err)
+		lane <- task
+		dh.holds.release(&dist.DistInst)
+	}
+}
+
+type distTask struct {
+	sub  metadata.Subscription
+	dist *metadata.DistInstCtx
+}
+
+func (dh *DistHandler) runLane(lane chan distTask) {
+	for task := range lane {
+		dh.handleTask(task)
+	}
+}
+
+func (dh *DistHandler) handleTask(task distTask) {
+	defer func() {
+		if syserror := recover(); syserror != nil {
+			blog.Errorf("handle dist %d panic: %v, stack: %s", task.dist.DstbID, syserror, debug.Stack())
+		}
+	}()
+	if err := dh.handleDist(&task.sub, task.dist); err != nil {
+		blog.Errorf("error handle dist: %v, %v", err, task.dist)
+	}
+}
+
+// handleDist sends the dist after the previous one of the same instance is done
 func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
 	blog.Infof("handling dist %s", dist.Raw)
-	distID := fmt.Sprint(dist.DstbID - 1)
 	subscriberID := fmt.Sprint(dist.SubscriptionID)
-	runningkey := types.EventCacheDistRunningPrefix + subscriberID + "_" + distID
+	runningkey := types.EventCacheDistRunningPrefix + subscriberID + "_" + fmt.Sprint(dist.DstbID)
 	if err = saveRunning(dh.cache, runningkey, timeout+sub.GetTimeout()); err != nil {
 		if ErrProcessExists == err {
 			blog.Infof("process exist, continue")
@@ -189,46 +261,44 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 		return err
 	}
 
-	priviousID := fmt.Sprint(dist.DstbID - 1)
-	priviousRunningkey := types.EventCacheDistRunningPrefix + subscriberID + "_" + priviousID
-	done, err := checkFromDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, priviousID)
-	if err != nil {
-		return err
-	}
-	if !done {
-
-		running, checkErr := checkFromRunning(dh.cache, priviousRunningkey)
-		if checkErr != nil {
-			return checkErr
+	defer func() {
+		if err = dh.saveDistDone(dist); err != nil {
+			return
 		}
-		if !running {
+		blog.Infof("done event dist : %v", dist.DstbID)
+	}()
 
-			time.Sleep(time.Second * 5)
-			running, checkErr = checkFromRunning(dh.cache, priviousRunningkey)
-			if checkErr != nil {
-				return checkErr
-			}
+	// the dist scheduled to be sent again is still running for the order of the instance
+	retrying := false
+	if dist.OrderKey != "" {
+		ordered, orderErr := dh.waitOrder(sub, dist)
+		if orderErr != nil {
+			blog.Errorf("wait the previous event of %s for dist %d failed, send it directly, err: %v", dist.OrderKey, dist.DstbID, orderErr)
 		}
-		if running {
-
-			blog.Infof("waitting previous id: " + priviousID)
-			if checkErr = waitPreviousDone(dh.cache, types.EventCacheDistDonePrefix+subscriberID, priviousID, sub.GetTimeout()); checkErr != nil && checkErr != ErrWaitTimeout {
-				return checkErr
+		if !ordered {
+			blog.Warnf("dist %d of subscription %d is out of order, move it to the dead letter", dist.DstbID, dist.SubscriptionID)
+			return dh.saveDeadLetter(sub, dist, 0, ErrOutOfOrder)
+		}
+		defer func() {
+			if retrying {
+				return
 			}
-			if checkErr == ErrWaitTimeout {
-				blog.Infof("wait timeout previous id: %v, begin send callback", priviousID)
+			if doneErr := dh.saveOrderDone(dist); doneErr != nil {
+				blog.Errorf("save the order of dist %d done failed: %v", dist.DstbID, doneErr)
 			}
-		}
+		}()
 	}
 
-	defer func() {
-		if err = dh.saveDistDone(dist); err != nil {
-			return
+	if err = dh.sendWithRetry(sub, dist); err != nil {
+		if err == errRetryScheduled {
+			retrying = true
+			// the later dists of the instance are held back until the retry, unless the policy skips the blocked one
+			if sub.OrderPolicy == nil || sub.OrderPolicy.OnBlocked != metadata.OrderBlockedSkip {
+				dh.holds.hold(&dist.DistInst)
+			}
+			blog.Infof("dist %d of subscription %d is scheduled to be sent again", dist.DstbID, dist.SubscriptionID)
+			return nil
 		}
-		blog.Infof("done event dist : %v", dist.DstbID)
-	}()
-
-	if err = dh.SendCallback(sub, dist.Raw); err != nil {
 		blog.Errorf("send callback error: %v", err)
 		return
 	}
@@ -237,7 +307,7 @@ func (dh *DistHandler) handleDist(sub *metadata.Subscription, dist *metadata.Dis
 }
 
 func (dh *DistHandler) popDistInst(subID int64) *metadata.DistInstCtx {
-	eventslice := dh.cache.BLPop(time.Second*10, types.EventCacheDistQueuePrefix+fmt.Sprint(subID)).Val()
+	eventslice := dh.cache.BLPop(requeueInterval, types.EventCacheDistQueuePrefix+fmt.Sprint(subID)).Val()
 
 	if len(eventslice) <= 0 {
 		return nil
@@ -0,0 +1,203 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// filterRefreshInterval the interval to reload the subscription filters from db
+var filterRefreshInterval = time.Second * 10
+
+// topoFields the fields of the host topology, which are not in the host data
+var topoFields = []string{common.BKAppIDField, common.BKSetIDField, common.BKModuleIDField}
+
+type subscriptionFilters struct {
+	sync.Mutex
+	filters   map[int64]*metadata.SubscriptionFilter
+	refreshed time.Time
+}
+
+// getFilter returns the filter of the subscription, nil means all the events are accepted
+func (eh *EventHandler) getFilter(subscriptionID int64) *metadata.SubscriptionFilter {
+	eh.filters.Lock()
+	defer eh.filters.Unlock()
+
+	if eh.filters.filters == nil || time.Since(eh.filters.refreshed) > filterRefreshInterval {
+		subscriptions := make([]metadata.Subscription, 0)
+		err := eh.db.Table(common.BKTableNameSubscription).Find(mapstr.MapStr{}).
+			Fields(common.BKSubscriptionIDField, "filter").All(eh.ctx, &subscriptions)
+		if err != nil {
+			blog.Errorf("load subscription filters failed, the previous ones are used, err: %v", err)
+		} else {
+			filters := make(map[int64]*metadata.SubscriptionFilter, len(subscriptions))
+			for index := range subscriptions {
+				if subscriptions[index].Filter != nil {
+					filters[subscriptions[index].SubscriptionID] = subscriptions[index].Filter
+				}
+			}
+			eh.filters.filters = filters
+			eh.filters.refreshed = time.Now()
+		}
+	}
+	return eh.filters.filters[subscriptionID]
+}
+
+// hostTopoCache the topology of the hosts of a event, the topology of a host is the same for all the subscriptions,
+// so it's looked up at most once when the first filter needs it, and the others reuse it.
+type hostTopoCache struct {
+	lookup func(hostID int64) (mapstr.MapStr, error)
+	topos  map[int64]mapstr.MapStr
+}
+
+func (eh *EventHandler) newHostTopoCache() *hostTopoCache {
+	return &hostTopoCache{lookup: eh.hostTopo, topos: make(map[int64]mapstr.MapStr)}
+}
+
+// get returns the topology of the host, nil if it failed to be looked up
+func (c *hostTopoCache) get(hostID int64) mapstr.MapStr {
+	if topo, exists := c.topos[hostID]; exists {
+		return topo
+	}
+	topo, err := c.lookup(hostID)
+	if err != nil {
+		// the failure is kept as well, so that it's not looked up again for each subscription
+		blog.Errorf("fill host topology, but get the module relations of host %d failed, err: %v", hostID, err)
+		topo = nil
+	}
+	c.topos[hostID] = topo
+	return topo
+}
+
+// matchFilter check whether the dist should be pushed to the subscription, the topos is shared by the subscriptions of the event
+func (eh *EventHandler) matchFilter(filter *metadata.SubscriptionFilter, dist *metadata.DistInst, topos *hostTopoCache) bool {
+	if filter == nil || len(dist.Data) == 0 {
+		return true
+	}
+
+	if len(filter.ChangedFields) > 0 && dist.Action == metadata.EventActionUpdate {
+		changed := false
+		for _, data := range dist.Data {
+			if fieldsChanged(filter.ChangedFields, toMapStr(data.PreData), toMapStr(data.CurData)) {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false
+		}
+	}
+
+	if len(filter.Condition) == 0 {
+		return true
+	}
+	for _, data := range dist.Data {
+		inst := toMapStr(data.CurData)
+		if dist.Action == metadata.EventActionDelete {
+			inst = toMapStr(data.PreData)
+		}
+		if dist.ObjType == common.BKInnerObjIDHost {
+			inst = fillHostTopo(filter.Condition, inst, topos)
+		}
+
+		matched, err := condition.Match(filter.Condition, inst)
+		if err != nil {
+			blog.Errorf("match subscription filter failed, the event is pushed, err: %v", err)
+			return true
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// fillHostTopo returns the host with the business, set and module ids if the condition needs them. the event data
+// is shared by all the subscriptions, so the ids are filled into a copy of the host rather than the host itself.
+func fillHostTopo(items []metadata.ConditionItem, host mapstr.MapStr, topos *hostTopoCache) mapstr.MapStr {
+	need := false
+	for _, item := range items {
+		if util.InStrArr(topoFields, item.Field) {
+			if _, exists := host[item.Field]; !exists {
+				need = true
+				break
+			}
+		}
+	}
+	if !need {
+		return host
+	}
+
+	hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+	if err != nil {
+		blog.Warnf("fill host topology, but get host id failed, err: %v, host: %v", err, host)
+		return host
+	}
+	topo := topos.get(hostID)
+	if topo == nil {
+		return host
+	}
+
+	filled := host.Clone()
+	filled.Merge(topo)
+	return filled
+}
+
+// hostTopo look up the business, set and module ids of the host
+func (eh *EventHandler) hostTopo(hostID int64) (mapstr.MapStr, error) {
+	relations := make([]metadata.ModuleHost, 0)
+	cond := condition.CreateCondition().Field(common.BKHostIDField).Eq(hostID)
+	if err := eh.db.Table(common.BKTableNameModuleHostConfig).Find(cond.ToMapStr()).All(eh.ctx, &relations); err != nil {
+		return nil, err
+	}
+
+	bizIDs, setIDs, moduleIDs := make([]int64, 0), make([]int64, 0), make([]int64, 0)
+	for _, relation := range relations {
+		bizIDs = append(bizIDs, relation.AppID)
+		setIDs = append(setIDs, relation.SetID)
+		moduleIDs = append(moduleIDs, relation.ModuleID)
+	}
+	return mapstr.MapStr{
+		common.BKAppIDField:    util.IntArrayUnique(bizIDs),
+		common.BKSetIDField:    util.IntArrayUnique(setIDs),
+		common.BKModuleIDField: util.IntArrayUnique(moduleIDs),
+	}, nil
+}
+
+func fieldsChanged(fields []string, pre, cur mapstr.MapStr) bool {
+	for _, field := range fields {
+		if !reflect.DeepEqual(pre[field], cur[field]) {
+			return true
+		}
+	}
+	return false
+}
+
+func toMapStr(data interface{}) mapstr.MapStr {
+	switch d := data.(type) {
+	case map[string]interface{}:
+		return mapstr.MapStr(d)
+	case mapstr.MapStr:
+		return d
+	}
+	return mapstr.MapStr{}
+}
@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+func TestMatchFilter(t *testing.T) {
+	eh := &EventHandler{}
+	dist := &metadata.DistInst{EventInst: metadata.EventInst{
+		ObjType: common.BKInnerObjIDSet,
+		Action:  metadata.EventActionUpdate,
+		Data: []metadata.EventData{{
+			PreData: map[string]interface{}{"bk_set_name": "db", "operator": "admin", "bk_biz_id": float64(2)},
+			CurData: map[string]interface{}{"bk_set_name": "db", "operator": "user", "bk_biz_id": float64(2)},
+		}},
+	}}
+
+	tests := []struct {
+		name   string
+		filter *metadata.SubscriptionFilter
+		want   bool
+	}{
+		{"no filter", nil, true},
+		{"changed", &metadata.SubscriptionFilter{ChangedFields: []string{"bk_set_name", "operator"}}, true},
+		{"not changed", &metadata.SubscriptionFilter{ChangedFields: []string{"bk_set_name"}}, false},
+		{"condition", &metadata.SubscriptionFilter{Condition: []metadata.ConditionItem{{Field: "bk_biz_id", Operator: common.BKDBEQ, Value: 2}}}, true},
+		{"condition not match", &metadata.SubscriptionFilter{Condition: []metadata.ConditionItem{{Field: "operator", Operator: common.BKDBEQ, Value: "admin"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := eh.matchFilter(tt.filter, dist, eh.newHostTopoCache()); got != tt.want {
+				t.Errorf("matchFilter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchFilterHostTopo(t *testing.T) {
+	eh := &EventHandler{}
+	dist := &metadata.DistInst{EventInst: metadata.EventInst{
+		ObjType: common.BKInnerObjIDHost,
+		Action:  metadata.EventActionUpdate,
+		Data: []metadata.EventData{{
+			PreData: map[string]interface{}{"bk_host_id": float64(1), "bk_host_innerip": "127.0.0.1"},
+			CurData: map[string]interface{}{"bk_host_id": float64(1), "bk_host_innerip": "127.0.0.2"},
+		}},
+	}}
+
+	lookups := 0
+	topos := &hostTopoCache{
+		lookup: func(hostID int64) (mapstr.MapStr, error) {
+			lookups++
+			return mapstr.MapStr{common.BKAppIDField: []int64{2}, common.BKSetIDField: []int64{3}, common.BKModuleIDField: []int64{4}}, nil
+		},
+		topos: make(map[int64]mapstr.MapStr),
+	}
+
+	// the subscriptions of the event share the topology of the host
+	filters := []*metadata.SubscriptionFilter{
+		{Condition: []metadata.ConditionItem{{Field: common.BKAppIDField, Operator: common.BKDBIN, Value: []interface{}{2}}}},
+		{Condition: []metadata.ConditionItem{{Field: common.BKModuleIDField, Operator: common.BKDBIN, Value: []interface{}{4}}}},
+		{Condition: []metadata.ConditionItem{{Field: common.BKSetIDField, Operator: common.BKDBIN, Value: []interface{}{5}}}},
+	}
+	wants := []bool{true, true, false}
+	for index, filter := range filters {
+		if got := eh.matchFilter(filter, dist, topos); got != wants[index] {
+			t.Errorf("filter %d: matchFilter() = %v, want %v", index, got, wants[index])
+		}
+	}
+	if lookups != 1 {
+		t.Errorf("the host topology is looked up %d times, want 1", lookups)
+	}
+	if _, exists := dist.Data[0].CurData.(map[string]interface{})[common.BKAppIDField]; exists {
+		t.Errorf("the host topology is filled into the shared event data")
+	}
+}
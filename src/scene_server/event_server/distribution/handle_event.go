@@ -114,7 +114,13 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 	}()
 
 	origindists := eh.GetDistInst(&event.EventInst)
+	// the lost history expires the cursors of the watchers before it, so the event is still distributed
+	if historyErr := eh.saveHistory(event, origindists); historyErr != nil {
+		blog.Errorf("save event %d to history failed, the watchers before it need to resync: %v", event.ID, historyErr)
+	}
 
+	// the host topology needed by the filters is looked up once for the event
+	topos := eh.newHostTopoCache()
 	for _, origindist := range origindists {
 		subscribers := eh.findEventTypeSubscribers(origindist.GetType(), event.OwnerID)
 		if len(subscribers) <= 0 || nilstr == subscribers[0] {
@@ -125,16 +131,27 @@ func (eh *EventHandler) handleInst(event *metadata.EventInstCtx) (err error) {
 		for _, subscriber := range subscribers {
 			var dstbID, subscribeID int64
 			distinst := origindist
-			dstbID, err = eh.nextDistID(subscriber)
+			subscribeID, err = strconv.ParseInt(subscriber, 10, 64)
 			if err != nil {
 				return err
 			}
-			subscribeID, err = strconv.ParseInt(subscriber, 10, 64)
+			// filter before the distribution id is generated, otherwise the next dist waits for the filtered one
+			if !eh.matchFilter(eh.getFilter(subscribeID), &distinst, topos) {
+				blog.V(4).Infof("event %v is filtered by subscription %d", event.ID, subscribeID)
+				continue
+			}
+			dstbID, err = eh.nextDistID(subscriber)
 			if err != nil {
 				return err
 			}
 			distinst.DstbID = dstbID
 			distinst.SubscriptionID = subscribeID
+			if distinst.OrderKey = orderKey(&distinst); distinst.OrderKey != "" {
+				if err = eh.pushOrdered(&distinst); err != nil {
+					return err
+				}
+				continue
+			}
 			distByte, _ := json.Marshal(distinst)
 			eh.pushToQueue(types.EventCacheDistQueuePrefix+subscriber, string(distByte))
 		}
@@ -0,0 +1,169 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"context"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/storage/dal"
+)
+
+const (
+	// historySaveRetry the times to try inserting a event history
+	historySaveRetry = 3
+	// historySaveTimeout the event history must be inserted in this time after its id is reserved,
+	// it must be less than the settle time of the watch api, see watchSettleTime of the service,
+	// otherwise the watchers may have passed its id before it's inserted.
+	historySaveTimeout = 500 * time.Millisecond
+)
+
+// saveHistory persist the event, so that it can be watched by the clients with cursor.
+// if the event history is lost, the watermark of the owner is raised over it, so that the watchers
+// whose cursor is before it resync the data instead of missing the event silently.
+func (eh *EventHandler) saveHistory(event *metadata.EventInstCtx, dists []metadata.DistInst) error {
+	if len(dists) == 0 {
+		return nil
+	}
+
+	id, err := eh.db.NextSequence(eh.ctx, common.BKTableNameEventHistory)
+	if err != nil {
+		// no id is reserved, so the watermark is raised over the id of the next saved history of the owner
+		eh.markHistoryLost(event.OwnerID)
+		return err
+	}
+	history := metadata.EventHistory{
+		ID:         int64(id),
+		EventID:    event.ID,
+		EventType:  dists[0].GetType(),
+		OwnerID:    event.OwnerID,
+		Raw:        event.Raw,
+		CreateTime: metadata.Now(),
+	}
+
+	deadline := time.Now().Add(historySaveTimeout)
+	for try := 1; ; try++ {
+		err = eh.db.Table(common.BKTableNameEventHistory).Insert(eh.ctx, history)
+		if err != nil && eh.db.IsDuplicatedError(err) {
+			// inserted by the previous try which reported the error
+			err = nil
+		}
+		if err == nil || try >= historySaveRetry || !time.Now().Before(deadline) {
+			break
+		}
+	}
+	if err != nil {
+		if raiseErr := raiseWatermark(eh.ctx, eh.db, event.OwnerID, history.ID); raiseErr != nil {
+			blog.Errorf("raise the watermark of %s over the lost event history %d failed: %v", event.OwnerID, history.ID, raiseErr)
+			eh.markHistoryLost(event.OwnerID)
+		}
+		return err
+	}
+
+	if eh.lostHistory[event.OwnerID] {
+		if err := raiseWatermark(eh.ctx, eh.db, event.OwnerID, history.ID); err != nil {
+			blog.Errorf("raise the watermark of %s over the lost event history failed: %v", event.OwnerID, err)
+			return nil
+		}
+		delete(eh.lostHistory, event.OwnerID)
+	}
+	return nil
+}
+
+// markHistoryLost remember the owner whose event history is lost without raising the watermark
+func (eh *EventHandler) markHistoryLost(ownerID string) {
+	if eh.lostHistory == nil {
+		eh.lostHistory = make(map[string]bool)
+	}
+	eh.lostHistory[ownerID] = true
+}
+
+// cleanEventHistory remove the events older than the history retention and
+// the delivery logs older than the delivery retention
+func cleanEventHistory(ctx context.Context, db dal.RDB, historyRetention, deliveryRetention time.Duration) {
+	tick := util.NewTicker(time.Minute * 10)
+	tick.Tick()
+	for range tick.C {
+		expire := time.Now().Add(-historyRetention)
+		if err := cleanExpiredHistory(ctx, db, expire); err != nil {
+			blog.Errorf("clean the %s before %v failed: %v", common.BKTableNameEventHistory, expire, err)
+		}
+
+		expire = time.Now().Add(-deliveryRetention)
+		cond := condition.CreateCondition().Field(common.CreateTimeField).Lt(expire)
+		if err := db.Table(common.BKTableNameEventDelivery).Delete(ctx, cond.ToMapStr()); err != nil {
+			blog.Errorf("clean the %s before %v failed: %v", common.BKTableNameEventDelivery, expire, err)
+		}
+	}
+}
+
+// cleanExpiredHistory remove the events before the expire time owner by owner,
+// the max id of the removed events is recorded as the watermark of the owner
+// before removing, so that the watchers behind it know the events are lost.
+func cleanExpiredHistory(ctx context.Context, db dal.RDB, expire time.Time) error {
+	cond := condition.CreateCondition().Field(common.CreateTimeField).Lt(expire)
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: cond.ToMapStr()},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":        "$" + common.BKOwnerIDField,
+			"cleaned_id": mapstr.MapStr{"$max": "$" + common.BKFieldID},
+		}},
+	}
+	cleaned := make([]struct {
+		OwnerID   string `bson:"_id"`
+		CleanedID int64  `bson:"cleaned_id"`
+	}, 0)
+	if err := db.Table(common.BKTableNameEventHistory).AggregateAll(ctx, pipeline, &cleaned); err != nil {
+		return err
+	}
+
+	for _, owner := range cleaned {
+		if err := raiseWatermark(ctx, db, owner.OwnerID, owner.CleanedID); err != nil {
+			return err
+		}
+		cond := condition.CreateCondition()
+		cond.Field(common.BKOwnerIDField).Eq(owner.OwnerID)
+		cond.Field(common.BKFieldID).Lte(owner.CleanedID)
+		cond.Field(common.CreateTimeField).Lt(expire)
+		if err := db.Table(common.BKTableNameEventHistory).Delete(ctx, cond.ToMapStr()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// raiseWatermark set the watermark of the owner to the cleaned id if it's larger than the current one
+func raiseWatermark(ctx context.Context, db dal.RDB, ownerID string, cleanedID int64) error {
+	watermark := metadata.EventWatermark{OwnerID: ownerID, CleanedID: cleanedID, LastTime: metadata.Now()}
+	cond := condition.CreateCondition().Field(common.BKOwnerIDField).Eq(ownerID)
+	count, err := db.Table(common.BKTableNameEventWatermark).Find(cond.ToMapStr()).Count(ctx)
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		err = db.Table(common.BKTableNameEventWatermark).Insert(ctx, watermark)
+		if err == nil || !db.IsDuplicatedError(err) {
+			return err
+		}
+		// inserted by the other event server at the same time, raise it as well
+	}
+
+	cond.Field("cleaned_id").Lt(cleanedID)
+	return db.Table(common.BKTableNameEventWatermark).Update(ctx, cond.ToMapStr(), watermark)
+}
@@ -0,0 +1,241 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+var (
+	// orderKeyExpire the order sequence of an instance restarts if there is no event in it
+	orderKeyExpire = time.Hour * 24
+	// defaultOrderBlockTimeout how long to wait for the previous event of the same instance by default
+	defaultOrderBlockTimeout = time.Minute
+	// distLanes the count of the routines to deliver the events of a subscription in parallel,
+	// the events of the same instance are always delivered by the same lane.
+	distLanes      = 8
+	distLaneBuffer = 100
+	// laneOverflowDelay how long a dist is deferred when its lane is full
+	laneOverflowDelay = time.Second
+)
+
+// ErrOutOfOrder the event is sent after a later one of the same instance, so it's not sent any more
+var ErrOutOfOrder = fmt.Errorf("a later event of the same instance has been sent")
+
+// saveOrderDoneScript set the done sequence if it's larger than the current one
+var saveOrderDoneScript = redis.NewScript(`
+local current = tonumber(redis.call('get', KEYS[1]) or '0')
+if tonumber(ARGV[1]) > current then
+	redis.call('set', KEYS[1], ARGV[1])
+end
+redis.call('expire', KEYS[1], ARGV[2])
+return 1
+`)
+
+// pushOrderedScript generate the sequence of the dist in the dists of the same instance and push the dist to the queue
+// in one step, so that the dists of an instance are always queued by their sequences. the dist json is passed without
+// the sequence, which is appended to the end of the json object.
+var pushOrderedScript = redis.NewScript(`
+local seq = redis.call('incr', KEYS[1])
+redis.call('expire', KEYS[1], ARGV[2])
+if seq == 1 then
+	redis.call('set', KEYS[2], 0, 'EX', ARGV[2])
+end
+redis.call('rpush', KEYS[3], string.sub(ARGV[1], 1, -2) .. ',"order_seq":' .. seq .. '}')
+return seq
+`)
+
+// orderKey returns the object type and the instance id of the event, the events without instance id are not ordered
+func orderKey(dist *metadata.DistInst) string {
+	if len(dist.Data) == 0 {
+		return ""
+	}
+	data := dist.Data[0].CurData
+	if dist.Action == metadata.EventActionDelete {
+		data = dist.Data[0].PreData
+	}
+	id, ok := toMapStr(data)[common.GetInstIDField(dist.ObjType)]
+	if !ok || id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s:%v", dist.ObjType, id)
+}
+
+// laneIndex returns the lane to deliver the dist, the dists without order key are spread by the distribution id
+func laneIndex(dist *metadata.DistInst, lanes int) int {
+	if dist.OrderKey == "" {
+		return int(dist.DstbID % int64(lanes))
+	}
+	h := fnv.New32a()
+	h.Write([]byte(dist.OrderKey))
+	return int(h.Sum32() % uint32(lanes))
+}
+
+func orderSuffix(subscriptionID int64, key string) string {
+	return fmt.Sprintf("%d_%s", subscriptionID, key)
+}
+
+func orderRunningKey(subscriptionID int64, key string, seq int64) string {
+	return types.EventCacheDistOrderRunningPrefix + fmt.Sprintf("%s_%d", orderSuffix(subscriptionID, key), seq)
+}
+
+// pushOrdered push the dist to the queue of the subscription with the next sequence of its instance.
+// the sequence restarts when the instance has no event for a while, and the done sequence is reset with it.
+func (eh *EventHandler) pushOrdered(dist *metadata.DistInst) error {
+	unsequenced := *dist
+	unsequenced.OrderSeq = 0
+	raw, err := json.Marshal(unsequenced)
+	if err != nil {
+		return err
+	}
+	suffix := orderSuffix(dist.SubscriptionID, dist.OrderKey)
+	keys := []string{
+		types.EventCacheDistOrderSeqPrefix + suffix,
+		types.EventCacheDistOrderDonePrefix + suffix,
+		types.EventCacheDistQueuePrefix + fmt.Sprint(dist.SubscriptionID),
+	}
+	return pushOrderedScript.Run(eh.cache, keys, string(raw), int64(orderKeyExpire/time.Second)).Err()
+}
+
+// waitOrder wait for the previous event of the same instance, it returns false if a later event has been sent.
+// the previous event is waited until the block timeout, then it's skipped by the order policy of the subscription:
+// OrderBlockedSkip skips it anyway, OrderBlockedWait skips it only if it's not being sent, which means it's lost.
+func (dh *DistHandler) waitOrder(sub *metadata.Subscription, dist *metadata.DistInstCtx) (bool, error) {
+	blockTimeout, onBlocked := defaultOrderBlockTimeout, metadata.OrderBlockedWait
+	if sub.OrderPolicy != nil {
+		if sub.OrderPolicy.BlockTimeout > 0 {
+			blockTimeout = time.Duration(sub.OrderPolicy.BlockTimeout) * time.Second
+		}
+		if sub.OrderPolicy.OnBlocked != "" {
+			onBlocked = sub.OrderPolicy.OnBlocked
+		}
+	}
+
+	doneKey := types.EventCacheDistOrderDonePrefix + orderSuffix(dist.SubscriptionID, dist.OrderKey)
+	runningKey := orderRunningKey(dist.SubscriptionID, dist.OrderKey, dist.OrderSeq)
+	previousRunningKey := orderRunningKey(dist.SubscriptionID, dist.OrderKey, dist.OrderSeq-1)
+	start := time.Now()
+	for {
+		// keep the running flag alive, so that the next event keeps waiting for us
+		if err := dh.cache.Set(runningKey, dist.DstbID, timeout+sub.GetTimeout()).Err(); err != nil {
+			return true, err
+		}
+
+		done, err := dh.cache.Get(doneKey).Int64()
+		if err != nil && err != redis.Nil {
+			return true, err
+		}
+		if done >= dist.OrderSeq {
+			return false, nil
+		}
+		if done >= dist.OrderSeq-1 {
+			return true, nil
+		}
+
+		if time.Since(start) >= blockTimeout {
+			previousRunning, err := checkFromRunning(dh.cache, previousRunningKey)
+			if err != nil {
+				return true, err
+			}
+			if onBlocked == metadata.OrderBlockedSkip || !previousRunning {
+				blog.Warnf("dist %d of subscription %d is blocked by the previous event of %s for %v, send it by the %s policy, previous running: %v",
+					dist.DstbID, dist.SubscriptionID, dist.OrderKey, time.Since(start), onBlocked, previousRunning)
+				increaseBlocked(dh.cache, dist.SubscriptionID)
+				return true, nil
+			}
+		}
+		time.Sleep(waitperiod)
+	}
+}
+
+// saveOrderDone mark the event done, so that the next event of the same instance can be sent
+func (dh *DistHandler) saveOrderDone(dist *metadata.DistInstCtx) error {
+	doneKey := types.EventCacheDistOrderDonePrefix + orderSuffix(dist.SubscriptionID, dist.OrderKey)
+	expire := int64(orderKeyExpire / time.Second)
+	if err := saveOrderDoneScript.Run(dh.cache, []string{doneKey}, dist.OrderSeq, expire).Err(); err != nil {
+		return err
+	}
+	return dh.cache.Del(orderRunningKey(dist.SubscriptionID, dist.OrderKey, dist.OrderSeq)).Err()
+}
+
+// orderHolds records the earliest deferred dist of each instance. the later dists of the instance are deferred
+// as well until it's handed to a lane, so that they don't take up the lane waiting for it.
+type orderHolds struct {
+	lock  sync.Mutex
+	holds map[string]int64
+}
+
+func newOrderHolds() *orderHolds {
+	return &orderHolds{holds: map[string]int64{}}
+}
+
+// hold records the dist is deferred, the earliest sequence of the instance is kept
+func (h *orderHolds) hold(dist *metadata.DistInst) {
+	if dist.OrderKey == "" {
+		return
+	}
+	key := orderSuffix(dist.SubscriptionID, dist.OrderKey)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if seq, ok := h.holds[key]; !ok || dist.OrderSeq < seq {
+		h.holds[key] = dist.OrderSeq
+	}
+}
+
+// held returns whether an earlier dist of the same instance is deferred
+func (h *orderHolds) held(dist *metadata.DistInst) bool {
+	if dist.OrderKey == "" {
+		return false
+	}
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	seq, ok := h.holds[orderSuffix(dist.SubscriptionID, dist.OrderKey)]
+	return ok && dist.OrderSeq > seq
+}
+
+// release removes the hold of the instance when the deferred dist is handed to a lane
+func (h *orderHolds) release(dist *metadata.DistInst) {
+	if dist.OrderKey == "" {
+		return
+	}
+	key := orderSuffix(dist.SubscriptionID, dist.OrderKey)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if seq, ok := h.holds[key]; ok && dist.OrderSeq == seq {
+		delete(h.holds, key)
+	}
+}
+
+// clear removes the holds of the subscription
+func (h *orderHolds) clear(subscriptionID int64) {
+	prefix := fmt.Sprintf("%d_", subscriptionID)
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for key := range h.holds {
+		if strings.HasPrefix(key, prefix) {
+			delete(h.holds, key)
+		}
+	}
+}
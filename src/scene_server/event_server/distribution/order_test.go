@@ -0,0 +1,113 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestOrderKey(t *testing.T) {
+	tests := []struct {
+		name string
+		dist metadata.DistInst
+		want string
+	}{
+		{
+			name: "host update",
+			dist: metadata.DistInst{EventInst: metadata.EventInst{ObjType: "host", Action: metadata.EventActionUpdate,
+				Data: []metadata.EventData{{CurData: map[string]interface{}{"bk_host_id": 3}}}}},
+			want: "host:3",
+		},
+		{
+			name: "host delete",
+			dist: metadata.DistInst{EventInst: metadata.EventInst{ObjType: "host", Action: metadata.EventActionDelete,
+				Data: []metadata.EventData{{PreData: map[string]interface{}{"bk_host_id": 3}}}}},
+			want: "host:3",
+		},
+		{
+			name: "object instance",
+			dist: metadata.DistInst{EventInst: metadata.EventInst{ObjType: "switch", Action: metadata.EventActionCreate,
+				Data: []metadata.EventData{{CurData: map[string]interface{}{"bk_inst_id": 7}}}}},
+			want: "switch:7",
+		},
+		{
+			name: "no instance id",
+			dist: metadata.DistInst{EventInst: metadata.EventInst{ObjType: "host", Action: metadata.EventActionCreate,
+				Data: []metadata.EventData{{CurData: map[string]interface{}{"bk_host_innerip": "127.0.0.1"}}}}},
+			want: "",
+		},
+		{
+			name: "no data",
+			dist: metadata.DistInst{EventInst: metadata.EventInst{ObjType: "host", Action: metadata.EventActionCreate}},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := orderKey(&tt.dist); got != tt.want {
+				t.Errorf("orderKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLaneIndex(t *testing.T) {
+	// the events of the same instance always go to the same lane
+	first := laneIndex(&metadata.DistInst{DstbID: 1, OrderKey: "host:3"}, distLanes)
+	for id := int64(2); id < 100; id++ {
+		if got := laneIndex(&metadata.DistInst{DstbID: id, OrderKey: "host:3"}, distLanes); got != first {
+			t.Fatalf("laneIndex() of dist %d = %d, want %d", id, got, first)
+		}
+	}
+	// the events without order key are spread
+	if laneIndex(&metadata.DistInst{DstbID: 1}, distLanes) == laneIndex(&metadata.DistInst{DstbID: 2}, distLanes) {
+		t.Errorf("the dists without order key should be spread over the lanes")
+	}
+}
+
+func TestOrderHolds(t *testing.T) {
+	holds := newOrderHolds()
+	dist := func(sub int64, key string, seq int64) *metadata.DistInst {
+		return &metadata.DistInst{SubscriptionID: sub, OrderKey: key, OrderSeq: seq}
+	}
+
+	holds.hold(dist(1, "host:1", 5))
+	holds.hold(dist(1, "host:1", 6))
+	if holds.held(dist(1, "host:1", 5)) {
+		t.Errorf("the earliest deferred dist should not be held")
+	}
+	if !holds.held(dist(1, "host:1", 6)) {
+		t.Errorf("the later dist should be held by the deferred one")
+	}
+	if holds.held(dist(1, "host:2", 6)) || holds.held(dist(2, "host:1", 6)) || holds.held(dist(1, "", 6)) {
+		t.Errorf("the dists of the other instances should not be held")
+	}
+
+	holds.release(dist(1, "host:1", 6))
+	if !holds.held(dist(1, "host:1", 6)) {
+		t.Errorf("the hold should be released only by the earliest deferred dist")
+	}
+	holds.release(dist(1, "host:1", 5))
+	if holds.held(dist(1, "host:1", 6)) {
+		t.Errorf("the hold should be released after the earliest deferred dist is handed to the lane")
+	}
+
+	holds.hold(dist(1, "host:1", 7))
+	holds.hold(dist(2, "host:1", 7))
+	holds.clear(1)
+	if holds.held(dist(1, "host:1", 8)) || !holds.held(dist(2, "host:1", 8)) {
+		t.Errorf("only the holds of the subscription should be cleared")
+	}
+}
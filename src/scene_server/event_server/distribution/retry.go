@@ -0,0 +1,180 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// default retry policy, which keeps the behavior of sending only once
+var (
+	defaultMaxAttempts = 1
+	defaultInterval    = time.Second
+	defaultMaxInterval = time.Minute
+)
+
+// requeueInterval the interval to move the dists due to be sent again back to the dist queue,
+// the pop of the dist queue blocks no longer than it, so that the requeue is not delayed.
+const requeueInterval = time.Second
+
+// maxAttempts returns the max send times of the subscription
+func maxAttempts(policy *metadata.RetryPolicy) int {
+	if policy == nil || policy.MaxAttempts <= 0 {
+		return defaultMaxAttempts
+	}
+	return policy.MaxAttempts
+}
+
+// backoff returns the duration to wait before the attempt-th retry, attempt starts from 1.
+// the duration grows exponentially from the interval and is limited by the max interval,
+// then a random jitter is applied to avoid the subscribers being flooded at the same time.
+func backoff(policy *metadata.RetryPolicy, attempt int) time.Duration {
+	interval, maxInterval, jitter := defaultInterval, defaultMaxInterval, float64(0)
+	if policy != nil {
+		if policy.Interval > 0 {
+			interval = time.Duration(policy.Interval) * time.Millisecond
+		}
+		if policy.MaxInterval > 0 {
+			maxInterval = time.Duration(policy.MaxInterval) * time.Millisecond
+		}
+		if policy.Jitter > 0 && policy.Jitter <= 1 {
+			jitter = policy.Jitter
+		}
+	}
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	wait := interval
+	for i := 1; i < attempt && wait < maxInterval; i++ {
+		wait *= 2
+	}
+	if wait > maxInterval {
+		wait = maxInterval
+	}
+	if jitter > 0 {
+		delta := float64(wait) * jitter
+		wait = time.Duration(float64(wait) - delta + rand.Float64()*2*delta)
+	}
+	return wait
+}
+
+// errRetryScheduled the send failed and the dist is scheduled to be sent again later
+var errRetryScheduled = errors.New("the dist is scheduled to be sent again")
+
+// requeueRetryScript moves the dists due to be sent again from the retry set to the dist queue
+var requeueRetryScript = redis.NewScript(`
+local items = redis.call('zrangebyscore', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+for _, item in ipairs(items) do
+	redis.call('zrem', KEYS[1], item)
+	redis.call('rpush', KEYS[2], item)
+end
+return #items
+`)
+
+// requeueBatch how many due dists are moved back to the queue at a time
+var requeueBatch = 100
+
+// sendWithRetry sends the dist to the subscriber once. if it fails and the retry policy is not exhausted, the dist is
+// scheduled to be sent again after the backoff rather than waiting here, so that the lane goes on with the next dist.
+// the dist will be moved to the dead letter table if all the attempts failed.
+func (dh *DistHandler) sendWithRetry(sub *metadata.Subscription, dist *metadata.DistInstCtx) (err error) {
+	attempts := maxAttempts(sub.RetryPolicy)
+	attempt := dist.Attempts + 1
+	if err = dh.send(sub, dist, attempt); err == nil {
+		return nil
+	}
+	blog.Warnf("send callback of dist %d to subscription %d failed, attempt %d/%d, err: %v", dist.DstbID, sub.SubscriptionID, attempt, attempts, err)
+
+	if attempt < attempts {
+		scheduleErr := dh.scheduleRetry(sub, dist, attempt, backoff(sub.RetryPolicy, attempt))
+		if scheduleErr == nil {
+			return errRetryScheduled
+		}
+		blog.Errorf("schedule the retry of dist %d for subscription %d failed, move it to the dead letter, err: %v", dist.DstbID, sub.SubscriptionID, scheduleErr)
+	}
+
+	if saveErr := dh.saveDeadLetter(sub, dist, attempt, err); saveErr != nil {
+		blog.Errorf("save dead letter of dist %d for subscription %d failed: %v, raw: %s", dist.DstbID, sub.SubscriptionID, saveErr, dist.Raw)
+	}
+	return err
+}
+
+// scheduleRetry puts the dist into the retry set, it's moved back to the dist queue when it's due.
+// it also defers the dists which can't be handed to the lanes, with the attempts unchanged.
+func (dh *DistHandler) scheduleRetry(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempt int, wait time.Duration) error {
+	retry := dist.DistInst
+	retry.Attempts = attempt
+	raw, err := json.Marshal(retry)
+	if err != nil {
+		return err
+	}
+
+	// keep the order running flag alive, so that the following dist of the instance keeps waiting for the retry
+	if dist.OrderKey != "" {
+		orderRunningkey := orderRunningKey(dist.SubscriptionID, dist.OrderKey, dist.OrderSeq)
+		if err := dh.cache.Set(orderRunningkey, dist.DstbID, timeout+sub.GetTimeout()+wait).Err(); err != nil {
+			return err
+		}
+	}
+
+	due := time.Now().Add(wait).UnixNano() / int64(time.Millisecond)
+	return dh.cache.ZAdd(retryKey(dist.SubscriptionID), redis.Z{Score: float64(due), Member: string(raw)}).Err()
+}
+
+// requeueRetries moves the dists due to be sent again back to the dist queue of the subscription
+func (dh *DistHandler) requeueRetries(subID int64) error {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	keys := []string{retryKey(subID), types.EventCacheDistQueuePrefix + fmt.Sprint(subID)}
+	return requeueRetryScript.Run(dh.cache, keys, now, requeueBatch).Err()
+}
+
+func retryKey(subID int64) string {
+	return types.EventCacheDistRetryPrefix + fmt.Sprint(subID)
+}
+
+func (dh *DistHandler) saveDeadLetter(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempts int, sendErr error) error {
+	id, err := dh.db.NextSequence(dh.ctx, common.BKTableNameEventDeadLetter)
+	if err != nil {
+		return err
+	}
+
+	letter := metadata.EventDeadLetter{
+		ID:             int64(id),
+		SubscriptionID: sub.SubscriptionID,
+		DstbID:         dist.DstbID,
+		EventType:      dist.EventType,
+		Action:         dist.Action,
+		ObjType:        dist.ObjType,
+		Attempts:       attempts,
+		Raw:            dist.Raw,
+		OwnerID:        sub.OwnerID,
+		CreateTime:     metadata.Now(),
+	}
+	if sendErr != nil {
+		letter.LastError = sendErr.Error()
+	}
+
+	return dh.db.Table(common.BKTableNameEventDeadLetter).Insert(dh.ctx, letter)
+}
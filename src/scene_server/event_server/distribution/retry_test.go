@@ -0,0 +1,63 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"testing"
+	"time"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestBackoff(t *testing.T) {
+	policy := &metadata.RetryPolicy{MaxAttempts: 5, Interval: 100, MaxInterval: 500}
+	tests := []struct {
+		name    string
+		policy  *metadata.RetryPolicy
+		attempt int
+		want    time.Duration
+	}{
+		{"default", nil, 1, defaultInterval},
+		{"first", policy, 1, 100 * time.Millisecond},
+		{"second", policy, 2, 200 * time.Millisecond},
+		{"third", policy, 3, 400 * time.Millisecond},
+		{"limited", policy, 4, 500 * time.Millisecond},
+		{"overflow", policy, 100, 500 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backoff(tt.policy, tt.attempt); got != tt.want {
+				t.Errorf("backoff() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := &metadata.RetryPolicy{Interval: 1000, Jitter: 0.2}
+	for i := 0; i < 100; i++ {
+		got := backoff(policy, 1)
+		if got < 800*time.Millisecond || got > 1200*time.Millisecond {
+			t.Fatalf("backoff() = %v, out of jitter range", got)
+		}
+	}
+}
+
+func TestMaxAttempts(t *testing.T) {
+	if got := maxAttempts(nil); got != defaultMaxAttempts {
+		t.Errorf("maxAttempts() = %v, want %v", got, defaultMaxAttempts)
+	}
+	if got := maxAttempts(&metadata.RetryPolicy{MaxAttempts: 3}); got != 3 {
+		t.Errorf("maxAttempts() = %v, want 3", got)
+	}
+}
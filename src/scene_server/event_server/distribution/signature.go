@@ -0,0 +1,67 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+)
+
+// signRequest set the signature header of the callback request if the subscription has a secret.
+// the header looks like "t=1557300000,v1=5257a869...", the v1 is the hex encoded HMAC-SHA256 of
+// "{t}.{body}", there are two v1 in the grace period of the secret rotation, one for each secret.
+func signRequest(secretKey string, receiver *metadata.Subscription, req *http.Request, body string) error {
+	if receiver.Secret == "" {
+		return nil
+	}
+
+	secrets := make([]string, 0)
+	secret, err := util.AESDecrypt(secretKey, receiver.Secret)
+	if err != nil {
+		return fmt.Errorf("decrypt secret failed: %v", err)
+	}
+	secrets = append(secrets, secret)
+
+	now := time.Now()
+	if receiver.PreviousSecret != "" && receiver.PreviousSecretExpire != nil && now.Before(receiver.PreviousSecretExpire.Time) {
+		previous, err := util.AESDecrypt(secretKey, receiver.PreviousSecret)
+		if err != nil {
+			return fmt.Errorf("decrypt previous secret failed: %v", err)
+		}
+		secrets = append(secrets, previous)
+	}
+
+	req.Header.Set(common.BKHTTPEventSignature, signature(secrets, now.Unix(), body))
+	return nil
+}
+
+func signature(secrets []string, timestamp int64, body string) string {
+	ts := strconv.FormatInt(timestamp, 10)
+	parts := []string{"t=" + ts}
+	for _, secret := range secrets {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(ts + "." + body))
+		parts = append(parts, "v1="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	return strings.Join(parts, ",")
+}
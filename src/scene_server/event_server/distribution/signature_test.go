@@ -0,0 +1,35 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets []string
+		want    string
+	}{
+		{"one secret", []string{"secret"}, "t=1557300000,v1=cc99fcc48bdd1c81b60501255536cc66e1e2f419c1ae2b2d2d998a77224e9e0c"},
+		{"rotated", []string{"secret", "old"}, "t=1557300000,v1=cc99fcc48bdd1c81b60501255536cc66e1e2f419c1ae2b2d2d998a77224e9e0c,v1=5cbaa1fb15f032f535aa43f63d38bfdc2f45d30001190e20df342e2eab7b3a28"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := signature(tt.secrets, 1557300000, `{"event_type":"instdata"}`); got != tt.want {
+				t.Errorf("signature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/metadata"
+)
+
+// Sink deliver the events to the subscribers, the sink is chosen by the sink type of the subscription
+type Sink interface {
+	// Send returns error if the event is not accepted by the subscriber, it will be retried by the retry policy
+	Send(sub *metadata.Subscription, dist *metadata.DistInstCtx) (*SinkResponse, error)
+}
+
+// sinkReleaser is implemented by the sinks which hold resources for the subscriptions
+type sinkReleaser interface {
+	// Release free the resources of the subscription, it's called when the subscription is deleted or changed
+	Release(subscriptionID int64)
+}
+
+// SinkResponse the response of the subscriber, which is recorded in the delivery log
+type SinkResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+func newSinks(cache *redis.Client, secretKey string, fileConf FileSinkConfig) map[string]Sink {
+	return map[string]Sink{
+		metadata.SinkTypeHTTP:  &httpSink{secretKey: secretKey},
+		metadata.SinkTypeRedis: &redisSink{cache: cache},
+		metadata.SinkTypeFile:  newFileSink(fileConf),
+		metadata.SinkTypeMQ:    &mqSink{},
+	}
+}
+
+// releaseSinks free the resources held by the sinks for the subscription
+func (dh *DistHandler) releaseSinks(subscriptionID int64) {
+	for _, sink := range dh.sinks {
+		if releaser, ok := sink.(sinkReleaser); ok {
+			releaser.Release(subscriptionID)
+		}
+	}
+}
+
+// send sends the dist by the sink of the subscription, the attempt is recorded in the delivery log
+func (dh *DistHandler) send(sub *metadata.Subscription, dist *metadata.DistInstCtx, attempt int) (err error) {
+	increaseTotal(dh.cache, sub.SubscriptionID)
+
+	delivery := newDelivery(sub, dist, attempt)
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			increaseFailue(dh.cache, sub.SubscriptionID)
+		}
+		delivery.Latency = int64(time.Since(start) / time.Millisecond)
+		dh.saveDelivery(delivery, err)
+	}()
+
+	sink, ok := dh.sinks[sub.GetSinkType()]
+	if !ok {
+		return fmt.Errorf("event distribute fail, unknown sink type %s", sub.GetSinkType())
+	}
+	resp, err := sink.Send(sub, dist)
+	if resp != nil {
+		delivery.StatusCode = resp.StatusCode
+		delivery.Response = truncateResponse(resp.Body)
+	}
+	return err
+}
@@ -0,0 +1,192 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+)
+
+// FileSinkConfig the config of the file sink
+type FileSinkConfig struct {
+	// Dir the directory of the sink files, the file sink is disabled if it's empty
+	Dir string
+	// MaxSize the file is rotated when it's larger than it, byte
+	MaxSize int64
+	// MaxBackups the count of the rotated files to keep
+	MaxBackups int
+}
+
+// defaultFileSinkMaxSize the default max size of the sink file, 100MB
+const defaultFileSinkMaxSize = 100 * 1024 * 1024
+
+var fileSinkNameRegexp = regexp.MustCompile(`^[\w.-]+$`)
+
+// ValidFileSinkName check the file name of the file sink, which must not contain the path separator
+func ValidFileSinkName(name string) bool {
+	return name != "." && name != ".." && fileSinkNameRegexp.MatchString(name)
+}
+
+// fileSink append the events to the file in the sink directory of the owner, one event json per line
+type fileSink struct {
+	conf  FileSinkConfig
+	lock  sync.Mutex
+	files map[string]*rotateFile
+	// subs the file key of the subscriptions, the file is closed when no subscription writes to it
+	subs map[int64]string
+}
+
+func newFileSink(conf FileSinkConfig) *fileSink {
+	if conf.MaxSize <= 0 {
+		conf.MaxSize = defaultFileSinkMaxSize
+	}
+	return &fileSink{conf: conf, files: map[string]*rotateFile{}, subs: map[int64]string{}}
+}
+
+func (s *fileSink) Send(sub *metadata.Subscription, dist *metadata.DistInstCtx) (*SinkResponse, error) {
+	if s.conf.Dir == "" {
+		return nil, fmt.Errorf("event distribute fail, the file sink is disabled")
+	}
+	name := sub.SinkConfig["name"]
+	if !ValidFileSinkName(name) {
+		return nil, fmt.Errorf("event distribute fail, invalid file sink name %s", name)
+	}
+	if !ValidFileSinkName(sub.OwnerID) {
+		return nil, fmt.Errorf("event distribute fail, invalid owner %s of the file sink", sub.OwnerID)
+	}
+
+	// the subscriptions of the same owner may share the same file, so the writes are serialized
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	key := filepath.Join(sub.OwnerID, name)
+	if old, ok := s.subs[sub.SubscriptionID]; ok && old != key {
+		s.release(sub.SubscriptionID)
+	}
+	s.subs[sub.SubscriptionID] = key
+	file, ok := s.files[key]
+	if !ok {
+		if err := os.MkdirAll(filepath.Join(s.conf.Dir, sub.OwnerID), 0755); err != nil {
+			return nil, fmt.Errorf("event distribute fail, create the sink directory of owner %s error: %v", sub.OwnerID, err)
+		}
+		file = &rotateFile{path: filepath.Join(s.conf.Dir, key), maxSize: s.conf.MaxSize, maxBackups: s.conf.MaxBackups}
+		s.files[key] = file
+	}
+	if err := file.write([]byte(dist.Raw + "\n")); err != nil {
+		return nil, fmt.Errorf("event distribute fail, write to file %s error: %v", file.path, err)
+	}
+	return nil, nil
+}
+
+// Release close the file of the subscription if no other subscription writes to it
+func (s *fileSink) Release(subscriptionID int64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.release(subscriptionID)
+}
+
+func (s *fileSink) release(subscriptionID int64) {
+	key, ok := s.subs[subscriptionID]
+	if !ok {
+		return
+	}
+	delete(s.subs, subscriptionID)
+	for _, other := range s.subs {
+		if other == key {
+			return
+		}
+	}
+	if file, ok := s.files[key]; ok {
+		if err := file.close(); err != nil {
+			blog.Errorf("close the sink file %s failed: %v", file.path, err)
+		}
+		delete(s.files, key)
+	}
+}
+
+// rotateFile a file which is renamed to path.1, path.2 ... when it's larger than the max size
+type rotateFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func (f *rotateFile) write(data []byte) error {
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return err
+		}
+	}
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return err
+}
+
+func (f *rotateFile) close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *rotateFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotateFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+
+	if f.maxBackups <= 0 {
+		if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return f.open()
+	}
+	for i := f.maxBackups - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(f.path, f.path+".1"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return f.open()
+}
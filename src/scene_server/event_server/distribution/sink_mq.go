@@ -0,0 +1,99 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"sync"
+
+	"configcenter/src/common/metadata"
+)
+
+// MessageQueue the adapter of a message queue, which is registered with a name by RegisterMessageQueue,
+// and the subscriptions choose it by the queue of the sink config.
+//
+// none is registered by default, a deployment which delivers the events to its message queue implements
+// the adapter in a package whose init function registers it, and imports the package in the main package
+// of the event server, such as:
+//
+//	import _ "configcenter/src/scene_server/event_server/mq/kafka"
+//
+// the subscriptions of the queue which is not registered are rejected.
+type MessageQueue interface {
+	Publish(topic string, message []byte) error
+}
+
+var (
+	messageQueueLock sync.RWMutex
+	messageQueues    = map[string]MessageQueue{}
+)
+
+// RegisterMessageQueue register the message queue adapter, the one with the same name is replaced
+func RegisterMessageQueue(name string, queue MessageQueue) {
+	messageQueueLock.Lock()
+	defer messageQueueLock.Unlock()
+	messageQueues[name] = queue
+}
+
+// MessageQueueRegistered returns whether the message queue adapter of the name is registered
+func MessageQueueRegistered(name string) bool {
+	_, ok := getMessageQueue(name)
+	return ok
+}
+
+func getMessageQueue(name string) (MessageQueue, bool) {
+	messageQueueLock.RLock()
+	defer messageQueueLock.RUnlock()
+	queue, ok := messageQueues[name]
+	return queue, ok
+}
+
+// mqSink publish the events to the topic of the registered message queue
+type mqSink struct{}
+
+func (s *mqSink) Send(sub *metadata.Subscription, dist *metadata.DistInstCtx) (*SinkResponse, error) {
+	name, topic := sub.SinkConfig["queue"], sub.SinkConfig["topic"]
+	queue, ok := getMessageQueue(name)
+	if !ok {
+		return nil, fmt.Errorf("event distribute fail, message queue %s is not registered", name)
+	}
+	if err := queue.Publish(topic, []byte(dist.Raw)); err != nil {
+		return nil, fmt.Errorf("event distribute fail, publish to %s topic %s error: %v", name, topic, err)
+	}
+	return nil, nil
+}
+
+// MemoryQueue the in-process message queue, which keeps all the messages in memory.
+// it's a stand-in of the real message queue for tests.
+type MemoryQueue struct {
+	lock     sync.Mutex
+	messages map[string][][]byte
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{messages: map[string][][]byte{}}
+}
+
+func (q *MemoryQueue) Publish(topic string, message []byte) error {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.messages[topic] = append(q.messages[topic], message)
+	return nil
+}
+
+// Messages returns the messages published to the topic in order
+func (q *MemoryQueue) Messages(topic string) [][]byte {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([][]byte{}, q.messages[topic]...)
+}
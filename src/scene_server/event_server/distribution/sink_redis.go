@@ -0,0 +1,90 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"fmt"
+	"strconv"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/types"
+)
+
+// the modes of the redis sink
+const (
+	redisSinkModeList   = "list"
+	redisSinkModeStream = "stream"
+)
+
+// DefaultRedisSinkMaxLength the max length of the redis sink if it's not set,
+// the events are kept in the redis of the event server, so they can not grow without limit.
+const DefaultRedisSinkMaxLength = 10000
+
+// redisSink push the events to the redis of the event server, the key is prefixed by types.EventCacheSinkPrefix
+// and the owner of the subscription. the list mode is used by default, the stream mode requires redis 5.0 or later.
+type redisSink struct {
+	cache *redis.Client
+}
+
+// RedisSinkMaxLength returns the max length of the redis sink config, which must be positive
+func RedisSinkMaxLength(config map[string]string) (int64, error) {
+	length := config["max_length"]
+	if length == "" {
+		return DefaultRedisSinkMaxLength, nil
+	}
+	maxLength, err := strconv.ParseInt(length, 10, 64)
+	if err != nil || maxLength <= 0 {
+		return 0, fmt.Errorf("invalid redis sink max_length %s", length)
+	}
+	return maxLength, nil
+}
+
+// redisSinkKey returns the redis key of the sink, the keys of the owners do not collide
+func redisSinkKey(sub *metadata.Subscription) string {
+	return types.EventCacheSinkPrefix + sub.OwnerID + ":" + sub.SinkConfig["key"]
+}
+
+func (s *redisSink) Send(sub *metadata.Subscription, dist *metadata.DistInstCtx) (*SinkResponse, error) {
+	if sub.SinkConfig["key"] == "" {
+		return nil, fmt.Errorf("event distribute fail, redis sink key is not set")
+	}
+	key := redisSinkKey(sub)
+
+	maxLength, err := RedisSinkMaxLength(sub.SinkConfig)
+	if err != nil {
+		return nil, fmt.Errorf("event distribute fail, %v", err)
+	}
+
+	switch sub.SinkConfig["mode"] {
+	case "", redisSinkModeList:
+		if err := s.cache.RPush(key, dist.Raw).Err(); err != nil {
+			return nil, fmt.Errorf("event distribute fail, push to redis list %s error: %v", key, err)
+		}
+		if err := s.cache.LTrim(key, -maxLength, -1).Err(); err != nil {
+			return nil, fmt.Errorf("event distribute fail, trim redis list %s error: %v", key, err)
+		}
+		return nil, nil
+
+	case redisSinkModeStream:
+		cmd := redis.NewStringCmd("XADD", key, "MAXLEN", "~", maxLength, "*", "event", dist.Raw)
+		if err := s.cache.Process(cmd); err != nil {
+			return nil, fmt.Errorf("event distribute fail, add to redis stream %s error: %v", key, err)
+		}
+		return &SinkResponse{Body: []byte(cmd.Val())}, nil
+
+	default:
+		return nil, fmt.Errorf("event distribute fail, unknown redis sink mode %s", sub.SinkConfig["mode"])
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package distribution
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestFileSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event_sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := newFileSink(FileSinkConfig{Dir: dir, MaxSize: 10, MaxBackups: 2})
+	sub := &metadata.Subscription{SubscriptionID: 1, OwnerID: "0", SinkType: metadata.SinkTypeFile, SinkConfig: map[string]string{"name": "events.jsonl"}}
+	for _, raw := range []string{`{"a":1}`, `{"a":2}`, `{"a":3}`, `{"a":4}`} {
+		if _, err := sink.Send(sub, &metadata.DistInstCtx{Raw: raw}); err != nil {
+			t.Fatalf("send %s failed: %v", raw, err)
+		}
+	}
+
+	// every file holds one event, and only 2 backups are kept
+	want := map[string]string{
+		"events.jsonl":   "{\"a\":4}\n",
+		"events.jsonl.1": "{\"a\":3}\n",
+		"events.jsonl.2": "{\"a\":2}\n",
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "0"))
+	if len(files) != len(want) {
+		t.Errorf("got %d files, want %d", len(files), len(want))
+	}
+	for name, content := range want {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "0", name))
+		if err != nil {
+			t.Errorf("read %s failed: %v", name, err)
+			continue
+		}
+		if string(data) != content {
+			t.Errorf("%s = %q, want %q", name, data, content)
+		}
+	}
+
+	sub.SinkConfig["name"] = "../events.jsonl"
+	if _, err := sink.Send(sub, &metadata.DistInstCtx{Raw: `{}`}); err == nil {
+		t.Errorf("send to the file outside the sink directory should fail")
+	}
+}
+
+func TestFileSinkOwners(t *testing.T) {
+	dir, err := ioutil.TempDir("", "event_sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sink := newFileSink(FileSinkConfig{Dir: dir})
+	subA := &metadata.Subscription{SubscriptionID: 1, OwnerID: "a", SinkType: metadata.SinkTypeFile, SinkConfig: map[string]string{"name": "events"}}
+	subB := &metadata.Subscription{SubscriptionID: 2, OwnerID: "b", SinkType: metadata.SinkTypeFile, SinkConfig: map[string]string{"name": "events"}}
+	if _, err := sink.Send(subA, &metadata.DistInstCtx{Raw: `{"a":1}`}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sink.Send(subB, &metadata.DistInstCtx{Raw: `{"b":1}`}); err != nil {
+		t.Fatal(err)
+	}
+
+	// the owners with the same file name write to their own files
+	for owner, content := range map[string]string{"a": "{\"a\":1}\n", "b": "{\"b\":1}\n"} {
+		data, err := ioutil.ReadFile(filepath.Join(dir, owner, "events"))
+		if err != nil || string(data) != content {
+			t.Errorf("file of owner %s = %q, %v, want %q", owner, data, err, content)
+		}
+	}
+	if len(sink.files) != 2 {
+		t.Errorf("got %d open files, want 2", len(sink.files))
+	}
+
+	// the file is closed when the sink of the subscription is changed
+	subA.SinkConfig["name"] = "renamed"
+	if _, err := sink.Send(subA, &metadata.DistInstCtx{Raw: `{"a":2}`}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := sink.files[filepath.Join("a", "events")]; ok {
+		t.Errorf("the file of the old sink name is still open")
+	}
+
+	// and when the subscription is deleted
+	sink.Release(subA.SubscriptionID)
+	sink.Release(subB.SubscriptionID)
+	if len(sink.files) != 0 || len(sink.subs) != 0 {
+		t.Errorf("got %d open files, %d subscriptions after release, want none", len(sink.files), len(sink.subs))
+	}
+
+	subB.OwnerID = ".."
+	if _, err := sink.Send(subB, &metadata.DistInstCtx{Raw: `{}`}); err == nil {
+		t.Errorf("send to the file of an invalid owner should fail")
+	}
+}
+
+func TestMQSink(t *testing.T) {
+	queue := NewMemoryQueue()
+	RegisterMessageQueue("memory", queue)
+
+	sink := &mqSink{}
+	sub := &metadata.Subscription{SinkType: metadata.SinkTypeMQ, SinkConfig: map[string]string{"queue": "memory", "topic": "cmdb"}}
+	for _, raw := range []string{`{"a":1}`, `{"a":2}`} {
+		if _, err := sink.Send(sub, &metadata.DistInstCtx{Raw: raw}); err != nil {
+			t.Fatalf("send %s failed: %v", raw, err)
+		}
+	}
+	messages := queue.Messages("cmdb")
+	if len(messages) != 2 || string(messages[0]) != `{"a":1}` || string(messages[1]) != `{"a":2}` {
+		t.Errorf("got messages %q", messages)
+	}
+
+	sub.SinkConfig["queue"] = "unknown"
+	if _, err := sink.Send(sub, &metadata.DistInstCtx{Raw: `{}`}); err == nil {
+		t.Errorf("send to the unregistered queue should fail")
+	}
+}
+
+func TestRedisSinkConfig(t *testing.T) {
+	subA := &metadata.Subscription{OwnerID: "a", SinkType: metadata.SinkTypeRedis, SinkConfig: map[string]string{"key": "events"}}
+	subB := &metadata.Subscription{OwnerID: "b", SinkType: metadata.SinkTypeRedis, SinkConfig: map[string]string{"key": "events"}}
+	if redisSinkKey(subA) == redisSinkKey(subB) {
+		t.Errorf("the redis sink keys of the different owners collide: %s", redisSinkKey(subA))
+	}
+
+	tests := []struct {
+		length string
+		want   int64
+		fail   bool
+	}{
+		{length: "", want: DefaultRedisSinkMaxLength},
+		{length: "100", want: 100},
+		{length: "0", fail: true},
+		{length: "-1", fail: true},
+		{length: "many", fail: true},
+	}
+	for _, tt := range tests {
+		got, err := RedisSinkMaxLength(map[string]string{"max_length": tt.length})
+		if (err != nil) != tt.fail || got != tt.want {
+			t.Errorf("RedisSinkMaxLength(%q) = %d, %v, want %d, fail %v", tt.length, got, err, tt.want, tt.fail)
+		}
+	}
+}
@@ -30,19 +30,22 @@ import (
 	"configcenter/src/storage/rpc"
 )
 
-func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client) error {
+// Start start the event handling and distribution, secretKey is used to decrypt the subscription secrets,
+// the event history older than historyRetention and the delivery logs older than deliveryRetention are cleaned,
+// and fileSink is the config of the file sink.
+func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client, secretKey string, historyRetention, deliveryRetention time.Duration, fileSink FileSinkConfig) error {
 	chErr := make(chan error, 1)
 	err := migrateIDToMongo(ctx, cache, db)
 	if err != nil {
 		return fmt.Errorf("migrateIDToMongo failed: %v", err)
 	}
 
-	eh := &EventHandler{cache: cache}
+	eh := &EventHandler{cache: cache, db: db, ctx: ctx}
 	go func() {
 		chErr <- eh.StartHandleInsts()
 	}()
 
-	dh := &DistHandler{cache: cache, db: db, ctx: ctx}
+	dh := &DistHandler{cache: cache, db: db, ctx: ctx, sinks: newSinks(cache, secretKey, fileSink), holds: newOrderHolds()}
 	go func() {
 		chErr <- dh.StartDistribute()
 	}()
@@ -53,6 +56,7 @@ func Start(ctx context.Context, cache *redis.Client, db dal.RDB, rc rpc.Client)
 	}()
 
 	go cleanOutdateEvents(cache)
+	go cleanEventHistory(ctx, db, historyRetention, deliveryRetention)
 
 	if rc != nil {
 		th := &TxnHandler{cache: cache, db: db, ctx: ctx, rc: rc, committed: make(chan string, 100), shouldClose: util.NewBool(false)}
@@ -99,11 +103,21 @@ func migrateIDToMongo(ctx context.Context, cache *redis.Client, db dal.RDB) erro
 	return cache.Del(common.EventCacheEventIDKey).Err()
 }
 
-type EventHandler struct{ cache *redis.Client }
+type EventHandler struct {
+	cache   *redis.Client
+	db      dal.RDB
+	ctx     context.Context
+	filters subscriptionFilters
+	// lostHistory the owners whose event history is lost, and the watermark is not raised over it yet
+	lostHistory map[string]bool
+}
 type DistHandler struct {
 	cache *redis.Client
 	db    dal.RDB
 	ctx   context.Context
+	sinks map[string]Sink
+	// holds the instances whose dists are deferred until the earliest deferred one is handed to a lane
+	holds *orderHolds
 }
 
 type TxnHandler struct {
@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/emicklei/go-restful"
+)
+
+// SearchDeadLetter list the dead letter events of a subscription
+func (s *Service) SearchDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subscribeID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterSearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("search dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	cond := dat.Condition
+	if cond == nil {
+		cond = map[string]interface{}{}
+	}
+	cond[common.BKSubscriptionIDField] = subscribeID
+	cond = util.SetModOwner(cond, ownerID)
+
+	limit := dat.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sort := dat.Page.Sort
+	if sort == "" {
+		sort = common.BKFieldID
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count dead letter failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	letters := make([]metadata.EventDeadLetter, 0)
+	err = s.db.Table(common.BKTableNameEventDeadLetter).Find(cond).Sort(sort).Start(uint64(dat.Page.Start)).Limit(uint64(limit)).All(s.ctx, &letters)
+	if err != nil {
+		blog.Errorf("search dead letter failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterSearch{Count: count, Info: letters}))
+}
+
+// GetDeadLetter inspect one dead letter event
+func (s *Service) GetDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subscribeID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+	letterID, err := strconv.ParseInt(req.PathParameter("deadLetterID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKFieldID)})
+		return
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(common.BKSubscriptionIDField).Eq(subscribeID)
+	cond.Field(common.BKFieldID).Eq(letterID)
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+
+	letter := metadata.EventDeadLetter{}
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(cond.ToMapStr()).One(s.ctx, &letter); err != nil {
+		if s.db.IsNotFoundError(err) {
+			resp.WriteError(http.StatusNotFound, &metadata.RespError{Msg: defErr.Error(common.CCErrCommNotFound)})
+			return
+		}
+		blog.Errorf("get dead letter %d of subscription %d failed, err: %v", letterID, subscribeID, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(letter))
+}
+
+// ReplayDeadLetter push the dead letter events to the subscription again,
+// the replayed events will be removed from the dead letter table.
+func (s *Service) ReplayDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subscribeID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterIDs{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("replay dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	letters := make([]metadata.EventDeadLetter, 0)
+	cond := deadLetterCondition(ownerID, subscribeID, dat.IDs)
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Find(cond.ToMapStr()).Sort(common.BKFieldID).All(s.ctx, &letters); err != nil {
+		blog.Errorf("replay dead letter, but get dead letters failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterSelectFailed)})
+		return
+	}
+
+	subID := fmt.Sprint(subscribeID)
+	result := metadata.RspDeadLetterOperate{}
+	for _, letter := range letters {
+		dist := metadata.DistInst{}
+		if err := json.Unmarshal([]byte(letter.Raw), &dist); err != nil {
+			blog.Errorf("replay dead letter %d, but unmarshal raw failed, err: %v, raw: %s", letter.ID, err, letter.Raw)
+			result.Invalid = append(result.Invalid, letter.ID)
+			continue
+		}
+
+		// the replayed event is treated as a new distribution, so that it keeps the order with the others
+		dstbID, err := s.cache.Incr(types.EventCacheDistIDPrefix + subID).Result()
+		if err != nil {
+			blog.Errorf("replay dead letter %d, but generate distribution id failed, err: %v", letter.ID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+		dist.DstbID = dstbID
+		dist.SubscriptionID = subscribeID
+		// the replay is asked explicitly, so it's sent without waiting for the other events of the instance
+		dist.OrderKey, dist.OrderSeq = "", 0
+		dist.Attempts = 0
+		distByte, _ := json.Marshal(dist)
+		if err := s.cache.RPush(types.EventCacheDistQueuePrefix+subID, string(distByte)).Err(); err != nil {
+			blog.Errorf("replay dead letter %d, but push to queue failed, err: %v", letter.ID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+
+		delCond := condition.CreateCondition().Field(common.BKFieldID).Eq(letter.ID)
+		if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, delCond.ToMapStr()); err != nil {
+			blog.Errorf("replay dead letter %d, but remove it failed, err: %v", letter.ID, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterReplayFailed)})
+			return
+		}
+		result.Count++
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(result))
+}
+
+// PurgeDeadLetter remove the dead letter events of a subscription
+func (s *Service) PurgeDeadLetter(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subscribeID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+
+	dat := metadata.ParamDeadLetterIDs{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("purge dead letter, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	cond := deadLetterCondition(ownerID, subscribeID, dat.IDs)
+	count, err := s.db.Table(common.BKTableNameEventDeadLetter).Find(cond.ToMapStr()).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("purge dead letter, but count failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterDeleteFailed)})
+		return
+	}
+	if err := s.db.Table(common.BKTableNameEventDeadLetter).Delete(s.ctx, cond.ToMapStr()); err != nil {
+		blog.Errorf("purge dead letter failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeadLetterDeleteFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeadLetterOperate{Count: int64(count)}))
+}
+
+func deadLetterCondition(ownerID string, subscribeID int64, ids []int64) condition.Condition {
+	cond := condition.CreateCondition()
+	cond.Field(common.BKSubscriptionIDField).Eq(subscribeID)
+	cond.Field(common.BKOwnerIDField).Eq(ownerID)
+	if len(ids) > 0 {
+		cond.Field(common.BKFieldID).In(ids)
+	}
+	return cond
+}
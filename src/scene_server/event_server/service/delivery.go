@@ -0,0 +1,232 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+// defaultStatisticsWindows the recent 1 hour and 1 day
+var defaultStatisticsWindows = []int64{3600, 86400}
+
+// maxStatisticsWindows the max count of the statistics windows, each window is aggregated for every subscription
+const maxStatisticsWindows = 5
+
+// statisticsWindows returns the distinct windows in the request, at most maxStatisticsWindows of them are kept,
+// and the windows are bounded by the retention, for the delivery logs before it are cleaned.
+func statisticsWindows(windows []int64, retention time.Duration) []int64 {
+	if len(windows) == 0 {
+		windows = defaultStatisticsWindows
+	}
+	horizon := int64(retention / time.Second)
+	results := make([]int64, 0, maxStatisticsWindows)
+	exists := make(map[int64]bool)
+	for _, window := range windows {
+		if window <= 0 {
+			continue
+		}
+		if horizon > 0 && window > horizon {
+			window = horizon
+		}
+		if exists[window] {
+			continue
+		}
+		exists[window] = true
+		results = append(results, window)
+		if len(results) == maxStatisticsWindows {
+			break
+		}
+	}
+	return results
+}
+
+// SearchDelivery list the callback logs of a subscription
+func (s *Service) SearchDelivery(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	subscribeID, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+
+	dat := metadata.ParamDeliverySearch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("search delivery, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	cond := dat.Condition
+	if cond == nil {
+		cond = map[string]interface{}{}
+	}
+	cond[common.BKSubscriptionIDField] = subscribeID
+	cond = util.SetModOwner(cond, ownerID)
+
+	limit := dat.Page.Limit
+	if limit <= 0 {
+		limit = common.BKNoLimit
+	}
+	sort := dat.Page.Sort
+	if sort == "" {
+		sort = "-" + common.BKFieldID
+	}
+
+	count, err := s.db.Table(common.BKTableNameEventDelivery).Find(cond).Count(s.ctx)
+	if err != nil {
+		blog.Errorf("count delivery failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeliverySelectFailed)})
+		return
+	}
+
+	deliveries := make([]metadata.EventDelivery, 0)
+	err = s.db.Table(common.BKTableNameEventDelivery).Find(cond).Sort(sort).Start(uint64(dat.Page.Start)).Limit(uint64(limit)).All(s.ctx, &deliveries)
+	if err != nil {
+		blog.Errorf("search delivery failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventDeliverySelectFailed)})
+		return
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(metadata.RspDeliverySearch{Count: count, Info: deliveries}))
+}
+
+// latencyBucket the count of the deliveries with the same latency
+type latencyBucket struct {
+	Latency int64 `bson:"_id"`
+	Total   int64 `bson:"total"`
+	Failure int64 `bson:"failure"`
+}
+
+// subscriptionLatencyBucket the deliveries of a subscription with the same latency
+type subscriptionLatencyBucket struct {
+	ID struct {
+		SubscriptionID int64 `bson:"subscription_id"`
+		Latency        int64 `bson:"latency"`
+	} `bson:"_id"`
+	Total   int64 `bson:"total"`
+	Failure int64 `bson:"failure"`
+}
+
+// deliveryStatistics aggregate the delivery logs of the subscriptions in each of the recent time windows,
+// the subscriptions are aggregated together, so there is one aggregation for each window.
+func (s *Service) deliveryStatistics(ownerID string, subscribeIDs []int64, windows []int64) (map[int64][]metadata.DeliveryWindowStatistics, error) {
+	results := make(map[int64][]metadata.DeliveryWindowStatistics)
+	if len(subscribeIDs) == 0 {
+		return results, nil
+	}
+	now := time.Now()
+	for _, window := range windows {
+		if window <= 0 {
+			continue
+		}
+		cond := condition.CreateCondition()
+		cond.Field(common.BKSubscriptionIDField).In(subscribeIDs)
+		cond.Field(common.BKOwnerIDField).Eq(ownerID)
+		cond.Field(common.CreateTimeField).Gte(now.Add(-time.Duration(window) * time.Second))
+
+		pipeline := []mapstr.MapStr{
+			{common.BKDBMatch: cond.ToMapStr()},
+			{common.BKDBGroup: mapstr.MapStr{
+				"_id":   mapstr.MapStr{common.BKSubscriptionIDField: "$" + common.BKSubscriptionIDField, "latency": "$latency"},
+				"total": mapstr.MapStr{common.BKDBSum: 1},
+				"failure": mapstr.MapStr{common.BKDBSum: mapstr.MapStr{
+					"$cond": []interface{}{mapstr.MapStr{common.BKDBEQ: []interface{}{"$success", false}}, 1, 0},
+				}},
+			}},
+		}
+		buckets := make([]subscriptionLatencyBucket, 0)
+		if err := s.db.Table(common.BKTableNameEventDelivery).AggregateAll(s.ctx, pipeline, &buckets); err != nil {
+			return nil, err
+		}
+		subBuckets := groupLatencyBuckets(buckets)
+		for _, subscribeID := range subscribeIDs {
+			results[subscribeID] = append(results[subscribeID], windowStatistics(window, subBuckets[subscribeID]))
+		}
+	}
+	return results, nil
+}
+
+// groupLatencyBuckets group the latency buckets by the subscription
+func groupLatencyBuckets(buckets []subscriptionLatencyBucket) map[int64][]latencyBucket {
+	results := make(map[int64][]latencyBucket)
+	for _, bucket := range buckets {
+		results[bucket.ID.SubscriptionID] = append(results[bucket.ID.SubscriptionID], latencyBucket{
+			Latency: bucket.ID.Latency,
+			Total:   bucket.Total,
+			Failure: bucket.Failure,
+		})
+	}
+	return results
+}
+
+// windowStatistics summarize the latency buckets of a time window
+func windowStatistics(window int64, buckets []latencyBucket) metadata.DeliveryWindowStatistics {
+	stat := metadata.DeliveryWindowStatistics{Window: window}
+	for _, bucket := range buckets {
+		stat.Total += bucket.Total
+		stat.Failure += bucket.Failure
+	}
+	if stat.Total == 0 {
+		return stat
+	}
+	stat.SuccessRate = float64(stat.Total-stat.Failure) / float64(stat.Total)
+
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].Latency < buckets[j].Latency })
+	stat.P50Latency = latencyAt(buckets, percentileIndex(uint64(stat.Total), 0.5))
+	stat.P99Latency = latencyAt(buckets, percentileIndex(uint64(stat.Total), 0.99))
+	return stat
+}
+
+// latencyAt returns the latency at the index when the deliveries of the sorted buckets are expanded
+func latencyAt(buckets []latencyBucket, index uint64) int64 {
+	var seen uint64
+	for _, bucket := range buckets {
+		seen += uint64(bucket.Total)
+		if index < seen {
+			return bucket.Latency
+		}
+	}
+	return 0
+}
+
+// percentileIndex returns the index of the p percentile in the sorted values with nearest rank method
+func percentileIndex(total uint64, p float64) uint64 {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(p * float64(total)))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > total {
+		rank = total
+	}
+	return rank - 1
+}
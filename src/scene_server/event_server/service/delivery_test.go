@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPercentileIndex(t *testing.T) {
+	tests := []struct {
+		total uint64
+		p     float64
+		want  uint64
+	}{
+		{total: 0, p: 0.5, want: 0},
+		{total: 1, p: 0.5, want: 0},
+		{total: 1, p: 0.99, want: 0},
+		{total: 2, p: 0.5, want: 0},
+		{total: 10, p: 0.5, want: 4},
+		{total: 10, p: 0.99, want: 9},
+		{total: 200, p: 0.99, want: 197},
+		{total: 10, p: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := percentileIndex(tt.total, tt.p); got != tt.want {
+			t.Errorf("percentileIndex(%d, %v) = %d, want %d", tt.total, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestWindowStatistics(t *testing.T) {
+	buckets := []latencyBucket{
+		{Latency: 300, Total: 1, Failure: 1},
+		{Latency: 10, Total: 5},
+		{Latency: 20, Total: 4, Failure: 1},
+	}
+	stat := windowStatistics(3600, buckets)
+	if stat.Total != 10 || stat.Failure != 2 {
+		t.Errorf("total, failure = %d, %d, want 10, 2", stat.Total, stat.Failure)
+	}
+	if stat.SuccessRate != 0.8 {
+		t.Errorf("success rate = %v, want 0.8", stat.SuccessRate)
+	}
+	if stat.P50Latency != 10 || stat.P99Latency != 300 {
+		t.Errorf("p50, p99 = %d, %d, want 10, 300", stat.P50Latency, stat.P99Latency)
+	}
+
+	empty := windowStatistics(60, nil)
+	if empty.Total != 0 || empty.P50Latency != 0 || empty.Window != 60 {
+		t.Errorf("unexpected statistics of an empty window: %+v", empty)
+	}
+}
+
+func TestGroupLatencyBuckets(t *testing.T) {
+	bucket := func(subscribeID, latency, total int64) subscriptionLatencyBucket {
+		b := subscriptionLatencyBucket{Total: total}
+		b.ID.SubscriptionID, b.ID.Latency = subscribeID, latency
+		return b
+	}
+	buckets := []subscriptionLatencyBucket{bucket(1, 10, 2), bucket(2, 20, 3), bucket(1, 30, 1)}
+	want := map[int64][]latencyBucket{
+		1: {{Latency: 10, Total: 2}, {Latency: 30, Total: 1}},
+		2: {{Latency: 20, Total: 3}},
+	}
+	if got := groupLatencyBuckets(buckets); !reflect.DeepEqual(got, want) {
+		t.Errorf("groupLatencyBuckets() = %v, want %v", got, want)
+	}
+}
+
+func TestStatisticsWindows(t *testing.T) {
+	tests := []struct {
+		name      string
+		windows   []int64
+		retention time.Duration
+		want      []int64
+	}{
+		{name: "default", windows: nil, retention: 168 * time.Hour, want: []int64{3600, 86400}},
+		{name: "invalid and duplicated", windows: []int64{0, -1, 60, 60}, retention: time.Hour, want: []int64{60}},
+		{name: "cut to retention", windows: []int64{60, 86400, 7 * 86400}, retention: 24 * time.Hour, want: []int64{60, 86400}},
+		{name: "no retention", windows: []int64{7 * 86400}, retention: 0, want: []int64{7 * 86400}},
+		{name: "at most 5", windows: []int64{1, 2, 3, 4, 5, 6, 7}, retention: time.Hour, want: []int64{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		if got := statisticsWindows(tt.windows, tt.retention); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: statisticsWindows() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
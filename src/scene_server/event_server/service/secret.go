@@ -0,0 +1,150 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/types"
+
+	"github.com/emicklei/go-restful"
+)
+
+// defaultSecretGracePeriod the time the previous secret keeps valid when the secret is changed by rebook,
+// or rotated without the grace period
+const defaultSecretGracePeriod = 24 * time.Hour
+
+var errSecretKeyNotConfigured = errors.New("the secret key of event server is not configured")
+
+// RotateSecret set a new secret for the subscription, the previous one is still
+// used to sign the callback in the grace period, so that the subscriber can switch smoothly.
+func (s *Service) RotateSecret(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	id, err := strconv.ParseInt(req.PathParameter("subscribeID"), 10, 64)
+	if nil != err {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, common.BKSubscriptionIDField)})
+		return
+	}
+
+	dat := metadata.ParamSubscriptionSecret{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("rotate subscription secret, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	gracePeriod, err := secretGracePeriod(dat.GracePeriod)
+	if err != nil {
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "grace_period")})
+		return
+	}
+	if dat.Secret != "" && s.secretKey == "" {
+		blog.Errorf("rotate subscription secret, but the secret can not be saved, err: %v", errSecretKeyNotConfigured)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "secret")})
+		return
+	}
+
+	sub := metadata.Subscription{}
+	cond := util.NewMapBuilder(common.BKSubscriptionIDField, id, common.BKOwnerIDField, ownerID).Build()
+	if err := s.db.Table(common.BKTableNameSubscription).Find(cond).One(s.ctx, &sub); err != nil {
+		blog.Errorf("rotate subscription secret, but get subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSelectFailed)})
+		return
+	}
+
+	if dat.Secret == "" {
+		sub.Secret, sub.PreviousSecret, sub.PreviousSecretExpire = "", "", nil
+	} else if err := s.rotateSecret(&sub, dat.Secret, gracePeriod); err != nil {
+		blog.Errorf("rotate subscription secret of %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSecretFailed)})
+		return
+	}
+
+	doc := mapstr.MapStr{
+		"secret":                 sub.Secret,
+		"previous_secret":        sub.PreviousSecret,
+		"previous_secret_expire": sub.PreviousSecretExpire,
+	}
+	if err := s.db.Table(common.BKTableNameSubscription).Update(s.ctx, cond, doc); err != nil {
+		blog.Errorf("rotate subscription secret, but update subscription %d failed, err: %v", id, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeUpdateFailed)})
+		return
+	}
+
+	mesg, _ := json.Marshal(&sub)
+	if err := s.cache.Publish(types.EventCacheProcessChannel, "update"+string(mesg)).Err(); err != nil {
+		blog.Errorf("rotate subscription secret, but publish the change of %d failed, err: %v", id, err)
+	}
+
+	resp.WriteEntity(metadata.NewSuccessResp(nil))
+}
+
+// secretGracePeriod returns the grace period of the seconds, the default one is used if it's omitted,
+// and the previous secret is dropped immediately only if 0 is given explicitly.
+func secretGracePeriod(seconds *int64) (time.Duration, error) {
+	if seconds == nil {
+		return defaultSecretGracePeriod, nil
+	}
+	if *seconds < 0 {
+		return 0, errors.New("negative grace period")
+	}
+	return time.Duration(*seconds) * time.Second, nil
+}
+
+// rotateSecret replace the secret of sub with the plain secret, and keep the current one as the previous secret
+// for the grace period. nothing happens if the secret is not changed.
+func (s *Service) rotateSecret(sub *metadata.Subscription, secret string, gracePeriod time.Duration) error {
+	if s.secretKey == "" {
+		return errSecretKeyNotConfigured
+	}
+
+	if sub.Secret != "" {
+		current, err := util.AESDecrypt(s.secretKey, sub.Secret)
+		if err == nil && current == secret {
+			return nil
+		}
+		if err != nil {
+			blog.Warnf("decrypt the secret of subscription %d failed, it will be replaced directly, err: %v", sub.SubscriptionID, err)
+		}
+	}
+
+	encrypted, err := util.AESEncrypt(s.secretKey, secret)
+	if err != nil {
+		return err
+	}
+
+	sub.PreviousSecret, sub.PreviousSecretExpire = "", nil
+	if sub.Secret != "" && gracePeriod > 0 {
+		expire := metadata.Time{Time: time.Now().Add(gracePeriod)}
+		sub.PreviousSecret, sub.PreviousSecretExpire = sub.Secret, &expire
+	}
+	sub.Secret = encrypted
+	return nil
+}
+
+// hideSecret remove the secrets from the subscription before it's returned to the user
+func hideSecret(sub *metadata.Subscription) {
+	sub.SecretEnabled = sub.Secret != ""
+	sub.Secret, sub.PreviousSecret = "", ""
+}
@@ -0,0 +1,39 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSecretGracePeriod(t *testing.T) {
+	seconds := func(n int64) *int64 { return &n }
+	tests := []struct {
+		name    string
+		seconds *int64
+		want    time.Duration
+		fail    bool
+	}{
+		{name: "omitted", seconds: nil, want: defaultSecretGracePeriod},
+		{name: "immediate switch", seconds: seconds(0), want: 0},
+		{name: "given", seconds: seconds(60), want: time.Minute},
+		{name: "negative", seconds: seconds(-1), fail: true},
+	}
+	for _, tt := range tests {
+		got, err := secretGracePeriod(tt.seconds)
+		if (err != nil) != tt.fail || got != tt.want {
+			t.Errorf("%s: secretGracePeriod() = %v, %v, want %v, fail %v", tt.name, got, err, tt.want, tt.fail)
+		}
+	}
+}
@@ -14,6 +14,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"configcenter/src/auth"
 	"configcenter/src/common"
@@ -31,10 +32,15 @@ import (
 
 type Service struct {
 	*backbone.Engine
-	db    dal.RDB
-	cache *redis.Client
-	auth  auth.Authorize
-	ctx   context.Context
+	db        dal.RDB
+	cache     *redis.Client
+	auth      auth.Authorize
+	ctx       context.Context
+	secretKey string
+	// sinkFileDir the directory of the file sinks, the file sink is disabled if it's empty
+	sinkFileDir string
+	// deliveryRetention how long the delivery logs are kept, the statistics windows are bounded by it
+	deliveryRetention time.Duration
 }
 
 func NewService(ctx context.Context) *Service {
@@ -51,6 +57,18 @@ func (s *Service) SetCache(db *redis.Client) {
 	s.cache = db
 }
 
+func (s *Service) SetSecretKey(key string) {
+	s.secretKey = key
+}
+
+func (s *Service) SetSinkFileDir(dir string) {
+	s.sinkFileDir = dir
+}
+
+func (s *Service) SetDeliveryRetention(retention time.Duration) {
+	s.deliveryRetention = retention
+}
+
 func (s *Service) SetAuth(auth auth.Authorize) {
 	s.auth = auth
 }
@@ -68,10 +86,20 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/subscribe/search/{ownerID}/{appID}").To(s.Query))
 	api.Route(api.POST("/subscribe/ping").To(s.Ping))
 	api.Route(api.POST("/subscribe/telnet").To(s.Telnet))
+	api.Route(api.POST("/subscribe/secret/{ownerID}/{subscribeID}").To(s.RotateSecret))
 	api.Route(api.POST("/subscribe/{ownerID}/{appID}").To(s.Subscribe))
 	api.Route(api.DELETE("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.UnSubscribe))
 	api.Route(api.PUT("/subscribe/{ownerID}/{appID}/{subscribeID}").To(s.Rebook))
 
+	api.Route(api.POST("/deadletter/search/{ownerID}/{subscribeID}").To(s.SearchDeadLetter))
+	api.Route(api.POST("/deadletter/replay/{ownerID}/{subscribeID}").To(s.ReplayDeadLetter))
+	api.Route(api.GET("/deadletter/{ownerID}/{subscribeID}/{deadLetterID}").To(s.GetDeadLetter))
+	api.Route(api.DELETE("/deadletter/{ownerID}/{subscribeID}").To(s.PurgeDeadLetter))
+
+	api.Route(api.POST("/delivery/search/{ownerID}/{subscribeID}").To(s.SearchDelivery))
+
+	api.Route(api.POST("/watch/{ownerID}").To(s.Watch))
+
 	container.Add(api)
 
 	healthzAPI := new(restful.WebService).Produces(restful.MIME_JSON)
@@ -22,12 +22,15 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
+	"configcenter/src/scene_server/event_server/distribution"
 	"configcenter/src/scene_server/event_server/types"
 
 	"github.com/emicklei/go-restful"
@@ -46,6 +49,36 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	if err = validateFilter(sub.Filter); err != nil {
+		blog.Errorf("add subscription, but the filter is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "filter")})
+		return
+	}
+	if err = s.validateSink(sub); err != nil {
+		blog.Errorf("add subscription, but the sink is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "sink_config")})
+		return
+	}
+	if err = s.validateFileSinkOwner(ownerID, sub); err != nil {
+		blog.Errorf("add subscription, but the file sink is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "sink_config")})
+		return
+	}
+	if err = validateOrderPolicy(sub.OrderPolicy); err != nil {
+		blog.Errorf("add subscription, but the order policy is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "order_policy")})
+		return
+	}
+	if err = validateRetryPolicy(sub.RetryPolicy); err != nil {
+		blog.Errorf("add subscription, but the retry policy is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "retry_policy")})
+		return
+	}
+	if sub.Secret != "" && s.secretKey == "" {
+		blog.Errorf("add subscription, but the secret can not be saved, err: %v", errSecretKeyNotConfigured)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "secret")})
+		return
+	}
 	now := metadata.Now()
 	sub.Operator = util.GetUser(req.Request.Header)
 	if sub.TimeOut <= 0 {
@@ -56,6 +89,7 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 	}
 	sub.LastTime = now
 	sub.OwnerID = ownerID
+	sub.PreviousSecret, sub.PreviousSecretExpire = "", nil
 
 	sub.SubscriptionForm = strings.Replace(sub.SubscriptionForm, " ", "", -1)
 
@@ -76,6 +110,16 @@ func (s *Service) Subscribe(req *restful.Request, resp *restful.Response) {
 			return
 		}
 	} else {
+		if sub.Secret != "" {
+			secret := sub.Secret
+			sub.Secret = ""
+			if err := s.rotateSecret(sub, secret, 0); err != nil {
+				blog.Errorf("create subscription, but save the secret failed, err: %v", err)
+				resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeSecretFailed)})
+				return
+			}
+		}
+
 		nid, err := s.db.NextSequence(s.ctx, common.BKTableNameSubscription)
 		sub.SubscriptionID = int64(nid)
 		if nil != err {
@@ -163,7 +207,9 @@ func (s *Service) UnSubscribe(req *restful.Request, resp *restful.Response) {
 
 	s.cache.Del(types.EventCacheDistIDPrefix+subID,
 		types.EventCacheDistQueuePrefix+subID,
-		types.EventCacheDistDonePrefix+subID)
+		types.EventCacheDistRetryPrefix+subID,
+		types.EventCacheDistDonePrefix+subID,
+		types.EventCacheDistCallBackCountPrefix+subID)
 
 	mesg, _ := json.Marshal(&sub)
 	s.cache.Publish(types.EventCacheProcessChannel, "delete"+string(mesg))
@@ -189,6 +235,36 @@ func (s *Service) Rebook(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
 		return
 	}
+	if err = validateFilter(sub.Filter); err != nil {
+		blog.Errorf("update subscription, but the filter is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "filter")})
+		return
+	}
+	if err = s.validateSink(sub); err != nil {
+		blog.Errorf("update subscription, but the sink is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "sink_config")})
+		return
+	}
+	if err = s.validateFileSinkOwner(ownerID, sub); err != nil {
+		blog.Errorf("update subscription, but the file sink is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "sink_config")})
+		return
+	}
+	if err = validateOrderPolicy(sub.OrderPolicy); err != nil {
+		blog.Errorf("update subscription, but the order policy is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "order_policy")})
+		return
+	}
+	if err = validateRetryPolicy(sub.RetryPolicy); err != nil {
+		blog.Errorf("update subscription, but the retry policy is invalid, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "retry_policy")})
+		return
+	}
+	if sub.Secret != "" && s.secretKey == "" {
+		blog.Errorf("update subscription, but the secret can not be saved, err: %v", errSecretKeyNotConfigured)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Errorf(common.CCErrCommParamsInvalid, "secret")})
+		return
+	}
 	sub.Operator = util.GetUser(req.Request.Header)
 	if err = s.rebook(id, ownerID, sub); err != nil {
 		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrEventSubscribeUpdateFailed)})
@@ -217,6 +293,16 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 		}
 	}
 
+	// the secret is kept unless a new one is given, the changed secret is rotated with a grace period
+	secret := sub.Secret
+	sub.Secret, sub.PreviousSecret, sub.PreviousSecretExpire = oldsub.Secret, oldsub.PreviousSecret, oldsub.PreviousSecretExpire
+	if secret != "" {
+		if err := s.rotateSecret(sub, secret, defaultSecretGracePeriod); err != nil {
+			blog.Errorf("rotate the secret of subscription %d failed, err: %v", id, err)
+			return err
+		}
+	}
+
 	sub.SubscriptionID = oldsub.SubscriptionID
 	if sub.TimeOut <= 0 {
 		sub.TimeOut = 10
@@ -261,6 +347,114 @@ func (s *Service) rebook(id int64, ownerID string, sub *metadata.Subscription) e
 	return s.cache.Publish(types.EventCacheProcessChannel, "update"+string(mesg)).Err()
 }
 
+// validateSink check the sink config, and reject the sinks which can not deliver in this server
+func (s *Service) validateSink(sub *metadata.Subscription) error {
+	config := sub.SinkConfig
+	switch sub.GetSinkType() {
+	case metadata.SinkTypeHTTP:
+		return nil
+	case metadata.SinkTypeRedis:
+		if config["key"] == "" {
+			return fmt.Errorf("redis sink key is not set")
+		}
+		if mode := config["mode"]; mode != "" && mode != "list" && mode != "stream" {
+			return fmt.Errorf("unknown redis sink mode %s", mode)
+		}
+		if _, err := distribution.RedisSinkMaxLength(config); err != nil {
+			return err
+		}
+		return nil
+	case metadata.SinkTypeFile:
+		if s.sinkFileDir == "" {
+			return fmt.Errorf("file sink is disabled, the sink file directory is not configured")
+		}
+		if !distribution.ValidFileSinkName(config["name"]) {
+			return fmt.Errorf("invalid file sink name %s", config["name"])
+		}
+		return nil
+	case metadata.SinkTypeMQ:
+		if config["queue"] == "" || config["topic"] == "" {
+			return fmt.Errorf("message queue sink queue or topic is not set")
+		}
+		if !distribution.MessageQueueRegistered(config["queue"]) {
+			return fmt.Errorf("message queue %s is not registered", config["queue"])
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown sink type %s", sub.SinkType)
+	}
+}
+
+// validateFileSinkOwner reject the file sink name which is used by the subscriptions of the other owners,
+// the files are kept in the directories of the owners, but the same name is confusing for the operators.
+func (s *Service) validateFileSinkOwner(ownerID string, sub *metadata.Subscription) error {
+	if sub.GetSinkType() != metadata.SinkTypeFile {
+		return nil
+	}
+	if !distribution.ValidFileSinkName(ownerID) {
+		return fmt.Errorf("invalid owner %s of the file sink", ownerID)
+	}
+	cond := condition.CreateCondition()
+	cond.Field("sink_type").Eq(metadata.SinkTypeFile)
+	cond.Field("sink_config.name").Eq(sub.SinkConfig["name"])
+	cond.Field(common.BKOwnerIDField).NotEq(ownerID)
+	count, err := s.db.Table(common.BKTableNameSubscription).Find(cond.ToMapStr()).Count(s.ctx)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("file sink name %s is used by the other owners", sub.SinkConfig["name"])
+	}
+	return nil
+}
+
+// the limits of the retry policy, so that a failed event neither retries forever nor waits for too long
+const (
+	maxRetryAttempts    = 20
+	maxRetryIntervalMil = int64(time.Hour / time.Millisecond)
+)
+
+func validateRetryPolicy(policy *metadata.RetryPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	if policy.MaxAttempts < 0 || policy.MaxAttempts > maxRetryAttempts {
+		return fmt.Errorf("max_attempts %d is out of range [0, %d]", policy.MaxAttempts, maxRetryAttempts)
+	}
+	if policy.Interval < 0 || policy.Interval > maxRetryIntervalMil {
+		return fmt.Errorf("interval %d is out of range [0, %d]", policy.Interval, maxRetryIntervalMil)
+	}
+	if policy.MaxInterval < 0 || policy.MaxInterval > maxRetryIntervalMil {
+		return fmt.Errorf("max_interval %d is out of range [0, %d]", policy.MaxInterval, maxRetryIntervalMil)
+	}
+	if policy.MaxInterval > 0 && policy.Interval > policy.MaxInterval {
+		return fmt.Errorf("interval %d is larger than max_interval %d", policy.Interval, policy.MaxInterval)
+	}
+	if policy.Jitter < 0 || policy.Jitter > 1 {
+		return fmt.Errorf("jitter %v is out of range [0, 1]", policy.Jitter)
+	}
+	return nil
+}
+
+func validateOrderPolicy(policy *metadata.OrderPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.OnBlocked {
+	case "", metadata.OrderBlockedWait, metadata.OrderBlockedSkip:
+		return nil
+	default:
+		return fmt.Errorf("unknown on_blocked %s", policy.OnBlocked)
+	}
+}
+
+func validateFilter(filter *metadata.SubscriptionFilter) error {
+	if filter == nil {
+		return nil
+	}
+	return condition.ValidateConditionItems(filter.Condition)
+}
+
 func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 	pheader := req.Request.Header
 	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
@@ -302,6 +496,15 @@ func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	windows := statisticsWindows(dat.StatisticsWindows, s.deliveryRetention)
+	subscribeIDs := make([]int64, 0, len(results))
+	for index := range results {
+		subscribeIDs = append(subscribeIDs, results[index].SubscriptionID)
+	}
+	windowStats, err := s.deliveryStatistics(ownerID, subscribeIDs, windows)
+	if err != nil {
+		blog.Warnf("get delivery statistics of subscriptions %v failed, err: %v", subscribeIDs, err)
+	}
 	for index := range results {
 		val := s.cache.HGetAll(types.EventCacheDistCallBackCountPrefix + fmt.Sprint(results[index].SubscriptionID)).Val()
 		failue, err := strconv.ParseInt(val["failue"], 10, 64)
@@ -312,10 +515,15 @@ func (s *Service) Query(req *restful.Request, resp *restful.Response) {
 		if nil != err {
 			blog.Warnf("get total value error %s", err.Error())
 		}
+		// the blocked is not set until a event is blocked
+		blocked, _ := strconv.ParseInt(val["blocked"], 10, 64)
 		results[index].Statistics = &metadata.Statistics{
 			Total:   total,
 			Failure: failue,
+			Blocked: blocked,
 		}
+		results[index].Statistics.Windows = windowStats[results[index].SubscriptionID]
+		hideSecret(&results[index])
 	}
 
 	info := make(map[string]interface{})
@@ -0,0 +1,48 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/event_server/distribution"
+)
+
+func TestValidateSink(t *testing.T) {
+	distribution.RegisterMessageQueue("test_queue", distribution.NewMemoryQueue())
+
+	tests := []struct {
+		name    string
+		fileDir string
+		sub     metadata.Subscription
+		valid   bool
+	}{
+		{name: "http", sub: metadata.Subscription{}, valid: true},
+		{name: "file", fileDir: "/tmp/sinks",
+			sub: metadata.Subscription{SinkType: metadata.SinkTypeFile, SinkConfig: map[string]string{"name": "events"}}, valid: true},
+		{name: "file without directory",
+			sub: metadata.Subscription{SinkType: metadata.SinkTypeFile, SinkConfig: map[string]string{"name": "events"}}, valid: false},
+		{name: "registered queue",
+			sub: metadata.Subscription{SinkType: metadata.SinkTypeMQ, SinkConfig: map[string]string{"queue": "test_queue", "topic": "host"}}, valid: true},
+		{name: "unregistered queue",
+			sub: metadata.Subscription{SinkType: metadata.SinkTypeMQ, SinkConfig: map[string]string{"queue": "kafka", "topic": "host"}}, valid: false},
+	}
+	for _, tt := range tests {
+		s := &Service{sinkFileDir: tt.fileDir}
+		sub := tt.sub
+		if err := s.validateSink(&sub); (err == nil) != tt.valid {
+			t.Errorf("%s: validateSink() error = %v, want valid %v", tt.name, err, tt.valid)
+		}
+	}
+}
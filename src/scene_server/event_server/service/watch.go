@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+
+	"github.com/emicklei/go-restful"
+)
+
+const (
+	defaultWatchLimit = 200
+	maxWatchLimit     = 1000
+	maxWatchTimeout   = 60 * time.Second
+	watchInterval     = time.Second
+	// watchSettleTime the events saved in this time are not returned, because the
+	// events with smaller cursor may be saved later by the other event servers.
+	// it assumes the event history is inserted in less than it after its id is reserved,
+	// the event server gives up inserting it in time and raises the watermark over it instead.
+	watchSettleTime = time.Second
+)
+
+// Watch returns the events after the cursor, it waits for the new events until the timeout if there is none.
+// the client should resume from the returned cursor, and resync all the data if the cursor expired.
+func (s *Service) Watch(req *restful.Request, resp *restful.Response) {
+	pheader := req.Request.Header
+	defErr := s.CCErr.CreateDefaultCCErrorIf(util.GetLanguage(pheader))
+	ownerID := util.GetOwnerID(pheader)
+
+	dat := metadata.ParamEventWatch{}
+	if err := json.NewDecoder(req.Request.Body).Decode(&dat); err != nil {
+		blog.Errorf("watch event, but decode body failed, err: %v", err)
+		resp.WriteError(http.StatusBadRequest, &metadata.RespError{Msg: defErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+	if dat.Limit <= 0 {
+		dat.Limit = defaultWatchLimit
+	}
+	if dat.Limit > maxWatchLimit {
+		dat.Limit = maxWatchLimit
+	}
+	timeout := time.Duration(dat.Timeout) * time.Second
+	if timeout > maxWatchTimeout {
+		timeout = maxWatchTimeout
+	}
+
+	cursor, expired, err := s.locateCursor(ownerID, dat)
+	if err != nil {
+		blog.Errorf("watch event, but locate the cursor failed, input: %+v, err: %v", dat, err)
+		resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+		return
+	}
+	if expired {
+		resp.WriteError(http.StatusGone, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchCursorExpired)})
+		return
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		histories := make([]metadata.EventHistory, 0)
+		cond := condition.CreateCondition()
+		cond.Field(common.BKFieldID).Gt(cursor)
+		cond.Field(common.BKOwnerIDField).Eq(ownerID)
+		cond.Field(common.CreateTimeField).Lte(time.Now().Add(-watchSettleTime))
+		if len(dat.EventTypes) > 0 {
+			cond.Field("event_type").In(dat.EventTypes)
+		}
+		err := s.db.Table(common.BKTableNameEventHistory).Find(cond.ToMapStr()).Sort(common.BKFieldID).Limit(uint64(dat.Limit)).All(s.ctx, &histories)
+		if err != nil {
+			blog.Errorf("watch event, but find the event history failed, input: %+v, err: %v", dat, err)
+			resp.WriteError(http.StatusInternalServerError, &metadata.RespError{Msg: defErr.Error(common.CCErrEventWatchFailed)})
+			return
+		}
+
+		if len(histories) > 0 || !time.Now().Before(deadline) {
+			result := metadata.RspEventWatch{Cursor: cursor, Events: make([]metadata.WatchEvent, 0, len(histories))}
+			for _, history := range histories {
+				event := metadata.WatchEvent{Cursor: history.ID}
+				if err := json.Unmarshal([]byte(history.Raw), &event.EventInst); err != nil {
+					blog.Errorf("watch event, but unmarshal event %d failed, err: %v, raw: %s", history.EventID, err, history.Raw)
+				}
+				result.Events = append(result.Events, event)
+				result.Cursor = history.ID
+			}
+			resp.WriteEntity(metadata.NewSuccessResp(result))
+			return
+		}
+
+		select {
+		case <-req.Request.Context().Done():
+			return
+		case <-time.After(watchInterval):
+		}
+	}
+}
+
+// locateCursor returns the cursor to start watching, and whether the events after it are cleaned
+func (s *Service) locateCursor(ownerID string, dat metadata.ParamEventWatch) (int64, bool, error) {
+	switch {
+	case dat.Cursor > 0:
+		// the cleaner records the max id of the cleaned events of the owner before cleaning them,
+		// the events after the cursor are lost only if the cursor is before it.
+		watermark := new(metadata.EventWatermark)
+		cond := condition.CreateCondition().Field(common.BKOwnerIDField).Eq(ownerID)
+		err := s.db.Table(common.BKTableNameEventWatermark).Find(cond.ToMapStr()).One(s.ctx, watermark)
+		if err != nil && !s.db.IsNotFoundError(err) {
+			return dat.Cursor, false, err
+		}
+		if err != nil {
+			watermark = nil
+		}
+		return dat.Cursor, cursorExpired(dat.Cursor, watermark), nil
+
+	case dat.EventID > 0:
+		cond := condition.CreateCondition()
+		cond.Field("event_id").Eq(dat.EventID)
+		cond.Field(common.BKOwnerIDField).Eq(ownerID)
+		history, err := s.firstHistory(cond.ToMapStr(), common.BKFieldID)
+		if err != nil || history == nil {
+			return 0, history == nil, err
+		}
+		return history.ID, false, nil
+
+	default:
+		cond := condition.CreateCondition().Field(common.BKOwnerIDField).Eq(ownerID)
+		latest, err := s.firstHistory(cond.ToMapStr(), "-"+common.BKFieldID)
+		if err != nil || latest == nil {
+			return 0, false, err
+		}
+		return latest.ID, false, nil
+	}
+}
+
+// cursorExpired returns whether the events after the cursor are cleaned, nil watermark means none is cleaned
+func cursorExpired(cursor int64, watermark *metadata.EventWatermark) bool {
+	return watermark != nil && cursor < watermark.CleanedID
+}
+
+// firstHistory returns the first event history in the sort order, nil if there is none
+func (s *Service) firstHistory(cond mapstr.MapStr, sort string) (*metadata.EventHistory, error) {
+	histories := make([]metadata.EventHistory, 0)
+	err := s.db.Table(common.BKTableNameEventHistory).Find(cond).Sort(sort).Limit(1).All(s.ctx, &histories)
+	if err != nil || len(histories) == 0 {
+		return nil, err
+	}
+	return &histories[0], nil
+}
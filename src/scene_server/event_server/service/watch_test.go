@@ -0,0 +1,43 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package service
+
+import (
+	"testing"
+
+	"configcenter/src/common/metadata"
+)
+
+func TestCursorExpired(t *testing.T) {
+	tests := []struct {
+		name      string
+		cursor    int64
+		watermark *metadata.EventWatermark
+		want      bool
+	}{
+		// the events are never cleaned
+		{name: "no watermark", cursor: 10, watermark: nil, want: false},
+		// the client has read the event 100, then the cleaner removes the events up to 100
+		{name: "caught up after cleanup", cursor: 100, watermark: &metadata.EventWatermark{CleanedID: 100}, want: false},
+		// the events 1-100 are all removed, the client is at 50 and nothing survives to compare with
+		{name: "emptied table", cursor: 50, watermark: &metadata.EventWatermark{CleanedID: 100}, want: true},
+		{name: "behind the watermark", cursor: 79, watermark: &metadata.EventWatermark{CleanedID: 80}, want: true},
+		// the events of the other owners after the watermark may be cleaned, but not the events of this owner
+		{name: "ahead of the watermark", cursor: 120, watermark: &metadata.EventWatermark{CleanedID: 100}, want: false},
+	}
+	for _, tt := range tests {
+		if got := cursorExpired(tt.cursor, tt.watermark); got != tt.want {
+			t.Errorf("%s: cursorExpired(%d) = %v, want %v", tt.name, tt.cursor, got, tt.want)
+		}
+	}
+}
@@ -37,6 +37,17 @@ const (
 
 	EventCacheDistCallBackCountPrefix = common.BKCacheKeyV3Prefix + "event:dist_callback_"
 
+	// the keys to deliver the events of the same instance in order, which are suffixed by the subscription id and the order key
+	EventCacheDistOrderSeqPrefix     = common.BKCacheKeyV3Prefix + "event:dist_order_seq_"
+	EventCacheDistOrderDonePrefix    = common.BKCacheKeyV3Prefix + "event:dist_order_done_"
+	EventCacheDistOrderRunningPrefix = common.BKCacheKeyV3Prefix + "event:dist_order_running_"
+
+	// EventCacheDistRetryPrefix the sorted set of the dists to be sent again, scored by the time to send
+	EventCacheDistRetryPrefix = common.BKCacheKeyV3Prefix + "event:dist_retry_"
+
+	// EventCacheSinkPrefix the key prefix of the redis sinks
+	EventCacheSinkPrefix = common.BKCacheKeyV3Prefix + "event:sink:"
+
 	// EventCacheSubscribeformKey the key prefix in cache
 	EventCacheSubscribeformKey = common.BKCacheKeyV3Prefix + "event:subscribeform:"
 	EventCacheSubscribesKey    = common.BKCacheKeyV3Prefix + "event:subscribers"
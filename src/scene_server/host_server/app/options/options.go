@@ -44,4 +44,7 @@ func (s *ServerOption) AddFlags(fs *pflag.FlagSet) {
 type Config struct {
 	Redis redis.Config
 	Auth  authcenter.AuthConfig
+	// CloudFixtureDir the directory of the json files read by the fixture cloud accounts, which are used to test
+	// the cloud synchronization, the fixture accounts are disabled if it's empty.
+	CloudFixtureDir string
 }
@@ -31,6 +31,7 @@ import (
 	"configcenter/src/common/types"
 	"configcenter/src/common/version"
 	"configcenter/src/scene_server/host_server/app/options"
+	"configcenter/src/scene_server/host_server/cloud"
 	hostsvc "configcenter/src/scene_server/host_server/service"
 	"configcenter/src/storage/dal/redis"
 )
@@ -76,6 +77,14 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		return fmt.Errorf("new redis client failed, err: %s", err.Error())
 	}
 
+	if hostSrv.Config.CloudFixtureDir != "" {
+		if err := cloud.EnableFixture(hostSrv.Config.CloudFixtureDir); err != nil {
+			blog.Errorf("enable the fixture cloud accounts failed, err: %v", err)
+			return fmt.Errorf("enable the fixture cloud accounts failed, err: %v", err)
+		}
+		blog.Warnf("the fixture cloud accounts are enabled, they read the instances from %s", hostSrv.Config.CloudFixtureDir)
+	}
+
 	blog.Info("host server auth config is: %+v", hostSrv.Config.Auth)
 	authorizer, err := auth.NewAuthorize(nil, hostSrv.Config.Auth)
 	if err != nil {
@@ -118,6 +127,8 @@ func (h *HostServer) onHostConfigUpdate(previous, current cc.ProcessConfig) {
 	h.Config.Redis.Port = current.ConfigMap["redis.port"]
 	h.Config.Redis.MasterName = current.ConfigMap["redis.user"]
 
+	h.Config.CloudFixtureDir = current.ConfigMap["cloud.fixtureDir"]
+
 	h.Config.Auth, err = authcenter.ParseConfigFromKV("auth", current.ConfigMap)
 	if err != nil {
 		blog.Warnf("parse auth center config failed: %v", err)
@@ -0,0 +1,109 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"configcenter/src/common/mapstr"
+)
+
+// EnableFixture register the fixture accounts which read the instances from the json files in the directory,
+// they're used to test the synchronization, so they're only enabled by the config of the host server.
+func EnableFixture(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("the fixture directory %s is not a directory", dir)
+	}
+
+	Register(AccountTypeFixture, func(account Account) (Provider, error) {
+		return newFixtureProvider(dir, account)
+	})
+	return nil
+}
+
+// fixtureProvider reads the instances from the json array in the file of the endpoint, the instances are read again
+// in every synchronization, so that the fixture can be changed between them.
+type fixtureProvider struct {
+	path string
+}
+
+// newFixtureProvider create the provider of the file in the directory, the endpoint is the path of the file relative
+// to the directory, and it can not point out of the directory.
+func newFixtureProvider(dir string, account Account) (Provider, error) {
+	if account.Endpoint == "" {
+		return nil, fmt.Errorf("the endpoint of the fixture account is not set")
+	}
+	path := filepath.Join(dir, filepath.Clean(string(filepath.Separator)+account.Endpoint))
+	return &fixtureProvider{path: path}, nil
+}
+
+func (p *fixtureProvider) ListRegions(ctx context.Context) ([]string, error) {
+	instances, err := p.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	exists := make(map[string]bool)
+	for _, instance := range instances {
+		if !exists[instance.Region] {
+			exists[instance.Region] = true
+			result = append(result, instance.Region)
+		}
+	}
+	return result, nil
+}
+
+func (p *fixtureProvider) ListInstances(ctx context.Context, region string) ([]Instance, error) {
+	instances, err := p.read(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Instance, 0)
+	for _, instance := range instances {
+		if instance.Region == region {
+			result = append(result, instance)
+		}
+	}
+	return result, nil
+}
+
+func (p *fixtureProvider) HostAttributes(instance Instance) mapstr.MapStr {
+	return DefaultHostAttributes(instance)
+}
+
+func (p *fixtureProvider) read(ctx context.Context) ([]Instance, error) {
+	content, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0)
+	if err := json.Unmarshal(content, &instances); err != nil {
+		return nil, fmt.Errorf("decode the fixture %s failed, err: %v", filepath.Base(p.path), err)
+	}
+	return instances, nil
+}
@@ -0,0 +1,225 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import (
+	"strconv"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/validator"
+)
+
+// the fields of the instance which the mapping rules map from
+const (
+	FieldInstanceID   = "instance_id"
+	FieldInstanceName = "instance_name"
+	FieldRegion       = "region"
+	FieldZone         = "zone"
+	FieldState        = "state"
+	FieldInnerIP      = "inner_ip"
+	FieldOuterIP      = "outer_ip"
+	FieldOSName       = "os_name"
+	// FieldTagPrefix the prefix of the tag fields, eg: tag.owner is the value of the tag owner
+	FieldTagPrefix = "tag."
+)
+
+// the transforms of the value of the mapping rules
+const (
+	TransformTrim  = "trim"
+	TransformLower = "lower"
+	TransformUpper = "upper"
+)
+
+// reservedProperties the host attributes which are maintained by the synchronization itself
+var reservedProperties = []string{
+	common.BKHostIDField,
+	common.BKHostInnerIPField,
+	common.BKCloudIDField,
+	common.BKImportFrom,
+	common.BKOwnerIDField,
+}
+
+// mappableTypes the types of the host attributes which the attribute rules can map to
+var mappableTypes = []string{
+	common.FieldTypeSingleChar,
+	common.FieldTypeLongChar,
+	common.FieldTypeUser,
+	common.FieldTypeInt,
+	common.FieldTypeFloat,
+	common.FieldTypeEnum,
+	common.FieldTypeBool,
+}
+
+// InstanceField returns the value of the field of the instance, the ips are joined with comma
+func InstanceField(instance Instance, field string) (string, bool) {
+	switch field {
+	case FieldInstanceID:
+		return instance.InstanceID, true
+	case FieldInstanceName:
+		return instance.InstanceName, true
+	case FieldRegion:
+		return instance.Region, true
+	case FieldZone:
+		return instance.Zone, true
+	case FieldState:
+		return instance.State, true
+	case FieldInnerIP:
+		return strings.Join(instance.InnerIPs, ","), true
+	case FieldOuterIP:
+		return strings.Join(instance.OuterIPs, ","), true
+	case FieldOSName:
+		return instance.OSName, true
+	}
+
+	if strings.HasPrefix(field, FieldTagPrefix) && len(field) > len(FieldTagPrefix) {
+		return instance.Tags[strings.TrimPrefix(field, FieldTagPrefix)], true
+	}
+	return "", false
+}
+
+// ValidateRules checks the mapping rules with the host attributes, returns the invalid key if they're not valid.
+// the businesses and modules of the topology rules are checked by the caller.
+func ValidateRules(rules meta.CloudMappingRules, properties map[string]meta.Attribute) (string, bool) {
+	for _, rule := range rules.Attributes {
+		if _, ok := InstanceField(Instance{}, rule.Field); !ok {
+			return "bk_mapping_rules.attributes.field", false
+		}
+		property, exists := properties[rule.PropertyID]
+		if !exists {
+			return "bk_mapping_rules.attributes.bk_property_id", false
+		}
+		for _, reserved := range reservedProperties {
+			if rule.PropertyID == reserved {
+				return "bk_mapping_rules.attributes.bk_property_id", false
+			}
+		}
+		if !util.InStrArr(mappableTypes, property.PropertyType) {
+			return "bk_mapping_rules.attributes.bk_property_id", false
+		}
+		for _, transform := range rule.Transforms {
+			switch transform {
+			case TransformTrim, TransformLower, TransformUpper:
+			default:
+				return "bk_mapping_rules.attributes.transforms", false
+			}
+		}
+		for _, value := range rule.Mapping {
+			if _, ok := ConvertValue(value, property); !ok {
+				return "bk_mapping_rules.attributes.mapping", false
+			}
+		}
+		if rule.Default != "" {
+			if _, ok := ConvertValue(rule.Default, property); !ok {
+				return "bk_mapping_rules.attributes.default", false
+			}
+		}
+	}
+
+	for _, rule := range rules.Topology {
+		if rule.Tag == "" {
+			return "bk_mapping_rules.topology.tag", false
+		}
+		if rule.BizID <= 0 {
+			return "bk_mapping_rules.topology.bk_biz_id", false
+		}
+		if rule.ModuleID < 0 {
+			return "bk_mapping_rules.topology.bk_module_id", false
+		}
+	}
+	return "", true
+}
+
+// ConvertValue converts the mapped value to the type of the host attribute, the value of the enum
+// attribute is the id of the option
+func ConvertValue(value string, property meta.Attribute) (interface{}, bool) {
+	switch property.PropertyType {
+	case common.FieldTypeInt:
+		converted, err := strconv.ParseInt(value, 10, 64)
+		return converted, err == nil
+	case common.FieldTypeFloat:
+		converted, err := strconv.ParseFloat(value, 64)
+		return converted, err == nil
+	case common.FieldTypeBool:
+		converted, err := strconv.ParseBool(value)
+		return converted, err == nil
+	case common.FieldTypeEnum:
+		for _, option := range validator.ParseEnumOption(property.Option) {
+			if option.ID == value {
+				return value, true
+			}
+		}
+		return nil, false
+	}
+	return value, true
+}
+
+// MapInstance map the instance to the attributes of the host with the provider and the attribute rules,
+// the attribute rules override the attributes which the provider maps. the values are converted to the
+// types of the host attributes, the value which can't be converted is skipped.
+func MapInstance(provider Provider, instance Instance, rules meta.CloudMappingRules, properties map[string]meta.Attribute) mapstr.MapStr {
+	host := provider.HostAttributes(instance)
+	for _, rule := range rules.Attributes {
+		value, ok := InstanceField(instance, rule.Field)
+		if !ok {
+			continue
+		}
+		value = transformValue(value, rule)
+		if value == "" {
+			value = rule.Default
+		}
+		if value == "" {
+			continue
+		}
+		property, exists := properties[rule.PropertyID]
+		if !exists {
+			continue
+		}
+		converted, ok := ConvertValue(value, property)
+		if !ok {
+			continue
+		}
+		host[rule.PropertyID] = converted
+	}
+	return host
+}
+
+// MatchTopology returns the first topology rule which the tags of the instance matches
+func MatchTopology(instance Instance, rules meta.CloudMappingRules) (meta.CloudTopologyRule, bool) {
+	for _, rule := range rules.Topology {
+		if value, exists := instance.Tags[rule.Tag]; exists && value == rule.Value {
+			return rule, true
+		}
+	}
+	return meta.CloudTopologyRule{}, false
+}
+
+func transformValue(value string, rule meta.CloudAttributeRule) string {
+	for _, transform := range rule.Transforms {
+		switch transform {
+		case TransformTrim:
+			value = strings.TrimSpace(value)
+		case TransformLower:
+			value = strings.ToLower(value)
+		case TransformUpper:
+			value = strings.ToUpper(value)
+		}
+	}
+	if mapped, exists := rule.Mapping[value]; exists {
+		value = mapped
+	}
+	return value
+}
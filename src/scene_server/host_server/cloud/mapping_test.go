@@ -0,0 +1,92 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cloud
+
+import (
+	"testing"
+
+	"configcenter/src/common"
+	meta "configcenter/src/common/metadata"
+)
+
+func TestMapInstance(t *testing.T) {
+	rules := meta.CloudMappingRules{
+		Attributes: []meta.CloudAttributeRule{
+			{Field: "tag.owner", PropertyID: "operator", Transforms: []string{TransformTrim, TransformLower}},
+			{Field: "state", PropertyID: "bk_state", Mapping: map[string]string{"RUNNING": "running"}},
+			{Field: "tag.env", PropertyID: "bk_env", Default: "unknown"},
+			{Field: "os_name", PropertyID: common.BKOSNameField, Transforms: []string{TransformUpper}},
+			{Field: "tag.cpu", PropertyID: "bk_cpu", Default: "1"},
+		},
+		Topology: []meta.CloudTopologyRule{
+			{Tag: "biz", Value: "game", BizID: 2},
+			{Tag: "biz", Value: "web", BizID: 3, ModuleID: 10},
+		},
+	}
+	properties := map[string]meta.Attribute{
+		"operator": {PropertyID: "operator", PropertyType: common.FieldTypeUser},
+		"bk_state": {PropertyID: "bk_state", PropertyType: common.FieldTypeEnum,
+			Option: []interface{}{map[string]interface{}{"id": "running", "name": "running", "type": "text"}}},
+		"bk_env":             {PropertyID: "bk_env", PropertyType: common.FieldTypeSingleChar},
+		common.BKOSNameField: {PropertyID: common.BKOSNameField, PropertyType: common.FieldTypeSingleChar},
+		"bk_cpu":             {PropertyID: "bk_cpu", PropertyType: common.FieldTypeInt},
+		common.BKHostIDField: {PropertyID: common.BKHostIDField, PropertyType: common.FieldTypeInt},
+	}
+	if key, ok := ValidateRules(rules, properties); !ok {
+		t.Fatalf("the rules should be valid, but %s is invalid", key)
+	}
+
+	instance := Instance{
+		InstanceID: "ins-1",
+		State:      "RUNNING",
+		InnerIPs:   []string{"10.0.0.1"},
+		OSName:     "centos",
+		Tags:       map[string]string{"owner": " Admin ", "biz": "web", "cpu": "8"},
+	}
+	host := MapInstance(&fixtureProvider{}, instance, rules, properties)
+	if host["operator"] != "admin" || host["bk_state"] != "running" || host["bk_env"] != "unknown" {
+		t.Fatalf("the mapped attributes are wrong, got %v", host)
+	}
+	if host["bk_cpu"] != int64(8) {
+		t.Fatalf("the int attribute should be converted, got %#v", host["bk_cpu"])
+	}
+	if host[common.BKOSNameField] != "CENTOS" || host[common.BKHostInnerIPField] != "10.0.0.1" {
+		t.Fatalf("the provider attributes are wrong, got %v", host)
+	}
+
+	topology, matched := MatchTopology(instance, rules)
+	if !matched || topology.BizID != 3 || topology.ModuleID != 10 {
+		t.Fatalf("the instance should be matched to the web business, got %v", topology)
+	}
+	if _, matched := MatchTopology(Instance{}, rules); matched {
+		t.Fatal("the instance without tags should not be matched")
+	}
+
+	invalid := []meta.CloudMappingRules{
+		{Attributes: []meta.CloudAttributeRule{{Field: "unknown", PropertyID: "operator"}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "tag.", PropertyID: "operator"}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "region", PropertyID: common.BKHostInnerIPField}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "region", PropertyID: "operator", Transforms: []string{"reverse"}}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "region", PropertyID: "unknown"}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "region", PropertyID: "bk_date"}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "region", PropertyID: "bk_cpu", Default: "many"}}},
+		{Attributes: []meta.CloudAttributeRule{{Field: "state", PropertyID: "bk_state", Mapping: map[string]string{"STOPPED": "stopped"}}}},
+		{Topology: []meta.CloudTopologyRule{{Tag: "biz", Value: "game"}}},
+	}
+	properties["bk_date"] = meta.Attribute{PropertyID: "bk_date", PropertyType: common.FieldTypeDate}
+	for _, rules := range invalid {
+		if _, ok := ValidateRules(rules, properties); ok {
+			t.Fatalf("the rules %v should be invalid", rules)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+const (
+	// AccountTypeTencent the account of the tencent cloud
+	AccountTypeTencent = "tencent_cloud"
+	// AccountTypeFixture the account which reads the instances from a json file in the fixture directory,
+	// it's used to test the synchronization without a cloud account, and it's disabled unless the directory is set.
+	AccountTypeFixture = "fixture"
+)
+
+// Account the account of the cloud provider
+type Account struct {
+	Type      string
+	SecretID  string
+	SecretKey string
+	// Endpoint the address of the provider, it's optional for the public clouds, and it's limited to the domains of
+	// the cloud, such as the regional endpoints.
+	Endpoint string
+}
+
+// Instance the virtual machine of the cloud provider
+type Instance struct {
+	InstanceID   string            `json:"instance_id"`
+	InstanceName string            `json:"instance_name"`
+	Region       string            `json:"region"`
+	Zone         string            `json:"zone"`
+	State        string            `json:"state"`
+	InnerIPs     []string          `json:"inner_ips"`
+	OuterIPs     []string          `json:"outer_ips"`
+	OSName       string            `json:"os_name"`
+	Tags         map[string]string `json:"tags"`
+}
+
+// Provider the cloud provider which the hosts are synchronized from,
+// the new kind of cloud only needs to implement it and register the factory of it.
+type Provider interface {
+	// ListRegions returns the regions of the account
+	ListRegions(ctx context.Context) ([]string, error)
+	// ListInstances returns all the instances in the region
+	ListInstances(ctx context.Context, region string) ([]Instance, error)
+	// HostAttributes map the fields of the instance to the attributes of the host
+	HostAttributes(instance Instance) mapstr.MapStr
+}
+
+// Factory create the provider with the account
+type Factory func(account Account) (Provider, error)
+
+var (
+	lock      sync.RWMutex
+	factories = make(map[string]Factory)
+)
+
+// Register register the factory of the account type, the registered one is replaced
+func Register(accountType string, factory Factory) {
+	lock.Lock()
+	defer lock.Unlock()
+	factories[accountType] = factory
+}
+
+// NewProvider create the provider of the account
+func NewProvider(account Account) (Provider, error) {
+	lock.RLock()
+	factory, exists := factories[account.Type]
+	lock.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unsupported cloud account type %s", account.Type)
+	}
+	return factory(account)
+}
+
+// ListInstances returns the instances in all the regions of the provider
+func ListInstances(ctx context.Context, provider Provider) ([]Instance, error) {
+	regions, err := provider.ListRegions(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list regions failed, err: %v", err)
+	}
+
+	instances := make([]Instance, 0)
+	for _, region := range regions {
+		regionInstances, err := provider.ListInstances(ctx, region)
+		if err != nil {
+			return nil, fmt.Errorf("list instances in region %s failed, err: %v", region, err)
+		}
+		instances = append(instances, regionInstances...)
+	}
+	return instances, nil
+}
+
+// DefaultHostAttributes the attributes of the host which most of the providers have,
+// the first inner and outer ip are the ips of the host.
+func DefaultHostAttributes(instance Instance) mapstr.MapStr {
+	host := mapstr.MapStr{
+		common.BKHostCloudRegionField: instance.Region,
+		common.BKHostInnerIPField:     "",
+		common.BKHostOuterIPField:     "",
+		common.BKOSNameField:          instance.OSName,
+	}
+	if len(instance.InnerIPs) > 0 {
+		host[common.BKHostInnerIPField] = instance.InnerIPs[0]
+	}
+	if len(instance.OuterIPs) > 0 {
+		host[common.BKHostOuterIPField] = instance.OuterIPs[0]
+	}
+	return host
+}
@@ -0,0 +1,105 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cloud
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"configcenter/src/common"
+)
+
+const fixture = `[
+	{"instance_id": "ins-1", "region": "ap-guangzhou", "inner_ips": ["10.0.0.1"], "outer_ips": ["1.1.1.1"], "os_name": "centos"},
+	{"instance_id": "ins-2", "region": "ap-shanghai", "inner_ips": ["10.0.0.2"], "os_name": "ubuntu"},
+	{"instance_id": "ins-3", "region": "ap-guangzhou", "inner_ips": ["10.0.0.3", "10.0.1.3"]}
+]`
+
+func TestFixtureProvider(t *testing.T) {
+	if _, err := NewProvider(Account{Type: AccountTypeFixture, Endpoint: "fixture.json"}); err == nil {
+		t.Fatal("the fixture account should be disabled unless the directory is set")
+	}
+
+	dir, err := ioutil.TempDir("", "cloud_fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "fixture.json"), []byte(fixture), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnableFixture(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	// the endpoint is confined to the directory
+	for _, endpoint := range []string{"fixture.json", "/fixture.json", "../" + filepath.Base(dir) + "/../fixture.json"} {
+		provider, err := NewProvider(Account{Type: AccountTypeFixture, Endpoint: endpoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		regions, err := provider.ListRegions(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(regions) != 2 || regions[0] != "ap-guangzhou" || regions[1] != "ap-shanghai" {
+			t.Fatalf("the regions of %s should be guangzhou and shanghai, but got %v", endpoint, regions)
+		}
+
+		instances, err := ListInstances(context.Background(), provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(instances) != 3 {
+			t.Fatalf("there should be 3 instances of %s, but got %d", endpoint, len(instances))
+		}
+
+		host := provider.HostAttributes(instances[1])
+		if host[common.BKHostInnerIPField] != "10.0.0.3" || host[common.BKHostOuterIPField] != "" {
+			t.Fatalf("the ips of the instance 3 are wrong, got %v", host)
+		}
+	}
+
+	for _, endpoint := range []string{"../etc/passwd", "http://127.0.0.1/fixture.json"} {
+		provider, err := NewProvider(Account{Type: AccountTypeFixture, Endpoint: endpoint})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := provider.ListRegions(context.Background()); err == nil {
+			t.Fatalf("the endpoint %s out of the fixture directory should be failed", endpoint)
+		}
+	}
+
+	if _, err := NewProvider(Account{Type: "unknown"}); err == nil {
+		t.Fatal("the unknown account type should be failed")
+	}
+}
+
+func TestValidTencentEndpoint(t *testing.T) {
+	valid := []string{"cvm.tencentcloudapi.com", "cvm.ap-guangzhou.tencentcloudapi.com"}
+	invalid := []string{".tencentcloudapi.com", "tencentcloudapi.com", "evil.com", "cvm.tencentcloudapi.com.evil.com",
+		"127.0.0.1:8080", "evil.com/.tencentcloudapi.com", "evil.com#.tencentcloudapi.com"}
+	for _, endpoint := range valid {
+		if !validTencentEndpoint(endpoint) {
+			t.Errorf("the endpoint %s should be valid", endpoint)
+		}
+	}
+	for _, endpoint := range invalid {
+		if validTencentEndpoint(endpoint) {
+			t.Errorf("the endpoint %s should be invalid", endpoint)
+		}
+	}
+}
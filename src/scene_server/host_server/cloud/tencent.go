@@ -0,0 +1,166 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cloud
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+)
+
+func init() {
+	Register(AccountTypeTencent, newTencentProvider)
+}
+
+// tencentPageSize the max instances of a page of the tencent cloud
+const tencentPageSize = 100
+
+// tencentEndpointDomain the domain of the endpoints of the tencent cloud api, the endpoint of the account can only be
+// the host name in it, such as the regional endpoint cvm.ap-guangzhou.tencentcloudapi.com.
+const tencentEndpointDomain = ".tencentcloudapi.com"
+
+type tencentProvider struct {
+	credential *com.Credential
+	profile    *profile.ClientProfile
+}
+
+func newTencentProvider(account Account) (Provider, error) {
+	cpf := profile.NewClientProfile()
+	cpf.HttpProfile.ReqMethod = common.BKHttpGet
+	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
+	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
+	if account.Endpoint != "" {
+		if !validTencentEndpoint(account.Endpoint) {
+			return nil, fmt.Errorf("the endpoint %s is not the one of the tencent cloud api", account.Endpoint)
+		}
+		cpf.HttpProfile.Endpoint = account.Endpoint
+	}
+	cpf.SignMethod = common.TencentCloudSignMethod
+
+	return &tencentProvider{
+		credential: com.NewCredential(account.SecretID, account.SecretKey),
+		profile:    cpf,
+	}, nil
+}
+
+func (p *tencentProvider) ListRegions(ctx context.Context) ([]string, error) {
+	// the regions can be listed in any region
+	client, err := cvm.NewClient(p.credential, regions.Guangzhou, p.profile)
+	if err != nil {
+		return nil, err
+	}
+	response, err := client.DescribeRegions(cvm.NewDescribeRegionsRequest())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0)
+	if response.Response == nil {
+		return result, nil
+	}
+	for _, region := range response.Response.RegionSet {
+		if region != nil && region.Region != nil {
+			result = append(result, *region.Region)
+		}
+	}
+	return result, nil
+}
+
+func (p *tencentProvider) ListInstances(ctx context.Context, region string) ([]Instance, error) {
+	client, err := cvm.NewClient(p.credential, region, p.profile)
+	if err != nil {
+		return nil, err
+	}
+
+	instances := make([]Instance, 0)
+	for offset := int64(0); ; offset += tencentPageSize {
+		request := cvm.NewDescribeInstancesRequest()
+		request.Offset = com.Int64Ptr(offset)
+		request.Limit = com.Int64Ptr(tencentPageSize)
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			return nil, err
+		}
+		if response.Response == nil {
+			break
+		}
+
+		for _, inst := range response.Response.InstanceSet {
+			if inst != nil {
+				instances = append(instances, tencentInstance(region, inst))
+			}
+		}
+		if len(response.Response.InstanceSet) < tencentPageSize {
+			break
+		}
+	}
+	return instances, nil
+}
+
+func (p *tencentProvider) HostAttributes(instance Instance) mapstr.MapStr {
+	return DefaultHostAttributes(instance)
+}
+
+func tencentInstance(region string, inst *cvm.Instance) Instance {
+	instance := Instance{
+		InstanceID:   stringValue(inst.InstanceId),
+		InstanceName: stringValue(inst.InstanceName),
+		Region:       region,
+		State:        stringValue(inst.InstanceState),
+		InnerIPs:     stringValues(inst.PrivateIpAddresses),
+		OuterIPs:     stringValues(inst.PublicIpAddresses),
+		OSName:       stringValue(inst.OsName),
+		Tags:         make(map[string]string),
+	}
+	if inst.Placement != nil {
+		instance.Zone = stringValue(inst.Placement.Zone)
+	}
+	for _, tag := range inst.Tags {
+		if tag != nil && tag.Key != nil {
+			instance.Tags[*tag.Key] = stringValue(tag.Value)
+		}
+	}
+	return instance
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+func stringValues(values []*string) []string {
+	result := make([]string, 0)
+	for _, value := range values {
+		if value != nil {
+			result = append(result, *value)
+		}
+	}
+	return result
+}
+
+// validTencentEndpoint check the endpoint is the host name in the domain of the tencent cloud api
+func validTencentEndpoint(endpoint string) bool {
+	if strings.ContainsAny(endpoint, ":/@?#") {
+		return false
+	}
+	return strings.HasSuffix(endpoint, tencentEndpointDomain) && len(endpoint) > len(tencentEndpointDomain)
+}
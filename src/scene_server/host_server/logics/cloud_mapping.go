@@ -0,0 +1,275 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/cloud"
+	hutil "configcenter/src/scene_server/host_server/util"
+)
+
+// cloudHostTarget the business and module which the new cloud host is added to
+type cloudHostTarget struct {
+	bizID    int64
+	moduleID int64
+}
+
+// cloudHostData returns the host attributes of the cloud host, the fields which are not host attributes,
+// such as the fields of the resource confirm and the topology, are removed.
+func cloudHostData(cloudHost mapstr.MapStr, properties map[string]bool) mapstr.MapStr {
+	data := mapstr.MapStr{}
+	for key, value := range cloudHost {
+		if properties[key] {
+			data[key] = value
+		}
+	}
+	return data
+}
+
+// cloudHostChanged checks whether the attributes of the cloud host differ from the exist host
+func cloudHostChanged(existHost, data mapstr.MapStr) bool {
+	for key, value := range data {
+		existValue := existHost[key]
+		if existValue == nil {
+			existValue = ""
+		}
+		if fmt.Sprint(existValue) != fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloudHostTopology returns the target of the cloud host which the topology rules matched
+func cloudHostTopology(cloudHost mapstr.MapStr) cloudHostTarget {
+	target := cloudHostTarget{}
+	if bizID, err := util.GetInt64ByInterface(cloudHost[common.BKAppIDField]); err == nil {
+		target.bizID = bizID
+	}
+	if moduleID, err := util.GetInt64ByInterface(cloudHost[common.BKModuleIDField]); err == nil {
+		target.moduleID = moduleID
+	}
+	return target
+}
+
+// cloudHostProperties returns the host attributes which the cloud synchronization can set
+func (lgc *Logics) cloudHostProperties(ctx context.Context) (map[string]bool, error) {
+	headers, err := lgc.GetHostAttributes(ctx, util.GetOwnerID(lgc.header), nil)
+	if err != nil {
+		blog.Errorf("get host attributes failed, err: %v, rid: %s", err, lgc.rid)
+		return nil, err
+	}
+
+	properties := make(map[string]bool)
+	for _, header := range headers {
+		properties[header.PropertyID] = true
+	}
+	for _, reserved := range []string{common.BKHostIDField, common.BKCloudIDField, common.BKImportFrom, common.BKOwnerIDField} {
+		delete(properties, reserved)
+	}
+	return properties, nil
+}
+
+// cloudMappingProperties returns the host attributes which the attribute rules map to
+func (lgc *Logics) cloudMappingProperties(ctx context.Context) (map[string]meta.Attribute, error) {
+	query := &meta.QueryCondition{
+		Condition: hutil.NewOperation().WithObjID(common.BKInnerObjIDHost).WithOwnerID(lgc.ownerID).WithAttrComm().MapStr(),
+	}
+	result, err := lgc.CoreAPI.CoreService().Model().ReadModelAttr(ctx, lgc.header, common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("get host attributes http do error, err: %v, input: %+v, rid: %s", err, query, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("get host attributes http response error, err code: %d, err msg: %s, input: %+v, rid: %s", result.Code, result.ErrMsg, query, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	properties := make(map[string]meta.Attribute)
+	for _, property := range result.Data.Info {
+		properties[property.PropertyID] = property
+	}
+	return properties, nil
+}
+
+// ValidateCloudMappingRules checks the mapping rules with the host attributes, the businesses and
+// the modules of the topology rules, returns the invalid key if they're not valid
+func (lgc *Logics) ValidateCloudMappingRules(ctx context.Context, rules meta.CloudMappingRules) (string, bool, error) {
+	properties, err := lgc.cloudMappingProperties(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	if key, ok := cloud.ValidateRules(rules, properties); !ok {
+		return key, false, nil
+	}
+
+	for _, rule := range rules.Topology {
+		biz, err := lgc.GetSingleApp(ctx, mapstr.MapStr{common.BKAppIDField: rule.BizID})
+		if err != nil {
+			blog.Errorf("get the business %d of the topology rule failed, err: %v, rid: %s", rule.BizID, err, lgc.rid)
+			return "", false, err
+		}
+		if biz == nil {
+			return "bk_mapping_rules.topology.bk_biz_id", false, nil
+		}
+		if rule.ModuleID == 0 {
+			continue
+		}
+		modules, err := lgc.GetNormalModuleByModuleID(ctx, rule.BizID, rule.ModuleID)
+		if err != nil {
+			blog.Errorf("get the module %d of the topology rule failed, err: %v, rid: %s", rule.ModuleID, err, lgc.rid)
+			return "", false, err
+		}
+		if len(modules) == 0 {
+			return "bk_mapping_rules.topology.bk_module_id", false, nil
+		}
+	}
+	return "", true, nil
+}
+
+// cloudHostModule returns the business and module which the new cloud host is added to,
+// it's the idle module of the default business if the target is not specified.
+func (lgc *Logics) cloudHostModule(ctx context.Context, target cloudHostTarget) (int64, int64, errors.CCError) {
+	bizID := target.bizID
+	if bizID == 0 {
+		var err errors.CCError
+		bizID, err = lgc.GetDefaultAppIDWithSupplier(ctx)
+		if err != nil {
+			blog.Errorf("get default business failed, err: %v, rid: %s", err, lgc.rid)
+			return 0, 0, err
+		}
+	}
+
+	cond := hutil.NewOperation().WithAppID(bizID).Data()
+	if target.bizID == 0 || target.moduleID == 0 {
+		cond[common.BKModuleNameField] = common.DefaultResModuleName
+		cond[common.BKDefaultField] = common.DefaultResModuleFlag
+	} else {
+		cond[common.BKModuleIDField] = target.moduleID
+	}
+	moduleID, err := lgc.GetResoulePoolModuleID(ctx, cond)
+	if err != nil {
+		blog.Errorf("get the module of cloud hosts failed, cond: %v, err: %v, rid: %s", cond, err, lgc.rid)
+		return 0, 0, err
+	}
+	return bizID, moduleID, nil
+}
+
+// mapCloudInstances map the instances of the cloud account to the hosts, the hosts matched by the
+// topology rules have the business and module id fields, which are not the attributes of the host.
+func (lgc *Logics) mapCloudInstances(ctx context.Context, account cloud.Account, rules meta.CloudMappingRules) ([]cloud.Instance, []mapstr.MapStr, error) {
+	provider, err := cloud.NewProvider(account)
+	if err != nil {
+		blog.Errorf("create the cloud provider of %s failed, err: %v, rid: %s", account.Type, err, lgc.rid)
+		return nil, nil, err
+	}
+
+	instances, err := cloud.ListInstances(ctx, provider)
+	if err != nil {
+		blog.Errorf("obtain cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+		return nil, nil, err
+	}
+
+	properties, err := lgc.cloudMappingProperties(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mapped := make([]cloud.Instance, 0)
+	hosts := make([]mapstr.MapStr, 0)
+	for _, instance := range instances {
+		if len(instance.InnerIPs) == 0 {
+			blog.V(3).Infof("skip the cloud instance %s without inner ip, rid: %s", instance.InstanceID, lgc.rid)
+			continue
+		}
+		host := cloud.MapInstance(provider, instance, rules, properties)
+		if topology, matched := cloud.MatchTopology(instance, rules); matched {
+			host[common.BKAppIDField] = topology.BizID
+			host[common.BKModuleIDField] = topology.ModuleID
+		}
+		mapped = append(mapped, instance)
+		hosts = append(hosts, host)
+	}
+	return mapped, hosts, nil
+}
+
+// PreviewCloudMapping map the current instances of the cloud account with the rules without saving them
+func (lgc *Logics) PreviewCloudMapping(ctx context.Context, account cloud.Account, rules meta.CloudMappingRules) ([]meta.CloudMappingPreview, error) {
+	instances, hosts, err := lgc.mapCloudInstances(ctx, account, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	properties, err := lgc.cloudHostProperties(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]string, 0)
+	for _, host := range hosts {
+		ips = append(ips, util.GetStrByInterface(host[common.BKHostInnerIPField]))
+	}
+	existIPs, err := lgc.existHostInnerIPs(ctx, ips)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]meta.CloudMappingPreview, 0)
+	for index, host := range hosts {
+		target := cloudHostTopology(host)
+		previews = append(previews, meta.CloudMappingPreview{
+			InstanceID: instances[index].InstanceID,
+			Tags:       instances[index].Tags,
+			Host:       cloudHostData(host, properties),
+			BizID:      target.bizID,
+			ModuleID:   target.moduleID,
+			Exists:     existIPs[util.GetStrByInterface(host[common.BKHostInnerIPField])],
+		})
+	}
+	return previews, nil
+}
+
+// existHostInnerIPs returns the inner ips which the hosts in cmdb have
+func (lgc *Logics) existHostInnerIPs(ctx context.Context, ips []string) (map[string]bool, error) {
+	exists := make(map[string]bool)
+	if len(ips) == 0 {
+		return exists, nil
+	}
+
+	query := &meta.QueryCondition{
+		Fields:    []string{common.BKHostInnerIPField},
+		Condition: mapstr.MapStr{common.BKHostInnerIPField: mapstr.MapStr{common.BKDBIN: ips}},
+	}
+	result, err := lgc.CoreAPI.CoreService().Instance().ReadInstance(ctx, lgc.header, common.BKInnerObjIDHost, query)
+	if err != nil {
+		blog.Errorf("search hosts by inner ips http do error, err: %v, rid: %s", err, lgc.rid)
+		return nil, lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !result.Result {
+		blog.Errorf("search hosts by inner ips http reply error, code: %d, msg: %s, rid: %s", result.Code, result.ErrMsg, lgc.rid)
+		return nil, lgc.ccErr.New(result.Code, result.ErrMsg)
+	}
+
+	for _, host := range result.Data.Info {
+		exists[util.GetStrByInterface(host[common.BKHostInnerIPField])] = true
+	}
+	return exists, nil
+}
@@ -23,18 +23,12 @@ import (
 	"sync"
 	"time"
 
-	com "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/regions"
-	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
-
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
 	"configcenter/src/common/util"
-	hutil "configcenter/src/scene_server/host_server/util"
+	"configcenter/src/scene_server/host_server/cloud"
 )
 
 var (
@@ -462,6 +456,14 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 		lgc.CloudSyncHistory(ctx, taskInfo.TaskID, startTime, cloudHistory)
 	}()
 
+	// the task may be changed after the sync timer started
+	latestTask, err := lgc.latestCloudTask(ctx, taskInfo)
+	if err != nil {
+		errOrigin = err
+		return err
+	}
+	taskInfo = latestTask
+
 	// obtain the hosts from cc_HostBase
 	body := new(meta.HostCommonSearch)
 	host, err := lgc.SearchHost(ctx, body, false)
@@ -472,6 +474,8 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 	}
 
 	existHostList := make([]string, 0)
+	existHostIDs := make(map[string]int64)
+	existHosts := make(map[string]mapstr.MapStr)
 	for i := 0; i < host.Count; i++ {
 		hostInfo, err := mapstr.NewFromInterface(host.Info[i]["host"])
 		if err != nil {
@@ -487,28 +491,40 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 			return err
 		}
 
+		hostID, err := hostInfo.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("get hostID failed with err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
+		}
+
 		existHostList = append(existHostList, ip)
+		existHostIDs[ip] = hostID
+		existHosts[ip] = hostInfo
 	}
 
-	// obtain hosts from TencentCloud needs secretID and secretKey
-	decodeBytes, errDecode := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
+	// obtain hosts from the cloud provider of the account
+	account, errDecode := cloudAccount(taskInfo)
 	if errDecode != nil {
 		blog.Errorf("Base64 decode secretKey failed, rid: %s", lgc.rid)
 		errOrigin = errDecode
 		return errDecode
 	}
-	secretKey := string(decodeBytes)
-
-	secretID := taskInfo.SecretID
 
-	// ObtainCloudHosts obtain cloud hosts
-	cloudHostInfo, err := lgc.ObtainCloudHosts(ctx, secretID, secretKey)
+	// obtain the cloud instances and map them to the hosts
+	cloudInstances, cloudHostInfo, err := lgc.mapCloudInstances(ctx, account, taskInfo.MappingRules)
 	if err != nil {
 		blog.Errorf("obtain cloud hosts failed with err: %v, rid: %s", err, lgc.rid)
 		errOrigin = err
 		return err
 	}
 
+	properties, err := lgc.cloudHostProperties(ctx)
+	if err != nil {
+		errOrigin = err
+		return err
+	}
+
 	// pick out the new add cloud hosts
 	newAddHost := make([]string, 0)
 	newCloudHost := make([]mapstr.MapStr, 0)
@@ -528,61 +544,38 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 	for _, hostInfo := range cloudHostInfo {
 		newHostInnerip, ok := hostInfo[common.BKHostInnerIPField].(string)
 		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
+			blog.Errorf("interface convert to string failed, rid: %s", lgc.rid)
 			continue
 		}
-		newHostOuterip, ok := hostInfo[common.BKHostOuterIPField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
+		existHostInfo, exists := existHosts[newHostInnerip]
+		if !exists {
 			continue
 		}
-		newHostOsname, ok := hostInfo[common.BKOSNameField].(string)
-		if !ok {
-			blog.Errorf("interface convert to string failed, err: %v, rid: %s", err, lgc.rid)
-			continue
+		if cloudHostChanged(existHostInfo, cloudHostData(hostInfo, properties)) {
+			hostInfo[common.BKHostIDField] = existHostIDs[newHostInnerip]
+			cloudHostAttr = append(cloudHostAttr, hostInfo)
 		}
+	}
 
-		for i := 0; i < host.Count; i++ {
-			existHostInfo, err := mapstr.NewFromInterface(host.Info[i]["host"])
-			if err != nil {
-				blog.Errorf("get hostInfo failed with err: %v, rid: %s", err, lgc.rid)
-				errOrigin = err
-				return err
-			}
-
-			existHostIp, ok := existHostInfo.String(common.BKHostInnerIPField)
-			if ok != nil {
-				blog.Errorf("get hostIp failed with err: %v, rid: %s", ok, lgc.rid)
-				errOrigin = ok
-				break
-			}
-			existHostOsname, osOk := existHostInfo.String(common.BKOSNameField)
-			if osOk != nil {
-				blog.Errorf("get os name failed with err: %v, rid: %s", ok, lgc.rid)
-				errOrigin = osOk
-				break
-			}
-
-			existHostOuterip, ipOk := existHostInfo.String(common.BKHostOuterIPField)
-			if ipOk != nil {
-				blog.Errorf("get outerip failed with, rid: %s", lgc.rid)
-				errOrigin = ipOk
-				break
-			}
-
-			existHostID, ok := existHostInfo.String(common.BKHostIDField)
-			if ok != nil {
-				blog.Errorf("get hostID failed, rid: %s", lgc.rid)
-				errOrigin = ok
-				break
-			}
+	// handle the hosts whose cloud instances are terminated since the last sync
+	syncedInstances := cloudSyncedInstances(cloudInstances, cloudHostInfo)
+	if skipTerminatedReconcile(taskInfo.SyncedInstances, syncedInstances) {
+		blog.Warnf("cloud task %d obtains no cloud instances, skip the terminated hosts reconciliation, rid: %s", taskInfo.TaskID, lgc.rid)
+	} else {
+		terminated, err := lgc.ReconcileTerminatedHosts(ctx, taskInfo, syncedInstances, existHostIDs)
+		cloudHistory.Terminated = len(terminated)
+		cloudHistory.TerminatedHosts = terminated
+		if err != nil {
+			blog.Errorf("reconcile terminated cloud hosts failed, err: %v, rid: %s", err, lgc.rid)
+			errOrigin = err
+			return err
+		}
 
-			if existHostIp == newHostInnerip {
-				if existHostOsname != newHostOsname || existHostOuterip != newHostOuterip {
-					hostInfo[common.BKHostIDField] = existHostID
-					cloudHostAttr = append(cloudHostAttr, hostInfo)
-				}
-			}
+		syncedData := mapstr.MapStr{common.BKCloudTaskID: taskInfo.TaskID, common.BKSyncedInstances: syncedInstances}
+		if _, err := lgc.CoreAPI.HostController().Cloud().UpdateCloudTask(ctx, lgc.header, syncedData); err != nil {
+			blog.Errorf("update the synced instances of task %d failed, err: %v, rid: %s", taskInfo.TaskID, err, lgc.rid)
+			errOrigin = err
+			return err
 		}
 	}
 
@@ -625,30 +618,9 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 		blog.V(5).Info("attr chang")
 
 		for _, host := range cloudHostAttr {
-			resourceConfirm := mapstr.MapStr{}
+			resourceConfirm := cloudHostData(host, properties)
 			resourceConfirm["bk_obj_id"] = taskInfo.ObjID
-			innerIp, err := host.String(common.BKHostInnerIPField)
-			if err != nil {
-				blog.Debug("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = err
-				return err
-			}
-			outerIp, errOut := host.String(common.BKHostOuterIPField)
-			if errOut != nil {
-				blog.Error("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = errOut
-				return errOut
-			}
-			osName, err := host.String(common.BKOSNameField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, rid: %s", lgc.rid)
-				errOrigin = err
-				return err
-			}
-
-			resourceConfirm[common.BKHostInnerIPField] = innerIp
-			resourceConfirm[common.BKHostOuterIPField] = outerIp
-			resourceConfirm[common.BKOSNameField] = osName
+			resourceConfirm[common.BKHostIDField] = host[common.BKHostIDField]
 			resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
 			resourceConfirm[common.BKAttrConfirm] = attrConfirm
 			resourceConfirm[common.BKCloudConfirm] = false
@@ -671,52 +643,52 @@ func (lgc *Logics) ExecSync(ctx context.Context, taskInfo meta.CloudTaskInfo) er
 	return nil
 }
 
+// AddCloudHosts add the new cloud hosts to the business modules which the topology rules matched,
+// the hosts which are not matched are added to the resource pool.
 func (lgc *Logics) AddCloudHosts(ctx context.Context, newCloudHost []mapstr.MapStr) error {
 	hostList := new(meta.HostList)
-	hostInfoMap := make(map[int64]map[string]interface{}, 0)
-	appID := hostList.ApplicationID
-
-	if appID == 0 {
-		// get default app id
-		var err error
-		appID, err = lgc.GetDefaultAppIDWithSupplier(ctx)
-		if err != nil {
-			blog.Errorf("add host, but get default appid failed, err: %v, rid: %s", err, lgc.rid)
-			return err
-		}
-	}
-
-	cond := hutil.NewOperation().WithModuleName(common.DefaultResModuleName).WithAppID(appID).Data()
-	cond[common.BKDefaultField] = common.DefaultResModuleFlag
-	moduleID, err := lgc.GetResoulePoolModuleID(ctx, cond)
+	properties, err := lgc.cloudHostProperties(ctx)
 	if err != nil {
-		blog.Errorf("add host, but get module id failed, err: %s, rid: %s", err.Error(), lgc.rid)
 		return err
 	}
 
-	blog.V(5).Info("resource confirm add new hosts")
+	targetHosts := make(map[cloudHostTarget]map[int64]map[string]interface{})
 	for index, hostInfo := range newCloudHost {
-		if _, ok := hostInfoMap[int64(index)]; !ok {
-			hostInfoMap[int64(index)] = make(map[string]interface{}, 0)
+		target := cloudHostTopology(hostInfo)
+		if _, ok := targetHosts[target]; !ok {
+			targetHosts[target] = make(map[int64]map[string]interface{})
 		}
 
-		hostInfoMap[int64(index)][common.BKHostInnerIPField] = hostInfo[common.BKHostInnerIPField]
-		hostInfoMap[int64(index)][common.BKHostOuterIPField] = hostInfo[common.BKHostOuterIPField]
-		hostInfoMap[int64(index)][common.BKOSNameField] = hostInfo[common.BKOSNameField]
-		hostInfoMap[int64(index)][common.BKImportFrom] = "3"
-		hostInfoMap[int64(index)][common.BKCloudIDField] = 1
+		host := cloudHostData(hostInfo, properties)
+		host[common.BKImportFrom] = "3"
+		host[common.BKCloudIDField] = 1
+		targetHosts[target][int64(index)] = host
 	}
 
-	hostIDs, succ, updateErrRow, errRow, ok := lgc.AddHost(ctx, appID, []int64{moduleID}, util.GetOwnerID(lgc.header), hostInfoMap, hostList.InputType)
-	if ok != nil {
-		blog.Errorf("add host failed, hostIDs: %+v, succ: %v, update: %v, err: %v, %v, rid: %s", hostIDs, succ, updateErrRow, ok, errRow, lgc.rid)
-		return ok
+	blog.V(5).Info("resource confirm add new hosts")
+	for target, hostInfoMap := range targetHosts {
+		appID, moduleID, err := lgc.cloudHostModule(ctx, target)
+		if err != nil {
+			blog.Errorf("add host, but get module id failed, err: %s, rid: %s", err.Error(), lgc.rid)
+			return err
+		}
+
+		hostIDs, succ, updateErrRow, errRow, ok := lgc.AddHost(ctx, appID, []int64{moduleID}, util.GetOwnerID(lgc.header), hostInfoMap, hostList.InputType)
+		if ok != nil {
+			blog.Errorf("add host failed, hostIDs: %+v, succ: %v, update: %v, err: %v, %v, rid: %s", hostIDs, succ, updateErrRow, ok, errRow, lgc.rid)
+			return ok
+		}
 	}
 
 	return nil
 }
 
 func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.MapStr) error {
+	properties, err := lgc.cloudHostProperties(ctx)
+	if err != nil {
+		return err
+	}
+
 	for _, hostInfo := range cloudHostAttr {
 		hostID, err := hostInfo.Int64(common.BKHostIDField)
 		if err != nil {
@@ -724,16 +696,21 @@ func (lgc *Logics) UpdateCloudHosts(ctx context.Context, cloudHostAttr []mapstr.
 			return err
 		}
 
-		delete(hostInfo, common.BKHostIDField)
-		delete(hostInfo, common.BKCloudConfirm)
-		delete(hostInfo, common.BKAttrConfirm)
-		opt := mapstr.MapStr{"condition": mapstr.MapStr{common.BKHostIDField: hostID}, "data": hostInfo}
+		// the hosts are updated by core service, so that the synced values are checked by the attribute rules
+		opt := &meta.UpdateOption{
+			Condition: mapstr.MapStr{common.BKHostIDField: hostID},
+			Data:      cloudHostData(hostInfo, properties),
+		}
 
 		blog.V(5).Infof("opt: %+v", opt)
-		result, err := lgc.CoreAPI.ObjectController().Instance().UpdateObject(ctx, common.BKInnerObjIDHost, lgc.header, opt)
-		if err != nil || (err == nil && !result.Result) {
-			blog.Errorf("update host batch failed, ids[%v], err: %v, %v, rid: %s", hostID, err, result.ErrMsg, lgc.rid)
-			return err
+		result, err := lgc.CoreAPI.CoreService().Instance().UpdateInstance(ctx, lgc.header, common.BKInnerObjIDHost, opt)
+		if err != nil {
+			blog.Errorf("update cloud host failed, id: %d, err: %v, rid: %s", hostID, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("update cloud host failed, id: %d, err code: %d, err msg: %s, rid: %s", hostID, result.Code, result.ErrMsg, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
 		}
 	}
 	return nil
@@ -779,22 +756,11 @@ func (lgc *Logics) NewAddConfirm(ctx context.Context, taskInfo meta.CloudTaskInf
 				blog.Errorf("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
 				return 0, err
 			}
-			outerIp, err := host.String(common.BKHostOuterIPField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
-				return 0, err
-			}
-			osName, err := host.String(common.BKOSNameField)
-			if err != nil {
-				blog.Error("mapstr.Map convert to string failed, err: %v, rid: %s", err, lgc.rid)
-				return 0, err
-			}
-			resourceConfirm := mapstr.MapStr{}
+			// keep all the mapped fields and the topology, they're picked out when the hosts are added
+			resourceConfirm := host.Clone()
 			resourceConfirm["bk_obj_id"] = taskInfo.ObjID
 			resourceConfirm[common.BKHostInnerIPField] = innerIp
 			resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
-			resourceConfirm[common.BKOSNameField] = osName
-			resourceConfirm[common.BKHostOuterIPField] = outerIp
 			resourceConfirm[common.BKCloudConfirm] = true
 			resourceConfirm[common.BKAttrConfirm] = false
 			resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
@@ -896,6 +862,7 @@ func (lgc *Logics) CloudSyncHistory(ctx context.Context, taskID int64, startTime
 	updateData[common.BKSyncStatus] = cloudHistory.Status
 	updateData[common.BKNewAddHost] = cloudHistory.NewAdd
 	updateData[common.BKAttrChangedHost] = cloudHistory.AttrChanged
+	updateData[common.BKTerminatedHost] = cloudHistory.Terminated
 
 	if _, err := lgc.CoreAPI.HostController().Cloud().UpdateCloudTask(ctx, lgc.header, updateData); err != nil {
 		blog.Errorf("update task failed, taskInfo: %#v, err: %v, rid: %s", updateData, err, lgc.rid)
@@ -910,82 +877,32 @@ func (lgc *Logics) CloudSyncHistory(ctx context.Context, taskID int64, startTime
 	return nil
 }
 
-func (lgc *Logics) ObtainCloudHosts(ctx context.Context, secretID string, secretKey string) ([]map[string]interface{}, error) {
-	credential := com.NewCredential(
-		secretID,
-		secretKey,
-	)
-
-	cpf := profile.NewClientProfile()
-	cpf.HttpProfile.ReqMethod = common.BKHttpGet
-	cpf.HttpProfile.ReqTimeout = common.BKTencentCloudTimeOut
-	cpf.HttpProfile.Endpoint = common.TencentCloudUrl
-	cpf.SignMethod = common.TencentCloudSignMethod
-
-	ClientRegion, _ := cvm.NewClient(credential, regions.Guangzhou, cpf)
-	regionRequest := cvm.NewDescribeRegionsRequest()
-	Response, err := ClientRegion.DescribeRegions(regionRequest)
-
+// ObtainCloudHosts obtain the hosts of all the instances of the cloud account with the mapping rules
+func (lgc *Logics) ObtainCloudHosts(ctx context.Context, account cloud.Account, rules meta.CloudMappingRules) ([]mapstr.MapStr, error) {
+	_, cloudHostInfo, err := lgc.mapCloudInstances(ctx, account, rules)
 	if err != nil {
 		return nil, err
 	}
+	return cloudHostInfo, nil
+}
 
-	data := Response.ToJsonString()
-	regionResponse := new(meta.RegionResponse)
-	if err := json.Unmarshal([]byte(data), regionResponse); err != nil {
-		blog.Errorf("json unmarsha1 error :%v\n", err)
-		return nil, err
+// cloudAccount returns the account of the cloud sync task, the task without account type is of the tencent cloud
+func cloudAccount(taskInfo meta.CloudTaskInfo) (cloud.Account, error) {
+	decodeBytes, err := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
+	if err != nil {
+		return cloud.Account{}, err
 	}
 
-	cloudHostInfo := make([]map[string]interface{}, 0)
-	for _, region := range regionResponse.Response.Data {
-		var inneripList string
-		var outeripList string
-		var osName string
-		regionHosts := make(map[string]interface{})
-
-		client, _ := cvm.NewClient(credential, region.Region, cpf)
-		instRequest := cvm.NewDescribeInstancesRequest()
-		response, err := client.DescribeInstances(instRequest)
-
-		if _, ok := err.(*errors.TencentCloudSDKError); ok {
-			fmt.Printf("An API error has returned: %s", err)
-			return nil, err
-		}
-		if err != nil {
-			blog.Error("obtain cloud hosts failed")
-			return nil, err
-		}
-
-		data := response.ToJsonString()
-		Hosts := meta.HostResponse{}
-		if err := json.Unmarshal([]byte(data), &Hosts); err != nil {
-			fmt.Printf("json unmarsha1 error :%v\n", err)
-		}
-
-		instSet := Hosts.HostResponse.InstanceSet
-		for _, obj := range instSet {
-			osName = obj.OsName
-			if len(obj.PrivateIpAddresses) > 0 {
-				inneripList = obj.PrivateIpAddresses[0]
-			}
-		}
-
-		for _, obj := range instSet {
-			if len(obj.PublicIpAddresses) > 0 {
-				outeripList = obj.PublicIpAddresses[0]
-			}
-		}
-
-		if len(instSet) > 0 {
-			regionHosts[common.BKHostCloudRegionField] = region.Region
-			regionHosts[common.BKHostInnerIPField] = inneripList
-			regionHosts[common.BKHostOuterIPField] = outeripList
-			regionHosts[common.BKOSNameField] = osName
-			cloudHostInfo = append(cloudHostInfo, regionHosts)
-		}
+	account := cloud.Account{
+		Type:      taskInfo.AccountType,
+		SecretID:  taskInfo.SecretID,
+		SecretKey: string(decodeBytes),
+		Endpoint:  taskInfo.Endpoint,
 	}
-	return cloudHostInfo, nil
+	if account.Type == "" {
+		account.Type = cloud.AccountTypeTencent
+	}
+	return account, nil
 }
 
 func copyHeader(ctx context.Context, header http.Header) http.Header {
@@ -0,0 +1,244 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
+	"configcenter/src/common/mapstr"
+	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/cloud"
+	hutil "configcenter/src/scene_server/host_server/util"
+)
+
+// cloudSyncedInstances returns the cloud instances synced by the task with the inner ips of their hosts
+func cloudSyncedInstances(instances []cloud.Instance, hosts []mapstr.MapStr) []meta.CloudSyncedInstance {
+	synced := make([]meta.CloudSyncedInstance, 0)
+	for index, instance := range instances {
+		synced = append(synced, meta.CloudSyncedInstance{
+			InstanceID: instance.InstanceID,
+			InnerIP:    util.GetStrByInterface(hosts[index][common.BKHostInnerIPField]),
+		})
+	}
+	return synced
+}
+
+// terminatedCloudHosts returns the inner ips of the hosts synced by the task last time whose cloud
+// instances are not returned by the provider any more, and which still exist in cmdb. the hosts are
+// matched by the cloud instance id, the inner ip taken by another returned instance is skipped.
+func terminatedCloudHosts(synced, current []meta.CloudSyncedInstance, existHosts map[string]int64) []string {
+	currentIDs := make(map[string]bool)
+	currentIPs := make(map[string]bool)
+	for _, instance := range current {
+		currentIDs[instance.InstanceID] = true
+		currentIPs[instance.InnerIP] = true
+	}
+
+	terminated := make([]string, 0)
+	for _, instance := range synced {
+		if currentIDs[instance.InstanceID] || currentIPs[instance.InnerIP] || util.InStrArr(terminated, instance.InnerIP) {
+			continue
+		}
+		if _, ok := existHosts[instance.InnerIP]; !ok {
+			continue
+		}
+		terminated = append(terminated, instance.InnerIP)
+	}
+	return terminated
+}
+
+// skipTerminatedReconcile returns whether the terminated hosts reconciliation is skipped, the provider returns
+// no instances while some were synced last time may be caused by the account or the provider, the synced
+// instances are kept then, instead of taking all of them as terminated.
+func skipTerminatedReconcile(synced, current []meta.CloudSyncedInstance) bool {
+	return len(current) == 0 && len(synced) > 0
+}
+
+// latestCloudTask get the cloud sync task from db, the task info of the sync timer is taken when the task starts
+func (lgc *Logics) latestCloudTask(ctx context.Context, taskInfo meta.CloudTaskInfo) (meta.CloudTaskInfo, error) {
+	cond := mapstr.MapStr{common.BKCloudTaskID: taskInfo.TaskID}
+	resp, err := lgc.CoreAPI.HostController().Cloud().SearchCloudTask(ctx, lgc.header, cond)
+	if err != nil {
+		blog.Errorf("search cloud task %d failed, err: %v, rid: %s", taskInfo.TaskID, err, lgc.rid)
+		return taskInfo, lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+	if len(resp.Info) == 0 {
+		blog.Errorf("cloud task %d not found, rid: %s", taskInfo.TaskID, lgc.rid)
+		return taskInfo, lgc.ccErr.Error(common.CCErrCloudGetTaskFail)
+	}
+	return resp.Info[0], nil
+}
+
+// ReconcileTerminatedHosts handles the hosts whose cloud instances are terminated according to the
+// terminated policy of the task, and returns the inner ips of these hosts
+func (lgc *Logics) ReconcileTerminatedHosts(ctx context.Context, taskInfo meta.CloudTaskInfo, current []meta.CloudSyncedInstance, existHosts map[string]int64) ([]string, error) {
+	terminated := terminatedCloudHosts(taskInfo.SyncedInstances, current, existHosts)
+	if len(terminated) == 0 {
+		return terminated, nil
+	}
+
+	hostIDs := make([]int64, 0)
+	for _, ip := range terminated {
+		hostIDs = append(hostIDs, existHosts[ip])
+	}
+
+	policy := taskInfo.TerminatedPolicy
+	blog.V(3).Infof("cloud task %d found terminated hosts %v, policy: %#v, rid: %s", taskInfo.TaskID, terminated, policy, lgc.rid)
+	switch policy.Action {
+	case meta.CloudTerminatedStatus:
+		hosts := make([]mapstr.MapStr, 0)
+		for _, hostID := range hostIDs {
+			hosts = append(hosts, mapstr.MapStr{common.BKHostIDField: hostID, policy.PropertyID: policy.Value})
+		}
+		if err := lgc.UpdateCloudHosts(ctx, hosts); err != nil {
+			blog.Errorf("mark terminated cloud hosts failed, hosts: %v, err: %v, rid: %s", hostIDs, err, lgc.rid)
+			return terminated, err
+		}
+	case meta.CloudTerminatedFault:
+		if err := lgc.TransferHostsToFaultModule(ctx, hostIDs); err != nil {
+			blog.Errorf("move terminated cloud hosts to fault module failed, hosts: %v, err: %v, rid: %s", hostIDs, err, lgc.rid)
+			return terminated, err
+		}
+	case meta.CloudTerminatedConfirm:
+		if err := lgc.terminatedConfirm(ctx, taskInfo, terminated, existHosts); err != nil {
+			blog.Errorf("terminated cloud hosts confirm failed, hosts: %v, err: %v, rid: %s", terminated, err, lgc.rid)
+			return terminated, err
+		}
+	}
+	return terminated, nil
+}
+
+// TransferHostsToFaultModule move the hosts to the fault module of the business they belong to
+func (lgc *Logics) TransferHostsToFaultModule(ctx context.Context, hostIDs []int64) errors.CCError {
+	relations, err := lgc.GetHostModuleRelation(ctx, meta.HostModuleRelationRequest{HostIDArr: hostIDs})
+	if err != nil {
+		return err
+	}
+
+	bizHosts := make(map[int64][]int64)
+	for _, relation := range relations {
+		if util.InArray(relation.HostID, bizHosts[relation.AppID]) {
+			continue
+		}
+		bizHosts[relation.AppID] = append(bizHosts[relation.AppID], relation.HostID)
+	}
+
+	for bizID, hostIDArr := range bizHosts {
+		cond := hutil.NewOperation().WithModuleName(common.DefaultFaultModuleName).WithAppID(bizID).Data()
+		cond[common.BKDefaultField] = common.DefaultFaultModuleFlag
+		moduleID, err := lgc.GetResoulePoolModuleID(ctx, cond)
+		if err != nil {
+			blog.Errorf("get fault module of business %d failed, err: %v, rid: %s", bizID, err, lgc.rid)
+			return err
+		}
+
+		audit := lgc.NewHostModuleLog(hostIDArr)
+		if err := audit.WithPrevious(ctx); err != nil {
+			blog.Errorf("get prev module host config failed, hosts: %v, err: %v, rid: %s", hostIDArr, err, lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+		}
+		// auth: deregister
+		if err := lgc.AuthManager.DeregisterHostsByID(ctx, lgc.header, hostIDArr...); err != nil {
+			blog.Errorf("deregister host from iam failed, hosts: %v, err: %v, rid: %s", hostIDArr, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+
+		input := &meta.TransferHostToInnerModule{
+			ApplicationID: bizID,
+			ModuleID:      moduleID,
+			HostID:        hostIDArr,
+		}
+		result, err := lgc.CoreAPI.CoreService().Host().TransferHostToInnerModule(ctx, lgc.header, input)
+		if err != nil {
+			blog.Errorf("transfer hosts to fault module http do error, input: %#v, err: %v, rid: %s", input, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommHTTPDoRequestFailed)
+		}
+		if !result.Result {
+			blog.Errorf("transfer hosts to fault module http reply error, input: %#v, result: %#v, rid: %s", input, result, lgc.rid)
+			return lgc.ccErr.New(result.Code, result.ErrMsg)
+		}
+
+		if err := audit.SaveAudit(ctx, bizID, lgc.user, "host to fault module"); err != nil {
+			blog.Errorf("save host module audit failed, hosts: %v, err: %v, rid: %s", hostIDArr, err, lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrCommResourceInitFailed, "audit server")
+		}
+		// auth: register
+		if err := lgc.AuthManager.RegisterHostsByID(ctx, lgc.header, hostIDArr...); err != nil {
+			blog.Errorf("register host to iam failed, hosts: %v, err: %v, rid: %s", hostIDArr, err, lgc.rid)
+			return lgc.ccErr.Error(common.CCErrCommRegistResourceToIAMFailed)
+		}
+	}
+	return nil
+}
+
+// terminatedConfirm queue the terminated hosts which are not in the resource confirm yet, the confirmed
+// terminated hosts are moved to the fault module, see ConfirmTerminatedHosts
+func (lgc *Logics) terminatedConfirm(ctx context.Context, taskInfo meta.CloudTaskInfo, terminated []string, existHosts map[string]int64) error {
+	opt := mapstr.MapStr{common.BKResourceType: common.BKTerminatedHost}
+	confirmHosts, err := lgc.CoreAPI.HostController().Cloud().SearchConfirm(ctx, lgc.header, opt)
+	if err != nil {
+		blog.Errorf("get confirm info failed with err: %v, rid: %s", err, lgc.rid)
+		return err
+	}
+
+	confirmIpList := make([]string, 0)
+	for _, confirmInfo := range confirmHosts.Info {
+		if ip, ok := confirmInfo[common.BKHostInnerIPField].(string); ok {
+			confirmIpList = append(confirmIpList, ip)
+		}
+	}
+
+	for _, ip := range terminated {
+		if util.InStrArr(confirmIpList, ip) {
+			continue
+		}
+		resourceConfirm := mapstr.MapStr{}
+		resourceConfirm["bk_obj_id"] = taskInfo.ObjID
+		resourceConfirm[common.BKHostInnerIPField] = ip
+		resourceConfirm[common.BKHostIDField] = existHosts[ip]
+		resourceConfirm[common.BKCloudTaskID] = taskInfo.TaskID
+		resourceConfirm[common.BKCloudConfirm] = false
+		resourceConfirm[common.BKAttrConfirm] = false
+		resourceConfirm[common.BKCloudSyncTaskName] = taskInfo.TaskName
+		resourceConfirm[common.BKCloudAccountType] = taskInfo.AccountType
+		resourceConfirm[common.BKCloudSyncAccountAdmin] = taskInfo.AccountAdmin
+		resourceConfirm[common.BKResourceType] = common.BKTerminatedHost
+
+		if _, err := lgc.CoreAPI.HostController().Cloud().ResourceConfirm(ctx, lgc.header, resourceConfirm); err != nil {
+			blog.Errorf("add resource confirm failed with confirmInfo: %#v, err: %v, rid: %s", resourceConfirm, err, lgc.rid)
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfirmTerminatedHosts move the confirmed terminated hosts of the resource confirm to the fault module
+func (lgc *Logics) ConfirmTerminatedHosts(ctx context.Context, confirmInfos []mapstr.MapStr) error {
+	hostIDs := make([]int64, 0)
+	for _, confirmInfo := range confirmInfos {
+		hostID, err := confirmInfo.Int64(common.BKHostIDField)
+		if err != nil {
+			blog.Errorf("get the host id of the terminated host confirm %#v failed, err: %v, rid: %s", confirmInfo, err, lgc.rid)
+			return lgc.ccErr.Errorf(common.CCErrCommParamsNeedInt, common.BKHostIDField)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+	if len(hostIDs) == 0 {
+		return nil
+	}
+	return lgc.TransferHostsToFaultModule(ctx, hostIDs)
+}
@@ -0,0 +1,108 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package logics
+
+import (
+	"reflect"
+	"testing"
+
+	meta "configcenter/src/common/metadata"
+)
+
+func TestTerminatedCloudHosts(t *testing.T) {
+	synced := []meta.CloudSyncedInstance{
+		{InstanceID: "ins-1", InnerIP: "10.0.0.1"},
+		{InstanceID: "ins-2", InnerIP: "10.0.0.2"},
+		{InstanceID: "ins-3", InnerIP: "10.0.0.3"},
+	}
+	existHosts := map[string]int64{"10.0.0.1": 1, "10.0.0.2": 2, "10.0.0.3": 3}
+
+	tests := []struct {
+		name       string
+		synced     []meta.CloudSyncedInstance
+		current    []meta.CloudSyncedInstance
+		existHosts map[string]int64
+		want       []string
+	}{
+		{
+			name:       "all the instances are still returned",
+			synced:     synced,
+			current:    synced,
+			existHosts: existHosts,
+			want:       []string{},
+		},
+		{
+			name:   "the instance id is still returned with another ip",
+			synced: synced,
+			current: []meta.CloudSyncedInstance{
+				{InstanceID: "ins-1", InnerIP: "10.0.0.11"},
+				{InstanceID: "ins-2", InnerIP: "10.0.0.2"},
+				{InstanceID: "ins-3", InnerIP: "10.0.0.3"},
+			},
+			existHosts: existHosts,
+			want:       []string{},
+		},
+		{
+			name:   "the ip is reused by another instance",
+			synced: synced,
+			current: []meta.CloudSyncedInstance{
+				{InstanceID: "ins-4", InnerIP: "10.0.0.1"},
+				{InstanceID: "ins-2", InnerIP: "10.0.0.2"},
+			},
+			existHosts: existHosts,
+			want:       []string{"10.0.0.3"},
+		},
+		{
+			name:       "the host is no longer in cmdb",
+			synced:     synced,
+			current:    []meta.CloudSyncedInstance{{InstanceID: "ins-2", InnerIP: "10.0.0.2"}},
+			existHosts: map[string]int64{"10.0.0.2": 2, "10.0.0.3": 3},
+			want:       []string{"10.0.0.3"},
+		},
+		{
+			name: "the terminated instances have the same ip",
+			synced: []meta.CloudSyncedInstance{
+				{InstanceID: "ins-1", InnerIP: "10.0.0.1"},
+				{InstanceID: "ins-5", InnerIP: "10.0.0.1"},
+				{InstanceID: "ins-2", InnerIP: "10.0.0.2"},
+			},
+			current:    []meta.CloudSyncedInstance{{InstanceID: "ins-2", InnerIP: "10.0.0.2"}},
+			existHosts: existHosts,
+			want:       []string{"10.0.0.1"},
+		},
+	}
+	for _, tt := range tests {
+		if got := terminatedCloudHosts(tt.synced, tt.current, tt.existHosts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: terminatedCloudHosts() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSkipTerminatedReconcile(t *testing.T) {
+	synced := []meta.CloudSyncedInstance{{InstanceID: "ins-1", InnerIP: "10.0.0.1"}}
+	tests := []struct {
+		name    string
+		synced  []meta.CloudSyncedInstance
+		current []meta.CloudSyncedInstance
+		want    bool
+	}{
+		{name: "the provider returns no instance", synced: synced, current: nil, want: true},
+		{name: "nothing was synced", synced: nil, current: nil, want: false},
+		{name: "the provider returns instances", synced: synced, current: synced, want: false},
+	}
+	for _, tt := range tests {
+		if got := skipTerminatedReconcile(tt.synced, tt.current); got != tt.want {
+			t.Errorf("%s: skipTerminatedReconcile() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
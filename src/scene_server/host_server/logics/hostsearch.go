@@ -23,15 +23,20 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	hostParse "configcenter/src/common/paraparse"
+	"configcenter/src/common/querylang"
 	"configcenter/src/common/util"
 )
 
 func (lgc *Logics) SearchHost(ctx context.Context, data *metadata.HostCommonSearch, isDetail bool) (*metadata.SearchHost, error) {
-	searchHostInst := NewSearchHost(ctx, lgc, data)
-	searchHostInst.ParseCondition()
 	retHostInfo := &metadata.SearchHost{
 		Info: make([]mapstr.MapStr, 0),
 	}
+	if err := lgc.CompileHostQuery(ctx, data); err != nil {
+		return retHostInfo, err
+	}
+
+	searchHostInst := NewSearchHost(ctx, lgc, data)
+	searchHostInst.ParseCondition()
 	err := searchHostInst.SearchHostByConds()
 	if err != nil {
 		return retHostInfo, err
@@ -48,6 +53,44 @@ func (lgc *Logics) SearchHost(ctx context.Context, data *metadata.HostCommonSear
 	return retHostInfo, nil
 }
 
+// CompileHostQuery compiles the query statement of the search into the conditions of the objects, and merges them
+// into the conditions of the search. the query is cleared after it's compiled.
+func (lgc *Logics) CompileHostQuery(ctx context.Context, data *metadata.HostCommonSearch) errors.CCError {
+	if "" == data.Query {
+		return nil
+	}
+	query, err := querylang.Parse(data.Query)
+	if err != nil {
+		blog.Errorf("parse host query failed, query: %s, err: %v, rid: %s", data.Query, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommQueryInvalid, err.Error())
+	}
+
+	var attrErr errors.CCError
+	conds, err := query.Compile(common.BKInnerObjIDHost, func(objID string) ([]metadata.Attribute, error) {
+		attrs, err := lgc.GetObjectAttributes(ctx, lgc.ownerID, objID, metadata.BasePage{})
+		if err != nil {
+			attrErr = err
+			return nil, err
+		}
+		return attrs, nil
+	})
+	if attrErr != nil {
+		return attrErr
+	}
+	if err != nil {
+		blog.Errorf("compile host query failed, query: %s, err: %v, rid: %s", data.Query, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommQueryInvalid, err.Error())
+	}
+
+	data.Condition, err = querylang.MergeSearchConditions(data.Condition, conds)
+	if err != nil {
+		blog.Errorf("merge host query failed, query: %s, err: %v, rid: %s", data.Query, err, lgc.rid)
+		return lgc.ccErr.Errorf(common.CCErrCommQueryInvalid, err.Error())
+	}
+	data.Query = ""
+	return nil
+}
+
 type searchHostConds struct {
 	hostCond      metadata.SearchCondition
 	appCond       metadata.SearchCondition
@@ -680,6 +723,10 @@ func (sh *searchHost) searchByHostConds() errors.CCError {
 	condition := make(map[string]interface{})
 	hostParse.ParseHostParams(sh.conds.hostCond.Condition, condition)
 	hostParse.ParseHostIPParams(sh.hostSearchParam.Ip, condition)
+	if err := hostParse.ParseHostIPRangeParams(sh.hostSearchParam.Ip, condition); err != nil {
+		blog.Errorf("search host failed, parse ip ranges failed, err: %v, rid: %s", err, sh.ccRid)
+		return sh.ccErr.Errorf(common.CCErrCommParamsIsInvalid, err.Error())
+	}
 
 	query := &metadata.QueryInput{
 		Condition: condition,
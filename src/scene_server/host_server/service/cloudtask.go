@@ -20,10 +20,13 @@ import (
 
 	"github.com/emicklei/go-restful"
 
+	authmeta "configcenter/src/auth/meta"
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/scene_server/host_server/cloud"
 )
 
 // CloudAddTask create cloud sync task
@@ -37,6 +40,15 @@ func (s *Service) AddCloudTask(req *restful.Request, resp *restful.Response) {
 		return
 	}
 
+	if key, ok := taskList.TerminatedPolicy.Validate(); !ok {
+		blog.Errorf("add task failed, invalid terminated policy: %#v, rid: %s", taskList.TerminatedPolicy, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, key)})
+		return
+	}
+	if !s.checkCloudMappingRules(srvData, resp, taskList.MappingRules, true) {
+		return
+	}
+
 	taskList.User = srvData.user
 
 	if err := srvData.lgc.AddCloudTask(srvData.ctx, taskList); err != nil {
@@ -91,6 +103,39 @@ func (s *Service) UpdateCloudTask(req *restful.Request, resp *restful.Response)
 		return
 	}
 
+	if data.Exists(common.BKTerminatedPolicy) {
+		policy := meta.CloudTerminatedPolicy{}
+		policyData, err := data.MapStr(common.BKTerminatedPolicy)
+		if err == nil {
+			err = policyData.MarshalJSONInto(&policy)
+		}
+		if err != nil {
+			blog.Errorf("update task failed, decode terminated policy failed, err: %v, rid: %s", err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKTerminatedPolicy)})
+			return
+		}
+		if key, ok := policy.Validate(); !ok {
+			blog.Errorf("update task failed, invalid terminated policy: %#v, rid: %s", policy, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, key)})
+			return
+		}
+	}
+	if data.Exists(common.BKMappingRules) {
+		rules := meta.CloudMappingRules{}
+		rulesData, err := data.MapStr(common.BKMappingRules)
+		if err == nil {
+			err = rulesData.MarshalJSONInto(&rules)
+		}
+		if err != nil {
+			blog.Errorf("update task failed, decode mapping rules failed, err: %v, rid: %s", err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, common.BKMappingRules)})
+			return
+		}
+		if !s.checkCloudMappingRules(srvData, resp, rules, true) {
+			return
+		}
+	}
+
 	// TaskName Uniqueness check
 	response, err := s.CoreAPI.HostController().Cloud().TaskNameCheck(srvData.ctx, srvData.header, data)
 	if err != nil {
@@ -217,7 +262,14 @@ func (s *Service) ResourceConfirm(req *restful.Request, resp *restful.Response)
 
 	AddHostList := make([]mapstr.MapStr, 0)
 	updateHostList := make([]mapstr.MapStr, 0)
+	terminatedHostList := make([]mapstr.MapStr, 0)
 	for _, hostInfo := range cloudHostInfo {
+		// the terminated hosts exist in cmdb already, they are not new add or attribute changed hosts
+		if resourceType, _ := hostInfo[common.BKResourceType].(string); resourceType == common.BKTerminatedHost {
+			terminatedHostList = append(terminatedHostList, hostInfo)
+			continue
+		}
+
 		addConfirm, ok := hostInfo["bk_confirm"].(bool)
 		if !ok {
 			blog.Errorf("interface convert to bool fail")
@@ -254,6 +306,14 @@ func (s *Service) ResourceConfirm(req *restful.Request, resp *restful.Response)
 		}
 	}
 
+	if len(terminatedHostList) > 0 {
+		err := srvData.lgc.ConfirmTerminatedHosts(srvData.ctx, terminatedHostList)
+		if err != nil {
+			blog.Errorf("move terminated cloud hosts to fault module failed, err: %v, rid: %s", err, srvData.rid)
+			srvData.ccErr.Error(1110003)
+		}
+	}
+
 	// After resource confirmation, delete the items from table cc_CloudResourceSync
 	for _, id := range resourceIDs {
 		_, errD := srvData.lgc.CoreAPI.HostController().Cloud().DeleteConfirm(srvData.ctx, srvData.header, id)
@@ -391,3 +451,89 @@ func (s *Service) SearchConfirmHistory(req *restful.Request, resp *restful.Respo
 
 	resp.WriteEntity(meta.NewSuccessResp(response))
 }
+
+// PreviewCloudMapping map the current instances of the cloud account with the mapping rules,
+// so that the rules can be checked before they're applied.
+func (s *Service) PreviewCloudMapping(req *restful.Request, resp *restful.Response) {
+	srvData := s.newSrvComm(req.Request.Header)
+
+	input := new(meta.CloudMappingPreviewRequest)
+	if err := json.NewDecoder(req.Request.Body).Decode(input); err != nil {
+		blog.Errorf("preview cloud mapping failed with decode body err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommJSONUnmarshalFailed)})
+		return
+	}
+
+	var account cloud.Account
+	var rules meta.CloudMappingRules
+	if input.TaskID > 0 {
+		response, err := s.CoreAPI.HostController().Cloud().SearchCloudTask(srvData.ctx, srvData.header, map[string]interface{}{common.BKCloudTaskID: input.TaskID})
+		if err != nil || len(response.Info) == 0 {
+			blog.Errorf("preview cloud mapping, but get task %d failed, err: %v, rid: %s", input.TaskID, err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudGetTaskFail)})
+			return
+		}
+		taskInfo := response.Info[0]
+		decodeBytes, err := base64.StdEncoding.DecodeString(taskInfo.SecretKey)
+		if err != nil {
+			blog.Errorf("Base64 decode secretKey failed, err: %v, rid: %s", err, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudGetTaskFail)})
+			return
+		}
+		account = cloud.Account{Type: taskInfo.AccountType, SecretID: taskInfo.SecretID, SecretKey: string(decodeBytes), Endpoint: taskInfo.Endpoint}
+		rules = taskInfo.MappingRules
+	} else {
+		account = cloud.Account{Type: input.AccountType, SecretID: input.SecretID, SecretKey: input.SecretKey, Endpoint: input.Endpoint}
+	}
+	if account.Type == "" {
+		account.Type = cloud.AccountTypeTencent
+	}
+	if input.MappingRules != nil {
+		rules = *input.MappingRules
+	}
+	if !s.checkCloudMappingRules(srvData, resp, rules, false) {
+		return
+	}
+
+	previews, err := srvData.lgc.PreviewCloudMapping(srvData.ctx, account, rules)
+	if err != nil {
+		blog.Errorf("preview cloud mapping failed, err: %v, rid: %s", err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCloudObtainInstancesFail)})
+		return
+	}
+
+	resp.WriteEntity(meta.NewSuccessResp(previews))
+}
+
+// checkCloudMappingRules validates the mapping rules and authorizes the businesses which the topology rules
+// add the hosts to, the error is written to the response if they're not valid
+func (s *Service) checkCloudMappingRules(srvData *srvComm, resp *restful.Response, rules meta.CloudMappingRules, authorize bool) bool {
+	key, ok, err := srvData.lgc.ValidateCloudMappingRules(srvData.ctx, rules)
+	if err != nil {
+		blog.Errorf("validate mapping rules failed, rules: %#v, err: %v, rid: %s", rules, err, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return false
+	}
+	if !ok {
+		blog.Errorf("invalid mapping rules: %#v, key: %s, rid: %s", rules, key, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Errorf(common.CCErrCommParamsIsInvalid, key)})
+		return false
+	}
+	if !authorize || len(rules.Topology) == 0 {
+		return true
+	}
+
+	bizIDs := make([]int64, 0)
+	for _, rule := range rules.Topology {
+		if !util.InArray(rule.BizID, bizIDs) {
+			bizIDs = append(bizIDs, rule.BizID)
+		}
+	}
+	// auth: the hosts are added to the businesses of the topology rules
+	if err := s.AuthManager.AuthorizeByBusinessID(srvData.ctx, srvData.header, authmeta.Update, bizIDs...); err != nil {
+		blog.Errorf("authorize the businesses %v of the mapping rules failed, err: %v, rid: %s", bizIDs, err, srvData.rid)
+		resp.WriteError(http.StatusForbidden, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrCommAuthorizeFailed)})
+		return false
+	}
+	return true
+}
@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common"
 	"configcenter/src/common/auditoplog"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/errors"
 	"configcenter/src/common/mapstr"
 	meta "configcenter/src/common/metadata"
 	"configcenter/src/common/util"
@@ -425,6 +426,11 @@ func (s *Service) SearchHost(req *restful.Request, resp *restful.Response) {
 	}
 
 	host, err := srvData.lgc.SearchHost(srvData.ctx, body, false)
+	if ccErr, ok := err.(errors.CCErrorCoder); ok && ccErr.GetCode() == common.CCErrCommQueryInvalid {
+		blog.Errorf("search host failed, invalid query, err: %v,input:%+v,rid:%s", err, body, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
 	if err != nil {
 		blog.Errorf("search host failed, err: %v,input:%+v,rid:%s", err, body, srvData.rid)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: srvData.ccErr.Error(common.CCErrHostGetFail)})
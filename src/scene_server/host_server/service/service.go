@@ -160,6 +160,7 @@ func (s *Service) WebService() *restful.Container {
 	api.Route(api.POST("/hosts/cloud/confirmHistory/search").To(s.SearchConfirmHistory))
 	api.Route(api.POST("/hosts/cloud/accountSearch").To(s.SearchAccount))
 	api.Route(api.POST("/hosts/cloud/syncHistory").To(s.CloudSyncHistory))
+	api.Route(api.POST("/hosts/cloud/mapping/preview").To(s.PreviewCloudMapping))
 
 	container.Add(api)
 
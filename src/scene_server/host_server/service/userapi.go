@@ -48,6 +48,12 @@ func (s *Service) AddUserCustomQuery(req *restful.Request, resp *restful.Respons
 		return
 	}
 
+	if err := s.validateUserCustomQuery(srvData, ucq.Info); err != nil {
+		blog.Errorf("AddUserCustomQuery add user custom query failed, invalid query, err: %v, input:%+v,rid:%s", err, ucq, srvData.rid)
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+		return
+	}
+
 	ucq.CreateUser = srvData.user
 	result, err := s.CoreAPI.HostController().User().AddUserConfig(srvData.ctx, srvData.header, ucq)
 	if err != nil {
@@ -81,6 +87,14 @@ func (s *Service) UpdateUserCustomQuery(req *restful.Request, resp *restful.Resp
 		return
 	}
 
+	if info, ok := params["info"].(string); ok {
+		if err := s.validateUserCustomQuery(srvData, info); err != nil {
+			blog.Errorf("update user custom query failed, invalid query, err: %v, input:%+v,rid:%s", err, params, srvData.rid)
+			resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: err})
+			return
+		}
+	}
+
 	params["modify_user"] = srvData.user
 	params[common.LastTimeField] = time.Now().UTC()
 	bizID := req.PathParameter("bk_biz_id")
@@ -292,3 +306,13 @@ func (s *Service) GetUserCustomQueryResult(req *restful.Request, resp *restful.R
 
 	return
 }
+
+// validateUserCustomQuery checks the query statement of the saved search, so that the dynamic group with an invalid
+// query is rejected when it's saved rather than when it's used.
+func (s *Service) validateUserCustomQuery(srvData *srvComm, info string) error {
+	input := new(meta.HostCommonSearch)
+	if err := json.Unmarshal([]byte(info), input); nil != err || "" == input.Query {
+		return nil
+	}
+	return srvData.lgc.CompileHostQuery(srvData.ctx, input)
+}
@@ -20,6 +20,7 @@ import (
 	"strconv"
 	"time"
 
+	"configcenter/src/auth"
 	"configcenter/src/auth/authcenter"
 	"configcenter/src/auth/extensions"
 	"configcenter/src/common"
@@ -101,7 +102,7 @@ func Run(ctx context.Context, op *options.ServerOption) error {
 		return err
 	}
 
-	authorize, err := authcenter.NewAuthCenter(nil, server.Config.Auth)
+	authorize, err := auth.NewAuthorize(nil, server.Config.Auth)
 	if err != nil {
 		blog.Errorf("it is failed to create a new auth API, err:%s", err.Error())
 	}
@@ -95,6 +95,7 @@ type AssociationOperationInterface interface {
 	SearchInst(params types.ContextParams, request *metadata.SearchAssociationInstRequest) (resp *metadata.SearchAssociationInstResult, err error)
 	CreateInst(params types.ContextParams, request *metadata.CreateAssociationInstRequest) (resp *metadata.CreateAssociationInstResult, err error)
 	DeleteInst(params types.ContextParams, assoID int64) (resp *metadata.DeleteAssociationInstResult, err error)
+	SearchInstMappingViolation(params types.ContextParams, request *metadata.SearchAsstMappingViolationRequest) ([]metadata.AsstMappingViolation, error)
 
 	ImportInstAssociation(ctx context.Context, params types.ContextParams, objID string, importData map[int]metadata.ExcelAssocation) (resp metadata.ResponeImportAssociationData, err error)
 
@@ -219,6 +220,10 @@ func (a *association) CreateCommonAssociation(params types.ContextParams, data *
 	if len(data.OnDelete) == 0 {
 		data.OnDelete = metadata.NoAction
 	}
+	if !data.OnDelete.IsValid() {
+		blog.Errorf("[operation-asst] failed to create the association, invalid on delete action: %s", data.OnDelete)
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, metadata.AssociationFieldOnDelete)
+	}
 
 	//  check the association
 	cond := condition.CreateCondition()
@@ -431,6 +436,11 @@ func (a *association) UpdateAssociation(params types.ContextParams, data mapstr.
 		return params.Err.Error(common.CCErrorTopoObjectAssociationUpdateForbiddenFields)
 	}
 
+	if len(asst.OnDelete) != 0 && !asst.OnDelete.IsValid() {
+		blog.Errorf("[operation-asst] update association[%d], but got invalid on delete action: %s", assoID, asst.OnDelete)
+		return params.Err.Errorf(common.CCErrCommParamsInvalid, metadata.AssociationFieldOnDelete)
+	}
+
 	cond := condition.CreateCondition()
 	cond.Field(metadata.AssociationFieldAssociationId).Eq(assoID)
 	cond.Field(metadata.AssociationFieldSupplierAccount).Eq(params.SupplierAccount)
@@ -937,3 +947,19 @@ func (a *association) DeleteInst(params types.ContextParams, assoID int64) (resp
 
 	return resp, err
 }
+
+// SearchInstMappingViolation find the instance associations which exceed the mapping of the model association.
+func (a *association) SearchInstMappingViolation(params types.ContextParams, request *metadata.SearchAsstMappingViolationRequest) ([]metadata.AsstMappingViolation, error) {
+	rsp, err := a.clientSet.CoreService().Association().ReadInstAssociationMappingViolation(context.Background(), params.Header, request)
+	if nil != err {
+		blog.Errorf("[operation-asst] failed to request object controller, err: %s, rid: %s", err.Error(), params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-asst] failed to search the mapping violations by %#v, err: %s, rid: %s", request, rsp.ErrMsg, params.ReqID)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return rsp.Data, nil
+}
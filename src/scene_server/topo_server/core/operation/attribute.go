@@ -119,6 +119,12 @@ func (a *attribute) CreateObjectAttribute(params types.ContextParams, data mapst
 		return nil, err
 	}
 
+	if att.Attribute().PropertyType == common.FieldTypeComputed {
+		if err := a.recomputeInstances(params, objID); nil != err {
+			return nil, err
+		}
+	}
+
 	return att, nil
 }
 
@@ -230,5 +236,34 @@ func (a *attribute) UpdateObjectAttribute(params types.ContextParams, data mapst
 		return params.Err.New(rsp.Code, rsp.ErrMsg)
 	}
 
+	// the values of the computed attribute are evaluated again with the new option
+	attrs, err := a.FindObjectAttribute(params, condition.CreateCondition().Field(common.BKFieldID).Eq(attID))
+	if nil != err {
+		return err
+	}
+	for _, attr := range attrs {
+		if attr.Attribute().PropertyType == common.FieldTypeComputed {
+			if err := a.recomputeInstances(params, attr.Attribute().ObjectID); nil != err {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// recomputeInstances evaluate the computed attributes of all the instances of the model
+func (a *attribute) recomputeInstances(params types.ContextParams, objID string) error {
+	rsp, err := a.clientSet.CoreService().Instance().RecomputeInstance(context.Background(), params.Header, objID, &metadata.RecomputeOption{})
+	if nil != err {
+		blog.Errorf("[operation-attr] failed to request core service, error info is %s, rid: %s", err.Error(), params.ReqID)
+		return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-attr] failed to recompute the instances of the model(%s), error info is %s, rid: %s", objID, rsp.ErrMsg, params.ReqID)
+		return params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
 	return nil
 }
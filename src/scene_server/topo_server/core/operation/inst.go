@@ -20,6 +20,7 @@ import (
 
 	"configcenter/src/apimachinery"
 	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
 	"configcenter/src/common/errors"
@@ -36,13 +37,14 @@ type InstOperationInterface interface {
 	CreateInst(params types.ContextParams, obj model.Object, data mapstr.MapStr) (inst.Inst, error)
 	CreateInstBatch(params types.ContextParams, obj model.Object, batchInfo *InstBatchInfo) (*BatchResult, error)
 	DeleteInst(params types.ContextParams, obj model.Object, cond condition.Condition, needCheckHost bool) error
-	DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error
+	DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool, dryRun bool) (*metadata.DeleteImpact, error)
 	FindOriginInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput) (*metadata.InstResult, error)
 	FindInst(params types.ContextParams, obj model.Object, cond *metadata.QueryInput, needAsstDetail bool) (count int, results []inst.Inst, err error)
 	FindInstByAssociationInst(params types.ContextParams, obj model.Object, data mapstr.MapStr) (cont int, results []inst.Inst, err error)
 	FindInstChildTopo(params types.ContextParams, obj model.Object, instID int64, query *metadata.QueryInput) (count int, results []*CommonInstTopo, err error)
 	FindInstParentTopo(params types.ContextParams, obj model.Object, instID int64, query *metadata.QueryInput) (count int, results []*CommonInstTopo, err error)
 	FindInstTopo(params types.ContextParams, obj model.Object, instID int64, query *metadata.QueryInput) (count int, results []CommonInstTopoV2, err error)
+	FindInstGraph(params types.ContextParams, obj model.Object, instID int64, request *metadata.SearchInstGraphRequest) (*metadata.InstGraph, error)
 	UpdateInst(params types.ContextParams, data mapstr.MapStr, obj model.Object, cond condition.Condition, instID int64) error
 
 	SetProxy(modelFactory model.Factory, instFactory inst.Factory, asst AssociationOperationInterface, obj ObjectOperationInterface)
@@ -346,7 +348,7 @@ func (c *commonInst) hasHost(params types.ContextParams, targetInst inst.Inst, c
 	return instIDS, false, nil
 }
 
-func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool) error {
+func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Object, instID []int64, needCheckHost bool, dryRun bool) (*metadata.DeleteImpact, error) {
 
 	object := obj.Object()
 	cond := condition.CreateCondition()
@@ -360,67 +362,141 @@ func (c *commonInst) DeleteInstByInstID(params types.ContextParams, obj model.Ob
 
 	_, insts, err := c.FindInst(params, obj, query, false)
 	if nil != err {
-		return err
+		return nil, err
 	}
 
 	deleteIDS := []deletedInst{}
 	for _, inst := range insts {
 		ids, exists, err := c.hasHost(params, inst, needCheckHost)
 		if nil != err {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+			return nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
 		}
 
 		if exists {
-			return params.Err.Error(common.CCErrTopoHasHostCheckFailed)
+			return nil, params.Err.Error(common.CCErrTopoHasHostCheckFailed)
 		}
 
 		deleteIDS = append(deleteIDS, ids...)
 	}
 
-	for _, delInst := range deleteIDS {
-		preAudit := NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CreateSnapshot(delInst.instID, condition.CreateCondition().ToMapStr())
-		// if this instance has been bind to a instance by the association, then this instance should not be deleted.
-		innerCond := condition.CreateCondition()
-		innerCond.Field(common.BKAsstObjIDField).Eq(delInst.obj.GetObjectID())
-		innerCond.Field(common.BKAsstInstIDField).Eq(delInst.instID)
-		err := c.asst.CheckBeAssociation(params, delInst.obj, innerCond)
-		if nil != err {
-			return err
-		}
+	// the instances related by the associations are deleted with these instances or refuse the deletion,
+	// which is decided by the on delete action of the associations.
+	groups := groupDeletedInst(deleteIDS)
+	impact, err := c.deleteImpact(params, groups)
+	if nil != err {
+		return nil, err
+	}
 
-		// this instance has not be bind to another instance, we can delete all the associations it created
-		// by the association with other instances.
-		innerCond = condition.CreateCondition()
-		innerCond.Field(common.BKObjIDField).Eq(delInst.obj.GetObjectID())
-		innerCond.Field(common.BKInstIDField).Eq(delInst.instID)
-		if err := c.asst.DeleteInstAssociation(params, innerCond); nil != err {
-			blog.Errorf("[operation-inst] failed to delete the inst asst, err: %s", err.Error())
-			return err
+	if dryRun {
+		return impact, nil
+	}
+
+	if 0 != len(impact.Restrictions) {
+		assts := []string{}
+		for _, asst := range impact.Restrictions {
+			assts = append(assts, fmt.Sprintf("%s(%d->%d)", asst.ObjectAsstID, asst.InstID, asst.AsstInstID))
 		}
+		blog.Errorf("[operation-inst] the instances can not be deleted, restricted by the associations: %v", assts)
+		return nil, params.Err.Errorf(common.CCErrTopoInstDeleteRestricted, strings.Join(assts, ","))
+	}
 
-		// delete this instance now.
-		delCond := condition.CreateCondition()
-		delCond.Field(delInst.obj.GetInstIDFieldName()).In(delInst.instID)
-		if delInst.obj.IsCommon() {
-			delCond.Field(common.BKObjIDField).Eq(delInst.obj.GetObjectID())
+	audits := []*deleteAudit{}
+	for _, impactInst := range impact.Instances {
+		delObj, err := c.obj.FindSingleObject(params, impactInst.ObjectID)
+		if nil != err {
+			return nil, err
 		}
-		// clear association
-		rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, delInst.obj.GetObjectID(), &metadata.DeleteOption{Condition: delCond.ToMapStr()})
+		preAudit := NewSupplementary().Audit(params, c.clientSet, delObj, c).CreateSnapshot(impactInst.InstID, condition.CreateCondition().ToMapStr())
+		audits = append(audits, &deleteAudit{obj: delObj, preAudit: preAudit})
+	}
+
+	// delete these instances now, the instance associations and the cascaded instances are deleted with them.
+	if 0 != len(groups) {
+		delOpt := deleteOption(groups)
+		rsp, err := c.clientSet.CoreService().Instance().DeleteInstance(context.Background(), params.Header, groups[0].obj.GetObjectID(), delOpt)
 		if nil != err {
 			blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
-			return params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+			return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
 		}
 
 		if !rsp.Result {
-			blog.Errorf("[operation-inst] failed to delete the object(%s) inst by the condition(%#v), err: %s", delInst.obj.GetObjectID(), delCond.ToMapStr(), rsp.ErrMsg)
-			return params.Err.New(rsp.Code, rsp.ErrMsg)
+			blog.Errorf("[operation-inst] failed to delete the insts by the option(%#v), err: %s", delOpt, rsp.ErrMsg)
+			return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+		}
+	}
+
+	for _, audit := range audits {
+		NewSupplementary().Audit(params, c.clientSet, audit.obj, c).CommitDeleteLog(audit.preAudit, nil, nil)
+	}
+	if err := c.saveAsstDeleteAudit(params, impact.Associations); nil != err {
+		return nil, err
+	}
+	return impact, nil
+}
+
+// saveAsstDeleteAudit record the audit logs of the instance associations deleted with the instances
+func (c *commonInst) saveAsstDeleteAudit(params types.ContextParams, assts []metadata.InstAsst) error {
+	var bizID int64
+	if params.MetaData != nil {
+		var err error
+		bizID, err = metadata.BizIDFromMetadata(*params.MetaData)
+		if err != nil {
+			blog.Errorf("[operation-inst] parse business id from request failed, params: %+v, err: %+v, rid: %s", params, err, params.ReqID)
+			return params.Err.Error(common.CCErrCommHTTPInputInvalid)
 		}
+	}
 
-		NewSupplementary().Audit(params, c.clientSet, delInst.obj, c).CommitDeleteLog(preAudit, nil, nil)
+	for _, asst := range assts {
+		auditlog := metadata.SaveAuditLogParams{
+			ID:    asst.ID,
+			Model: "instance_association",
+			Content: metadata.Content{
+				PreData: asst,
+				Headers: InstanceAssociationAuditHeaders,
+			},
+			OpDesc: "delete instance association",
+			OpType: auditoplog.AuditOpTypeDel,
+			BizID:  bizID,
+		}
+		auditresp, err := c.clientSet.CoreService().Audit().SaveAuditLog(params.Context, params.Header, auditlog)
+		if err != nil {
+			blog.Errorf("[operation-inst] delete the instances finished, but save the association audit log failed, err: %v, rid: %s", err, params.ReqID)
+			return params.Err.Error(common.CCErrAuditSaveLogFaile)
+		}
+		if !auditresp.Result {
+			blog.Errorf("[operation-inst] delete the instances finished, but save the association audit log failed, err: %s, rid: %s", auditresp.ErrMsg, params.ReqID)
+			return params.Err.New(auditresp.Code, auditresp.ErrMsg)
+		}
 	}
 	return nil
 }
 
+// deleteImpact collect the impact of deleting the instances of all the groups,
+// which are planned as one deletion just like they are deleted.
+func (c *commonInst) deleteImpact(params types.ContextParams, groups []*deletedInstGroup) (*metadata.DeleteImpact, error) {
+	if 0 == len(groups) {
+		return &metadata.DeleteImpact{
+			Instances:    []metadata.DeleteImpactInst{},
+			Associations: []metadata.InstAsst{},
+			Restrictions: []metadata.InstAsst{},
+		}, nil
+	}
+
+	delOpt := deleteOption(groups)
+	rsp, err := c.clientSet.CoreService().Instance().DeleteInstanceImpact(context.Background(), params.Header, groups[0].obj.GetObjectID(), delOpt)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to request object controller, err: %s", err.Error())
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+
+	if !rsp.Result {
+		blog.Errorf("[operation-inst] failed to get the delete impact of the insts by the option(%#v), err: %s", delOpt, rsp.ErrMsg)
+		return nil, params.Err.New(rsp.Code, rsp.ErrMsg)
+	}
+
+	return &rsp.Data, nil
+}
+
 func (c *commonInst) DeleteInst(params types.ContextParams, obj model.Object, cond condition.Condition, needCheckHost bool) error {
 
 	// clear inst associations
@@ -444,7 +520,7 @@ func (c *commonInst) DeleteInst(params types.ContextParams, obj model.Object, co
 		if nil != err {
 			return err
 		}
-		err = c.DeleteInstByInstID(params, obj, []int64{targetInstID}, needCheckHost)
+		_, err = c.DeleteInstByInstID(params, obj, []int64{targetInstID}, needCheckHost, false)
 		if nil != err {
 			return err
 		}
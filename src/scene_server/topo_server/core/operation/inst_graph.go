@@ -0,0 +1,274 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package operation
+
+import (
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/condition"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/model"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// instGraphWalker walks the instance associations level by level from the start instance
+type instGraphWalker struct {
+	c       *commonInst
+	params  types.ContextParams
+	request *metadata.SearchInstGraphRequest
+	graph   *metadata.InstGraph
+	// depths the depth of the instances reached
+	depths  map[string]int
+	edges   map[int64]bool
+	objects map[string]model.Object
+}
+
+// the edge to a instance which is not reached yet
+type pendingInstGraphEdge struct {
+	asst   metadata.InstAsst
+	target string
+}
+
+func instGraphKey(objID string, instID int64) string {
+	return fmt.Sprintf("%s|%d", objID, instID)
+}
+
+// FindInstGraph walk the instance associations from the instance to the max depth
+func (c *commonInst) FindInstGraph(params types.ContextParams, obj model.Object, instID int64, request *metadata.SearchInstGraphRequest) (*metadata.InstGraph, error) {
+	request.Normalize()
+	if !request.Direction.IsValid() {
+		return nil, params.Err.Errorf(common.CCErrCommParamsInvalid, "direction")
+	}
+
+	cond := condition.CreateCondition()
+	cond.Field(obj.GetInstIDFieldName()).Eq(instID)
+	_, insts, err := c.FindInst(params, obj, &metadata.QueryInput{Condition: cond.ToMapStr()}, false)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to find the start inst(%s/%d) of the graph, err: %s, rid: %s", obj.GetObjectID(), instID, err.Error(), params.ReqID)
+		return nil, err
+	}
+	if len(insts) == 0 {
+		blog.Errorf("[operation-inst] the start inst(%s/%d) of the graph is not found, rid: %s", obj.GetObjectID(), instID, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommNotFound)
+	}
+
+	w := &instGraphWalker{
+		c:       c,
+		params:  params,
+		request: request,
+		graph:   &metadata.InstGraph{Nodes: make([]metadata.InstGraphNode, 0), Edges: make([]metadata.InstGraphEdge, 0)},
+		depths:  make(map[string]int),
+		edges:   make(map[int64]bool),
+		objects: map[string]model.Object{obj.GetObjectID(): obj},
+	}
+
+	name, err := insts[0].GetInstName()
+	if nil != err {
+		return nil, err
+	}
+	w.addNode(obj.GetObjectID(), instID, name, 0)
+
+	frontier := map[string][]int64{obj.GetObjectID(): {instID}}
+	for depth := 1; depth <= request.MaxDepth && len(frontier) != 0 && !w.graph.Truncated; depth++ {
+		if frontier, err = w.walk(frontier, depth); nil != err {
+			return nil, err
+		}
+	}
+
+	w.graph.Cyclic = metadata.InstGraphCyclic(w.graph.Edges)
+	return w.graph, nil
+}
+
+// walk reach the instances of the next level from the frontier, and returns the new frontier
+func (w *instGraphWalker) walk(frontier map[string][]int64, depth int) (map[string][]int64, error) {
+	assts, err := w.searchAssociations(frontier)
+	if nil != err {
+		return nil, err
+	}
+
+	inFrontier := make(map[string]bool)
+	for objID, instIDs := range frontier {
+		for _, instID := range instIDs {
+			inFrontier[instGraphKey(objID, instID)] = true
+		}
+	}
+
+	allowedObjects := make(map[string]bool)
+	for _, objID := range w.request.Objects {
+		allowedObjects[objID] = true
+	}
+
+	pending := make([]pendingInstGraphEdge, 0)
+	candidates := make(map[string][]int64)
+	candidateExists := make(map[string]bool)
+	visit := func(asst metadata.InstAsst, objID string, instID int64) {
+		if len(allowedObjects) != 0 && !allowedObjects[objID] {
+			return
+		}
+
+		key := instGraphKey(objID, instID)
+		if _, exists := w.depths[key]; exists {
+			w.addEdge(asst)
+			return
+		}
+
+		pending = append(pending, pendingInstGraphEdge{asst: asst, target: key})
+		if !candidateExists[key] {
+			candidateExists[key] = true
+			candidates[objID] = append(candidates[objID], instID)
+		}
+	}
+
+	for _, asst := range assts {
+		// the association walked through on the previous level
+		if w.edges[asst.ID] {
+			continue
+		}
+		if w.request.Direction != metadata.InstGraphInbound && inFrontier[instGraphKey(asst.ObjectID, asst.InstID)] {
+			visit(asst, asst.AsstObjectID, asst.AsstInstID)
+		}
+		if w.request.Direction != metadata.InstGraphOutbound && inFrontier[instGraphKey(asst.AsstObjectID, asst.AsstInstID)] {
+			visit(asst, asst.ObjectID, asst.InstID)
+		}
+	}
+
+	next := make(map[string][]int64)
+	for objID, instIDs := range candidates {
+		if w.graph.Truncated {
+			break
+		}
+
+		reached, err := w.reach(objID, instIDs, depth)
+		if nil != err {
+			return nil, err
+		}
+		if len(reached) != 0 {
+			next[objID] = reached
+		}
+	}
+
+	for _, edge := range pending {
+		if _, exists := w.depths[edge.target]; exists {
+			w.addEdge(edge.asst)
+		}
+	}
+
+	return next, nil
+}
+
+// searchAssociations search the associations of the instances in the frontier in the allowed direction
+func (w *instGraphWalker) searchAssociations(frontier map[string][]int64) ([]metadata.InstAsst, error) {
+	conds := make([]mapstr.MapStr, 0)
+	for objID, instIDs := range frontier {
+		if w.request.Direction != metadata.InstGraphInbound {
+			conds = append(conds, mapstr.MapStr{
+				common.BKObjIDField:  objID,
+				common.BKInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+			})
+		}
+		if w.request.Direction != metadata.InstGraphOutbound {
+			conds = append(conds, mapstr.MapStr{
+				common.BKAsstObjIDField:  objID,
+				common.BKAsstInstIDField: mapstr.MapStr{common.BKDBIN: instIDs},
+			})
+		}
+	}
+
+	cond := mapstr.MapStr{common.BKDBOR: conds}
+	if len(w.request.AssociationKinds) != 0 {
+		cond.Set(common.AssociationKindIDField, mapstr.MapStr{common.BKDBIN: w.request.AssociationKinds})
+	}
+
+	assts, err := w.c.asst.SearchInstAssociation(w.params, &metadata.QueryInput{Condition: cond})
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to search the inst associations of the graph, err: %s, rid: %s", err.Error(), w.params.ReqID)
+		return nil, err
+	}
+	return assts, nil
+}
+
+// reach find the instances which match the filters of the level, and add them to the graph
+func (w *instGraphWalker) reach(objID string, instIDs []int64, depth int) ([]int64, error) {
+	obj, exists := w.objects[objID]
+	if !exists {
+		var err error
+		obj, err = w.c.obj.FindSingleObject(w.params, objID)
+		if nil != err {
+			blog.Errorf("[operation-inst] failed to find the object(%s) of the graph, err: %s, rid: %s", objID, err.Error(), w.params.ReqID)
+			return nil, err
+		}
+		w.objects[objID] = obj
+	}
+
+	cond := mapstr.MapStr{obj.GetInstIDFieldName(): mapstr.MapStr{common.BKDBIN: instIDs}}
+	if filters := w.request.FilterOf(depth, objID); len(filters) != 0 {
+		cond = mapstr.MapStr{common.BKDBAND: append([]mapstr.MapStr{cond}, filters...)}
+	}
+
+	_, insts, err := w.c.FindInst(w.params, obj, &metadata.QueryInput{Condition: cond, Limit: common.BKNoLimit}, false)
+	if nil != err {
+		blog.Errorf("[operation-inst] failed to find the insts of the object(%s) of the graph, err: %s, rid: %s", objID, err.Error(), w.params.ReqID)
+		return nil, err
+	}
+
+	reached := make([]int64, 0)
+	for _, inst := range insts {
+		if len(w.graph.Nodes) >= w.request.MaxNodes {
+			w.graph.Truncated = true
+			break
+		}
+
+		instID, err := inst.GetInstID()
+		if nil != err {
+			return nil, err
+		}
+		name, err := inst.GetInstName()
+		if nil != err {
+			return nil, err
+		}
+
+		w.addNode(objID, instID, name, depth)
+		reached = append(reached, instID)
+	}
+
+	return reached, nil
+}
+
+func (w *instGraphWalker) addNode(objID string, instID int64, name string, depth int) {
+	w.depths[instGraphKey(objID, instID)] = depth
+	w.graph.Nodes = append(w.graph.Nodes, metadata.InstGraphNode{
+		ObjectID: objID,
+		InstID:   instID,
+		InstName: name,
+		Depth:    depth,
+	})
+}
+
+func (w *instGraphWalker) addEdge(asst metadata.InstAsst) {
+	if w.edges[asst.ID] {
+		return
+	}
+	w.edges[asst.ID] = true
+	w.graph.Edges = append(w.graph.Edges, metadata.InstGraphEdge{
+		ID:                asst.ID,
+		ObjectAsstID:      asst.ObjectAsstID,
+		AssociationKindID: asst.AssociationKindID,
+		ObjectID:          asst.ObjectID,
+		InstID:            asst.InstID,
+		AsstObjectID:      asst.AsstObjectID,
+		AsstInstID:        asst.AsstInstID,
+	})
+}
@@ -15,6 +15,8 @@ package operation
 import (
 	"time"
 
+	"configcenter/src/common"
+	"configcenter/src/common/condition"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/scene_server/topo_server/core/model"
@@ -26,6 +28,8 @@ type opcondition struct {
 
 type deleteCondition struct {
 	opcondition `json:",inline"`
+	// DryRun only returns the impact of the deletion without deleting anything.
+	DryRun bool `json:"dry_run"`
 }
 
 type updateCondition struct {
@@ -78,6 +82,50 @@ type deletedInst struct {
 	obj    model.Object
 }
 
+// deletedInstGroup the deleted instances of the same object
+type deletedInstGroup struct {
+	obj     model.Object
+	instIDs []int64
+}
+
+// groupDeletedInst group the deleted instances by the object, the order of the objects is kept.
+func groupDeletedInst(insts []deletedInst) []*deletedInstGroup {
+	groups := []*deletedInstGroup{}
+	index := map[string]*deletedInstGroup{}
+	for _, inst := range insts {
+		group, exists := index[inst.obj.GetObjectID()]
+		if !exists {
+			group = &deletedInstGroup{obj: inst.obj}
+			index[inst.obj.GetObjectID()] = group
+			groups = append(groups, group)
+		}
+		group.instIDs = append(group.instIDs, inst.instID)
+	}
+	return groups
+}
+
+// deleteOption the option which deletes the instances of all the groups in one go, the instances of the
+// first group are matched by the condition, and the others are deleted along with them.
+func deleteOption(groups []*deletedInstGroup) *metadata.DeleteOption {
+	first := groups[0]
+	cond := condition.CreateCondition()
+	cond.Field(first.obj.GetInstIDFieldName()).In(first.instIDs)
+	if first.obj.IsCommon() {
+		cond.Field(common.BKObjIDField).Eq(first.obj.GetObjectID())
+	}
+
+	opt := &metadata.DeleteOption{Condition: cond.ToMapStr()}
+	for _, group := range groups[1:] {
+		opt.Along = append(opt.Along, metadata.DeleteAlongInsts{ObjectID: group.obj.GetObjectID(), InstIDs: group.instIDs})
+	}
+	return opt
+}
+
+type deleteAudit struct {
+	obj      model.Object
+	preAudit *WrapperResult
+}
+
 // OperationLog opeartion log item definition
 type OperationLog struct {
 	OwnerID       string      `bson:"bk_supplier_account"    json:"bk_supplier_account"`
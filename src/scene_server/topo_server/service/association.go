@@ -303,3 +303,13 @@ func (s *Service) DeleteAssociationInst(params types.ContextParams, pathParams,
 
 	}
 }
+
+// SearchAssociationInstMappingViolation search the instances associated with more instances than the mapping allows
+func (s *Service) SearchAssociationInstMappingViolation(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	request := &metadata.SearchAsstMappingViolationRequest{}
+	if err := data.MarshalJSONInto(request); err != nil {
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	return s.Core.AssociationOperation().SearchInstMappingViolation(params, request)
+}
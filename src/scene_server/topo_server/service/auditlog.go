@@ -176,6 +176,54 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		}
 	}
 
+	if resp, err := s.authorizeInstanceAudit(params, objectID, instanceID, businessID); err != nil {
+		return resp, err
+	}
+
+	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v", query)
+	return s.Core.AuditOperation().Query(params, query)
+}
+
+// InstanceHistoryQuery rebuild the instance and its associations at a moment from the audit logs,
+// with the changes of them before the moment.
+func (s *Service) InstanceHistoryQuery(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	query := metadata.InstanceHistoryOption{}
+	if err := data.MarshalJSONInto(&query); nil != err {
+		blog.Errorf("InstanceHistoryQuery failed, failed to parse the input (%#v), error info is %s", data, err.Error())
+		return nil, params.Err.New(common.CCErrCommJSONUnmarshalFailed, err.Error())
+	}
+
+	objectID := pathParams("bk_obj_id")
+	if query.InstID <= 0 {
+		blog.Errorf("InstanceHistoryQuery failed, the instance id is not set, query: %+v", query)
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedSet, common.BKInstIDField)
+	}
+
+	var businessID int64
+	if objectID == common.BKInnerObjIDApp {
+		businessID = query.InstID
+	}
+	if resp, err := s.authorizeInstanceAudit(params, objectID, query.InstID, businessID); err != nil {
+		return resp, err
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Audit().SearchInstanceHistory(params.Context, params.Header, objectID, query)
+	if err != nil {
+		blog.Errorf("InstanceHistoryQuery failed, rebuild the instance %s %d failed, err: %v", objectID, query.InstID, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("InstanceHistoryQuery failed, rebuild the instance %s %d failed, err: %s", objectID, query.InstID, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// authorizeInstanceAudit authorize the audit logs of the instance by the instance,
+// the response is returned to the user if it's not nil when the authorization is failed.
+func (s *Service) authorizeInstanceAudit(params types.ContextParams, objectID string, instanceID, businessID int64) (interface{}, error) {
+	var err error
 	action := meta.Find
 	switch objectID {
 	case common.BKInnerObjIDHost:
@@ -185,7 +233,7 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		if err != nil && err == auth.NoAuthorizeError {
 			resp, err := s.AuthManager.GenProcessNoPermissionResp(params.Context, params.Header, businessID)
 			if err != nil {
-				return nil, params.Err.Errorf(common.CCErrTopoGetAppFailed, bizID)
+				return nil, params.Err.Errorf(common.CCErrTopoGetAppFailed, businessID)
 			}
 			return resp, auth.NoAuthorizeError
 		}
@@ -212,10 +260,9 @@ func (s *Service) InstanceAuditQuery(params types.ContextParams, pathParams, que
 		err = s.AuthManager.AuthorizeByInstanceID(params.Context, params.Header, action, objectID, instanceID)
 	}
 	if err != nil {
-		blog.Errorf("InstanceAuditQuery failed, query instance audit log failed, authorization on instance of model %s failed, err: %+v", objectID, err)
+		blog.Errorf("query instance audit log failed, authorization on instance of model %s failed, err: %+v", objectID, err)
 		return nil, params.Err.Error(common.CCErrCommAuthorizeFailed)
 	}
 
-	blog.V(4).Infof("InstanceAuditQuery failed, AuditOperation parameter: %+v", query)
-	return s.Core.AuditOperation().Query(params, query)
+	return nil, nil
 }
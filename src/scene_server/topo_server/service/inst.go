@@ -13,6 +13,8 @@
 package service
 
 import (
+	"context"
+	"fmt"
 	"strconv"
 	"strings"
 
@@ -22,6 +24,8 @@ import (
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	paraparse "configcenter/src/common/paraparse"
+	"configcenter/src/common/querylang"
+	"configcenter/src/scene_server/topo_server/core/model"
 	"configcenter/src/scene_server/topo_server/core/operation"
 	"configcenter/src/scene_server/topo_server/core/types"
 )
@@ -115,13 +119,11 @@ func (s *Service) DeleteInsts(params types.ContextParams, pathParams, queryParam
 		return nil, err
 	}
 
-	// auth: deregister resources
-	if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, obj.GetObjectID(), deleteCondition.Delete.InstID...); err != nil {
-		blog.Errorf("batch delete instance failed, deregister instance failed, instID: %d, err: %s", deleteCondition.Delete.InstID, err)
-		return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+	if deleteCondition.Delete.DryRun {
+		return s.Core.InstOperation().DeleteInstByInstID(params, obj, deleteCondition.Delete.InstID, true, true)
 	}
 
-	return nil, s.Core.InstOperation().DeleteInstByInstID(params, obj, deleteCondition.Delete.InstID, true)
+	return nil, s.deleteInsts(params, obj, deleteCondition.Delete.InstID)
 }
 
 // DeleteInst delete the inst
@@ -143,14 +145,53 @@ func (s *Service) DeleteInst(params types.ContextParams, pathParams, queryParams
 		return nil, err
 	}
 
-	// auth: deregister resources
-	if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, obj.GetObjectID(), instID); err != nil {
-		blog.Errorf("delete instance failed, deregister instance failed, instID: %d, err: %s", instID, err)
-		return nil, params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+	if "true" == queryParams("dry_run") {
+		return s.Core.InstOperation().DeleteInstByInstID(params, obj, []int64{instID}, true, true)
 	}
 
-	err = s.Core.InstOperation().DeleteInstByInstID(params, obj, []int64{instID}, true)
-	return nil, err
+	return nil, s.deleteInsts(params, obj, []int64{instID})
+}
+
+// deleteInsts delete the instances in a transaction, the instances related by the associations are
+// deleted together or refuse the deletion as the on delete action of the associations says.
+func (s *Service) deleteInsts(params types.ContextParams, obj model.Object, instIDs []int64) error {
+	tx, err := s.Txn.StartTransaction(context.Background())
+	if err != nil {
+		blog.Errorf("delete instance failed, start transaction failed, err: %v", err)
+		return params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	params.Header = tx.TxnInfo().IntoHeader(params.Header)
+
+	impact, err := s.Core.InstOperation().DeleteInstByInstID(params, obj, instIDs, true, false)
+	if err != nil {
+		blog.Errorf("delete instance failed, instID: %v, err: %v", instIDs, err)
+		if txnErr := tx.Abort(context.Background()); txnErr != nil {
+			blog.Errorf("delete instance, but abort transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, txnErr)
+		}
+		return err
+	}
+
+	if txnErr := tx.Commit(context.Background()); txnErr != nil {
+		blog.Errorf("delete instance, but commit transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, txnErr)
+		return params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	// auth: deregister resources after the deletion is committed, including the instances deleted by the cascade.
+	objIDs := []string{}
+	impactIDs := map[string][]int64{}
+	for _, inst := range impact.Instances {
+		if _, exists := impactIDs[inst.ObjectID]; !exists {
+			objIDs = append(objIDs, inst.ObjectID)
+		}
+		impactIDs[inst.ObjectID] = append(impactIDs[inst.ObjectID], inst.InstID)
+	}
+	for _, objID := range objIDs {
+		if err := s.AuthManager.DeregisterInstanceByRawID(params.Context, params.Header, objID, impactIDs[objID]...); err != nil {
+			blog.Errorf("delete instance success, but deregister instance failed, objID: %s, instID: %v, err: %s", objID, impactIDs[objID], err)
+			return params.Err.Error(common.CCErrCommUnRegistResourceToIAMFailed)
+		}
+	}
+	return nil
 }
 
 func (s *Service) UpdateInsts(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
@@ -273,6 +314,13 @@ func (s *Service) SearchInsts(params types.ContextParams, pathParams, queryParam
 		blog.Errorf("[api-inst] failed to parse the data and the condition, the input (%#v), error info is %s", data, err.Error())
 		return nil, err
 	}
+	if "" != queryCond.Query {
+		cond, err := s.instQueryCondition(params, obj, queryCond.Condition, queryCond.Query)
+		if nil != err {
+			return nil, err
+		}
+		queryCond.Condition = cond
+	}
 	page := metadata.ParsePage(queryCond.Page)
 	query := &metadata.QueryInput{}
 	query.Condition = queryCond.Condition
@@ -310,6 +358,13 @@ func (s *Service) SearchInstAndAssociationDetail(params types.ContextParams, pat
 		blog.Errorf("[api-inst] failed to parse the data and the condition, the input (%#v), error info is %s", data, err.Error())
 		return nil, err
 	}
+	if "" != queryCond.Query {
+		cond, err := s.instQueryCondition(params, obj, queryCond.Condition, queryCond.Query)
+		if nil != err {
+			return nil, err
+		}
+		queryCond.Condition = cond
+	}
 	page := metadata.ParsePage(queryCond.Page)
 	query := &metadata.QueryInput{}
 	query.Condition = queryCond.Condition
@@ -347,6 +402,13 @@ func (s *Service) SearchInstByObject(params types.ContextParams, pathParams, que
 		blog.Errorf("[api-inst] failed to parse the data and the condition, the input (%#v), error info is %s", data, err.Error())
 		return nil, err
 	}
+	if "" != queryCond.Query {
+		cond, err := s.instQueryCondition(params, obj, queryCond.Condition, queryCond.Query)
+		if nil != err {
+			return nil, err
+		}
+		queryCond.Condition = cond
+	}
 	page := metadata.ParsePage(queryCond.Page)
 	query := &metadata.QueryInput{}
 	query.Condition = queryCond.Condition
@@ -377,6 +439,12 @@ func (s *Service) SearchInstByAssociation(params types.ContextParams, pathParams
 		return nil, err
 	}
 
+	if query, ok := data["query"].(string); ok && "" != query {
+		if err := s.mergeInstAssociationQuery(params, obj, data, query); nil != err {
+			return nil, err
+		}
+	}
+
 	cnt, instItems, err := s.Core.InstOperation().FindInstByAssociationInst(params, obj, data)
 	if nil != err {
 		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s", pathParams("bk_obj_id"), err.Error())
@@ -476,3 +544,119 @@ func (s *Service) SearchInstTopo(params types.ContextParams, pathParams, queryPa
 
 	return instItems, err
 }
+
+// SearchInstGraph walk the instance associations from a instance to multiple levels
+func (s *Service) SearchInstGraph(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams("bk_obj_id")
+	instID, err := strconv.ParseInt(pathParams("inst_id"), 10, 64)
+	if nil != err {
+		blog.Errorf("search inst graph failed, path parameter inst_id invalid, inst_id: %s, err: %+v, rid: %s", pathParams("inst_id"), err, params.ReqID)
+		return nil, params.Err.Error(common.CCErrCommParamsIsInvalid)
+	}
+
+	request := &metadata.SearchInstGraphRequest{}
+	if err := data.MarshalJSONInto(request); nil != err {
+		blog.Errorf("search inst graph failed, decode the request failed, err: %v, rid: %s", err, params.ReqID)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	obj, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+	if nil != err {
+		blog.Errorf("[api-inst] failed to find the objects(%s), error info is %s, rid: %s", objID, err.Error(), params.ReqID)
+		return nil, err
+	}
+
+	return s.Core.InstOperation().FindInstGraph(params, obj, instID, request)
+}
+
+// compileInstQuery compiles the query statement of the instance search, the unqualified fields are the fields of the object
+func (s *Service) compileInstQuery(params types.ContextParams, obj model.Object, query string) ([]metadata.SearchCondition, error) {
+	q, err := querylang.Parse(query)
+	if nil != err {
+		blog.Errorf("[api-inst] failed to parse the query %s, error info is %s, rid: %s", query, err.Error(), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommQueryInvalid, err.Error())
+	}
+
+	var attrErr error
+	conds, err := q.Compile(obj.Object().ObjectID, func(objID string) ([]metadata.Attribute, error) {
+		target, err := s.Core.ObjectOperation().FindSingleObject(params, objID)
+		if nil != err {
+			attrErr = err
+			return nil, err
+		}
+		attrs, err := target.GetAttributes()
+		if nil != err {
+			attrErr = err
+			return nil, err
+		}
+		results := make([]metadata.Attribute, 0)
+		for _, attr := range attrs {
+			results = append(results, *attr.Attribute())
+		}
+		return results, nil
+	})
+	if nil != attrErr {
+		return nil, attrErr
+	}
+	if nil != err {
+		blog.Errorf("[api-inst] failed to compile the query %s, error info is %s, rid: %s", query, err.Error(), params.ReqID)
+		return nil, params.Err.Errorf(common.CCErrCommQueryInvalid, err.Error())
+	}
+	return conds, nil
+}
+
+// instQueryCondition returns the condition which matches both the condition and the query on the fields of the object
+func (s *Service) instQueryCondition(params types.ContextParams, obj model.Object, cond map[string]interface{}, query string) (map[string]interface{}, error) {
+	conds, err := s.compileInstQuery(params, obj, query)
+	if nil != err {
+		return nil, err
+	}
+
+	queryCond := make(map[string]interface{})
+	for _, item := range conds {
+		if item.ObjectID != obj.Object().ObjectID {
+			msg := fmt.Sprintf("the fields of the object %s can only be searched by the association search", item.ObjectID)
+			return nil, params.Err.Errorf(common.CCErrCommQueryInvalid, msg)
+		}
+		paraparse.ParseCommonParams(item.Condition, queryCond)
+	}
+	if 0 == len(cond) {
+		return queryCond, nil
+	}
+	return map[string]interface{}{common.BKDBAND: []interface{}{cond, queryCond}}, nil
+}
+
+// mergeInstAssociationQuery appends the conditions compiled from the query to the conditions of the association search
+func (s *Service) mergeInstAssociationQuery(params types.ContextParams, obj model.Object, data mapstr.MapStr, query string) error {
+	conds, err := s.compileInstQuery(params, obj, query)
+	if nil != err {
+		return err
+	}
+
+	asstParams := &operation.AssociationParams{}
+	if err := data.MarshalJSONInto(asstParams); nil != err {
+		blog.Errorf("[api-inst] failed to parse the association search params, the input (%#v), error info is %s", data, err.Error())
+		return params.Err.Errorf(common.CCErrTopoInstSelectFailed, err.Error())
+	}
+	if nil == asstParams.Condition {
+		asstParams.Condition = make(map[string][]operation.ConditionItem)
+	}
+	for _, cond := range conds {
+		for _, item := range cond.Condition {
+			// the association search keeps only one item of each field
+			for _, exist := range asstParams.Condition[cond.ObjectID] {
+				if exist.Field == item.Field {
+					msg := fmt.Sprintf("the field %s of the object %s is in both the condition and the query", item.Field, cond.ObjectID)
+					return params.Err.Errorf(common.CCErrCommQueryInvalid, msg)
+				}
+			}
+			asstParams.Condition[cond.ObjectID] = append(asstParams.Condition[cond.ObjectID], operation.ConditionItem{
+				Field:    item.Field,
+				Operator: item.Operator,
+				Value:    item.Value,
+			})
+		}
+	}
+	data.Set("condition", asstParams.Condition)
+	return nil
+}
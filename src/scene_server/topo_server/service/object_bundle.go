@@ -0,0 +1,303 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// ExportObjectBundle export the objects as a declarative bundle, in json or yaml
+func (s *Service) ExportObjectBundle(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := metadata.ExportModelBundleOption{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("[ExportObjectBundle] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+	format, _ := data.String("format")
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().ExportModelBundle(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("[ExportObjectBundle] export the objects %v failed, err: %v", input.ObjectIDs, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[ExportObjectBundle] export the objects %v failed, err: %s", input.ObjectIDs, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	if format != metadata.ModelBundleFormatYAML {
+		return resp.Data, nil
+	}
+	content, err := metadata.EncodeModelBundle(&resp.Data, format)
+	if err != nil {
+		blog.Errorf("[ExportObjectBundle] encode the bundle in %s failed, err: %v", format, err)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+	return mapstr.MapStr{"format": format, "content": string(content)}, nil
+}
+
+// PlanObjectBundle show the changes to make the objects match the bundle
+func (s *Service) PlanObjectBundle(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input, err := parseBundleRequest(params, data)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().PlanModelBundle(params.Context, params.Header, *input)
+	if err != nil {
+		blog.Errorf("[PlanObjectBundle] plan the bundle failed, err: %v", err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[PlanObjectBundle] plan the bundle failed, err: %s", resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// ApplyObjectBundle apply the changes to make the objects match the bundle, the changes are applied in a transaction
+func (s *Service) ApplyObjectBundle(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input, err := parseBundleRequest(params, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// the pruned objects are searched before they're deleted, to deregister them from iam
+	pruned, err := s.searchPrunedObjects(params, input)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.Txn.StartTransaction(context.Background())
+	if err != nil {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle failed, start transaction failed, err: %v", err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	params.Header = tx.TxnInfo().IntoHeader(params.Header)
+
+	result, err := s.applyObjectBundle(params, input)
+	if err != nil {
+		if txnErr := tx.Abort(context.Background()); txnErr != nil {
+			blog.Errorf("[ApplyObjectBundle] apply the bundle, but abort transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, txnErr)
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle, but commit transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	// auth: register the created classifications and objects to iam, and deregister the deleted objects
+	if err := s.registerBundleResources(params, result.Changes); err != nil {
+		return nil, params.Err.New(common.CCErrCommRegistResourceToIAMFailed, err.Error())
+	}
+	if err := s.AuthManager.DeregisterObject(params.Context, params.Header, pruned...); err != nil {
+		blog.Errorf("[ApplyObjectBundle] deregister the pruned objects failed, err: %v", err)
+		return nil, params.Err.New(common.CCErrCommUnRegistResourceToIAMFailed, err.Error())
+	}
+
+	return result, nil
+}
+
+func (s *Service) applyObjectBundle(params types.ContextParams, input *metadata.ModelBundleOption) (*metadata.ModelBundleApplyResult, error) {
+	resp, err := s.Engine.CoreAPI.CoreService().Model().ApplyModelBundle(params.Context, params.Header, *input)
+	if err != nil {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle failed, err: %v", err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle failed, err: %s", resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	// the bundle is applied entirely or not at all
+	for _, change := range resp.Data.Changes {
+		if len(change.Error) != 0 {
+			blog.Errorf("[ApplyObjectBundle] apply the bundle failed, %s the %s %s, err: %s", change.Action, change.Kind, change.Key, change.Error)
+			return nil, params.Err.Errorf(common.CCErrTopoModelBundleApplyFailed, fmt.Sprintf("%s %s %s: %s", change.Action, change.Kind, change.Key, change.Error))
+		}
+	}
+
+	if err := s.saveBundleAuditLogs(params, resp.Data.Changes); err != nil {
+		return nil, err
+	}
+	return &resp.Data, nil
+}
+
+// saveBundleAuditLogs save the audit logs of the applied changes of the bundle
+func (s *Service) saveBundleAuditLogs(params types.ContextParams, changes []metadata.BundleChange) error {
+	logs := make([]metadata.SaveAuditLogParams, 0)
+	for _, change := range changes {
+		var opType auditoplog.AuditOpType
+		switch change.Action {
+		case metadata.BundleActionCreate:
+			opType = auditoplog.AuditOpTypeAdd
+		case metadata.BundleActionUpdate:
+			opType = auditoplog.AuditOpTypeModify
+		case metadata.BundleActionDelete:
+			opType = auditoplog.AuditOpTypeDel
+		default:
+			continue
+		}
+		logs = append(logs, metadata.SaveAuditLogParams{
+			Model: metadata.AuditTargetModelBundle,
+			Content: metadata.Content{
+				CurData: change,
+				Headers: bundleAuditHeaders,
+			},
+			OpDesc: fmt.Sprintf("%s %s by the bundle", change.Action, change.Kind),
+			OpType: opType,
+		})
+	}
+	if len(logs) == 0 {
+		return nil
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Audit().SaveAuditLog(params.Context, params.Header, logs...)
+	if err != nil {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle success, but save audit log failed, err: %v", err)
+		return params.Err.Error(common.CCErrAuditSaveLogFaile)
+	}
+	if !resp.Result {
+		blog.Errorf("[ApplyObjectBundle] apply the bundle success, but save audit log failed, err: %s", resp.ErrMsg)
+		return params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	return nil
+}
+
+// bundleAuditHeaders the headers of the audit logs of the changes of the bundle
+var bundleAuditHeaders = []metadata.Header{
+	{PropertyID: "action", PropertyName: "action"},
+	{PropertyID: "kind", PropertyName: "kind"},
+	{PropertyID: common.BKObjIDField, PropertyName: common.BKObjIDField},
+	{PropertyID: "key", PropertyName: "key"},
+	{PropertyID: "fields", PropertyName: "fields"},
+}
+
+// searchPrunedObjects returns the objects which are deleted by applying the bundle
+func (s *Service) searchPrunedObjects(params types.ContextParams, input *metadata.ModelBundleOption) ([]metadata.Object, error) {
+	objects := make([]metadata.Object, 0)
+	if !input.Prune {
+		return objects, nil
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().PlanModelBundle(params.Context, params.Header, *input)
+	if err != nil {
+		blog.Errorf("[ApplyObjectBundle] plan the bundle failed, err: %v", err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[ApplyObjectBundle] plan the bundle failed, err: %s", resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	objIDs := make([]interface{}, 0)
+	for _, change := range resp.Data.Changes {
+		if change.Action == metadata.BundleActionDelete && change.Kind == metadata.BundleKindModel {
+			objIDs = append(objIDs, change.ObjectID)
+		}
+	}
+	if len(objIDs) == 0 {
+		return objects, nil
+	}
+
+	cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}}
+	models, err := s.Engine.CoreAPI.CoreService().Model().ReadModel(params.Context, params.Header, cond)
+	if err != nil {
+		blog.Errorf("[ApplyObjectBundle] search the pruned objects %v failed, err: %v", objIDs, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !models.Result {
+		blog.Errorf("[ApplyObjectBundle] search the pruned objects %v failed, err: %s", objIDs, models.ErrMsg)
+		return nil, params.Err.New(models.Code, models.ErrMsg)
+	}
+	for _, info := range models.Data.Info {
+		objects = append(objects, info.Spec)
+	}
+	return objects, nil
+}
+
+func parseBundleRequest(params types.ContextParams, data mapstr.MapStr) (*metadata.ModelBundleOption, error) {
+	request := metadata.ModelBundleRequest{}
+	if err := data.MarshalJSONInto(&request); err != nil {
+		blog.Errorf("[parseBundleRequest] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	input, err := request.Option()
+	if err != nil {
+		blog.Errorf("[parseBundleRequest] decode the bundle in %s failed, err: %v", request.Format, err)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+	return input, nil
+}
+
+func (s *Service) registerBundleResources(params types.ContextParams, changes []metadata.BundleChange) error {
+	clsIDs, objIDs := make([]interface{}, 0), make([]interface{}, 0)
+	for _, change := range changes {
+		if change.Action != metadata.BundleActionCreate {
+			continue
+		}
+		switch change.Kind {
+		case metadata.BundleKindClassification:
+			clsIDs = append(clsIDs, change.Key)
+		case metadata.BundleKindModel:
+			objIDs = append(objIDs, change.ObjectID)
+		}
+	}
+
+	if len(clsIDs) != 0 {
+		cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKClassificationIDField: mapstr.MapStr{common.BKDBIN: clsIDs}}}
+		resp, err := s.Engine.CoreAPI.CoreService().Model().ReadModelClassification(params.Context, params.Header, cond)
+		if err != nil {
+			return err
+		}
+		if !resp.Result {
+			return params.Err.New(resp.Code, resp.ErrMsg)
+		}
+		if err := s.AuthManager.RegisterClassification(params.Context, params.Header, resp.Data.Info...); err != nil {
+			blog.Errorf("[registerBundleResources] register the classifications %v failed, err: %v", clsIDs, err)
+			return err
+		}
+	}
+
+	if len(objIDs) != 0 {
+		cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}}
+		resp, err := s.Engine.CoreAPI.CoreService().Model().ReadModel(params.Context, params.Header, cond)
+		if err != nil {
+			return err
+		}
+		if !resp.Result {
+			return params.Err.New(resp.Code, resp.ErrMsg)
+		}
+		objects := make([]metadata.Object, 0)
+		for _, info := range resp.Data.Info {
+			objects = append(objects, info.Spec)
+		}
+		if err := s.AuthManager.RegisterObject(params.Context, params.Header, objects...); err != nil {
+			blog.Errorf("[registerBundleResources] register the objects %v failed, err: %v", objIDs, err)
+			return err
+		}
+	}
+
+	return nil
+}
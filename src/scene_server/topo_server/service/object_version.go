@@ -0,0 +1,138 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchObjectVersion search the versions of the object schema
+func (s *Service) SearchObjectVersion(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	input := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("[SearchObjectVersion] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().ReadModelVersion(params.Context, params.Header, objID, input)
+	if err != nil {
+		blog.Errorf("[SearchObjectVersion] search the versions of the object %s failed, err: %v", objID, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[SearchObjectVersion] search the versions of the object %s failed, err: %s", objID, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// DiffObjectVersion compare two versions of the object schema
+func (s *Service) DiffObjectVersion(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	input := metadata.DiffModelVersionOption{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("[DiffObjectVersion] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().DiffModelVersion(params.Context, params.Header, objID, input)
+	if err != nil {
+		blog.Errorf("[DiffObjectVersion] compare the versions %d and %d of the object %s failed, err: %v", input.From, input.To, objID, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[DiffObjectVersion] compare the versions %d and %d of the object %s failed, err: %s", input.From, input.To, objID, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// ExportObjectVersion export the version of the object schema as a portable document
+func (s *Service) ExportObjectVersion(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	version, err := strconv.ParseInt(pathParams("version"), 10, 64)
+	if err != nil {
+		return nil, params.Err.Errorf(common.CCErrCommParamsNeedInt, "version")
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Model().ExportModelVersion(params.Context, params.Header, objID, version)
+	if err != nil {
+		blog.Errorf("[ExportObjectVersion] export the version %d of the object %s failed, err: %v", version, objID, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[ExportObjectVersion] export the version %d of the object %s failed, err: %s", version, objID, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// RollbackObjectVersion restore the schema of the object to the version, the changes are applied in a transaction
+func (s *Service) RollbackObjectVersion(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	objID := pathParams(common.BKObjIDField)
+	input := metadata.RollbackModelVersionOption{}
+	if err := data.MarshalJSONInto(&input); err != nil {
+		blog.Errorf("[RollbackObjectVersion] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	if input.DryRun {
+		return s.rollbackObjectVersion(params, objID, input)
+	}
+
+	tx, err := s.Txn.StartTransaction(context.Background())
+	if err != nil {
+		blog.Errorf("[RollbackObjectVersion] rollback the object %s failed, start transaction failed, err: %v", objID, err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	params.Header = tx.TxnInfo().IntoHeader(params.Header)
+
+	result, err := s.rollbackObjectVersion(params, objID, input)
+	if err != nil {
+		if txnErr := tx.Abort(context.Background()); txnErr != nil {
+			blog.Errorf("[RollbackObjectVersion] rollback the object %s, but abort transaction[id: %s] failed, err: %v", objID, tx.TxnInfo().TxnID, txnErr)
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		blog.Errorf("[RollbackObjectVersion] rollback the object %s, but commit transaction[id: %s] failed, err: %v", objID, tx.TxnInfo().TxnID, err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	return result, nil
+}
+
+func (s *Service) rollbackObjectVersion(params types.ContextParams, objID string, input metadata.RollbackModelVersionOption) (*metadata.ModelVersionRollbackResult, error) {
+	resp, err := s.Engine.CoreAPI.CoreService().Model().RollbackModelVersion(params.Context, params.Header, objID, input)
+	if err != nil {
+		blog.Errorf("[RollbackObjectVersion] rollback the object %s to the version %d failed, err: %v", objID, input.Version, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[RollbackObjectVersion] rollback the object %s to the version %d failed, err: %s", objID, input.Version, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return &resp.Data, nil
+}
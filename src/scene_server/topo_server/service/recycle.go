@@ -0,0 +1,161 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/scene_server/topo_server/core/types"
+)
+
+// SearchRecycleItem search the deleted instances, hosts and models in the recycle bin
+func (s *Service) SearchRecycleItem(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := &metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(input); err != nil {
+		blog.Errorf("[SearchRecycleItem] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Recycle().SearchRecycleItem(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("[SearchRecycleItem] search the recycle items failed, err: %v", err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[SearchRecycleItem] search the recycle items failed, err: %s", resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+// RestoreRecycleItem restore the items in the recycle bin with their associations
+func (s *Service) RestoreRecycleItem(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := &metadata.RecycleOption{}
+	if err := data.MarshalJSONInto(input); err != nil {
+		blog.Errorf("[RestoreRecycleItem] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	// the items are restored and deleted from the recycle bin in a transaction
+	tx, err := s.Txn.StartTransaction(context.Background())
+	if err != nil {
+		blog.Errorf("[RestoreRecycleItem] restore the recycle items %v failed, start transaction failed, err: %v", input, err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+	params.Header = tx.TxnInfo().IntoHeader(params.Header)
+
+	resp, err := s.Engine.CoreAPI.CoreService().Recycle().RestoreRecycleItem(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("[RestoreRecycleItem] restore the recycle items %v failed, err: %v", input, err)
+		err = params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	} else if !resp.Result {
+		blog.Errorf("[RestoreRecycleItem] restore the recycle items %v failed, err: %s", input, resp.ErrMsg)
+		err = params.Err.New(resp.Code, resp.ErrMsg)
+	}
+	if err != nil {
+		if txnErr := tx.Abort(context.Background()); txnErr != nil {
+			blog.Errorf("[RestoreRecycleItem] restore the recycle items, but abort transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, txnErr)
+		}
+		return nil, err
+	}
+
+	if err := tx.Commit(context.Background()); err != nil {
+		blog.Errorf("[RestoreRecycleItem] restore the recycle items, but commit transaction[id: %s] failed, err: %v", tx.TxnInfo().TxnID, err)
+		return nil, params.Err.Error(common.CCErrObjectDBOpErrno)
+	}
+
+	// auth: register the restored resources to iam again
+	if err := s.registerRestoredResources(params, resp.Data.Restored); err != nil {
+		return nil, params.Err.New(common.CCErrCommRegistResourceToIAMFailed, err.Error())
+	}
+
+	return resp.Data, nil
+}
+
+// PurgeRecycleItem delete the items from the recycle bin permanently
+func (s *Service) PurgeRecycleItem(params types.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	input := &metadata.RecycleOption{}
+	if err := data.MarshalJSONInto(input); err != nil {
+		blog.Errorf("[PurgeRecycleItem] unmarshal error: %v, data: %#v", err, data)
+		return nil, params.Err.New(common.CCErrCommParamsInvalid, err.Error())
+	}
+
+	resp, err := s.Engine.CoreAPI.CoreService().Recycle().PurgeRecycleItem(params.Context, params.Header, input)
+	if err != nil {
+		blog.Errorf("[PurgeRecycleItem] purge the recycle items %v failed, err: %v", input, err)
+		return nil, params.Err.Error(common.CCErrCommHTTPDoRequestFailed)
+	}
+	if !resp.Result {
+		blog.Errorf("[PurgeRecycleItem] purge the recycle items %v failed, err: %s", input, resp.ErrMsg)
+		return nil, params.Err.New(resp.Code, resp.ErrMsg)
+	}
+
+	return resp.Data, nil
+}
+
+func (s *Service) registerRestoredResources(params types.ContextParams, items []metadata.RecycleItem) error {
+	objIDs := make([]interface{}, 0)
+	instIDs := make(map[string][]int64)
+	for _, item := range items {
+		if item.Kind == metadata.RecycleKindModel {
+			objIDs = append(objIDs, item.ObjectID)
+			continue
+		}
+		instIDs[item.ObjectID] = append(instIDs[item.ObjectID], item.InstID)
+	}
+
+	if len(objIDs) != 0 {
+		cond := &metadata.QueryCondition{Condition: mapstr.MapStr{common.BKObjIDField: mapstr.MapStr{common.BKDBIN: objIDs}}}
+		resp, err := s.Engine.CoreAPI.CoreService().Model().ReadModel(params.Context, params.Header, cond)
+		if err != nil {
+			return err
+		}
+		if !resp.Result {
+			return params.Err.New(resp.Code, resp.ErrMsg)
+		}
+		objects := make([]metadata.Object, 0)
+		for _, info := range resp.Data.Info {
+			objects = append(objects, info.Spec)
+		}
+		if err := s.AuthManager.RegisterObject(params.Context, params.Header, objects...); err != nil {
+			blog.Errorf("[registerRestoredResources] register the objects %v failed, err: %v", objIDs, err)
+			return err
+		}
+	}
+
+	for objID, ids := range instIDs {
+		var err error
+		switch objID {
+		case common.BKInnerObjIDApp:
+			err = s.AuthManager.RegisterBusinessesByID(params.Context, params.Header, ids...)
+		case common.BKInnerObjIDSet:
+			err = s.AuthManager.RegisterSetByID(params.Context, params.Header, ids...)
+		case common.BKInnerObjIDModule:
+			err = s.AuthManager.RegisterModuleByID(params.Context, params.Header, ids...)
+		case common.BKInnerObjIDHost:
+			err = s.AuthManager.RegisterHostsByID(params.Context, params.Header, ids...)
+		default:
+			err = s.AuthManager.RegisterInstancesByID(params.Context, params.Header, objID, ids...)
+		}
+		if err != nil {
+			blog.Errorf("[registerRestoredResources] register the %s instances %v failed, err: %v", objID, ids, err)
+			return err
+		}
+	}
+
+	return nil
+}
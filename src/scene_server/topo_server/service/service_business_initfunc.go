@@ -51,6 +51,25 @@ func (s *Service) initBusinessObjectUnique() {
 	s.addAction(http.MethodPost, "/find/objectunique/object/{bk_obj_id}", s.SearchObjectUnique, nil)
 }
 
+func (s *Service) initBusinessObjectVersion() {
+	s.addAction(http.MethodPost, "/find/objectversion/object/{bk_obj_id}", s.SearchObjectVersion, nil)
+	s.addAction(http.MethodPost, "/find/objectversion/object/{bk_obj_id}/diff", s.DiffObjectVersion, nil)
+	s.addAction(http.MethodPost, "/find/objectversion/object/{bk_obj_id}/version/{version}/export", s.ExportObjectVersion, nil)
+	s.addAction(http.MethodPost, "/update/objectversion/object/{bk_obj_id}/rollback", s.RollbackObjectVersion, nil)
+}
+
+func (s *Service) initBusinessObjectBundle() {
+	s.addAction(http.MethodPost, "/find/objectbundle/export", s.ExportObjectBundle, nil)
+	s.addAction(http.MethodPost, "/find/objectbundle/plan", s.PlanObjectBundle, nil)
+	s.addAction(http.MethodPost, "/update/objectbundle/apply", s.ApplyObjectBundle, nil)
+}
+
+func (s *Service) initBusinessRecycle() {
+	s.addAction(http.MethodPost, "/find/recycle", s.SearchRecycleItem, nil)
+	s.addAction(http.MethodPut, "/update/recycle/restore", s.RestoreRecycleItem, nil)
+	s.addAction(http.MethodDelete, "/delete/recycle/purge", s.PurgeRecycleItem, nil)
+}
+
 func (s *Service) initBusinessObjectAttrGroup() {
 	s.addAction(http.MethodPost, "/create/objectattgroup", s.CreateObjectGroup, nil)
 	s.addAction(http.MethodPut, "/update/objectattgroup", s.UpdateObjectGroup, nil)
@@ -89,10 +108,12 @@ func (s *Service) initBusinessAssociation() {
 	s.addAction(http.MethodPost, "/find/instassociation", s.SearchAssociationInst, nil)
 	s.addAction(http.MethodPost, "/create/instassociation", s.CreateAssociationInst, nil)
 	s.addAction(http.MethodDelete, "/delete/instassociation/{association_id}", s.DeleteAssociationInst, nil)
+	s.addAction(http.MethodPost, "/find/instassociation/mapping_violation", s.SearchAssociationInstMappingViolation, nil)
 
 	// topo search methods
 	s.addAction(http.MethodPost, "/find/instassociation/object/{bk_obj_id}", s.SearchInstByAssociation, nil)
 	s.addAction(http.MethodPost, "/find/instassttopo/object/{bk_obj_id}/inst/{inst_id}", s.SearchInstTopo, nil)
+	s.addAction(http.MethodPost, "/find/instgraph/object/{bk_obj_id}/inst/{inst_id}", s.SearchInstGraph, nil)
 
 	// ATTENTION: the following methods is not recommended
 	s.addAction(http.MethodPost, "/find/insttopo/object/{bk_obj_id}/inst/{inst_id}", s.SearchInstChildTopo, nil)
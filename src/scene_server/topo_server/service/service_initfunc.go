@@ -65,6 +65,7 @@ func (s *Service) initAuditLog() {
 
 	s.addAction(http.MethodPost, "/audit/search", s.AuditQuery, nil)
 	s.addAction(http.MethodPost, "/object/{bk_obj_id}/audit/search", s.InstanceAuditQuery, nil)
+	s.addAction(http.MethodPost, "/object/{bk_obj_id}/audit/history", s.InstanceHistoryQuery, nil)
 }
 
 func (s *Service) initCompatiblev2() {
@@ -214,6 +215,9 @@ func (s *Service) initService() {
 	s.initBusinessClassification()
 	s.initBusinessObjectAttribute()
 	s.initBusinessObjectUnique()
+	s.initBusinessObjectVersion()
+	s.initBusinessObjectBundle()
+	s.initBusinessRecycle()
 	s.initBusinessObjectAttrGroup()
 	s.initBusinessAssociation()
 	s.initBusinessGraphics()
@@ -27,8 +27,15 @@ type ServerOption struct {
 
 // Config export
 type Config struct {
-	Mongo mongo.Config
-	Redis redis.Config
+	Mongo   mongo.Config
+	Redis   redis.Config
+	Recycle RecycleConfig
+}
+
+// RecycleConfig the config of the recycle bin
+type RecycleConfig struct {
+	// RetentionDays the days the deleted resources are kept in the recycle bin
+	RetentionDays int
 }
 
 //NewServerOption create a ServerOption object
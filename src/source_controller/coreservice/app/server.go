@@ -24,6 +24,7 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/rdapi"
 	"configcenter/src/common/types"
+	"configcenter/src/common/util"
 	"configcenter/src/common/version"
 	"configcenter/src/source_controller/coreservice/app/options"
 	coresvr "configcenter/src/source_controller/coreservice/service"
@@ -31,6 +32,9 @@ import (
 	"configcenter/src/storage/dal/redis"
 )
 
+// defaultRecycleRetentionDays the days the deleted resources are kept in the recycle bin by default
+const defaultRecycleRetentionDays = 30
+
 // CoreServer the core server
 type CoreServer struct {
 	Core    *backbone.Engine
@@ -42,6 +46,10 @@ func (t *CoreServer) onCoreServiceConfigUpdate(previous, current cc.ProcessConfi
 
 	t.Config.Mongo = mongo.ParseConfigFromKV("mongodb", current.ConfigMap)
 	t.Config.Redis = redis.ParseConfigFromKV("redis", current.ConfigMap)
+	t.Config.Recycle.RetentionDays = defaultRecycleRetentionDays
+	if days, err := util.GetIntByInterface(current.ConfigMap["recycle.retentionDays"]); nil == err && days > 0 {
+		t.Config.Recycle.RetentionDays = days
+	}
 
 	blog.V(3).Infof("the new cfg:%#v the origin cfg:%#v", t.Config, current.ConfigMap)
 
@@ -28,7 +28,7 @@ type associationManager struct {
 
 // New create a new association manager instance
 func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperation {
-	asstModel := &associationModel{dbProxy: dbProxy}
+	asstModel := &associationModel{dbProxy: dbProxy, dependent: dependent}
 	asstKind := &associationKind{
 		dbProxy:          dbProxy,
 		associationModel: asstModel,
@@ -43,7 +43,8 @@ func New(dbProxy dal.RDB, dependent OperationDependences) core.AssociationOperat
 			dependent:        dependent,
 		},
 		associationModel: &associationModel{
-			dbProxy: dbProxy,
+			dbProxy:   dbProxy,
+			dependent: dependent,
 		},
 	}
 }
@@ -23,4 +23,10 @@ type OperationDependences interface {
 
 	// IsInstanceExist used to check if the  instances exist
 	IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error)
+
+	// RecomputeInstances used to evaluate the computed attributes of the instances again
+	RecomputeInstances(ctx core.ContextParams, objID string, instIDs []int64) error
+
+	// RecordModelVersion used to record the versions of the models whose associations are changed
+	RecordModelVersion(ctx core.ContextParams, objIDs []string) error
 }
@@ -76,7 +76,7 @@ func (m *associationInstance) countInstanceAssociation(ctx core.ContextParams, c
 	return count, err
 }
 
-func (m *associationInstance) save(ctx core.ContextParams, asstInst metadata.InstAsst) (id uint64, err error) {
+func (m *associationInstance) save(ctx core.ContextParams, asstInst metadata.InstAsst, mapping metadata.AssociationMapping) (id uint64, err error) {
 
 	id, err = m.dbProxy.NextSequence(ctx, common.BKTableNameInstAsst)
 	if err != nil {
@@ -86,10 +86,82 @@ func (m *associationInstance) save(ctx core.ContextParams, asstInst metadata.Ins
 	asstInst.ID = int64(id)
 	asstInst.OwnerID = ctx.SupplierAccount
 
-	err = m.dbProxy.Table(common.BKTableNameInstAsst).Insert(ctx, asstInst)
+	// the mapping keys are unique indexed, so the concurrent creations can not exceed the mapping.
+	err = m.dbProxy.Table(common.BKTableNameInstAsst).Insert(ctx, metadata.NewInstAsstWithMappingKey(asstInst, mapping))
+	if nil != err && m.dbProxy.IsDuplicatedError(err) {
+		blog.Errorf("association instance (%#v) exceeds the mapping %s, err: %v, rid: %s", asstInst, mapping, err, ctx.ReqID)
+		return id, mappingError(ctx, mapping)
+	}
 	return id, err
 }
 
+// checkMapping check whether the instances have been associated as many as the mapping allows.
+func (m *associationInstance) checkMapping(ctx core.ContextParams, asstInst metadata.InstAsst, mapping metadata.AssociationMapping) error {
+	conds := make([]mapstr.MapStr, 0)
+	switch mapping {
+	case metadata.OneToOneMapping:
+		conds = append(conds,
+			mapstr.MapStr{common.AssociationObjAsstIDField: asstInst.ObjectAsstID, common.BKInstIDField: asstInst.InstID},
+			mapstr.MapStr{common.AssociationObjAsstIDField: asstInst.ObjectAsstID, common.BKAsstInstIDField: asstInst.AsstInstID})
+	case metadata.OneToManyMapping:
+		conds = append(conds,
+			mapstr.MapStr{common.AssociationObjAsstIDField: asstInst.ObjectAsstID, common.BKAsstInstIDField: asstInst.AsstInstID})
+	}
+
+	for _, cond := range conds {
+		cond.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+		cnt, err := m.instCount(ctx, cond)
+		if nil != err {
+			blog.Errorf("check association instance (%#v) mapping failed, err: %v, rid: %s", asstInst, err, ctx.ReqID)
+			return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+		if 0 != cnt {
+			blog.Errorf("association instance (%#v) exceeds the mapping %s, rid: %s", asstInst, mapping, ctx.ReqID)
+			return mappingError(ctx, mapping)
+		}
+	}
+	return nil
+}
+
+// RestoreInstanceAssociation insert the association restored from the recycle bin with its original id, the mapping of
+// the model association is checked again, for the instances may have been associated with the others. false is
+// returned if the model association is deleted or the mapping is exceeded.
+func (m *associationInstance) RestoreInstanceAssociation(ctx core.ContextParams, asstInst metadata.InstAsst) (bool, error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: asstInst.ObjectAsstID})
+	cond.Element(&mongo.Eq{Key: common.BKOwnerIDField, Val: ctx.SupplierAccount})
+	modelAsst, exists, err := m.associationModel.isExists(ctx, cond)
+	if nil != err {
+		return false, err
+	}
+	if !exists {
+		blog.Warnf("the model association of the restored association instance (%#v) does not exist, rid: %s", asstInst, ctx.ReqID)
+		return false, nil
+	}
+	if err := m.checkMapping(ctx, asstInst, modelAsst.Mapping); nil != err {
+		blog.Warnf("the restored association instance (%#v) exceeds the mapping %s, rid: %s", asstInst, modelAsst.Mapping, ctx.ReqID)
+		return false, nil
+	}
+
+	asstInst.OwnerID = ctx.SupplierAccount
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).Insert(ctx, metadata.NewInstAsstWithMappingKey(asstInst, modelAsst.Mapping)); nil != err {
+		blog.Errorf("restore the association instance (%#v) failed, err: %v, rid: %s", asstInst, err, ctx.ReqID)
+		if m.dbProxy.IsDuplicatedError(err) {
+			return false, mappingError(ctx, modelAsst.Mapping)
+		}
+		return false, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	m.recomputeEnds(ctx, []metadata.InstAsst{asstInst})
+	return true, nil
+}
+
+func mappingError(ctx core.ContextParams, mapping metadata.AssociationMapping) error {
+	if mapping == metadata.OneToOneMapping {
+		return ctx.Error.Error(common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation)
+	}
+	return ctx.Error.Error(common.CCErrorTopoCreateMultipleInstancesForOneToManyAssociation)
+}
+
 func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParams, inputParam metadata.CreateOneInstanceAssociation) (*metadata.CreateOneDataResult, error) {
 	inputParam.Data.OwnerID = ctx.SupplierAccount
 	_, exists, err := m.isExists(ctx, inputParam.Data.InstID, inputParam.Data.AsstInstID, inputParam.Data.ObjectAsstID, inputParam.Data.Metadata)
@@ -104,7 +176,7 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 	//check association kind
 	cond := mongo.NewCondition()
 	cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: inputParam.Data.ObjectAsstID})
-	_, exists, err = m.associationModel.isExists(ctx, cond)
+	modelAsst, exists, err := m.associationModel.isExists(ctx, cond)
 	if nil != err {
 		blog.Errorf("check asst kind(%#v)is not exist", inputParam.Data.ObjectAsstID)
 		return nil, err
@@ -133,12 +205,21 @@ func (m *associationInstance) CreateOneInstanceAssociation(ctx core.ContextParam
 		blog.Errorf("asst inst is not exist objid(%#v), instid(%#v)", inputParam.Data.ObjectID, inputParam.Data.InstID)
 		return nil, ctx.Error.Error(common.CCErrorInstToAsstIsNotExist)
 	}
-	id, err := m.save(ctx, inputParam.Data)
-	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, err
+	//check association mapping
+	if err := m.checkMapping(ctx, inputParam.Data, modelAsst.Mapping); nil != err {
+		return nil, err
+	}
+	id, err := m.save(ctx, inputParam.Data, modelAsst.Mapping)
+	if nil != err {
+		return nil, err
+	}
+	m.recomputeEnds(ctx, []metadata.InstAsst{inputParam.Data})
+	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
 }
 
 func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextParams, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error) {
 	dataResult := &metadata.CreateManyDataResult{}
+	created := make([]metadata.InstAsst, 0)
 	for itemIdx, item := range inputParam.Datas {
 		item.OwnerID = ctx.SupplierAccount
 		//check is exist
@@ -157,8 +238,10 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			dataResult.Repeated = append(dataResult.Repeated, metadata.RepeatedDataResult{OriginIndex: int64(itemIdx), Data: mapstr.NewFromStruct(item, "field")})
 			continue
 		}
-		//check asst kind
-		_, exists, err = m.associationKind.isExists(ctx, item.ObjectAsstID)
+		//check model association
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: common.AssociationObjAsstIDField, Val: item.ObjectAsstID})
+		modelAsst, exists, err := m.associationModel.isExists(ctx, cond)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -170,8 +253,8 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 		}
 		if !exists {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
-				Message:     ctx.Error.Error(common.CCErrorAsstInstIsNotExist).Error(),
-				Code:        int64(common.CCErrorAsstInstIsNotExist),
+				Message:     ctx.Error.Error(common.CCErrorTopoAsstKindIsNotExist).Error(),
+				Code:        int64(common.CCErrorTopoAsstKindIsNotExist),
 				Data:        item,
 				OriginIndex: int64(itemIdx),
 			})
@@ -219,8 +302,18 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 			})
 			continue
 		}
+		//check association mapping
+		if err := m.checkMapping(ctx, item, modelAsst.Mapping); nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		//save asst inst
-		id, err := m.save(ctx, item)
+		id, err := m.save(ctx, item, modelAsst.Mapping)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
 				Message:     err.Error(),
@@ -234,8 +327,9 @@ func (m *associationInstance) CreateManyInstanceAssociation(ctx core.ContextPara
 		dataResult.Created = append(dataResult.Created, metadata.CreatedDataResult{
 			ID: id,
 		})
-
+		created = append(created, item)
 	}
+	m.recomputeEnds(ctx, created)
 
 	return dataResult, nil
 }
@@ -277,10 +371,32 @@ func (m *associationInstance) DeleteInstanceAssociation(ctx core.ContextParams,
 		return &metadata.DeletedCount{}, err
 	}
 
+	deleted, err := m.searchInstanceAssociation(ctx, metadata.QueryCondition{Condition: inputParam.Condition})
+	if nil != err {
+		blog.Errorf("delete inst association search inst association [%#v] err [%#v]", inputParam.Condition, err)
+		return &metadata.DeletedCount{}, err
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameInstAsst).Delete(ctx, inputParam.Condition)
 	if nil != err {
 		blog.Errorf("delete inst association [%#v] err [%#v]", inputParam.Condition, err)
 		return &metadata.DeletedCount{}, err
 	}
+	m.recomputeEnds(ctx, deleted)
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
+
+// recomputeEnds evaluate the computed attributes of the instances at both ends of the associations,
+// which may aggregate over the instances at the other end.
+func (m *associationInstance) recomputeEnds(ctx core.ContextParams, assts []metadata.InstAsst) {
+	instIDs := make(map[string][]int64)
+	for _, asst := range assts {
+		instIDs[asst.ObjectID] = append(instIDs[asst.ObjectID], asst.InstID)
+		instIDs[asst.AsstObjectID] = append(instIDs[asst.AsstObjectID], asst.AsstInstID)
+	}
+	for objID, ids := range instIDs {
+		if err := m.dependent.RecomputeInstances(ctx, objID, ids); nil != err {
+			blog.Errorf("recompute the instances %v of model %s err [%#v], rid: %s", ids, objID, err, ctx.ReqID)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package association
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// SearchInstanceAssociationMappingViolation find the instances which are associated with more instances than
+// the mapping of the model association allows, they are created before the mapping is checked.
+func (m *associationInstance) SearchInstanceAssociationMappingViolation(ctx core.ContextParams, inputParam metadata.SearchAsstMappingViolationRequest) ([]metadata.AsstMappingViolation, error) {
+	cond := mapstr.MapStr{
+		common.BKOwnerIDField:            ctx.SupplierAccount,
+		metadata.AssociationFieldMapping: mapstr.MapStr{common.BKDBIN: []metadata.AssociationMapping{metadata.OneToOneMapping, metadata.OneToManyMapping}},
+	}
+	if len(inputParam.ObjectAsstIDs) != 0 {
+		cond.Set(common.AssociationObjAsstIDField, mapstr.MapStr{common.BKDBIN: inputParam.ObjectAsstIDs})
+	}
+
+	modelAssts := make([]metadata.Association, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAsst).Find(cond).All(ctx, &modelAssts); nil != err {
+		blog.Errorf("search the model associations by the condition (%#v) failed, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	violations := make([]metadata.AsstMappingViolation, 0)
+	for _, modelAsst := range modelAssts {
+		// the destination instance can be associated with only one source instance in both 1:1 and 1:n.
+		destViolations, err := m.searchMappingViolation(ctx, modelAsst, common.BKAsstInstIDField, modelAsst.AsstObjID)
+		if nil != err {
+			return nil, err
+		}
+		violations = append(violations, destViolations...)
+
+		if modelAsst.Mapping != metadata.OneToOneMapping {
+			continue
+		}
+
+		srcViolations, err := m.searchMappingViolation(ctx, modelAsst, common.BKInstIDField, modelAsst.ObjectID)
+		if nil != err {
+			return nil, err
+		}
+		violations = append(violations, srcViolations...)
+	}
+
+	return violations, nil
+}
+
+// searchMappingViolation find the instances of the instField side which are associated more than once.
+func (m *associationInstance) searchMappingViolation(ctx core.ContextParams, modelAsst metadata.Association, instField, objID string) ([]metadata.AsstMappingViolation, error) {
+	pipeline := []mapstr.MapStr{
+		{common.BKDBMatch: mapstr.MapStr{
+			common.BKOwnerIDField:            ctx.SupplierAccount,
+			common.AssociationObjAsstIDField: modelAsst.AssociationName,
+		}},
+		{common.BKDBGroup: mapstr.MapStr{
+			"_id":   "$" + instField,
+			"total": mapstr.MapStr{common.BKDBSum: 1},
+			"ids":   mapstr.MapStr{common.BKDBPush: "$id"},
+		}},
+		{common.BKDBMatch: mapstr.MapStr{
+			"total": mapstr.MapStr{common.BKDBGT: 1},
+		}},
+	}
+
+	results := make([]struct {
+		InstID int64   `bson:"_id"`
+		IDs    []int64 `bson:"ids"`
+	}, 0)
+	if err := m.dbProxy.Table(common.BKTableNameInstAsst).AggregateAll(ctx, pipeline, &results); nil != err {
+		blog.ErrorJSON("search the mapping violations of the association %s failed, err: %s, pipeline: %s, rid: %s", modelAsst.AssociationName, err, pipeline, ctx.ReqID)
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	violations := make([]metadata.AsstMappingViolation, 0)
+	for _, result := range results {
+		violations = append(violations, metadata.AsstMappingViolation{
+			ObjectAsstID:   modelAsst.AssociationName,
+			Mapping:        modelAsst.Mapping,
+			ObjectID:       objID,
+			InstID:         result.InstID,
+			AssociationIDs: result.IDs,
+		})
+	}
+	return violations, nil
+}
@@ -75,10 +75,28 @@ func (s *mockDependences) CascadeDeleteInstances(ctx core.ContextParams, objIDS
 	return nil
 }
 
+// ApplyBundleAssociation used to create, update or delete the association of the model bundle by the action
+func (s *mockDependences) ApplyBundleAssociation(ctx core.ContextParams, action string, asst metadata.Association) error {
+	return nil
+}
+
+// ArchiveDeleted move the deleted models into the recycle bin
+func (s *mockDependences) ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error {
+	return nil
+}
+
 func (m *mockDependences) IsInstanceExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error) {
 	return false, nil
 }
 
+func (m *mockDependences) RecomputeInstances(ctx core.ContextParams, objID string, instIDs []int64) error {
+	return nil
+}
+
+func (m *mockDependences) RecordModelVersion(ctx core.ContextParams, objIDs []string) error {
+	return nil
+}
+
 func newModel(t *testing.T) core.ModelOperation {
 
 	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
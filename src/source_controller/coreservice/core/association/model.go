@@ -23,7 +23,8 @@ import (
 )
 
 type associationModel struct {
-	dbProxy dal.RDB
+	dbProxy   dal.RDB
+	dependent OperationDependences
 }
 
 func (m *associationModel) CreateModelAssociation(ctx core.ContextParams, inputParam metadata.CreateModelAssociation) (*metadata.CreateOneDataResult, error) {
@@ -90,6 +91,9 @@ func (m *associationModel) createModelAssociation(ctx core.ContextParams, inputP
 		blog.Errorf("request(%s): it is failed to create a new association (%s=>%s), error info is %s", ctx.ReqID, inputParam.Spec.ObjectID, inputParam.Spec.AsstObjID, err.Error())
 		return &metadata.CreateOneDataResult{}, err
 	}
+	if err := m.recordModelVersion(ctx, []metadata.Association{inputParam.Spec}); nil != err {
+		return &metadata.CreateOneDataResult{}, err
+	}
 	return &metadata.CreateOneDataResult{Created: metadata.CreatedDataResult{ID: id}}, nil
 }
 
@@ -131,12 +135,26 @@ func (m *associationModel) UpdateModelAssociation(ctx core.ContextParams, inputP
 		blog.Warnf("update object association got invalid fields: %v", filterOutFields)
 	}
 
+	originItems, err := m.search(ctx, updateCond)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to search all by the condition (%#v), error info is %s", ctx.ReqID, updateCond.ToMapStr(), err.Error())
+		return &metadata.UpdatedCount{}, err
+	}
+
 	cnt, err := m.update(ctx, validData, updateCond)
 	if nil != err {
 		blog.Errorf("request(%s): it is to update the association by the condition (%#v), error info is %s", ctx.ReqID, updateCond.ToMapStr(), err.Error())
 		return &metadata.UpdatedCount{}, err
 	}
 
+	// the associated model is changed when a business model level is added
+	if asstObjID, ok := validData[metadata.AssociationFieldAssociationObjectID].(string); ok {
+		originItems = append(originItems, metadata.Association{AsstObjID: asstObjID})
+	}
+	if err := m.recordModelVersion(ctx, originItems); nil != err {
+		return &metadata.UpdatedCount{}, err
+	}
+
 	return &metadata.UpdatedCount{Count: cnt}, nil
 }
 
@@ -194,6 +212,9 @@ func (m *associationModel) DeleteModelAssociation(ctx core.ContextParams, inputP
 		blog.Errorf("request(%s): it is delete the instances by the condition (%#v), error info is %s", ctx.ReqID, deleteCond.ToMapStr(), err.Error())
 		return &metadata.DeletedCount{}, err
 	}
+	if err := m.recordModelVersion(ctx, needDeleteAssocaitionItems); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
 
@@ -230,5 +251,8 @@ func (m *associationModel) CascadeDeleteModelAssociation(ctx core.ContextParams,
 		blog.Errorf("request(%s): it is to delete some associations by the condition (%#v), error info is %s", ctx.ReqID, deleteCond.ToMapStr(), err.Error())
 		return &metadata.DeletedCount{}, err
 	}
+	if err := m.recordModelVersion(ctx, needDeleteAssocaitionItems); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
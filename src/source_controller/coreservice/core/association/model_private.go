@@ -83,3 +83,24 @@ func (m *associationModel) cascadeInstanceAssociation(ctx core.ContextParams, as
 	// TODO: need to implement
 	return nil
 }
+
+// recordModelVersion records the versions of the models of the changed associations, the change is failed along with it
+// in the transaction, otherwise the next version taking the other parts from the latest one misses the change
+func (m *associationModel) recordModelVersion(ctx core.ContextParams, associations []metadata.Association) error {
+	objIDs := make([]string, 0)
+	for _, asst := range associations {
+		for _, objID := range []string{asst.ObjectID, asst.AsstObjID} {
+			if 0 != len(objID) {
+				objIDs = append(objIDs, objID)
+			}
+		}
+	}
+	if 0 == len(objIDs) {
+		return nil
+	}
+	if err := m.dependent.RecordModelVersion(ctx, objIDs); nil != err {
+		blog.Errorf("request(%s): it is failed to record the versions of the models (%#v), error info is %s", ctx.ReqID, objIDs, err.Error())
+		return err
+	}
+	return nil
+}
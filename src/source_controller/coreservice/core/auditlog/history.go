@@ -0,0 +1,57 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package auditlog
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// SearchInstanceHistory rebuild the instance and its associations at the moment by replaying the audit logs
+func (m *auditManager) SearchInstanceHistory(ctx core.ContextParams, objID string, inputParam metadata.InstanceHistoryOption) (*metadata.InstanceHistory, error) {
+	if 0 >= inputParam.InstID {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, common.BKInstIDField)
+	}
+	if inputParam.Time.IsZero() {
+		inputParam.Time = time.Now()
+	}
+
+	instID := inputParam.InstID
+	// each branch of the $or is matched by its own index, see the upgrader x19.05.08.12
+	asstTarget := metadata.AuditTargetInstanceAssociation
+	cond := mapstr.MapStr{
+		common.BKOpTimeField: mapstr.MapStr{common.BKDBLTE: inputParam.Time},
+		common.BKDBOR: []mapstr.MapStr{
+			{common.BKOpTargetField: objID, metadata.AuditFieldInstID: instID},
+			// the content of the created association is the request to create it
+			{common.BKOpTargetField: asstTarget, "content.cur_data.data.bk_obj_id": objID, "content.cur_data.data.bk_inst_id": instID},
+			{common.BKOpTargetField: asstTarget, "content.cur_data.data.bk_asst_obj_id": objID, "content.cur_data.data.bk_asst_inst_id": instID},
+			{common.BKOpTargetField: asstTarget, "content.pre_data.bk_obj_id": objID, "content.pre_data.bk_inst_id": instID},
+			{common.BKOpTargetField: asstTarget, "content.pre_data.bk_asst_obj_id": objID, "content.pre_data.bk_asst_inst_id": instID},
+		},
+	}
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+
+	logs := make([]metadata.OperationLog, 0)
+	if err := m.dbProxy.Table(common.BKTableNameOperationLog).Find(cond).Sort(common.BKOpTimeField).All(ctx, &logs); nil != err {
+		blog.Errorf("request(%s): it is failed to search the audit logs by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	return metadata.ReplayInstanceHistory(objID, instID, inputParam.Time, logs), nil
+}
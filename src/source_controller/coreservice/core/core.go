@@ -13,6 +13,8 @@
 package core
 
 import (
+	"time"
+
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 )
@@ -60,12 +62,30 @@ type ModelAttrUnique interface {
 	SearchModelAttrUnique(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryUniqueResult, error)
 }
 
+// ModelVersion model schema version methods definitions
+type ModelVersion interface {
+	SearchModelVersion(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryModelVersionResult, error)
+	DiffModelVersion(ctx ContextParams, objID string, inputParam metadata.DiffModelVersionOption) (*metadata.ModelSchemaDiff, error)
+	ExportModelVersion(ctx ContextParams, objID string, version int64) (*metadata.ModelVersionDocument, error)
+	RollbackModelVersion(ctx ContextParams, objID string, inputParam metadata.RollbackModelVersionOption) (*metadata.ModelVersionRollbackResult, error)
+	RecordModelVersion(ctx ContextParams, action string, objIDs []string) error
+}
+
+// ModelBundle model bundle methods definitions
+type ModelBundle interface {
+	ExportModelBundle(ctx ContextParams, inputParam metadata.ExportModelBundleOption) (*metadata.ModelBundle, error)
+	PlanModelBundle(ctx ContextParams, inputParam metadata.ModelBundleOption) (*metadata.ModelBundlePlan, error)
+	ApplyModelBundle(ctx ContextParams, inputParam metadata.ModelBundleOption) (*metadata.ModelBundleApplyResult, error)
+}
+
 // ModelOperation model methods
 type ModelOperation interface {
 	ModelClassification
 	ModelAttributeGroup
 	ModelAttribute
 	ModelAttrUnique
+	ModelVersion
+	ModelBundle
 
 	CreateModel(ctx ContextParams, inputParam metadata.CreateModel) (*metadata.CreateOneDataResult, error)
 	SetModel(ctx ContextParams, inputParam metadata.SetModel) (*metadata.SetDataResult, error)
@@ -84,6 +104,10 @@ type InstanceOperation interface {
 	SearchModelInstance(ctx ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
 	CascadeDeleteModelInstance(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	DeleteModelInstanceImpact(ctx ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeleteImpact, error)
+	RecomputeModelInstance(ctx ContextParams, objID string, inputParam metadata.RecomputeOption) (*metadata.UpdatedCount, error)
+	RecomputeInstances(ctx ContextParams, objID string, instIDs []int64) error
+	ValidModelInstanceUnique(ctx ContextParams, objID string, instanceData mapstr.MapStr) error
 }
 
 // AssociationKind association kind methods
@@ -115,6 +139,8 @@ type InstanceAssociation interface {
 	CreateManyInstanceAssociation(ctx ContextParams, inputParam metadata.CreateManyInstanceAssociation) (*metadata.CreateManyDataResult, error)
 	SearchInstanceAssociation(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryResult, error)
 	DeleteInstanceAssociation(ctx ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error)
+	SearchInstanceAssociationMappingViolation(ctx ContextParams, inputParam metadata.SearchAsstMappingViolationRequest) ([]metadata.AsstMappingViolation, error)
+	RestoreInstanceAssociation(ctx ContextParams, asstInst metadata.InstAsst) (bool, error)
 }
 
 // DataSynchronize manager data synchronize interface
@@ -151,6 +177,16 @@ type AssociationOperation interface {
 type AuditOperation interface {
 	CreateAuditLog(ctx ContextParams, logs ...metadata.SaveAuditLogParams) error
 	SearchAuditLog(ctx ContextParams, param metadata.QueryInput) ([]metadata.OperationLog, uint64, error)
+	SearchInstanceHistory(ctx ContextParams, objID string, inputParam metadata.InstanceHistoryOption) (*metadata.InstanceHistory, error)
+}
+
+// RecycleOperation recycle bin methods
+type RecycleOperation interface {
+	ArchiveDeleted(ctx ContextParams, items ...metadata.RecycleItem) error
+	SearchRecycleItem(ctx ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryRecycleItemResult, error)
+	RestoreRecycleItem(ctx ContextParams, inputParam metadata.RecycleOption) (*metadata.RecycleRestoreResult, error)
+	PurgeRecycleItem(ctx ContextParams, inputParam metadata.RecycleOption) (*metadata.DeletedCount, error)
+	PurgeExpiredRecycleItem(ctx ContextParams, before time.Time) (*metadata.DeletedCount, error)
 }
 
 // Core core itnerfaces methods
@@ -162,6 +198,7 @@ type Core interface {
 	DataSynchronizeOperation() DataSynchronizeOperation
 	HostOperation() HostOperation
 	AuditOperation() AuditOperation
+	RecycleOperation() RecycleOperation
 }
 
 type core struct {
@@ -172,10 +209,11 @@ type core struct {
 	topo            TopoOperation
 	host            HostOperation
 	audit           AuditOperation
+	recycle         RecycleOperation
 }
 
 // New create core
-func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation) Core {
+func New(model ModelOperation, instance InstanceOperation, association AssociationOperation, dataSynchronize DataSynchronizeOperation, topo TopoOperation, host HostOperation, audit AuditOperation, recycle RecycleOperation) Core {
 	return &core{
 		model:           model,
 		instance:        instance,
@@ -184,6 +222,7 @@ func New(model ModelOperation, instance InstanceOperation, association Associati
 		topo:            topo,
 		host:            host,
 		audit:           audit,
+		recycle:         recycle,
 	}
 }
 
@@ -214,3 +253,7 @@ func (m *core) HostOperation() HostOperation {
 func (m *core) AuditOperation() AuditOperation {
 	return m.audit
 }
+
+func (m *core) RecycleOperation() RecycleOperation {
+	return m.recycle
+}
@@ -31,13 +31,13 @@ type hostManager struct {
 }
 
 // New create a new model manager instance
-func New(dbProxy dal.RDB, cache *redis.Client) core.HostOperation {
+func New(dbProxy dal.RDB, cache *redis.Client, dependent modulehost.OperationDependences) core.HostOperation {
 
 	coreMgr := &hostManager{
 		DbProxy: dbProxy,
 		Cache:   cache,
 		EventC:  eventclient.NewClientViaRedis(cache, dbProxy),
 	}
-	coreMgr.moduleHost = modulehost.New(dbProxy, cache, coreMgr.EventC)
+	coreMgr.moduleHost = modulehost.New(dbProxy, cache, coreMgr.EventC, dependent)
 	return coreMgr
 }
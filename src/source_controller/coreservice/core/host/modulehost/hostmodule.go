@@ -28,16 +28,18 @@ import (
 )
 
 type ModuleHost struct {
-	dbProxy dal.RDB
-	eventC  eventclient.Client
-	cache   *redis.Client
+	dbProxy   dal.RDB
+	eventC    eventclient.Client
+	cache     *redis.Client
+	dependent OperationDependences
 }
 
-func New(db dal.RDB, cache *redis.Client, ec eventclient.Client) *ModuleHost {
+func New(db dal.RDB, cache *redis.Client, ec eventclient.Client, dependent OperationDependences) *ModuleHost {
 	return &ModuleHost{
-		dbProxy: db,
-		cache:   cache,
-		eventC:  ec,
+		dbProxy:   db,
+		cache:     cache,
+		eventC:    ec,
+		dependent: dependent,
 	}
 }
 
@@ -73,6 +75,7 @@ func (mh *ModuleHost) TransferHostToInnerModule(ctx core.ContextParams, input *m
 			})
 		}
 	}
+	transfer.recompute(ctx)
 	if len(exceptionArr) > 0 {
 		return exceptionArr, ctx.Error.CCError(common.CCErrCoreServiceTransferHostModuleErr)
 	}
@@ -102,6 +105,7 @@ func (mh *ModuleHost) TransferHostModule(ctx core.ContextParams, input *metadata
 			})
 		}
 	}
+	transfer.recompute(ctx)
 	if len(exceptionArr) > 0 {
 		return exceptionArr, ctx.Error.CCError(common.CCErrCoreServiceTransferHostModuleErr)
 	}
@@ -132,6 +136,7 @@ func (mh *ModuleHost) TransferHostCrossBusiness(ctx core.ContextParams, input *m
 			})
 		}
 	}
+	transfer.recompute(ctx)
 	if len(exceptionArr) > 0 {
 		return exceptionArr, ctx.Error.CCError(common.CCErrCoreServiceTransferHostModuleErr)
 	}
@@ -197,6 +202,7 @@ func (mh *ModuleHost) DeleteHost(ctx core.ContextParams, input *metadata.DeleteH
 			})
 		}
 	}
+	transfer.recompute(ctx)
 	if len(exceptionArr) > 0 {
 		return exceptionArr, ctx.Error.CCError(common.CCErrCoreServiceTransferHostModuleErr)
 	}
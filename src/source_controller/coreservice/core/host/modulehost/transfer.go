@@ -13,6 +13,8 @@
 package modulehost
 
 import (
+	"fmt"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/condition"
@@ -48,6 +50,9 @@ type transferHostModule struct {
 
 	// map[module id]set id
 	moduleIDSetIDmap map[int64]int64
+
+	// the host module relations deleted and added by the transfer
+	changedRelations []mapstr.MapStr
 }
 
 // NewHostModuleTransfer business normal module transfer
@@ -113,9 +118,10 @@ func (t *transferHostModule) Transfer(ctx core.ContextParams, hostID int64) erro
 		//t.origindatas = nil
 		return err
 	}
+	t.changedRelations = append(t.changedRelations, originDatas...)
 	// delete host.
 	if t.delHost {
-		hostInfo, err = t.deleteHost(ctx, hostID)
+		hostInfo, err = t.deleteHost(ctx, hostID, originDatas)
 		if err != nil {
 			return err
 		}
@@ -127,11 +133,45 @@ func (t *transferHostModule) Transfer(ctx core.ContextParams, hostID int64) erro
 	if err != nil {
 		return err
 	}
+	t.changedRelations = append(t.changedRelations, curDatas...)
 
 	return nil
 }
 
-func (t *transferHostModule) deleteHost(ctx core.ContextParams, hostID int64) (mapstr.MapStr, errors.CCErrorCoder) {
+// recompute evaluate the computed attributes of the business, sets and modules whose hosts are changed by the transfer
+func (t *transferHostModule) recompute(ctx core.ContextParams) {
+	fields := map[string]string{
+		common.BKInnerObjIDApp:    common.BKAppIDField,
+		common.BKInnerObjIDSet:    common.BKSetIDField,
+		common.BKInnerObjIDModule: common.BKModuleIDField,
+	}
+	instIDs := make(map[string][]int64)
+	exists := make(map[string]bool)
+	for _, relation := range t.changedRelations {
+		for objID, field := range fields {
+			id, err := relation.Int64(field)
+			if err != nil {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", objID, id)
+			if !exists[key] {
+				exists[key] = true
+				instIDs[objID] = append(instIDs[objID], id)
+			}
+		}
+	}
+	t.changedRelations = nil
+
+	for objID, ids := range instIDs {
+		if err := t.mh.dependent.RecomputeInstances(ctx, objID, ids); err != nil {
+			blog.Errorf("recompute the instances %v of model %s error. err:%s, rid:%s", ids, objID, err.Error(), ctx.ReqID)
+		}
+	}
+}
+
+// deleteHost delete the host, the host and its module relations which are deleted by the transfer are
+// archived into the recycle bin first.
+func (t *transferHostModule) deleteHost(ctx core.ContextParams, hostID int64, relations []mapstr.MapStr) (mapstr.MapStr, errors.CCErrorCoder) {
 	hostCond := condition.CreateCondition()
 	hostCond.Field(common.BKHostIDField).Eq(hostID)
 	hostCondMap := util.SetQueryOwner(hostCond.ToMapStr(), ctx.SupplierAccount)
@@ -146,6 +186,15 @@ func (t *transferHostModule) deleteHost(ctx core.ContextParams, hostID int64) (m
 		blog.ErrorJSON("deleteHost not found host error. cond:%s, rid:%s", hostCond.ToMapStr(), ctx.ReqID)
 		return nil, ctx.Error.CCErrorf(common.CCErrCoreServiceHostNotExist, hostID)
 	}
+
+	hostIP, _ := hostInfoArr[0].String(common.BKHostInnerIPField)
+	item := metadata.NewRecycleItem(metadata.RecycleKindHost, common.BKInnerObjIDHost, hostID, hostIP, common.BKTableNameBaseHost, hostInfoArr[0])
+	item.BizID = t.bizID
+	item.Archive(common.BKTableNameModuleHostConfig, relations...)
+	if err := t.mh.dependent.ArchiveDeleted(ctx, item); err != nil {
+		blog.ErrorJSON("deleteHost archive host error. err:%s, hostID:%s, rid:%s", err.Error(), hostID, ctx.ReqID)
+		return nil, ctx.Error.CCErrorf(common.CCErrCommDBInsertFailed)
+	}
 	delMoudleHost := condition.CreateCondition()
 	delMoudleHost.Field(common.BKHostIDField).Eq(hostID)
 	delMoudleHost.Field(common.BKAppIDField).Eq(t.bizID)
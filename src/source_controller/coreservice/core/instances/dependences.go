@@ -22,8 +22,14 @@ import (
 // OperationDependences methods definition
 type OperationDependences interface {
 
-	// IsInstanceExist used to check if the  instances  asst exist
-	IsInstAsstExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error)
+	// SearchInstAsst used to search the inst asst which the instance is the source or destination of
+	SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (assts []metadata.InstAsst, err error)
+
+	// SearchInstAssts used to search the inst assts which the instances are the source or destination of
+	SearchInstAssts(ctx core.ContextParams, objID string, instIDs []int64) (assts []metadata.InstAsst, err error)
+
+	// SearchModelAsst used to search the model asst by the bk_obj_asst_id
+	SearchModelAsst(ctx core.ContextParams, objAsstIDs []string) (assts []metadata.Association, err error)
 
 	// DeleteInstAsst used to delete inst asst
 	DeleteInstAsst(ctx core.ContextParams, objID string, instID uint64) error
@@ -33,4 +39,16 @@ type OperationDependences interface {
 
 	// SearchUnique search unique attribute
 	SearchUnique(ctx core.ContextParams, objID string) (uniqueAttr []metadata.ObjectUnique, err error)
+
+	// SearchComputedAttributes search the computed attributes of all the models
+	SearchComputedAttributes(ctx core.ContextParams) (attribute []metadata.Attribute, err error)
+
+	// SearchHostModuleRelation search the host module relations
+	SearchHostModuleRelation(ctx core.ContextParams, input *metadata.HostModuleRelationRequest) (relations []metadata.ModuleHost, err error)
+
+	// SaveAuditLog used to save the audit logs of the changes made by core service itself
+	SaveAuditLog(ctx core.ContextParams, logs ...metadata.SaveAuditLogParams) error
+
+	// ArchiveDeleted move the deleted instances into the recycle bin
+	ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error
 }
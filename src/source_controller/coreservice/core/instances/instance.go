@@ -36,6 +36,7 @@ type instanceManager struct {
 	validator validator
 	Cache     *redis.Client
 	EventC    eventclient.Client
+	computed  *computedAttributeCache
 }
 
 // New create a new instance manager instance
@@ -44,6 +45,7 @@ func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client) c
 		dbProxy:   dbProxy,
 		dependent: dependent,
 		EventC:    eventclient.NewClientViaRedis(cache, dbProxy),
+		computed:  &computedAttributeCache{},
 	}
 }
 
@@ -62,6 +64,10 @@ func (m *instanceManager) CreateModelInstance(ctx core.ContextParams, objID stri
 		blog.Errorf("CreateModelInstance failed, valid error: %+v, rid: %s", err, rid)
 		return nil, err
 	}
+	// the computed attributes are saved along with the instance, so that the create event carries them
+	if err := m.fillComputed(ctx, objID, inputParam.Data); nil != err {
+		return nil, err
+	}
 	id, err := m.save(ctx, objID, inputParam.Data)
 	if err != nil {
 		blog.ErrorJSON("CreateModelInstance create objID(%s) instance error. err:%s, data:%s, rid:%s", objID, err.Error(), inputParam.Data, ctx.ReqID)
@@ -95,6 +101,15 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			continue
 		}
 		item.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+		if err := m.fillComputed(ctx, objID, item); nil != err {
+			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
+				Message:     err.Error(),
+				Code:        int64(err.(errors.CCErrorCoder).GetCode()),
+				Data:        item,
+				OriginIndex: int64(itemIdx),
+			})
+			continue
+		}
 		id, err := m.save(ctx, objID, item)
 		if nil != err {
 			dataResult.Exceptions = append(dataResult.Exceptions, metadata.ExceptionResult{
@@ -110,8 +125,8 @@ func (m *instanceManager) CreateManyModelInstance(ctx core.ContextParams, objID
 			ID: id,
 		})
 		newIDs = append(newIDs, id)
-
 	}
+
 	instIDFieldName := common.GetInstIDField(objID)
 	// 处理事件数据的
 	eh := m.NewEventHandle(objID)
@@ -152,9 +167,11 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		}
 	}
 
+	instIDs := make([]int64, 0)
 	for _, origin := range origins {
 		instIDI := origin[instIDFieldName]
 		instID, _ := util.GetInt64ByInterface(instIDI)
+		instIDs = append(instIDs, instID)
 		err := m.validUpdateInstanceData(ctx, objID, inputParam.Data, instMedataData, uint64(instID))
 		if nil != err {
 			blog.Errorf("update module instance validate error :%v ,rid:%s", err, ctx.ReqID)
@@ -168,11 +185,19 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 		blog.Errorf("update module instance validate error :%v ,rid:%s", err, ctx.ReqID)
 		return &metadata.UpdatedCount{}, err
 	}
+	// the ranges of the ip attributes are stored along with them to be searched by the ip operators
+	if err := m.fillUpdateIPRange(ctx, objID, origins[0], inputParam.Data); nil != err {
+		return nil, err
+	}
 	cnt, err := m.update(ctx, objID, inputParam.Data, inputParam.Condition)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance update objID(%s) inst error. err:%s, condition:%s, rid:%s", objID, inputParam.Condition, ctx.ReqID)
 		return nil, err
 	}
+	// the computed attributes of the instances and the ones aggregating over them may be changed
+	m.recompute(ctx, objID, instIDs)
+	m.recomputeDependents(ctx, objID, instIDs)
+
 	err = eh.SetCurDataAndPush(ctx, objID, metadata.EventActionUpdate, inputParam.Condition)
 	if err != nil {
 		blog.ErrorJSON("UpdateModelInstance  event push instance current data error. err:%s, condition:%s, rid:%s", err, inputParam.Condition, ctx.ReqID)
@@ -183,6 +208,10 @@ func (m *instanceManager) UpdateModelInstance(ctx core.ContextParams, objID stri
 }
 
 func (m *instanceManager) SearchModelInstance(ctx core.ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryResult, error) {
+	if err := util.ConvertIPRangeCondition(inputParam.Condition); nil != err {
+		blog.Errorf("SearchModelInstance failed, convert the ip condition failed, inputParam: %+v, err: %+v, rid: %s", inputParam, err, ctx.ReqID)
+		return &metadata.QueryResult{}, ctx.Error.Errorf(common.CCErrCommParamsInvalid, err.Error())
+	}
 	condition, err := mongo.NewConditionFromMapStr(inputParam.Condition)
 	if nil != err {
 		blog.Errorf("SearchModelInstance failed, parse condition failed, inputParam: %+v, err: %+v", inputParam, err)
@@ -211,43 +240,44 @@ func (m *instanceManager) SearchModelInstance(ctx core.ContextParams, objID stri
 }
 
 func (m *instanceManager) DeleteModelInstance(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
-	tableName := common.GetInstTableName(objID)
-	instIDFieldName := common.GetInstIDField(objID)
-	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
-	origins, _, err := m.getInsts(ctx, objID, inputParam.Condition)
+	targets, err := m.deleteTargets(ctx, objID, inputParam)
 	if nil != err {
 		return &metadata.DeletedCount{}, err
 	}
 
-	// 处理事件数据的
-	eh := m.NewEventHandle(objID)
-
-	for _, origin := range origins {
-		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
-		if nil != err {
-			return nil, err
-		}
-		exists, err := m.dependent.IsInstAsstExist(ctx, objID, uint64(instID))
-		if nil != err {
-			return nil, err
-		}
-		if exists {
-			return &metadata.DeletedCount{}, ctx.Error.Error(common.CCErrorInstHasAsst)
-		}
-		eh.SetPreData(instID, origin)
-	}
-	err = m.dbProxy.Table(tableName).Delete(ctx, inputParam.Condition)
+	// the instances related by the associations are deleted or refuse the deletion as the OnDelete action says,
+	// all of them are checked before anything is deleted.
+	plan, err := planDelete(targets, m.newDeleteLookup(ctx))
 	if nil != err {
+		blog.ErrorJSON("DeleteModelInstance plan delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, ctx.ReqID)
+		return &metadata.DeletedCount{}, err
+	}
+	if err := plan.restrictError(ctx); nil != err {
+		blog.ErrorJSON("DeleteModelInstance objID(%s) instance is restricted by the associations:%s, rid:%s", objID, plan.restrictions, ctx.ReqID)
+		return &metadata.DeletedCount{}, err
+	}
+
+	if err := m.execute(ctx, plan); nil != err {
 		blog.ErrorJSON("DeleteModelInstance delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, ctx.ReqID)
 		return &metadata.DeletedCount{}, err
 	}
-	err = eh.Push(ctx, objID, metadata.EventActionDelete)
-	if err != nil {
-		blog.ErrorJSON("DeleteModelInstance push delete objType(%s) instance to event server error. data:%s, rid:%s", objID, origins, ctx.ReqID)
-		return &metadata.DeletedCount{Count: uint64(len(origins))}, ctx.Error.CCErrorf(common.CCErrCoreServiceEventPushEventFailed)
+	return &metadata.DeletedCount{Count: uint64(len(plan.targets))}, nil
+}
 
+// DeleteModelInstanceImpact returns the impact of deleting the instances without deleting them,
+// it's planned in the same way as DeleteModelInstance does.
+func (m *instanceManager) DeleteModelInstanceImpact(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeleteImpact, error) {
+	targets, err := m.deleteTargets(ctx, objID, inputParam)
+	if nil != err {
+		return nil, err
 	}
-	return &metadata.DeletedCount{Count: uint64(len(origins))}, nil
+
+	plan, err := planDelete(targets, m.newDeleteLookup(ctx))
+	if nil != err {
+		blog.ErrorJSON("DeleteModelInstanceImpact plan delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), inputParam.Condition, ctx.ReqID)
+		return nil, err
+	}
+	return plan.impact(), nil
 }
 
 func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
@@ -260,12 +290,27 @@ func (m *instanceManager) CascadeDeleteModelInstance(ctx core.ContextParams, obj
 		return &metadata.DeletedCount{}, err
 	}
 
+	items := make([]metadata.RecycleItem, 0)
 	for _, origin := range origins {
 		instID, err := util.GetInt64ByInterface(origin[instIDFieldName])
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
-		err = m.dependent.DeleteInstAsst(ctx, objID, uint64(instID))
+		assts, err := m.dependent.SearchInstAsst(ctx, objID, uint64(instID))
+		if nil != err {
+			return &metadata.DeletedCount{}, err
+		}
+		instName, _ := origin.String(common.GetInstNameField(objID))
+		items = append(items, newRecycleItem(objID, instID, instName, origin, assts))
+	}
+	// the instances and their associations are archived into the recycle bin before they are deleted
+	if err := m.dependent.ArchiveDeleted(ctx, items...); nil != err {
+		blog.Errorf("cascade delete model instance archive error:%v, rid:%s", err, ctx.ReqID)
+		return &metadata.DeletedCount{}, err
+	}
+
+	for _, item := range items {
+		err = m.dependent.DeleteInstAsst(ctx, objID, uint64(item.InstID))
 		if nil != err {
 			return &metadata.DeletedCount{}, err
 		}
@@ -0,0 +1,703 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instances
+
+import (
+	"sync"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/auditoplog"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// computedAttributesTTL how long the computed attributes of all the models are cached, they're needed on every write
+// of the instances and the host transfers but rarely changed, the recompute api reloads them at once.
+const computedAttributesTTL = time.Minute
+
+// computedAttributeCache the cache of the computed attributes of all the models
+type computedAttributeCache struct {
+	lock   sync.RWMutex
+	attrs  []metadata.Attribute
+	expire time.Time
+}
+
+// computedAttribute the computed attribute with the parsed option
+type computedAttribute struct {
+	metadata.Attribute
+	option *metadata.ComputedOption
+}
+
+// parseComputedAttributes pick out the computed attributes, the ones with invalid option are ignored.
+func parseComputedAttributes(ctx core.ContextParams, attrs []metadata.Attribute) []computedAttribute {
+	results := make([]computedAttribute, 0)
+	for _, attr := range attrs {
+		if attr.PropertyType != common.FieldTypeComputed {
+			continue
+		}
+		option, err := metadata.ParseComputedOption(attr.Option)
+		if nil != err {
+			blog.Errorf("the option of the computed attribute %s.%s is invalid, err: %v, rid: %s", attr.ObjectID, attr.PropertyID, err, ctx.ReqID)
+			continue
+		}
+		results = append(results, computedAttribute{Attribute: attr, option: option})
+	}
+	return results
+}
+
+// computedAttributes get the computed attributes of all the models from the cache, they're loaded again
+// when the cache is expired or reload is set.
+func (m *instanceManager) computedAttributes(ctx core.ContextParams, reload bool) ([]metadata.Attribute, error) {
+	if !reload {
+		m.computed.lock.RLock()
+		attrs, expire := m.computed.attrs, m.computed.expire
+		m.computed.lock.RUnlock()
+		if time.Now().Before(expire) {
+			return attrs, nil
+		}
+	}
+
+	attrs, err := m.dependent.SearchComputedAttributes(ctx)
+	if nil != err {
+		return nil, err
+	}
+	m.computed.lock.Lock()
+	m.computed.attrs = attrs
+	m.computed.expire = time.Now().Add(computedAttributesTTL)
+	m.computed.lock.Unlock()
+	return attrs, nil
+}
+
+// objComputedAttributes pick out the computed attributes of the objID which are public or belong to the business
+func objComputedAttributes(ctx core.ContextParams, attrs []metadata.Attribute, objID string, bizID int64) []computedAttribute {
+	selected := make([]metadata.Attribute, 0)
+	for _, attr := range attrs {
+		if attr.ObjectID != objID {
+			continue
+		}
+		if attr.OwnerID != ctx.SupplierAccount && attr.OwnerID != common.BKDefaultOwnerID {
+			continue
+		}
+		attrBizID, err := metadata.BizIDFromMetadata(attr.Metadata)
+		if nil != err || (attrBizID != 0 && attrBizID != bizID) {
+			continue
+		}
+		selected = append(selected, attr)
+	}
+	return parseComputedAttributes(ctx, selected)
+}
+
+// RecomputeModelInstance evaluate the computed attributes of the instances again, the values of the computed attributes
+// are kept up to date on the writes in core service, and it's used to catch up with the changes made by the other ways,
+// such as the computed attribute is created or its expression is changed, so the computed attributes are reloaded.
+func (m *instanceManager) RecomputeModelInstance(ctx core.ContextParams, objID string, inputParam metadata.RecomputeOption) (*metadata.UpdatedCount, error) {
+	attrs, err := m.computedAttributes(ctx, true)
+	if nil != err {
+		blog.Errorf("RecomputeModelInstance search the computed attributes failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, err
+	}
+	changed, err := m.recomputeByCondition(ctx, objID, inputParam.Condition, attrs, true)
+	if nil != err {
+		return nil, err
+	}
+	m.recomputeDependents(ctx, objID, changed)
+	return &metadata.UpdatedCount{Count: uint64(len(changed))}, nil
+}
+
+// RecomputeInstances evaluate the computed attributes of the instances whose associated instances or hosts are changed,
+// it's used by the writes of the associations and the host transfers, and the cached computed attributes are used.
+// the computed attributes aggregating over the changed values are evaluated in turn.
+func (m *instanceManager) RecomputeInstances(ctx core.ContextParams, objID string, instIDs []int64) error {
+	changed, err := m.recomputeInstances(ctx, objID, instIDs)
+	if nil != err {
+		return err
+	}
+	m.recomputeDependents(ctx, objID, changed)
+	return nil
+}
+
+// recomputeInstances evaluate the computed attributes of the instances with events, and returns the changed ones
+func (m *instanceManager) recomputeInstances(ctx core.ContextParams, objID string, instIDs []int64) ([]int64, error) {
+	if len(instIDs) == 0 {
+		return nil, nil
+	}
+	attrs, err := m.computedAttributes(ctx, false)
+	if nil != err {
+		blog.Errorf("RecomputeInstances search the computed attributes failed, err: %v, rid: %s", err, ctx.ReqID)
+		return nil, err
+	}
+	cond := mapstr.MapStr{common.GetInstIDField(objID): mapstr.MapStr{common.BKDBIN: instIDs}}
+	return m.recomputeByCondition(ctx, objID, cond, attrs, true)
+}
+
+// recomputeByCondition evaluate the computed attributes of the instances matching the condition, the changes are
+// pushed as the update events and recorded in the audit log if withEvent is set. it returns the changed instances.
+func (m *instanceManager) recomputeByCondition(ctx core.ContextParams, objID string, cond mapstr.MapStr, attrs []metadata.Attribute, withEvent bool) ([]int64, error) {
+	hasComputed := false
+	for _, attr := range attrs {
+		if attr.ObjectID == objID {
+			hasComputed = true
+			break
+		}
+	}
+	if !hasComputed {
+		return nil, nil
+	}
+
+	if nil == cond {
+		cond = mapstr.New()
+	}
+	cond.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	insts, _, err := m.getInsts(ctx, objID, cond)
+	if nil != err {
+		blog.ErrorJSON("recompute get objID(%s) instance error. err:%s, condition:%s, rid:%s", objID, err.Error(), cond, ctx.ReqID)
+		return nil, err
+	}
+	return m.compute(ctx, objID, insts, attrs, withEvent)
+}
+
+// recompute evaluate the computed attributes of the instances after they are updated, no event is pushed because
+// the update event is pushed with the current data of the instances later. the failure is only logged, because
+// the write is done, and the values can be recomputed later.
+func (m *instanceManager) recompute(ctx core.ContextParams, objID string, instIDs []int64) {
+	if len(instIDs) == 0 {
+		return
+	}
+	attrs, err := m.computedAttributes(ctx, false)
+	if nil != err {
+		blog.Errorf("recompute the objID(%s) instances %v failed, search the computed attributes err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		return
+	}
+	cond := mapstr.MapStr{common.GetInstIDField(objID): mapstr.MapStr{common.BKDBIN: instIDs}}
+	if _, err := m.recomputeByCondition(ctx, objID, cond, attrs, false); nil != err {
+		blog.Errorf("recompute the objID(%s) instances %v failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+	}
+}
+
+// recomputeDependents evaluate the computed attributes which aggregate over the instances of the objID, and then
+// the ones aggregating over the changed computed values in turn. the failure is only logged like recompute.
+func (m *instanceManager) recomputeDependents(ctx core.ContextParams, objID string, instIDs []int64) {
+	dependents := func(objID string, instIDs []int64) (map[string][]int64, error) {
+		dependents, err := m.dependents(ctx, objID, instIDs)
+		if nil != err {
+			blog.Errorf("find the dependents of the objID(%s) instances %v failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		}
+		return dependents, err
+	}
+	recompute := func(objID string, instIDs []int64) ([]int64, error) {
+		changed, err := m.recomputeInstances(ctx, objID, instIDs)
+		if nil != err {
+			blog.Errorf("recompute the objID(%s) instances %v failed, err: %v, rid: %s", objID, instIDs, err, ctx.ReqID)
+		}
+		return changed, err
+	}
+	cascadeRecompute(objID, instIDs, dependents, recompute)
+}
+
+// cascadeRecompute recompute the dependents of the changed instances level by level, until no computed value
+// is changed. each instance is recomputed at most once, so that the computed attributes aggregating over each
+// other don't recompute forever. the failed dependents are skipped, and the others go on.
+func cascadeRecompute(objID string, instIDs []int64,
+	dependents func(objID string, instIDs []int64) (map[string][]int64, error),
+	recompute func(objID string, instIDs []int64) ([]int64, error)) {
+
+	visited := make(map[string]bool)
+	for _, instID := range instIDs {
+		visited[instKey(objID, instID)] = true
+	}
+	changed := map[string][]int64{objID: instIDs}
+	for len(changed) != 0 {
+		next := make(map[string][]int64)
+		for objID, instIDs := range changed {
+			if len(instIDs) == 0 {
+				continue
+			}
+			depends, err := dependents(objID, instIDs)
+			if nil != err {
+				continue
+			}
+			for dependObjID, dependInstIDs := range depends {
+				ids := make([]int64, 0, len(dependInstIDs))
+				for _, instID := range dependInstIDs {
+					if key := instKey(dependObjID, instID); !visited[key] {
+						visited[key] = true
+						ids = append(ids, instID)
+					}
+				}
+				if len(ids) == 0 {
+					continue
+				}
+				recomputed, err := recompute(dependObjID, ids)
+				if nil != err {
+					continue
+				}
+				next[dependObjID] = append(next[dependObjID], recomputed...)
+			}
+		}
+		changed = next
+	}
+}
+
+// fillComputed evaluate the computed attributes of the instance to be created, so that they're saved and pushed
+// along with the instance. it has no associated instance or host yet, so all the aggregates are zero.
+func (m *instanceManager) fillComputed(ctx core.ContextParams, objID string, data mapstr.MapStr) error {
+	bizID, err := FetchBizIDFromInstance(objID, data)
+	if nil != err {
+		blog.ErrorJSON("fill the computed attributes of objID(%s) instance failed, get biz id error. err:%s, inst:%s, rid:%s", objID, err.Error(), data, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+	}
+	result, err := m.computedAttributes(ctx, false)
+	if nil != err {
+		blog.Errorf("fill the computed attributes of objID(%s) instance failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	attrs := objComputedAttributes(ctx, result, objID, bizID)
+	if len(attrs) == 0 {
+		return nil
+	}
+	data.Merge(evaluate(ctx, objID, 0, data, attrs, newComputeSource(objID)))
+	return nil
+}
+
+// dependents find the instances whose computed attributes aggregate over the instances of the objID
+func (m *instanceManager) dependents(ctx core.ContextParams, objID string, instIDs []int64) (map[string][]int64, error) {
+	attrs, err := m.computedAttributes(ctx, false)
+	if nil != err {
+		return nil, err
+	}
+
+	// the aggregates over the objID of each model
+	aggregates := make(map[string][]metadata.ComputedAggregate)
+	for _, attr := range parseComputedAttributes(ctx, attrs) {
+		for _, agg := range attr.option.Aggregates {
+			if agg.ObjectID == objID {
+				aggregates[attr.ObjectID] = append(aggregates[attr.ObjectID], agg)
+			}
+		}
+	}
+
+	results := make(map[string][]int64)
+	if len(aggregates) == 0 || len(instIDs) == 0 {
+		return results, nil
+	}
+	exists := make(map[string]bool)
+	add := func(dependObjID string, dependInstID int64) {
+		key := instKey(dependObjID, dependInstID)
+		if !exists[key] {
+			exists[key] = true
+			results[dependObjID] = append(results[dependObjID], dependInstID)
+		}
+	}
+	matches := func(dependObjID, objAsstID string) bool {
+		for _, agg := range aggregates[dependObjID] {
+			if len(agg.ObjectAsstID) == 0 || agg.ObjectAsstID == objAsstID {
+				return true
+			}
+		}
+		return false
+	}
+
+	if objID == common.BKInnerObjIDHost {
+		relations, err := m.dependent.SearchHostModuleRelation(ctx, &metadata.HostModuleRelationRequest{HostIDArr: instIDs})
+		if nil != err {
+			return nil, err
+		}
+		for _, relation := range relations {
+			if matches(common.BKInnerObjIDApp, "") {
+				add(common.BKInnerObjIDApp, relation.AppID)
+			}
+			if matches(common.BKInnerObjIDSet, "") {
+				add(common.BKInnerObjIDSet, relation.SetID)
+			}
+			if matches(common.BKInnerObjIDModule, "") {
+				add(common.BKInnerObjIDModule, relation.ModuleID)
+			}
+		}
+	}
+
+	ids := make(map[int64]bool, len(instIDs))
+	for _, instID := range instIDs {
+		ids[instID] = true
+	}
+	assts, err := m.dependent.SearchInstAssts(ctx, objID, instIDs)
+	if nil != err {
+		return nil, err
+	}
+	for _, asst := range assts {
+		if asst.ObjectID == objID && ids[asst.InstID] && matches(asst.AsstObjectID, asst.ObjectAsstID) {
+			add(asst.AsstObjectID, asst.AsstInstID)
+		}
+		if asst.AsstObjectID == objID && ids[asst.AsstInstID] && matches(asst.ObjectID, asst.ObjectAsstID) {
+			add(asst.ObjectID, asst.InstID)
+		}
+	}
+	return results, nil
+}
+
+// compute evaluate the computed attributes of the instances and save the changed values, the changes are pushed
+// as the update events and recorded in the audit log if withEvent is set.
+func (m *instanceManager) compute(ctx core.ContextParams, objID string, insts []mapstr.MapStr, allAttrs []metadata.Attribute, withEvent bool) ([]int64, error) {
+	instIDFieldName := common.GetInstIDField(objID)
+	tableName := common.GetInstTableName(objID)
+
+	// the instances are computed by the business, for the attributes may be different
+	bizAttrs := make(map[int64][]computedAttribute)
+	bizInsts := make(map[int64][]mapstr.MapStr)
+	for _, inst := range insts {
+		bizID, err := FetchBizIDFromInstance(objID, inst)
+		if nil != err {
+			blog.ErrorJSON("compute the objID(%s) instance failed, get biz id error. err:%s, inst:%s, rid:%s", objID, err.Error(), inst, ctx.ReqID)
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKAppIDField)
+		}
+		if _, exists := bizAttrs[bizID]; !exists {
+			bizAttrs[bizID] = objComputedAttributes(ctx, allAttrs, objID, bizID)
+		}
+		if len(bizAttrs[bizID]) != 0 {
+			bizInsts[bizID] = append(bizInsts[bizID], inst)
+		}
+	}
+
+	eh := m.NewEventHandle(objID)
+	auditLogs := make([]metadata.SaveAuditLogParams, 0)
+	changedIDs := make([]int64, 0)
+	for bizID, insts := range bizInsts {
+		attrs := bizAttrs[bizID]
+		instIDs := make([]int64, 0, len(insts))
+		for _, inst := range insts {
+			instID, err := util.GetInt64ByInterface(inst[instIDFieldName])
+			if nil != err {
+				return changedIDs, err
+			}
+			instIDs = append(instIDs, instID)
+		}
+		src, err := m.loadComputeSource(ctx, objID, instIDs, attrs)
+		if nil != err {
+			return changedIDs, err
+		}
+
+		headers := make([]metadata.Header, 0, len(attrs))
+		for _, attr := range attrs {
+			headers = append(headers, metadata.Header{PropertyID: attr.PropertyID, PropertyName: attr.PropertyName})
+		}
+		for idx, inst := range insts {
+			instID := instIDs[idx]
+			changed := mapstr.New()
+			for key, val := range evaluate(ctx, objID, instID, inst, attrs, src) {
+				if !computedEqual(inst[key], val) {
+					changed.Set(key, val)
+				}
+			}
+			if len(changed) == 0 {
+				continue
+			}
+
+			cond := mapstr.MapStr{instIDFieldName: instID}
+			if !util.IsInnerObject(objID) {
+				cond.Set(common.BKObjIDField, objID)
+			}
+			if err := m.dbProxy.Table(tableName).Update(ctx, cond, changed); nil != err {
+				blog.ErrorJSON("save the computed attributes of objID(%s) instance(%s) error. err:%s, values:%s, rid:%s", objID, instID, err.Error(), changed, ctx.ReqID)
+				return changedIDs, ctx.Error.Error(common.CCErrCommDBUpdateFailed)
+			}
+			changedIDs = append(changedIDs, instID)
+
+			if !withEvent {
+				continue
+			}
+			preData := mapstr.New()
+			preData.Merge(inst)
+			util.RemoveIPRange(preData)
+			curData := mapstr.New()
+			curData.Merge(preData)
+			curData.Merge(changed)
+			eh.SetPreData(instID, preData)
+			eh.SetCurData(instID, curData)
+			auditLogs = append(auditLogs, metadata.SaveAuditLogParams{
+				ID:      instID,
+				Model:   objID,
+				Content: metadata.Content{PreData: preData, CurData: curData, Headers: headers},
+				OpDesc:  "update instance",
+				OpType:  auditoplog.AuditOpTypeModify,
+				BizID:   bizID,
+			})
+		}
+	}
+
+	if len(auditLogs) == 0 {
+		return changedIDs, nil
+	}
+	if err := eh.Push(ctx, objID, metadata.EventActionUpdate); nil != err {
+		blog.Errorf("push the update events of the computed attributes of objID(%s) failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return changedIDs, err
+	}
+	if err := m.dependent.SaveAuditLog(ctx, auditLogs...); nil != err {
+		blog.Errorf("save the audit logs of the computed attributes of objID(%s) failed, err: %v, rid: %s", objID, err, ctx.ReqID)
+		return changedIDs, ctx.Error.Error(common.CCErrAuditSaveLogFaile)
+	}
+	return changedIDs, nil
+}
+
+// computedEqual check the computed value is not changed, the value is null if it can't be computed
+func computedEqual(origin, val interface{}) bool {
+	if nil == origin || nil == val {
+		return nil == origin && nil == val
+	}
+	originNum, err := util.GetFloat64ByInterface(origin)
+	if nil != err {
+		return false
+	}
+	num, err := util.GetFloat64ByInterface(val)
+	return nil == err && originNum == num
+}
+
+// computeSource the hosts and the instances aggregated by the computed attributes of a batch of instances,
+// they're loaded all at once rather than for each of the instances.
+type computeSource struct {
+	objID string
+	// hosts the ids of the hosts of the business, set or module
+	hosts map[int64][]int64
+	// assts the associations of the instances
+	assts []metadata.InstAsst
+	// insts the aggregated instances by the model and the instance id
+	insts map[string]map[int64]mapstr.MapStr
+}
+
+func newComputeSource(objID string) *computeSource {
+	return &computeSource{
+		objID: objID,
+		hosts: make(map[int64][]int64),
+		assts: make([]metadata.InstAsst, 0),
+		insts: make(map[string]map[int64]mapstr.MapStr),
+	}
+}
+
+// byHosts the hosts of the business, set and module are found by the host module relations
+func (s *computeSource) byHosts(agg metadata.ComputedAggregate) bool {
+	if agg.ObjectID != common.BKInnerObjIDHost || len(agg.ObjectAsstID) != 0 {
+		return false
+	}
+	return s.objID == common.BKInnerObjIDApp || s.objID == common.BKInnerObjIDSet || s.objID == common.BKInnerObjIDModule
+}
+
+// loadComputeSource load the hosts and the instances aggregated by the attributes of the instances
+func (m *instanceManager) loadComputeSource(ctx core.ContextParams, objID string, instIDs []int64, attrs []computedAttribute) (*computeSource, error) {
+	src := newComputeSource(objID)
+	aggs := make([]metadata.ComputedAggregate, 0)
+	for _, attr := range attrs {
+		aggs = append(aggs, attr.option.Aggregates...)
+	}
+	if len(aggs) == 0 || len(instIDs) == 0 {
+		return src, nil
+	}
+
+	needHosts, needAssts := false, false
+	for _, agg := range aggs {
+		if src.byHosts(agg) {
+			needHosts = true
+		} else {
+			needAssts = true
+		}
+	}
+	if needHosts {
+		if err := m.loadComputeHosts(ctx, src, instIDs); nil != err {
+			return nil, err
+		}
+	}
+	if needAssts {
+		assts, err := m.dependent.SearchInstAssts(ctx, objID, instIDs)
+		if nil != err {
+			return nil, err
+		}
+		src.assts = assts
+	}
+
+	// the values of the aggregated instances are needed except for counting them
+	aggInstIDs := make(map[string][]int64)
+	for _, agg := range aggs {
+		if agg.Func == metadata.ComputedAggregateCount {
+			continue
+		}
+		for _, instID := range instIDs {
+			aggInstIDs[agg.ObjectID] = append(aggInstIDs[agg.ObjectID], src.aggregatedInstIDs(instID, agg)...)
+		}
+	}
+	for aggObjID, ids := range aggInstIDs {
+		if len(ids) == 0 {
+			continue
+		}
+		aggInstIDField := common.GetInstIDField(aggObjID)
+		cond := mapstr.MapStr{aggInstIDField: mapstr.MapStr{common.BKDBIN: ids}}
+		insts, _, err := m.getInsts(ctx, aggObjID, cond)
+		if nil != err {
+			blog.ErrorJSON("aggregate the objID(%s) instances error. err:%s, condition:%s, rid:%s", aggObjID, err.Error(), cond, ctx.ReqID)
+			return nil, err
+		}
+		src.insts[aggObjID] = make(map[int64]mapstr.MapStr, len(insts))
+		for _, inst := range insts {
+			id, err := util.GetInt64ByInterface(inst[aggInstIDField])
+			if nil != err {
+				continue
+			}
+			src.insts[aggObjID][id] = inst
+		}
+	}
+	return src, nil
+}
+
+// loadComputeHosts load the hosts of the business, sets or modules by the host module relations
+func (m *instanceManager) loadComputeHosts(ctx core.ContextParams, src *computeSource, instIDs []int64) error {
+	inputs := make([]*metadata.HostModuleRelationRequest, 0)
+	switch src.objID {
+	case common.BKInnerObjIDApp:
+		for _, instID := range instIDs {
+			inputs = append(inputs, &metadata.HostModuleRelationRequest{ApplicationID: instID})
+		}
+	case common.BKInnerObjIDSet:
+		inputs = append(inputs, &metadata.HostModuleRelationRequest{SetIDArr: instIDs})
+	case common.BKInnerObjIDModule:
+		inputs = append(inputs, &metadata.HostModuleRelationRequest{ModuleIDArr: instIDs})
+	}
+
+	for _, input := range inputs {
+		relations, err := m.dependent.SearchHostModuleRelation(ctx, input)
+		if nil != err {
+			return err
+		}
+		for _, relation := range relations {
+			var ownerID int64
+			switch src.objID {
+			case common.BKInnerObjIDApp:
+				ownerID = relation.AppID
+			case common.BKInnerObjIDSet:
+				ownerID = relation.SetID
+			case common.BKInnerObjIDModule:
+				ownerID = relation.ModuleID
+			}
+			src.hosts[ownerID] = append(src.hosts[ownerID], relation.HostID)
+		}
+	}
+	return nil
+}
+
+// aggregatedInstIDs find the ids of the instances to aggregate, the hosts of the business, set and module are found
+// by the host module relations, and the other instances are found by the instance associations.
+func (s *computeSource) aggregatedInstIDs(instID int64, agg metadata.ComputedAggregate) []int64 {
+	instIDs := make([]int64, 0)
+	exists := make(map[int64]bool)
+	add := func(id int64) {
+		if !exists[id] {
+			exists[id] = true
+			instIDs = append(instIDs, id)
+		}
+	}
+
+	if s.byHosts(agg) {
+		for _, hostID := range s.hosts[instID] {
+			add(hostID)
+		}
+		return instIDs
+	}
+
+	for _, asst := range s.assts {
+		if len(agg.ObjectAsstID) != 0 && asst.ObjectAsstID != agg.ObjectAsstID {
+			continue
+		}
+		if asst.ObjectID == s.objID && asst.InstID == instID && asst.AsstObjectID == agg.ObjectID {
+			add(asst.AsstInstID)
+		}
+		if asst.AsstObjectID == s.objID && asst.AsstInstID == instID && asst.ObjectID == agg.ObjectID {
+			add(asst.InstID)
+		}
+	}
+	return instIDs
+}
+
+// aggregate evaluate the aggregate over the instances associated with the instance
+func (s *computeSource) aggregate(instID int64, agg metadata.ComputedAggregate) float64 {
+	instIDs := s.aggregatedInstIDs(instID, agg)
+	if agg.Func == metadata.ComputedAggregateCount {
+		return float64(len(instIDs))
+	}
+
+	values := make([]float64, 0)
+	for _, id := range instIDs {
+		if val, err := util.GetFloat64ByInterface(s.insts[agg.ObjectID][id][agg.Field]); nil == err {
+			values = append(values, val)
+		}
+	}
+	if len(values) == 0 {
+		return 0
+	}
+
+	result := values[0]
+	switch agg.Func {
+	case metadata.ComputedAggregateSum, metadata.ComputedAggregateAvg:
+		for _, val := range values[1:] {
+			result += val
+		}
+		if agg.Func == metadata.ComputedAggregateAvg {
+			result = result / float64(len(values))
+		}
+	case metadata.ComputedAggregateMin:
+		for _, val := range values[1:] {
+			if val < result {
+				result = val
+			}
+		}
+	case metadata.ComputedAggregateMax:
+		for _, val := range values[1:] {
+			if val > result {
+				result = val
+			}
+		}
+	}
+	return result
+}
+
+// evaluate evaluate the computed attributes of the instance, the value is null if it can't be computed,
+// such as some attributes of the expression are not set or it divides by zero.
+func evaluate(ctx core.ContextParams, objID string, instID int64, inst mapstr.MapStr, attrs []computedAttribute, src *computeSource) mapstr.MapStr {
+	fields := make(map[string]float64)
+	for key, val := range inst {
+		if num, err := util.GetFloat64ByInterface(val); nil == err {
+			fields[key] = num
+		}
+	}
+	// the expression can't refer to the computed attributes
+	for _, attr := range attrs {
+		delete(fields, attr.PropertyID)
+	}
+
+	values := mapstr.New()
+	for _, attr := range attrs {
+		vars := make(map[string]float64, len(fields)+len(attr.option.Aggregates))
+		for key, val := range fields {
+			vars[key] = val
+		}
+		for _, agg := range attr.option.Aggregates {
+			vars[agg.Name] = src.aggregate(instID, agg)
+		}
+
+		val, err := attr.option.Expr().Evaluate(vars)
+		if nil != err {
+			blog.V(3).Infof("evaluate the computed attribute %s of objID(%s) instance(%d) failed, err: %v, rid: %s", attr.PropertyID, objID, instID, err, ctx.ReqID)
+			values.Set(attr.PropertyID, nil)
+			continue
+		}
+		values.Set(attr.PropertyID, val)
+	}
+	return values
+}
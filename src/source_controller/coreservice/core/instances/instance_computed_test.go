@@ -0,0 +1,96 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instances
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// computeGraph the dependents of the instances, and the instances whose computed values are changed on recompute
+type computeGraph struct {
+	dependents map[string]map[string][]int64
+	changes    map[string]bool
+	recomputed []string
+}
+
+func (g *computeGraph) dependentsOf(objID string, instIDs []int64) (map[string][]int64, error) {
+	results := make(map[string][]int64)
+	for _, instID := range instIDs {
+		for dependObjID, dependInstIDs := range g.dependents[instKey(objID, instID)] {
+			results[dependObjID] = append(results[dependObjID], dependInstIDs...)
+		}
+	}
+	return results, nil
+}
+
+func (g *computeGraph) recompute(objID string, instIDs []int64) ([]int64, error) {
+	changed := make([]int64, 0)
+	for _, instID := range instIDs {
+		key := instKey(objID, instID)
+		g.recomputed = append(g.recomputed, key)
+		if g.changes[key] {
+			changed = append(changed, instID)
+		}
+	}
+	return changed, nil
+}
+
+func TestCascadeRecompute(t *testing.T) {
+	tests := []struct {
+		name       string
+		dependents map[string]map[string][]int64
+		changes    map[string]bool
+		want       []string
+	}{
+		{
+			// the module counts the hosts, and the set sums the computed counts of its modules
+			name: "two level aggregate",
+			dependents: map[string]map[string][]int64{
+				instKey("host", 1):    {"module": {10}},
+				instKey("module", 10): {"set": {100}},
+			},
+			changes: map[string]bool{instKey("module", 10): true, instKey("set", 100): true},
+			want:    []string{instKey("module", 10), instKey("set", 100)},
+		},
+		{
+			// the count of the module is not changed, so the set is not recomputed
+			name: "unchanged value stops",
+			dependents: map[string]map[string][]int64{
+				instKey("host", 1):    {"module": {10}},
+				instKey("module", 10): {"set": {100}},
+			},
+			changes: map[string]bool{},
+			want:    []string{instKey("module", 10)},
+		},
+		{
+			// the computed attributes of a and b aggregate over each other
+			name: "dependency cycle",
+			dependents: map[string]map[string][]int64{
+				instKey("host", 1): {"a": {1}},
+				instKey("a", 1):    {"b": {1}},
+				instKey("b", 1):    {"a": {1}},
+			},
+			changes: map[string]bool{instKey("a", 1): true, instKey("b", 1): true},
+			want:    []string{instKey("a", 1), instKey("b", 1)},
+		},
+	}
+	for _, tt := range tests {
+		g := &computeGraph{dependents: tt.dependents, changes: tt.changes}
+		cascadeRecompute("host", []int64{1}, g.dependentsOf, g.recompute)
+		sort.Strings(g.recomputed)
+		if !reflect.DeepEqual(g.recomputed, tt.want) {
+			t.Errorf("%s: recomputed %v, want %v", tt.name, g.recomputed, tt.want)
+		}
+	}
+}
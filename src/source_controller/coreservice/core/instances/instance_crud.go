@@ -84,6 +84,7 @@ func (m *instanceManager) getInstDataByID(ctx core.ContextParams, objID string,
 	if nil != err {
 		return nil, err
 	}
+	util.RemoveIPRange(origin)
 	return origin, nil
 }
 
@@ -110,6 +111,10 @@ func (m *instanceManager) searchInstance(ctx core.ContextParams, objID string, i
 	}
 	err = instHandler.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &results)
 	blog.V(9).Infof("searchInstance with table: %s and parameters: %s, results: %+v", tableName, condition.ToMapStr(), results)
+	// the address ranges of the ip attributes are only used to search the instances
+	for _, item := range results {
+		util.RemoveIPRange(item)
+	}
 
 	return results, err
 }
@@ -0,0 +1,93 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instances
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// ipRanges returns the address ranges of the values of the ip attributes in the data, keyed by the property id.
+// the range is nil if the value is empty, so that the range is cleared along with the value.
+func ipRanges(propertys map[string]metadata.Attribute, data mapstr.MapStr) map[string]*util.IPRange {
+	ranges := make(map[string]*util.IPRange)
+	for key, val := range data {
+		property, ok := propertys[key]
+		if !ok || property.PropertyType != common.FieldTypeIP {
+			continue
+		}
+		value, _ := val.(string)
+		if "" == value {
+			ranges[key] = nil
+			continue
+		}
+		r, err := util.ParseIPRange(value)
+		if nil != err {
+			blog.Warnf("parse the ip range of %s:%s failed, err: %v", key, value, err)
+			ranges[key] = nil
+			continue
+		}
+		ranges[key] = r
+	}
+	return ranges
+}
+
+// fillCreateIPRange stores the address ranges of the ip attributes in the instance to be created,
+// and the addresses of the inner and outer ips of the host, which are searched by the cidr and ip ranges.
+func (valid *validator) fillCreateIPRange(instanceData mapstr.MapStr) {
+	ranges := mapstr.New()
+	for key, r := range ipRanges(valid.propertys, instanceData) {
+		if nil != r {
+			ranges[key] = r
+		}
+	}
+	if common.BKInnerObjIDHost == valid.objID {
+		for key, addresses := range util.HostIPRanges(instanceData) {
+			ranges[key] = addresses
+		}
+	}
+	if 0 != len(ranges) {
+		instanceData[common.BKIPRangeField] = ranges
+	}
+}
+
+// fillUpdateIPRange updates the address ranges of the ip attributes along with their values,
+// origin is one of the instances to be updated, whose business decides the attributes of the model.
+func (m *instanceManager) fillUpdateIPRange(ctx core.ContextParams, objID string, origin mapstr.MapStr, data mapstr.MapStr) error {
+	bizID, err := FetchBizIDFromInstance(objID, origin)
+	if err != nil {
+		blog.Errorf("fillUpdateIPRange failed, FetchBizIDFromInstance failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "bk_biz_id")
+	}
+	valid, err := NewValidator(ctx, m.dependent, objID, bizID)
+	if nil != err {
+		blog.Errorf("init validator failed %s, rid: %s", err.Error(), ctx.ReqID)
+		return err
+	}
+	for key, r := range ipRanges(valid.propertys, data) {
+		if nil == r {
+			data[common.BKIPRangeField+"."+key] = nil
+			continue
+		}
+		data[common.BKIPRangeField+"."+key] = r
+	}
+	if common.BKInnerObjIDHost == objID {
+		for key, addresses := range util.HostIPRanges(data) {
+			data[common.BKIPRangeField+"."+key] = addresses
+		}
+	}
+	return nil
+}
@@ -0,0 +1,380 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"fmt"
+	"strings"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// deleteTarget a instance to be deleted
+type deleteTarget struct {
+	metadata.DeleteImpactInst
+	origin mapstr.MapStr
+}
+
+// deletePlan the instances and the instance associations to be deleted by a instance deletion,
+// it follows the OnDelete action of the model associations:
+// none: the instances can not be deleted while the other instances associate them, the associations
+// created by the instances are deleted with them.
+// delete_src: the source instances are deleted with the destination instance.
+// delete_dest: the destination instances are deleted with the source instance.
+// restrict: the instances can not be deleted while they are associated.
+type deletePlan struct {
+	targets      []deleteTarget
+	associations []metadata.InstAsst
+	restrictions []metadata.InstAsst
+}
+
+func instKey(objID string, instID int64) string {
+	return fmt.Sprintf("%s:%d", objID, instID)
+}
+
+// deleteLookup the lookups the delete plan depends on
+type deleteLookup struct {
+	// instAssts returns the inst assts which the instance is the source or destination of
+	instAssts func(objID string, instID int64) ([]metadata.InstAsst, error)
+	// modelAssts returns the model assts of the bk_obj_asst_ids
+	modelAssts func(objAsstIDs []string) ([]metadata.Association, error)
+	// inst returns the instance, it's nil if the instance does not exist
+	inst func(objID string, instID int64) (mapstr.MapStr, error)
+}
+
+func (m *instanceManager) newDeleteLookup(ctx core.ContextParams) *deleteLookup {
+	return &deleteLookup{
+		instAssts: func(objID string, instID int64) ([]metadata.InstAsst, error) {
+			return m.dependent.SearchInstAsst(ctx, objID, uint64(instID))
+		},
+		modelAssts: func(objAsstIDs []string) ([]metadata.Association, error) {
+			return m.dependent.SearchModelAsst(ctx, objAsstIDs)
+		},
+		inst: func(objID string, instID int64) (mapstr.MapStr, error) {
+			insts, _, err := m.getInsts(ctx, objID, instCond(ctx, objID, instID))
+			if nil != err || len(insts) == 0 {
+				return nil, err
+			}
+			return insts[0], nil
+		},
+	}
+}
+
+// deleteTargets returns the instances matched by the condition of the option, and the instances of the other
+// objects deleted along with them, which are all requested to delete.
+func (m *instanceManager) deleteTargets(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) ([]deleteTarget, error) {
+	inputParam.Condition.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	origins, _, err := m.getInsts(ctx, objID, inputParam.Condition)
+	if nil != err {
+		return nil, err
+	}
+
+	targets := make([]deleteTarget, 0)
+	appendTargets := func(objID string, origins []mapstr.MapStr) error {
+		for _, origin := range origins {
+			target, err := newDeleteTarget(objID, origin, "")
+			if nil != err {
+				return err
+			}
+			targets = append(targets, target)
+		}
+		return nil
+	}
+	if err := appendTargets(objID, origins); nil != err {
+		return nil, err
+	}
+
+	for _, group := range inputParam.Along {
+		if len(group.InstIDs) == 0 {
+			continue
+		}
+		cond := mapstr.MapStr{
+			common.GetInstIDField(group.ObjectID): mapstr.MapStr{common.BKDBIN: group.InstIDs},
+			common.BKOwnerIDField:                 ctx.SupplierAccount,
+		}
+		origins, _, err := m.getInsts(ctx, group.ObjectID, cond)
+		if nil != err {
+			return nil, err
+		}
+		if err := appendTargets(group.ObjectID, origins); nil != err {
+			return nil, err
+		}
+	}
+	return targets, nil
+}
+
+// planDelete find all the instances and instance associations impacted by deleting the targets,
+// the targets may be the instances of different objects.
+func planDelete(targets []deleteTarget, lookup *deleteLookup) (*deletePlan, error) {
+	plan := &deletePlan{
+		targets:      make([]deleteTarget, 0),
+		associations: make([]metadata.InstAsst, 0),
+		restrictions: make([]metadata.InstAsst, 0),
+	}
+
+	deleting := make(map[string]bool)
+	for _, target := range targets {
+		key := instKey(target.ObjectID, target.InstID)
+		if deleting[key] {
+			continue
+		}
+		deleting[key] = true
+		plan.targets = append(plan.targets, target)
+	}
+
+	modelAssts := make(map[string]metadata.Association)
+	seenAssts := make(map[int64]bool)
+	candidates := make([]metadata.InstAsst, 0)
+	// the targets grows while the deletion is cascaded.
+	for idx := 0; idx < len(plan.targets); idx++ {
+		target := plan.targets[idx]
+		assts, err := lookup.instAssts(target.ObjectID, target.InstID)
+		if nil != err {
+			return nil, err
+		}
+
+		if err := lookup.loadModelAsst(assts, modelAssts); nil != err {
+			return nil, err
+		}
+
+		for _, asst := range assts {
+			if seenAssts[asst.ID] {
+				continue
+			}
+			seenAssts[asst.ID] = true
+			plan.associations = append(plan.associations, asst)
+
+			isSource := asst.ObjectID == target.ObjectID && asst.InstID == target.InstID
+			relatedObjID, relatedInstID := asst.AsstObjectID, asst.AsstInstID
+			if !isSource {
+				relatedObjID, relatedInstID = asst.ObjectID, asst.InstID
+			}
+
+			switch modelAssts[asst.ObjectAsstID].OnDelete {
+			case metadata.Restrict:
+				candidates = append(candidates, asst)
+				continue
+			case metadata.DeleteDestinatioin:
+				if !isSource {
+					continue
+				}
+			case metadata.DeleteSource:
+				if isSource {
+					continue
+				}
+			default:
+				if !isSource {
+					candidates = append(candidates, asst)
+				}
+				continue
+			}
+
+			if deleting[instKey(relatedObjID, relatedInstID)] {
+				continue
+			}
+
+			// the inner objects have their own deletion rules, such as the hosts must be transferred to the
+			// idle module first, so the deletion can not be cascaded to them.
+			if util.IsInnerObject(relatedObjID) {
+				candidates = append(candidates, asst)
+				continue
+			}
+
+			related, err := lookup.inst(relatedObjID, relatedInstID)
+			if nil != err {
+				return nil, err
+			}
+			if related == nil {
+				// the related instance has been deleted, only the dangling association is deleted.
+				continue
+			}
+			cascaded, err := newDeleteTarget(relatedObjID, related, asst.ObjectAsstID)
+			if nil != err {
+				return nil, err
+			}
+			deleting[instKey(relatedObjID, relatedInstID)] = true
+			plan.targets = append(plan.targets, cascaded)
+		}
+	}
+
+	// the restricted association does not refuse the deletion if the instances of both sides are deleted,
+	// or the instance on the other side does not exist any more, then only the dangling association is deleted.
+	for _, asst := range candidates {
+		sourceDeleting := deleting[instKey(asst.ObjectID, asst.InstID)]
+		destDeleting := deleting[instKey(asst.AsstObjectID, asst.AsstInstID)]
+		if sourceDeleting && destDeleting {
+			continue
+		}
+		relatedObjID, relatedInstID := asst.ObjectID, asst.InstID
+		if sourceDeleting {
+			relatedObjID, relatedInstID = asst.AsstObjectID, asst.AsstInstID
+		}
+		related, err := lookup.inst(relatedObjID, relatedInstID)
+		if nil != err {
+			return nil, err
+		}
+		if related == nil {
+			continue
+		}
+		plan.restrictions = append(plan.restrictions, asst)
+	}
+
+	return plan, nil
+}
+
+func (l *deleteLookup) loadModelAsst(assts []metadata.InstAsst, modelAssts map[string]metadata.Association) error {
+	objAsstIDs := make([]string, 0)
+	for _, asst := range assts {
+		if _, exists := modelAssts[asst.ObjectAsstID]; !exists {
+			objAsstIDs = append(objAsstIDs, asst.ObjectAsstID)
+		}
+	}
+	if len(objAsstIDs) == 0 {
+		return nil
+	}
+
+	items, err := l.modelAssts(util.StrArrayUnique(objAsstIDs))
+	if nil != err {
+		return err
+	}
+	for _, item := range items {
+		modelAssts[item.AssociationName] = item
+	}
+	return nil
+}
+
+// impact returns the impact report of the plan
+func (p *deletePlan) impact() *metadata.DeleteImpact {
+	impact := &metadata.DeleteImpact{
+		Instances:    make([]metadata.DeleteImpactInst, 0),
+		Associations: p.associations,
+		Restrictions: p.restrictions,
+	}
+	for _, target := range p.targets {
+		impact.Instances = append(impact.Instances, target.DeleteImpactInst)
+	}
+	return impact
+}
+
+// restrictError returns the error which describe the restrictions of the plan
+func (p *deletePlan) restrictError(ctx core.ContextParams) error {
+	if len(p.restrictions) == 0 {
+		return nil
+	}
+	assts := make([]string, 0)
+	for _, asst := range p.restrictions {
+		assts = append(assts, fmt.Sprintf("%s(%d->%d)", asst.ObjectAsstID, asst.InstID, asst.AsstInstID))
+	}
+	return ctx.Error.Errorf(common.CCErrCoreServiceInstDeleteRestricted, strings.Join(assts, ","))
+}
+
+// execute delete the instance associations and the instances of the plan.
+func (m *instanceManager) execute(ctx core.ContextParams, plan *deletePlan) error {
+	targets := make(map[string][]deleteTarget)
+	objIDs := make([]string, 0)
+	for _, target := range plan.targets {
+		if _, exists := targets[target.ObjectID]; !exists {
+			objIDs = append(objIDs, target.ObjectID)
+		}
+		targets[target.ObjectID] = append(targets[target.ObjectID], target)
+	}
+
+	// the instances and their associations are archived into the recycle bin before they are deleted
+	if err := m.dependent.ArchiveDeleted(ctx, plan.recycleItems()...); nil != err {
+		blog.ErrorJSON("archive the deleted instances error. err:%s, rid:%s", err.Error(), ctx.ReqID)
+		return err
+	}
+
+	for _, objID := range objIDs {
+		eh := m.NewEventHandle(objID)
+		instIDs := make([]int64, 0)
+		for _, target := range targets[objID] {
+			if err := m.dependent.DeleteInstAsst(ctx, objID, uint64(target.InstID)); nil != err {
+				return err
+			}
+			eh.SetPreData(target.InstID, target.origin)
+			instIDs = append(instIDs, target.InstID)
+		}
+
+		cond := mapstr.MapStr{
+			common.GetInstIDField(objID): mapstr.MapStr{common.BKDBIN: instIDs},
+			common.BKOwnerIDField:        ctx.SupplierAccount,
+		}
+		if !util.IsInnerObject(objID) {
+			cond.Set(common.BKObjIDField, objID)
+		}
+		if err := m.dbProxy.Table(common.GetInstTableName(objID)).Delete(ctx, cond); nil != err {
+			blog.ErrorJSON("delete objID(%s) instance error. err:%s, coniditon:%s, rid:%s", objID, err.Error(), cond, ctx.ReqID)
+			return err
+		}
+
+		if err := eh.Push(ctx, objID, metadata.EventActionDelete); nil != err {
+			blog.ErrorJSON("push delete objType(%s) instance to event server error. ids:%s, rid:%s", objID, instIDs, ctx.ReqID)
+			return ctx.Error.CCErrorf(common.CCErrCoreServiceEventPushEventFailed)
+		}
+	}
+	return nil
+}
+
+// recycleItems returns the recycle items of the targets, each of them has the associations of the instance
+func (p *deletePlan) recycleItems() []metadata.RecycleItem {
+	items := make([]metadata.RecycleItem, 0)
+	for _, target := range p.targets {
+		items = append(items, newRecycleItem(target.ObjectID, target.InstID, target.InstName, target.origin, p.associations))
+	}
+	return items
+}
+
+func newRecycleItem(objID string, instID int64, instName string, origin mapstr.MapStr, assts []metadata.InstAsst) metadata.RecycleItem {
+	kind := metadata.RecycleKindInstance
+	if objID == common.BKInnerObjIDHost {
+		kind = metadata.RecycleKindHost
+	}
+	item := metadata.NewRecycleItem(kind, objID, instID, instName, common.GetInstTableName(objID), origin)
+	item.BizID, _ = FetchBizIDFromInstance(objID, origin)
+	for _, asst := range assts {
+		if (asst.ObjectID == objID && asst.InstID == instID) || (asst.AsstObjectID == objID && asst.AsstInstID == instID) {
+			item.Archive(common.BKTableNameInstAsst, mapstr.NewFromStruct(asst, "field"))
+		}
+	}
+	return item
+}
+
+func newDeleteTarget(objID string, origin mapstr.MapStr, cascadedBy string) (deleteTarget, error) {
+	instID, err := util.GetInt64ByInterface(origin[common.GetInstIDField(objID)])
+	if nil != err {
+		return deleteTarget{}, err
+	}
+	instName, _ := origin.String(common.GetInstNameField(objID))
+	return deleteTarget{
+		DeleteImpactInst: metadata.DeleteImpactInst{
+			ObjectID:   objID,
+			InstID:     instID,
+			InstName:   instName,
+			CascadedBy: cascadedBy,
+		},
+		origin: origin,
+	}, nil
+}
+
+func instCond(ctx core.ContextParams, objID string, instID int64) mapstr.MapStr {
+	return mapstr.MapStr{
+		common.GetInstIDField(objID): instID,
+		common.BKOwnerIDField:        ctx.SupplierAccount,
+	}
+}
@@ -0,0 +1,186 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package instances
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+)
+
+// asstGraph the instances, the instance associations and the on delete actions of the model associations
+type asstGraph struct {
+	insts    []string
+	assts    []metadata.InstAsst
+	onDelete map[string]metadata.AssociationOnDeleteAction
+}
+
+func (g *asstGraph) lookup() *deleteLookup {
+	return &deleteLookup{
+		instAssts: func(objID string, instID int64) ([]metadata.InstAsst, error) {
+			assts := make([]metadata.InstAsst, 0)
+			for _, asst := range g.assts {
+				if (asst.ObjectID == objID && asst.InstID == instID) || (asst.AsstObjectID == objID && asst.AsstInstID == instID) {
+					assts = append(assts, asst)
+				}
+			}
+			return assts, nil
+		},
+		modelAssts: func(objAsstIDs []string) ([]metadata.Association, error) {
+			assts := make([]metadata.Association, 0)
+			for _, objAsstID := range objAsstIDs {
+				assts = append(assts, metadata.Association{AssociationName: objAsstID, OnDelete: g.onDelete[objAsstID]})
+			}
+			return assts, nil
+		},
+		inst: func(objID string, instID int64) (mapstr.MapStr, error) {
+			for _, key := range g.insts {
+				if key == instKey(objID, instID) {
+					return testInst(objID, instID), nil
+				}
+			}
+			return nil, nil
+		},
+	}
+}
+
+func testInst(objID string, instID int64) mapstr.MapStr {
+	return mapstr.MapStr{common.GetInstIDField(objID): instID}
+}
+
+func testTarget(objID string, instID int64) deleteTarget {
+	target, _ := newDeleteTarget(objID, testInst(objID, instID), "")
+	return target
+}
+
+func testAsst(id int64, objAsstID string, objID string, instID int64, asstObjID string, asstInstID int64) metadata.InstAsst {
+	return metadata.InstAsst{
+		ID:           id,
+		ObjectAsstID: objAsstID,
+		ObjectID:     objID,
+		InstID:       instID,
+		AsstObjectID: asstObjID,
+		AsstInstID:   asstInstID,
+	}
+}
+
+func TestPlanDelete(t *testing.T) {
+	tests := []struct {
+		name         string
+		graph        asstGraph
+		deleting     []deleteTarget
+		targets      []string
+		restrictions []int64
+	}{
+		{
+			name: "cascade to the destination",
+			graph: asstGraph{
+				insts: []string{instKey("rack", 1), instKey("switch", 2), instKey("port", 3)},
+				assts: []metadata.InstAsst{
+					testAsst(1, "rack_switch", "rack", 1, "switch", 2),
+					testAsst(2, "switch_port", "switch", 2, "port", 3),
+				},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{
+					"rack_switch": metadata.DeleteDestinatioin,
+					"switch_port": metadata.DeleteDestinatioin,
+				},
+			},
+			deleting: []deleteTarget{testTarget("rack", 1)},
+			targets:  []string{instKey("port", 3), instKey("rack", 1), instKey("switch", 2)},
+		},
+		{
+			name: "restricted by the source",
+			graph: asstGraph{
+				insts:    []string{instKey("rack", 1), instKey("switch", 2)},
+				assts:    []metadata.InstAsst{testAsst(1, "rack_switch", "rack", 1, "switch", 2)},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{"rack_switch": metadata.Restrict},
+			},
+			deleting:     []deleteTarget{testTarget("switch", 2)},
+			targets:      []string{instKey("switch", 2)},
+			restrictions: []int64{1},
+		},
+		{
+			name: "dangling restricted association",
+			graph: asstGraph{
+				insts:    []string{instKey("switch", 2)},
+				assts:    []metadata.InstAsst{testAsst(1, "rack_switch", "rack", 1, "switch", 2)},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{"rack_switch": metadata.Restrict},
+			},
+			deleting: []deleteTarget{testTarget("switch", 2)},
+			targets:  []string{instKey("switch", 2)},
+		},
+		{
+			name: "the source of the other group is deleted too",
+			graph: asstGraph{
+				insts:    []string{instKey("rack", 1), instKey("switch", 2)},
+				assts:    []metadata.InstAsst{testAsst(1, "rack_switch", "rack", 1, "switch", 2)},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{"rack_switch": metadata.NoAction},
+			},
+			deleting: []deleteTarget{testTarget("switch", 2), testTarget("rack", 1)},
+			targets:  []string{instKey("rack", 1), instKey("switch", 2)},
+		},
+		{
+			name: "the source of the other group is not deleted",
+			graph: asstGraph{
+				insts:    []string{instKey("rack", 1), instKey("switch", 2)},
+				assts:    []metadata.InstAsst{testAsst(1, "rack_switch", "rack", 1, "switch", 2)},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{"rack_switch": metadata.NoAction},
+			},
+			deleting:     []deleteTarget{testTarget("switch", 2)},
+			targets:      []string{instKey("switch", 2)},
+			restrictions: []int64{1},
+		},
+		{
+			name: "the inner object is not cascaded",
+			graph: asstGraph{
+				insts:    []string{instKey("rack", 1), instKey(common.BKInnerObjIDHost, 2)},
+				assts:    []metadata.InstAsst{testAsst(1, "rack_host", "rack", 1, common.BKInnerObjIDHost, 2)},
+				onDelete: map[string]metadata.AssociationOnDeleteAction{"rack_host": metadata.DeleteDestinatioin},
+			},
+			deleting:     []deleteTarget{testTarget("rack", 1)},
+			targets:      []string{instKey("rack", 1)},
+			restrictions: []int64{1},
+		},
+	}
+
+	for _, tt := range tests {
+		plan, err := planDelete(tt.deleting, tt.graph.lookup())
+		if nil != err {
+			t.Fatalf("%s: plan delete failed, err: %v", tt.name, err)
+		}
+
+		planned := make([]string, 0)
+		for _, target := range plan.targets {
+			planned = append(planned, instKey(target.ObjectID, target.InstID))
+		}
+		sort.Strings(planned)
+		if !reflect.DeepEqual(planned, tt.targets) {
+			t.Errorf("%s: planned targets %v, want %v", tt.name, planned, tt.targets)
+		}
+
+		restrictions := make([]int64, 0)
+		for _, asst := range plan.restrictions {
+			restrictions = append(restrictions, asst.ID)
+		}
+		if tt.restrictions == nil {
+			tt.restrictions = []int64{}
+		}
+		if !reflect.DeepEqual(restrictions, tt.restrictions) {
+			t.Errorf("%s: restrictions %v, want %v", tt.name, restrictions, tt.restrictions)
+		}
+	}
+}
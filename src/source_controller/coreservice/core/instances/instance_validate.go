@@ -13,6 +13,8 @@
 package instances
 
 import (
+	"strconv"
+
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
@@ -129,6 +131,14 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			err = valid.validBool(val, key)
 		case common.FieldTypeForeignKey:
 			err = valid.validForeignKey(val, key)
+		case common.FieldTypeComputed:
+			err = valid.validComputed(val, nil, key)
+		case common.FieldTypeList:
+			err = valid.validList(val, key)
+		case common.FieldTypeTable:
+			err = valid.validTable(val, key)
+		case common.FieldTypeIP:
+			err = valid.validIP(val, key)
 		default:
 			continue
 		}
@@ -136,7 +146,15 @@ func (m *instanceManager) validCreateInstanceData(ctx core.ContextParams, objID
 			return err
 		}
 	}
-	return valid.validCreateUnique(ctx, instanceData, instMedataData, m)
+	if err := valid.validRules(ctx, m, bizID, instanceData, nil, 0); nil != err {
+		return err
+	}
+	if err := valid.validCreateUnique(ctx, instanceData, instMedataData, m); nil != err {
+		return err
+	}
+	// the ranges of the ip attributes are stored along with them to be searched by the ip operators
+	valid.fillCreateIPRange(instanceData)
+	return nil
 }
 
 func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID string, instanceData mapstr.MapStr, instMetaData metadata.Metadata, instID uint64) error {
@@ -191,6 +209,14 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 			err = valid.validBool(val, key)
 		case common.FieldTypeForeignKey:
 			err = valid.validForeignKey(val, key)
+		case common.FieldTypeComputed:
+			err = valid.validComputed(val, originData[key], key)
+		case common.FieldTypeList:
+			err = valid.validList(val, key)
+		case common.FieldTypeTable:
+			err = valid.validTable(val, key)
+		case common.FieldTypeIP:
+			err = valid.validIP(val, key)
 		default:
 			continue
 		}
@@ -198,5 +224,48 @@ func (m *instanceManager) validUpdateInstanceData(ctx core.ContextParams, objID
 			return err
 		}
 	}
+
+	// the rules may depend on the attributes which are not updated
+	mergedData := mapstr.New()
+	mergedData.Merge(originData)
+	mergedData.Merge(instanceData)
+	if err := valid.validRules(ctx, m, bizID, mergedData, instanceData, instID); nil != err {
+		return err
+	}
 	return valid.validUpdateUnique(ctx, instanceData, instMetaData, instID, m)
 }
+
+// ValidModelInstanceUnique check the instance which is not saved, such as the one restored from the recycle bin,
+// against the unique constraints and the business unique rules of the model
+func (m *instanceManager) ValidModelInstanceUnique(ctx core.ContextParams, objID string, instanceData mapstr.MapStr) error {
+	bizID, err := FetchBizIDFromInstance(objID, instanceData)
+	if err != nil {
+		blog.Errorf("ValidModelInstanceUnique failed, FetchBizIDFromInstance failed, err: %+v, rid: %s", err, ctx.ReqID)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, "bk_biz_id")
+	}
+
+	valid, err := NewValidator(ctx, m.dependent, objID, bizID)
+	if nil != err {
+		blog.Errorf("init validator failed %s", err.Error())
+		return err
+	}
+
+	var instMedataData metadata.Metadata
+	instMedataData.Label = make(metadata.Label)
+	if bizID > 0 && !util.IsInnerObject(objID) {
+		instMedataData.Label.Set(metadata.LabelBusinessID, strconv.FormatInt(bizID, 10))
+	}
+	if err := valid.validCreateUnique(ctx, instanceData, instMedataData, m); nil != err {
+		return err
+	}
+
+	for _, item := range valid.rules {
+		if item.rule.Type != metadata.AttributeRuleBizUnique || !item.rule.Matched(instanceData) {
+			continue
+		}
+		if err := valid.validBizUniqueRule(ctx, m, item, instanceData[item.property.PropertyID], bizID, 0); nil != err {
+			return err
+		}
+	}
+	return nil
+}
@@ -22,7 +22,6 @@ import (
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/instances"
-	"configcenter/src/storage/dal/mongo"
 	"configcenter/src/storage/dal/mongo/local"
 
 	"github.com/stretchr/testify/require"
@@ -31,9 +30,19 @@ import (
 type mockDependences struct {
 }
 
-// IsInstanceExist used to check if the  instances  asst exist
-func (s *mockDependences) IsInstAsstExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error) {
-	return false, nil
+// SearchInstAsst used to search the inst asst which the instance is the source or destination of
+func (s *mockDependences) SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (assts []metadata.InstAsst, err error) {
+	return nil, nil
+}
+
+// SearchInstAssts used to search the inst assts which the instances are the source or destination of
+func (s *mockDependences) SearchInstAssts(ctx core.ContextParams, objID string, instIDs []int64) (assts []metadata.InstAsst, err error) {
+	return nil, nil
+}
+
+// SearchModelAsst used to search the model asst by the bk_obj_asst_id
+func (s *mockDependences) SearchModelAsst(ctx core.ContextParams, objAsstIDs []string) (assts []metadata.Association, err error) {
+	return nil, nil
 }
 
 // DeleteInstAsst used to delete inst asst
@@ -42,7 +51,7 @@ func (s *mockDependences) DeleteInstAsst(ctx core.ContextParams, objID string, i
 }
 
 // SelectObjectAttWithParams select object att with params
-func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string) (attribute []metadata.Attribute, err error) {
+func (s *mockDependences) SelectObjectAttWithParams(ctx core.ContextParams, objID string, bizID int64) (attribute []metadata.Attribute, err error) {
 	return nil, nil
 }
 
@@ -51,15 +60,35 @@ func (s *mockDependences) SearchUnique(ctx core.ContextParams, objID string) (un
 	return nil, nil
 }
 
+// SearchComputedAttributes search the computed attributes of all the models
+func (s *mockDependences) SearchComputedAttributes(ctx core.ContextParams) (attribute []metadata.Attribute, err error) {
+	return nil, nil
+}
+
+// SearchHostModuleRelation search the host module relations
+func (s *mockDependences) SearchHostModuleRelation(ctx core.ContextParams, input *metadata.HostModuleRelationRequest) (relations []metadata.ModuleHost, err error) {
+	return nil, nil
+}
+
+// SaveAuditLog used to save the audit logs of the changes made by core service itself
+func (s *mockDependences) SaveAuditLog(ctx core.ContextParams, logs ...metadata.SaveAuditLogParams) error {
+	return nil
+}
+
+// ArchiveDeleted move the deleted instances into the recycle bin
+func (s *mockDependences) ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error {
+	return nil
+}
+
 func newInstances(t *testing.T) core.InstanceOperation {
 
 	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
 	require.NoError(t, err)
-	return instances.New(db, &mockDependences{})
+	return instances.New(db, &mockDependences{}, nil)
 }
 
 var defaultCtx = func() core.ContextParams {
-	err, _ := errors.New("../../../../../resources/errors/")
+	err, _ := errors.NewFactory("../../../../../resources/errors/")
 	lan, _ := language.New("../../../../../resources/language/")
 	return core.ContextParams{
 		Context:         context.Background(),
@@ -14,6 +14,7 @@ package instances
 
 import (
 	"configcenter/src/common"
+	"configcenter/src/common/blog"
 	"configcenter/src/common/errors"
 	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
@@ -26,6 +27,7 @@ type validator struct {
 	propertyslice []metadata.Attribute
 	require       map[string]bool
 	requirefields []string
+	rules         []attributeRule
 	dependent     OperationDependences
 	objID         string
 }
@@ -38,6 +40,7 @@ func NewValidator(ctx core.ContextParams, dependent OperationDependences, objID
 	valid.propertyslice = make([]metadata.Attribute, 0)
 	valid.require = make(map[string]bool)
 	valid.requirefields = make([]string, 0)
+	valid.rules = make([]attributeRule, 0)
 	valid.errif = ctx.Error
 	result, err := dependent.SelectObjectAttWithParams(ctx, objID, bizID)
 	if nil != err {
@@ -54,6 +57,14 @@ func NewValidator(ctx core.ContextParams, dependent OperationDependences, objID
 			valid.require[attr.PropertyID] = true
 			valid.requirefields = append(valid.requirefields, attr.PropertyID)
 		}
+		rules, err := metadata.ParseAttributeRules(attr.Rules)
+		if nil != err {
+			blog.Errorf("the rules of the attribute %s.%s is invalid, skip them, err: %v", objID, attr.PropertyID, err)
+			continue
+		}
+		for _, rule := range rules {
+			valid.rules = append(valid.rules, attributeRule{property: attr, rule: rule})
+		}
 	}
 	valid.objID = objID
 	valid.dependent = dependent
@@ -13,11 +13,14 @@
 package instances
 
 import (
+	"net"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
 	"configcenter/src/common/util"
 )
 
@@ -355,3 +358,203 @@ func (valid *validator) validChar(val interface{}, key string) error {
 
 	return nil
 }
+
+// validComputed valid object attribute that is computed type, the value is evaluated by core service and can't be written,
+// but the value which is not changed is allowed, for the clients may submit the whole instance they read.
+func (valid *validator) validComputed(val interface{}, origin interface{}, key string) error {
+	if nil == val || computedEqual(origin, val) {
+		return nil
+	}
+
+	blog.Errorf("params %s is a computed attribute, it can not be written", key)
+	return valid.errif.Errorf(common.CCErrCoreServiceComputedAttributeReadOnly, key)
+}
+
+// validElement valid the item of the list or the cell of the table with the validator of its type,
+// the element is validated as the value of the attribute described by attr.
+func (valid *validator) validElement(attr metadata.Attribute, val interface{}) error {
+	elem := &validator{
+		errif:     valid.errif,
+		propertys: map[string]metadata.Attribute{attr.PropertyID: attr},
+		require:   map[string]bool{attr.PropertyID: attr.IsRequired},
+	}
+	key := attr.PropertyID
+	switch attr.PropertyType {
+	case common.FieldTypeSingleChar:
+		return elem.validChar(val, key)
+	case common.FieldTypeLongChar:
+		return elem.validLongChar(val, key)
+	case common.FieldTypeInt:
+		return elem.validInt(val, key)
+	case common.FieldTypeFloat:
+		return elem.validFloat(val, key)
+	case common.FieldTypeEnum:
+		return elem.validEnum(val, key)
+	case common.FieldTypeDate:
+		return elem.validDate(val, key)
+	case common.FieldTypeTime:
+		return elem.validTime(val, key)
+	case common.FieldTypeBool:
+		return elem.validBool(val, key)
+	default:
+		blog.Errorf("params %s is of the unsupported type %s", key, attr.PropertyType)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+}
+
+// validList valid object attribute that is list type
+func (valid *validator) validList(val interface{}, key string) error {
+	if nil == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	items, ok := val.([]interface{})
+	if !ok {
+		blog.Errorf("params %s:%#v should be array", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if 0 == len(items) && valid.require[key] {
+		blog.Error("params can not be empty")
+		return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+	}
+
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	option, err := metadata.ParseListOption(property.Option)
+	if nil != err {
+		blog.Warnf("ParseListOption failed: %v", err)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if option.MaxItems > 0 && len(items) > option.MaxItems {
+		blog.Errorf("params %s over the max items %d", key, option.MaxItems)
+		return valid.errif.Errorf(common.CCErrCommOverLimit, key)
+	}
+
+	item := metadata.Attribute{PropertyID: key, PropertyType: option.ItemType, Option: option.ItemOption, IsRequired: true}
+	for _, val := range items {
+		if err := valid.validElement(item, val); nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// validTable valid object attribute that is table type
+func (valid *validator) validTable(val interface{}, key string) error {
+	if nil == val {
+		if valid.require[key] {
+			blog.Error("params can not be null")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	rows, ok := val.([]interface{})
+	if !ok {
+		blog.Errorf("params %s:%#v should be array", key, val)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if 0 == len(rows) && valid.require[key] {
+		blog.Error("params can not be empty")
+		return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+	}
+
+	property, ok := valid.propertys[key]
+	if !ok {
+		return nil
+	}
+	option, err := metadata.ParseTableOption(property.Option)
+	if nil != err {
+		blog.Warnf("ParseTableOption failed: %v", err)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	if option.MaxRows > 0 && len(rows) > option.MaxRows {
+		blog.Errorf("params %s over the max rows %d", key, option.MaxRows)
+		return valid.errif.Errorf(common.CCErrCommOverLimit, key)
+	}
+
+	columns := make(map[string]metadata.Attribute)
+	for _, column := range option.Columns {
+		columns[column.PropertyID] = metadata.Attribute{
+			PropertyID:   key + "." + column.PropertyID,
+			PropertyType: column.PropertyType,
+			Option:       column.Option,
+			IsRequired:   column.IsRequired,
+		}
+	}
+	for _, row := range rows {
+		cells, ok := row.(map[string]interface{})
+		if !ok {
+			blog.Errorf("params %s:%#v should be array of object", key, val)
+			return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+		}
+		for id := range cells {
+			if _, ok := columns[id]; !ok {
+				blog.Errorf("params %s has no column %s", key, id)
+				return valid.errif.Errorf(common.CCErrCommParamsInvalid, key+"."+id)
+			}
+		}
+		for id, column := range columns {
+			if err := valid.validElement(column, cells[id]); nil != err {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validIP valid object attribute that is ip type
+func (valid *validator) validIP(val interface{}, key string) error {
+	if nil == val || "" == val {
+		if valid.require[key] {
+			blog.Error("params in need")
+			return valid.errif.Errorf(common.CCErrCommParamsNeedSet, key)
+		}
+		return nil
+	}
+
+	value, ok := val.(string)
+	if !ok {
+		blog.Error("params should be  string")
+		return valid.errif.Errorf(common.CCErrCommParamsNeedString, key)
+	}
+
+	option := new(metadata.IPOption)
+	if property, ok := valid.propertys[key]; ok {
+		var err error
+		if option, err = metadata.ParseIPOption(property.Option); nil != err {
+			blog.Warnf("ParseIPOption failed: %v", err)
+			return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+		}
+	}
+
+	var ip net.IP
+	if strings.Contains(value, "/") {
+		if !option.AllowCIDR {
+			blog.Errorf("params %s:%s should be ip address, cidr is not allowed", key, value)
+			return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+		}
+		addr, _, err := net.ParseCIDR(value)
+		if nil != err {
+			blog.Errorf("params %s:%s is not valid cidr, err: %v", key, value, err)
+			return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+		}
+		ip = addr
+	} else if ip = net.ParseIP(value); nil == ip {
+		blog.Errorf("params %s:%s is not valid ip address", key, value)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+
+	isIPv4 := nil != ip.To4()
+	if (4 == option.Version && !isIPv4) || (6 == option.Version && isIPv4) {
+		blog.Errorf("params %s:%s is not ipv%d", key, value, option.Version)
+		return valid.errif.Errorf(common.CCErrCommParamsInvalid, key)
+	}
+	return nil
+}
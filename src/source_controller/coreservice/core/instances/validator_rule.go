@@ -0,0 +1,237 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package instances
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+type attributeRule struct {
+	property metadata.Attribute
+	rule     metadata.AttributeRule
+}
+
+// validRules valid the rules of the attributes with the whole data of the instance. when the instance is updated,
+// the changed data is given, and only the rules of the changed attributes or depending on them are checked.
+func (valid *validator) validRules(ctx core.ContextParams, m *instanceManager, bizID int64, instanceData mapstr.MapStr, changed mapstr.MapStr, instID uint64) error {
+	for _, item := range valid.rules {
+		key := item.property.PropertyID
+		if nil != changed && !ruleChanged(key, item.rule, changed) {
+			continue
+		}
+		if !item.rule.Matched(instanceData) {
+			continue
+		}
+
+		val := instanceData[key]
+		var err error
+		switch item.rule.Type {
+		case metadata.AttributeRuleRequired:
+			if isEmpty(val) {
+				blog.Errorf("[validRules] field [%s] is required by the rule for model [%s], rid: %s", key, valid.objID, ctx.ReqID)
+				err = valid.ruleError(ctx, item, common.CCErrCommParamsNeedSet, key)
+			}
+		case metadata.AttributeRuleCompare:
+			err = valid.validCompareRule(ctx, item, instanceData)
+		case metadata.AttributeRuleBizUnique:
+			err = valid.validBizUniqueRule(ctx, m, item, val, bizID, instID)
+		case metadata.AttributeRuleReference:
+			err = valid.validReferenceRule(ctx, m, item, val)
+		}
+		if nil != err {
+			return err
+		}
+	}
+	return nil
+}
+
+// ruleChanged returns whether the attribute or the ones the rule depends on are changed
+func ruleChanged(key string, rule metadata.AttributeRule, changed mapstr.MapStr) bool {
+	if _, ok := changed[key]; ok {
+		return true
+	}
+	for _, field := range rule.Fields() {
+		if _, ok := changed[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (valid *validator) validCompareRule(ctx core.ContextParams, item attributeRule, instanceData mapstr.MapStr) error {
+	val := instanceData[item.property.PropertyID]
+	target := item.rule.Value
+	if len(item.rule.Field) != 0 {
+		target = instanceData[item.rule.Field]
+	}
+	// the empty values are checked by the required rules
+	if isEmpty(val) || isEmpty(target) {
+		return nil
+	}
+
+	if !metadata.MatchRuleOperator(val, item.rule.Operator, target) {
+		blog.Errorf("[validCompareRule] field [%s] value %v does not satisfy the rule %s of model [%s], target: %v, rid: %s",
+			item.property.PropertyID, val, item.rule.String(), valid.objID, target, ctx.ReqID)
+		return valid.ruleError(ctx, item, common.CCErrCoreServiceAttributeRuleFailed, valid.propertyName(ctx, item.property), item.rule.String())
+	}
+	return nil
+}
+
+// validBizUniqueRule check the value is unique among the instances of the same business,
+// the instances which belong to no business are checked among the ones of the whole model.
+// the business of the hosts is given by the relations of them and the modules.
+func (valid *validator) validBizUniqueRule(ctx core.ContextParams, m *instanceManager, item attributeRule, val interface{}, bizID int64, instID uint64) error {
+	if isEmpty(val) {
+		return nil
+	}
+
+	cond := mapstr.MapStr{
+		item.property.PropertyID: val,
+		common.BKDataStatusField: mapstr.MapStr{common.BKDBNE: common.DataStatusDisabled},
+	}
+	if 0 != instID {
+		cond.Set(common.GetInstIDField(valid.objID), mapstr.MapStr{common.BKDBNE: instID})
+	}
+	switch valid.objID {
+	case common.BKInnerObjIDSet, common.BKInnerObjIDModule, common.BKInnerObjIDProc:
+		if 0 != bizID {
+			cond.Set(common.BKAppIDField, bizID)
+		}
+	case common.BKInnerObjIDHost:
+		// the hosts don't carry the business, it's given by the relations between the hosts and the modules
+		hostBizID, err := valid.hostBizID(ctx, m, bizID, instID)
+		if nil != err {
+			return err
+		}
+		if 0 != hostBizID {
+			return valid.validBizUniqueHost(ctx, m, item, cond, hostBizID)
+		}
+	default:
+		if 0 != bizID {
+			cond.Set(metadata.BKMetadata+"."+metadata.BKLabel+"."+common.BKAppIDField, strconv.FormatInt(bizID, 10))
+		}
+	}
+
+	cnt, err := m.countInstance(ctx, valid.objID, cond)
+	if nil != err {
+		blog.Errorf("[validBizUniqueRule] count the instances of model [%s] failed, condition: %#v, err: %v, rid: %s", valid.objID, cond, err, ctx.ReqID)
+		return valid.errif.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 < cnt {
+		blog.Errorf("[validBizUniqueRule] duplicate value of field [%s] in business %d of model [%s], condition: %#v, rid: %s",
+			item.property.PropertyID, bizID, valid.objID, cond, ctx.ReqID)
+		return valid.ruleError(ctx, item, common.CCErrCommDuplicateItem, valid.propertyName(ctx, item.property))
+	}
+	return nil
+}
+
+// hostBizID returns the business of the host, the business of the existing host is searched by
+// the relations if it's not given. 0 is returned if the host belongs to no business.
+func (valid *validator) hostBizID(ctx core.ContextParams, m *instanceManager, bizID int64, instID uint64) (int64, error) {
+	if 0 != bizID || 0 == instID {
+		return bizID, nil
+	}
+	relations, err := m.dependent.SearchHostModuleRelation(ctx, &metadata.HostModuleRelationRequest{HostIDArr: []int64{int64(instID)}})
+	if nil != err {
+		blog.Errorf("[validBizUniqueRule] search the relations of the host %d failed, err: %v, rid: %s", instID, err, ctx.ReqID)
+		return 0, err
+	}
+	for _, relation := range relations {
+		bizID = relation.AppID
+	}
+	return bizID, nil
+}
+
+// validBizUniqueHost check none of the other hosts with the same value belongs to the business, the hosts with
+// the same value are searched first, so that only their relations are searched instead of the ones of the business.
+func (valid *validator) validBizUniqueHost(ctx core.ContextParams, m *instanceManager, item attributeRule, cond mapstr.MapStr, bizID int64) error {
+	hosts := make([]mapstr.MapStr, 0)
+	err := m.dbProxy.Table(common.BKTableNameBaseHost).Find(cond).Fields(common.BKHostIDField).All(ctx, &hosts)
+	if nil != err {
+		blog.Errorf("[validBizUniqueRule] search the hosts with the same value failed, condition: %#v, err: %v, rid: %s", cond, err, ctx.ReqID)
+		return valid.errif.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 == len(hosts) {
+		return nil
+	}
+
+	hostIDs := make([]int64, 0)
+	for _, host := range hosts {
+		hostID, err := util.GetInt64ByInterface(host[common.BKHostIDField])
+		if nil != err {
+			blog.Errorf("[validBizUniqueRule] invalid host id %v, err: %v, rid: %s", host[common.BKHostIDField], err, ctx.ReqID)
+			return valid.errif.Error(common.CCErrCommDBSelectFailed)
+		}
+		hostIDs = append(hostIDs, hostID)
+	}
+	relations, err := m.dependent.SearchHostModuleRelation(ctx, &metadata.HostModuleRelationRequest{ApplicationID: bizID, HostIDArr: hostIDs})
+	if nil != err {
+		blog.Errorf("[validBizUniqueRule] search the relations of the hosts %v in business %d failed, err: %v, rid: %s", hostIDs, bizID, err, ctx.ReqID)
+		return err
+	}
+	if 0 < len(relations) {
+		blog.Errorf("[validBizUniqueRule] duplicate value of field [%s] in business %d of model [%s], hosts: %v, rid: %s",
+			item.property.PropertyID, bizID, valid.objID, hostIDs, ctx.ReqID)
+		return valid.ruleError(ctx, item, common.CCErrCommDuplicateItem, valid.propertyName(ctx, item.property))
+	}
+	return nil
+}
+
+// validReferenceRule check the value, or each item of the list value, is one of the values of the attribute
+// of the referenced model
+func (valid *validator) validReferenceRule(ctx core.ContextParams, m *instanceManager, item attributeRule, val interface{}) error {
+	if isEmpty(val) {
+		return nil
+	}
+
+	values := []interface{}{val}
+	if list, ok := val.([]interface{}); ok {
+		values = list
+	}
+	for _, value := range values {
+		cond := mapstr.MapStr{item.rule.Field: value}
+		cnt, err := m.countInstance(ctx, item.rule.ObjectID, cond)
+		if nil != err {
+			blog.Errorf("[validReferenceRule] count the instances of model [%s] failed, condition: %#v, err: %v, rid: %s", item.rule.ObjectID, cond, err, ctx.ReqID)
+			return valid.errif.Error(common.CCErrCommDBSelectFailed)
+		}
+		if 0 == cnt {
+			blog.Errorf("[validReferenceRule] value %v of field [%s] is not found in [%s] of model [%s], rid: %s",
+				value, item.property.PropertyID, item.rule.Field, item.rule.ObjectID, ctx.ReqID)
+			return valid.ruleError(ctx, item, common.CCErrCoreServiceAttributeRuleReferenceNotFound,
+				value, valid.propertyName(ctx, item.property), item.rule.Field, item.rule.ObjectID)
+		}
+	}
+	return nil
+}
+
+// ruleError returns the error of the rule, the custom message of the rule is used if it's set
+func (valid *validator) ruleError(ctx core.ContextParams, item attributeRule, code int, args ...interface{}) error {
+	if len(item.rule.Message) == 0 {
+		return valid.errif.Errorf(code, args...)
+	}
+	if len(ctx.Lang.Language(item.rule.Message)) == 0 {
+		return valid.errif.New(code, item.rule.Message)
+	}
+	return valid.errif.New(code, ctx.Lang.Languagef(item.rule.Message, valid.propertyName(ctx, item.property)))
+}
+
+func (valid *validator) propertyName(ctx core.ContextParams, property metadata.Attribute) string {
+	return util.FirstNotEmptyString(ctx.Lang.Language(valid.objID+"_property_"+property.PropertyID), property.PropertyName, property.PropertyID)
+}
@@ -56,6 +56,10 @@ func (m *modelAttribute) save(ctx core.ContextParams, attribute metadata.Attribu
 	if err = m.checkAttributeValidity(ctx, attribute); err != nil {
 		return 0, err
 	}
+	// the value of the computed attribute is evaluated by core service, it can't be edited
+	if attribute.PropertyType == common.FieldTypeComputed {
+		attribute.IsEditable = false
+	}
 
 	err = m.dbProxy.Table(common.BKTableNameObjAttDes).Insert(ctx, attribute)
 	return id, err
@@ -106,6 +110,36 @@ func (m *modelAttribute) checkAttributeValidity(ctx core.ContextParams, attribut
 		}
 	}
 
+	if attribute.PropertyType == common.FieldTypeComputed {
+		// the value of the computed attribute is evaluated, it can't be required to input
+		if attribute.IsRequired {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldIsRequired)
+		}
+		if _, err := metadata.ParseComputedOption(attribute.Option); nil != err {
+			blog.Errorf("request(%s): the option of the computed attribute(%s) is invalid, error is %v", ctx.ReqID, attribute.PropertyID, err)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+		}
+	}
+
+	var err error
+	switch attribute.PropertyType {
+	case common.FieldTypeList:
+		_, err = metadata.ParseListOption(attribute.Option)
+	case common.FieldTypeTable:
+		_, err = metadata.ParseTableOption(attribute.Option)
+	case common.FieldTypeIP:
+		_, err = metadata.ParseIPOption(attribute.Option)
+	}
+	if nil != err {
+		blog.Errorf("request(%s): the option of the %s attribute(%s) is invalid, error is %v", ctx.ReqID, attribute.PropertyType, attribute.PropertyID, err)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldOption)
+	}
+
+	if _, err := metadata.ParseAttributeRules(attribute.Rules); nil != err {
+		blog.Errorf("request(%s): the rules of the attribute(%s) is invalid, error is %v", ctx.ReqID, attribute.PropertyID, err)
+		return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.AttributeFieldRules)
+	}
+
 	return nil
 }
 
@@ -127,16 +161,61 @@ func (m *modelAttribute) update(ctx core.ContextParams, data mapstr.MapStr, cond
 		return 0, err
 	}
 
+	_, optionUpdated := data[metadata.AttributeFieldOption]
+	toListOrIP := attribute.PropertyType == common.FieldTypeList || attribute.PropertyType == common.FieldTypeIP
+	origins := make([]metadata.Attribute, 0)
+	if (optionUpdated && len(attribute.PropertyType) == 0) || toListOrIP || attribute.IsEditable {
+		origins, err = m.search(ctx, cond)
+		if nil != err {
+			blog.Errorf("request(%s): database operation is failed, error info is %s", ctx.ReqID, err.Error())
+			return 0, err
+		}
+	}
+
+	// the option of the computed, list, table and ip attribute is checked even if the property type is not updated
+	if optionUpdated && len(attribute.PropertyType) == 0 {
+		for _, origin := range origins {
+			switch origin.PropertyType {
+			case common.FieldTypeComputed, common.FieldTypeList, common.FieldTypeTable, common.FieldTypeIP:
+				attribute.PropertyType = origin.PropertyType
+			}
+		}
+	}
+
 	if err = m.checkAttributeValidity(ctx, attribute); err != nil {
 		return 0, err
 	}
 
+	// the value of the computed attribute is evaluated by core service, it can't be edited
+	if attribute.PropertyType == common.FieldTypeComputed {
+		data.Set(metadata.AttributeFieldIsEditable, false)
+	} else if attribute.IsEditable && len(attribute.PropertyType) == 0 {
+		for _, origin := range origins {
+			if origin.PropertyType == common.FieldTypeComputed {
+				data.Set(metadata.AttributeFieldIsEditable, false)
+			}
+		}
+	}
+
 	err = m.dbProxy.Table(common.BKTableNameObjAttDes).Update(ctx, cond.ToMapStr(), data)
 	if nil != err {
 		blog.Errorf("request(%s): database operation is failed, error info is %s", ctx.ReqID, err.Error())
 		return 0, err
 	}
 
+	// the values of the char attribute are converted when it's changed to the list or ip attribute
+	if toListOrIP {
+		for _, origin := range origins {
+			if origin.PropertyType != common.FieldTypeSingleChar && origin.PropertyType != common.FieldTypeLongChar {
+				continue
+			}
+			if err := m.convertInstanceValue(ctx, origin, attribute); nil != err {
+				blog.Errorf("request(%s): convert the values of the attribute %s.%s failed, error info is %s", ctx.ReqID, origin.ObjectID, origin.PropertyID, err.Error())
+				return 0, err
+			}
+		}
+	}
+
 	return cnt, err
 }
 
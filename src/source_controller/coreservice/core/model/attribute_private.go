@@ -15,8 +15,10 @@ package model
 import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
 	"configcenter/src/source_controller/coreservice/core"
 )
 
@@ -43,3 +45,50 @@ func (m *modelAttribute) isExists(ctx core.ContextParams, propertyID string, met
 	}
 	return oneAttribute, !m.dbProxy.IsNotFoundError(err), nil
 }
+
+// convertInstanceValue convert the text values of the instances of the char attribute origin, which is changed to
+// the list or ip attribute, the text is split into the items of the list, and the ranges of the ip are stored.
+func (m *modelAttribute) convertInstanceValue(ctx core.ContextParams, origin metadata.Attribute, attribute metadata.Attribute) error {
+	var listOption *metadata.ListOption
+	if attribute.PropertyType == common.FieldTypeList {
+		option, err := metadata.ParseListOption(attribute.Option)
+		if nil != err {
+			return err
+		}
+		listOption = option
+	}
+
+	tableName := common.GetInstTableName(origin.ObjectID)
+	instIDField := common.GetInstIDField(origin.ObjectID)
+	cond := mapstr.MapStr{origin.PropertyID: mapstr.MapStr{"$type": "string"}}
+	if tableName == common.BKTableNameBaseInst {
+		cond.Set(common.BKObjIDField, origin.ObjectID)
+	}
+	insts := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(tableName).Find(cond).Fields(instIDField, origin.PropertyID).All(ctx, &insts); nil != err {
+		return err
+	}
+
+	for _, inst := range insts {
+		value, _ := inst[origin.PropertyID].(string)
+		data := mapstr.New()
+		if nil != listOption {
+			data.Set(origin.PropertyID, listOption.ParseText(value))
+		} else {
+			if 0 == len(value) {
+				continue
+			}
+			r, err := util.ParseIPRange(value)
+			if nil != err {
+				// the invalid value is kept, and it's fixed by the next update
+				blog.Warnf("request(%s): the value %s of the ip attribute %s.%s is invalid, err: %v", ctx.ReqID, value, origin.ObjectID, origin.PropertyID, err)
+				continue
+			}
+			data.Set(common.BKIPRangeField+"."+origin.PropertyID, r)
+		}
+		if err := m.dbProxy.Table(tableName).Update(ctx, mapstr.MapStr{instIDField: inst[instIDField]}, data); nil != err {
+			return err
+		}
+	}
+	return nil
+}
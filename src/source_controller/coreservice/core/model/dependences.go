@@ -13,6 +13,7 @@
 package model
 
 import (
+	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 )
 
@@ -32,4 +33,10 @@ type OperationDependences interface {
 
 	// CascadeDeleteInstances cascade delete all instances(included instances, instance association) associated with modelObjID
 	CascadeDeleteInstances(ctx core.ContextParams, objIDS []string) error
+
+	// ApplyBundleAssociation used to create, update or delete the association of the model bundle by the action
+	ApplyBundleAssociation(ctx core.ContextParams, action string, asst metadata.Association) error
+
+	// ArchiveDeleted move the deleted models into the recycle bin
+	ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error
 }
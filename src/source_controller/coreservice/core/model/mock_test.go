@@ -19,6 +19,7 @@ import (
 
 	"configcenter/src/common/errors"
 	"configcenter/src/common/language"
+	"configcenter/src/common/metadata"
 	"configcenter/src/source_controller/coreservice/core"
 	"configcenter/src/source_controller/coreservice/core/model"
 	"configcenter/src/storage/dal/mongo"
@@ -50,6 +51,16 @@ func (s *mockDependences) CascadeDeleteInstances(ctx core.ContextParams, objIDS
 	return nil
 }
 
+// ApplyBundleAssociation used to create, update or delete the association of the model bundle by the action
+func (s *mockDependences) ApplyBundleAssociation(ctx core.ContextParams, action string, asst metadata.Association) error {
+	return nil
+}
+
+// ArchiveDeleted move the deleted models into the recycle bin
+func (s *mockDependences) ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error {
+	return nil
+}
+
 func newModel(t *testing.T) core.ModelOperation {
 
 	db, err := local.NewMgo("mongodb://cc:cc@localhost:27010,localhost:27011,localhost:27012,localhost:27013/cmdb", time.Minute)
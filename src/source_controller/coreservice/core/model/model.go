@@ -106,6 +106,9 @@ func (m *modelManager) CreateModel(ctx core.ContextParams, inputParam metadata.C
 		return dataResult, err
 	}
 	dataResult.Created.ID = id
+	if err := m.record(ctx, metadata.ModelVersionActionModel, inputParam.Spec.ObjectID); nil != err {
+		return dataResult, err
+	}
 	return dataResult, nil
 }
 func (m *modelManager) SetModel(ctx core.ContextParams, inputParam metadata.SetModel) (*metadata.SetDataResult, error) {
@@ -176,6 +179,9 @@ func (m *modelManager) SetModel(ctx core.ContextParams, inputParam metadata.SetM
 		return dataResult, err
 	}
 	_ = setAttrResult // TODO: how to return this result ? let me think about it;
+	if err := m.record(ctx, metadata.ModelVersionActionModel, inputParam.Spec.ObjectID); nil != err {
+		return dataResult, err
+	}
 	/*
 		// set attribute result, ignore model operation result
 		dataResult.CreatedCount = setAttrResult.CreatedCount
@@ -198,6 +204,9 @@ func (m *modelManager) UpdateModel(ctx core.ContextParams, inputParam metadata.U
 	updateCond.Element(&mongo.Eq{Key: metadata.ModelFieldOwnerID, Val: ctx.SupplierAccount})
 
 	cnt, err := m.update(ctx, inputParam.Data, updateCond)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionModel, m.searchObjIDs(ctx, common.BKTableNameObjDes, updateCond.ToMapStr())...)
+	}
 	return &metadata.UpdatedCount{Count: cnt}, err
 }
 
@@ -245,6 +254,11 @@ func (m *modelManager) DeleteModel(ctx core.ContextParams, inputParam metadata.D
 		return &metadata.DeletedCount{}, ctx.Error.Error(common.CCErrTopoForbiddenToDeleteModelFailed)
 	}
 
+	if err := m.archive(ctx, targetObjIDS); nil != err {
+		blog.Errorf("request(%s): it is failed to archive the models (%#v), error info is %s", ctx.ReqID, targetObjIDS, err.Error())
+		return &metadata.DeletedCount{}, err
+	}
+
 	// delete model self
 	cnt, err := m.deleteModelAndAttributes(ctx, targetObjIDS)
 	if nil != err {
@@ -252,6 +266,9 @@ func (m *modelManager) DeleteModel(ctx core.ContextParams, inputParam metadata.D
 		return &metadata.DeletedCount{}, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
 	}
 
+	if err := m.record(ctx, metadata.ModelVersionActionModel, targetObjIDS...); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
 	return &metadata.DeletedCount{Count: cnt}, nil
 }
 
@@ -264,12 +281,16 @@ func (m *modelManager) CascadeDeleteModel(ctx core.ContextParams, inputParam met
 		return &metadata.DeletedCount{}, ctx.Error.New(common.CCErrCommParamsInvalid, err.Error())
 	}
 
+	targetObjIDS := m.searchObjIDs(ctx, common.BKTableNameObjDes, deleteCond.ToMapStr())
 	cnt, err := m.cascadeDelete(ctx, deleteCond)
 	if nil != err {
 		blog.Errorf("request(%s): it is failed to cascade delete some models by the condition (%#v), error info is %s", ctx.ReqID, deleteCond.ToMapStr(), err.Error())
 		return &metadata.DeletedCount{}, err
 	}
-	return &metadata.DeletedCount{Count: cnt}, err
+	if err := m.record(ctx, metadata.ModelVersionActionModel, targetObjIDS...); nil != err {
+		return &metadata.DeletedCount{}, err
+	}
+	return &metadata.DeletedCount{Count: cnt}, nil
 }
 
 func (m *modelManager) SearchModel(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryModelDataResult, error) {
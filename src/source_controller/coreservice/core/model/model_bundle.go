@@ -0,0 +1,101 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package model
+
+import (
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (m *modelManager) ExportModelBundle(ctx core.ContextParams, inputParam metadata.ExportModelBundleOption) (*metadata.ModelBundle, error) {
+
+	current, err := m.currentBundle(ctx, inputParam.ObjectIDs)
+	if nil != err {
+		return nil, err
+	}
+
+	// only the classifications of the exported models are exported
+	classifications := make(map[string]bool)
+	for _, model := range current.Models {
+		classifications[model.Object.ObjCls] = true
+	}
+	bundle := current.Portable()
+	exported := bundle.Classifications[:0]
+	for _, cls := range bundle.Classifications {
+		if classifications[cls.ClassificationID] {
+			exported = append(exported, cls)
+		}
+	}
+	bundle.Classifications = exported
+
+	return &bundle, nil
+}
+
+func (m *modelManager) PlanModelBundle(ctx core.ContextParams, inputParam metadata.ModelBundleOption) (*metadata.ModelBundlePlan, error) {
+
+	if err := m.validBundleKeys(ctx, &inputParam.Bundle); nil != err {
+		return nil, err
+	}
+
+	objIDs := make([]string, 0)
+	for _, model := range inputParam.Bundle.Models {
+		objIDs = append(objIDs, model.Object.ObjectID)
+	}
+	if 0 == len(objIDs) && 0 == len(inputParam.Bundle.Classifications) {
+		return &metadata.ModelBundlePlan{Changes: make([]metadata.BundleChange, 0)}, nil
+	}
+	if inputParam.Prune {
+		objIDs = append(objIDs, m.prunableObjIDs(ctx, &inputParam.Bundle)...)
+	}
+
+	current, err := m.currentBundle(ctx, objIDs)
+	if nil != err {
+		return nil, err
+	}
+
+	// the whole bundle is checked before any change is applied
+	if err := m.validBundle(ctx, current, &inputParam.Bundle, inputParam.Prune); nil != err {
+		return nil, err
+	}
+
+	return metadata.PlanModelBundle(current, &inputParam.Bundle, inputParam.Prune), nil
+}
+
+func (m *modelManager) ApplyModelBundle(ctx core.ContextParams, inputParam metadata.ModelBundleOption) (*metadata.ModelBundleApplyResult, error) {
+
+	plan, err := m.PlanModelBundle(ctx, inputParam)
+	if nil != err {
+		return nil, err
+	}
+
+	// the changes are applied in order and it stops at the first failed one, the caller rolls back the applied ones
+	// by the transaction of the request
+	result := &metadata.ModelBundleApplyResult{Changes: plan.Changes}
+	for idx := range result.Changes {
+		change := &result.Changes[idx]
+		if metadata.BundleActionConflict == change.Action {
+			result.Skipped++
+			continue
+		}
+
+		if err := m.applyBundleChange(ctx, &inputParam.Bundle, change); nil != err {
+			blog.Errorf("request(%s): it is failed to %s the %s (%s) of the bundle, error info is %s", ctx.ReqID, change.Action, change.Kind, change.Key, err.Error())
+			change.Error = err.Error()
+			result.Failed++
+			break
+		}
+		result.Applied++
+	}
+
+	return result, nil
+}
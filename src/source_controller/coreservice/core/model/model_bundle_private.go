@@ -0,0 +1,403 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// currentBundle returns the bundle of the models in the cmdb, all the models are returned if objIDs is empty
+func (m *modelManager) currentBundle(ctx core.ContextParams, objIDs []string) (*metadata.ModelBundle, error) {
+	bundle := &metadata.ModelBundle{
+		Classifications: make([]metadata.Classification, 0),
+		Models:          make([]metadata.BundleModel, 0),
+		Associations:    make([]metadata.Association, 0),
+	}
+
+	cond := mapstr.MapStr{common.BKOwnerIDField: ctx.SupplierAccount}
+	if err := m.dbProxy.Table(common.BKTableNameObjClassifiction).Find(cond).All(ctx, &bundle.Classifications); nil != err {
+		blog.Errorf("request(%s): it is failed to search the classifications, error info is %s", ctx.ReqID, err.Error())
+		return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	if 0 == len(objIDs) {
+		objIDs = m.searchObjIDs(ctx, common.BKTableNameObjDes, mapstr.New())
+	}
+
+	assts := make(map[string]bool)
+	for _, objID := range objIDs {
+		schema, err := m.snapshot(ctx, objID, nil, "")
+		if nil != err {
+			return nil, err
+		}
+		if nil == schema.Object {
+			continue
+		}
+
+		bundle.Models = append(bundle.Models, metadata.BundleModel{
+			Object:     *schema.Object,
+			Attributes: schema.Attributes,
+			Groups:     schema.Groups,
+			Uniques:    schema.Uniques,
+		})
+		for _, asst := range schema.Associations {
+			if !assts[asst.AssociationName] {
+				assts[asst.AssociationName] = true
+				bundle.Associations = append(bundle.Associations, asst)
+			}
+		}
+	}
+
+	// the associations to the models out of the bundle and the mainline ones can't be applied by the bundle
+	models := make(map[string]bool)
+	for _, model := range bundle.Models {
+		models[model.Object.ObjectID] = true
+	}
+	associations := bundle.Associations[:0]
+	for _, asst := range bundle.Associations {
+		if models[asst.ObjectID] && models[asst.AsstObjID] && common.AssociationKindMainline != asst.AsstKindID {
+			associations = append(associations, asst)
+		}
+	}
+	bundle.Associations = associations
+
+	return bundle, nil
+}
+
+// validBundleKeys check the keys of the items of the bundle
+func (m *modelManager) validBundleKeys(ctx core.ContextParams, bundle *metadata.ModelBundle) error {
+	for _, cls := range bundle.Classifications {
+		if 0 == len(cls.ClassificationID) {
+			return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.ClassFieldClassificationID)
+		}
+	}
+
+	objIDs := make(map[string]bool)
+	for _, model := range bundle.Models {
+		if 0 == len(model.Object.ObjectID) {
+			return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.ModelFieldObjectID)
+		}
+		if objIDs[model.Object.ObjectID] {
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, model.Object.ObjectID)
+		}
+		objIDs[model.Object.ObjectID] = true
+
+		for _, attr := range model.Attributes {
+			if 0 == len(attr.PropertyID) {
+				return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AttributeFieldPropertyID)
+			}
+		}
+		for _, group := range model.Groups {
+			if 0 == len(group.GroupID) {
+				return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.GroupFieldGroupID)
+			}
+		}
+	}
+
+	for _, asst := range bundle.Associations {
+		if 0 == len(asst.AssociationName) {
+			return ctx.Error.Errorf(common.CCErrCommParamsNeedSet, metadata.AssociationFieldAsstID)
+		}
+	}
+	return nil
+}
+
+// validBundle check the references of the items of the bundle, the classifications of the models, the groups of the
+// attributes and the keys of the uniques should be defined by the bundle or exist, the ends of the associations should
+// be the models of the bundle.
+func (m *modelManager) validBundle(ctx core.ContextParams, current, bundle *metadata.ModelBundle, prune bool) error {
+	classifications := make(map[string]bool)
+	for _, cls := range current.Classifications {
+		classifications[cls.ClassificationID] = true
+	}
+	for _, cls := range bundle.Classifications {
+		classifications[cls.ClassificationID] = true
+	}
+	currentModels := make(map[string]metadata.BundleModel)
+	for _, model := range current.Models {
+		currentModels[model.Object.ObjectID] = model
+	}
+
+	models := make(map[string]bool)
+	for _, model := range bundle.Models {
+		objID := model.Object.ObjectID
+		models[objID] = true
+		if !classifications[model.Object.ObjCls] {
+			blog.Errorf("request(%s): the classification (%s) of the model (%s) of the bundle is not found", ctx.ReqID, model.Object.ObjCls, objID)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, metadata.ModelFieldObjCls)
+		}
+
+		// the current groups and attributes are kept unless the bundle is pruned
+		origin := currentModels[objID]
+		groups := make(map[string]bool)
+		for _, group := range origin.Groups {
+			groups[group.GroupID] = !prune || group.IsPre || group.IsDefault
+		}
+		for _, group := range model.Groups {
+			groups[group.GroupID] = true
+		}
+		attrs := make(map[string]bool)
+		for _, attr := range origin.Attributes {
+			attrs[attr.PropertyID] = !prune || attr.IsPre
+		}
+		for _, attr := range model.Attributes {
+			attrs[attr.PropertyID] = true
+			if 0 != len(attr.PropertyGroup) && !groups[attr.PropertyGroup] {
+				blog.Errorf("request(%s): the group (%s) of the attribute (%s.%s) of the bundle is not found", ctx.ReqID, attr.PropertyGroup, objID, attr.PropertyID)
+				return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, objID+"."+attr.PropertyID)
+			}
+		}
+		for _, unique := range model.Uniques {
+			for _, propertyID := range unique.Keys {
+				if !attrs[propertyID] {
+					blog.Errorf("request(%s): the key (%s) of the unique (%s.%s) of the bundle is not found", ctx.ReqID, propertyID, objID, unique.Key())
+					return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, objID+"."+unique.Key())
+				}
+			}
+		}
+	}
+
+	for _, asst := range bundle.Associations {
+		if common.AssociationKindMainline == asst.AsstKindID || !models[asst.ObjectID] || !models[asst.AsstObjID] {
+			blog.Errorf("request(%s): the association (%s) of the bundle is not between the models of the bundle", ctx.ReqID, asst.AssociationName)
+			return ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, asst.AssociationName)
+		}
+	}
+	return nil
+}
+
+// prunableObjIDs returns the models in the classifications of the bundle which are not defined by the bundle, the
+// mainline models are excluded, for they're deleted along with the topology of the businesses.
+func (m *modelManager) prunableObjIDs(ctx core.ContextParams, bundle *metadata.ModelBundle) []string {
+	clsIDs := make([]string, 0)
+	models := make(map[string]bool)
+	for _, cls := range bundle.Classifications {
+		clsIDs = append(clsIDs, cls.ClassificationID)
+	}
+	for _, model := range bundle.Models {
+		clsIDs = append(clsIDs, model.Object.ObjCls)
+		models[model.Object.ObjectID] = true
+	}
+	if 0 == len(clsIDs) {
+		return []string{}
+	}
+
+	for _, objID := range m.searchObjIDs(ctx, common.BKTableNameObjAsst, mapstr.MapStr{common.AssociationKindIDField: common.AssociationKindMainline}) {
+		models[objID] = true
+	}
+	objIDs := make([]string, 0)
+	cond := mapstr.MapStr{metadata.ModelFieldObjCls: mapstr.MapStr{common.BKDBIN: util.StrArrayUnique(clsIDs)}}
+	for _, objID := range m.searchObjIDs(ctx, common.BKTableNameObjDes, cond) {
+		if !models[objID] {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	return objIDs
+}
+
+// bundleChangeData returns the data to update the item by the changed fields
+func bundleChangeData(fields []metadata.FieldDiff) mapstr.MapStr {
+	data := mapstr.New()
+	for _, field := range fields {
+		data.Set(field.Field, field.To)
+	}
+	return data
+}
+
+func (m *modelManager) applyBundleChange(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	switch change.Kind {
+	case metadata.BundleKindClassification:
+		return m.applyBundleClassification(ctx, bundle, change)
+	case metadata.BundleKindModel:
+		return m.applyBundleModel(ctx, bundle, change)
+	case metadata.BundleKindGroup:
+		return m.applyBundleGroup(ctx, bundle, change)
+	case metadata.BundleKindAttribute:
+		return m.applyBundleAttribute(ctx, bundle, change)
+	case metadata.BundleKindUnique:
+		return m.applyBundleUnique(ctx, bundle, change)
+	case metadata.BundleKindAssociation:
+		asst := metadata.Association{AssociationName: change.Key}
+		for _, item := range bundle.Associations {
+			if item.AssociationName == change.Key {
+				asst = item
+			}
+		}
+		asst.ID, asst.OwnerID = 0, ctx.SupplierAccount
+		return m.dependent.ApplyBundleAssociation(ctx, change.Action, asst)
+	}
+	return nil
+}
+
+func (m *modelManager) applyBundleClassification(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	if metadata.BundleActionUpdate == change.Action {
+		cond := mapstr.MapStr{metadata.ClassFieldClassificationID: change.Key}
+		_, err := m.modelClassification.UpdateModelClassification(ctx, metadata.UpdateOption{Condition: cond, Data: bundleChangeData(change.Fields)})
+		return err
+	}
+
+	for _, cls := range bundle.Classifications {
+		if cls.ClassificationID == change.Key {
+			cls.ID = 0
+			_, err := m.modelClassification.CreateOneModelClassification(ctx, metadata.CreateOneModelClassification{Data: cls})
+			return err
+		}
+	}
+	return nil
+}
+
+func findBundleModel(bundle *metadata.ModelBundle, objID string) metadata.BundleModel {
+	for _, model := range bundle.Models {
+		if model.Object.ObjectID == objID {
+			return model
+		}
+	}
+	return metadata.BundleModel{}
+}
+
+func (m *modelManager) applyBundleModel(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	cond := mapstr.MapStr{metadata.ModelFieldObjectID: change.ObjectID}
+	switch change.Action {
+	case metadata.BundleActionUpdate:
+		_, err := m.UpdateModel(ctx, metadata.UpdateOption{Condition: cond, Data: bundleChangeData(change.Fields)})
+		return err
+	case metadata.BundleActionDelete:
+		// the models with instances or associations out of the bundle are refused to delete
+		_, err := m.DeleteModel(ctx, metadata.DeleteOption{Condition: cond})
+		return err
+	}
+
+	// the attributes are created after the groups which they are in
+	object := findBundleModel(bundle, change.ObjectID).Object
+	object.ID = 0
+	_, err := m.CreateModel(ctx, metadata.CreateModel{Spec: object})
+	return err
+}
+
+func (m *modelManager) applyBundleGroup(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	cond := mapstr.MapStr{metadata.GroupFieldObjectID: change.ObjectID, metadata.GroupFieldGroupID: change.Key}
+	switch change.Action {
+	case metadata.BundleActionUpdate:
+		_, err := m.UpdateModelAttributeGroup(ctx, change.ObjectID, metadata.UpdateOption{Condition: cond, Data: bundleChangeData(change.Fields)})
+		return err
+	case metadata.BundleActionDelete:
+		_, err := m.DeleteModelAttributeGroup(ctx, change.ObjectID, metadata.DeleteOption{Condition: cond})
+		return err
+	}
+
+	for _, group := range findBundleModel(bundle, change.ObjectID).Groups {
+		if group.GroupID == change.Key {
+			group.ID, group.ObjectID = 0, change.ObjectID
+			_, err := m.CreateModelAttributeGroup(ctx, change.ObjectID, metadata.CreateModelAttributeGroup{Data: group})
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *modelManager) applyBundleAttribute(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	cond := mapstr.MapStr{metadata.AttributeFieldObjectID: change.ObjectID, metadata.AttributeFieldPropertyID: change.Key}
+	switch change.Action {
+	case metadata.BundleActionUpdate:
+		_, err := m.UpdateModelAttributes(ctx, change.ObjectID, metadata.UpdateOption{Condition: cond, Data: bundleChangeData(change.Fields)})
+		return err
+	case metadata.BundleActionDelete:
+		_, err := m.DeleteModelAttributes(ctx, change.ObjectID, metadata.DeleteOption{Condition: cond})
+		return err
+	}
+
+	for _, attr := range findBundleModel(bundle, change.ObjectID).Attributes {
+		if attr.PropertyID != change.Key {
+			continue
+		}
+		attr.ID, attr.ObjectID = 0, change.ObjectID
+		result, err := m.CreateModelAttributes(ctx, change.ObjectID, metadata.CreateModelAttributes{Attributes: []metadata.Attribute{attr}})
+		if nil != err {
+			return err
+		}
+		for _, exception := range result.Exceptions {
+			return ctx.Error.New(int(exception.Code), exception.Message)
+		}
+	}
+	return nil
+}
+
+func (m *modelManager) applyBundleUnique(ctx core.ContextParams, bundle *metadata.ModelBundle, change *metadata.BundleChange) error {
+	cond := mapstr.MapStr{common.BKObjIDField: change.ObjectID, common.BKOwnerIDField: ctx.SupplierAccount}
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); nil != err {
+		blog.Errorf("request(%s): it is failed to search the attributes of the model (%s), error info is %s", ctx.ReqID, change.ObjectID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	if metadata.BundleActionCreate == change.Action {
+		for _, unique := range findBundleModel(bundle, change.ObjectID).Uniques {
+			if unique.Key() != change.Key {
+				continue
+			}
+			keys, err := bundleUniqueKeys(ctx, unique, attrs)
+			if nil != err {
+				return err
+			}
+			data := metadata.ObjectUnique{ObjID: change.ObjectID, MustCheck: unique.MustCheck, Keys: keys}
+			_, err = m.CreateModelAttrUnique(ctx, change.ObjectID, metadata.CreateModelAttrUnique{Data: data})
+			return err
+		}
+		return nil
+	}
+
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques); nil != err {
+		blog.Errorf("request(%s): it is failed to search the uniques of the model (%s), error info is %s", ctx.ReqID, change.ObjectID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	for _, unique := range uniques {
+		if metadata.NewSchemaUnique(unique, attrs).Key() != change.Key {
+			continue
+		}
+		if metadata.BundleActionDelete == change.Action {
+			_, err := m.DeleteModelAttrUnique(ctx, change.ObjectID, unique.ID, metadata.DeleteModelAttrUnique{Metadata: unique.Metadata})
+			return err
+		}
+
+		data := metadata.UpdateUniqueRequest{MustCheck: unique.MustCheck, Keys: unique.Keys, Metadata: unique.Metadata}
+		if mustCheck, ok := bundleChangeData(change.Fields)["must_check"].(bool); ok {
+			data.MustCheck = mustCheck
+		}
+		_, err := m.UpdateModelAttrUnique(ctx, change.ObjectID, unique.ID, metadata.UpdateModelAttrUnique{Data: data})
+		return err
+	}
+	return nil
+}
+
+// bundleUniqueKeys convert the property ids of the unique to the keys made of the ids of the attributes
+func bundleUniqueKeys(ctx core.ContextParams, unique metadata.SchemaUnique, attrs []metadata.Attribute) ([]metadata.UniqueKey, error) {
+	ids := make(map[string]int64)
+	for _, attr := range attrs {
+		ids[attr.PropertyID] = attr.ID
+	}
+
+	keys := make([]metadata.UniqueKey, 0)
+	for _, propertyID := range unique.Keys {
+		id, exists := ids[propertyID]
+		if !exists {
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, propertyID)
+		}
+		keys = append(keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty, ID: uint64(id)})
+	}
+	return keys, nil
+}
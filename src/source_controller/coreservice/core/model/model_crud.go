@@ -133,6 +133,11 @@ func (m *modelManager) cascadeDelete(ctx core.ContextParams, cond universalsql.C
 		targetObjIDS = append(targetObjIDS, modelItem.ObjectID)
 	}
 
+	if err := m.archive(ctx, targetObjIDS); nil != err {
+		blog.Errorf("request(%s): it is failed to archive the models (%#v), error info is %s", ctx.ReqID, targetObjIDS, err.Error())
+		return 0, err
+	}
+
 	// cascade delete the other resource
 	if err := m.dependent.CascadeDeleteAssociation(ctx, targetObjIDS); nil != err {
 		blog.Errorf("request(%s): it is failed to execute a cascade model association deletion operation by the modelIDS(%#v), error info is %s", ctx.ReqID, targetObjIDS, err.Error())
@@ -15,6 +15,7 @@ package model
 import (
 	"configcenter/src/common"
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
@@ -77,3 +78,53 @@ func (m *modelManager) deleteModelAndAttributes(ctx core.ContextParams, targetOb
 
 	return cnt, nil
 }
+
+// archive move the models into the recycle bin with their attributes, groups, uniques and associations
+func (m *modelManager) archive(ctx core.ContextParams, targetObjIDS []string) error {
+	if 0 == len(targetObjIDS) {
+		return nil
+	}
+
+	models := make([]mapstr.MapStr, 0)
+	cond := mapstr.MapStr{
+		metadata.ModelFieldObjectID: mapstr.MapStr{common.BKDBIN: targetObjIDS},
+		metadata.ModelFieldOwnerID:  ctx.SupplierAccount,
+	}
+	if err := m.dbProxy.Table(common.BKTableNameObjDes).Find(cond).All(ctx, &models); nil != err {
+		blog.Errorf("request(%s): it is failed to find the models by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	items := make([]metadata.RecycleItem, 0)
+	for _, model := range models {
+		objID, _ := model.String(metadata.ModelFieldObjectID)
+		objName, _ := model.String(metadata.ModelFieldObjectName)
+		id, _ := model.Int64(metadata.ModelFieldID)
+		item := metadata.NewRecycleItem(metadata.RecycleKindModel, objID, id, objName, common.BKTableNameObjDes, model)
+
+		archives := []struct {
+			table string
+			cond  mapstr.MapStr
+		}{
+			{table: common.BKTableNameObjAttDes, cond: mapstr.MapStr{common.BKObjIDField: objID}},
+			{table: common.BKTableNamePropertyGroup, cond: mapstr.MapStr{common.BKObjIDField: objID}},
+			{table: common.BKTableNameObjUnique, cond: mapstr.MapStr{common.BKObjIDField: objID}},
+			{table: common.BKTableNameObjAsst, cond: mapstr.MapStr{common.BKDBOR: []mapstr.MapStr{
+				{common.BKObjIDField: objID},
+				{common.BKAsstObjIDField: objID},
+			}}},
+		}
+		for _, archive := range archives {
+			docs := make([]mapstr.MapStr, 0)
+			archive.cond.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+			if err := m.dbProxy.Table(archive.table).Find(archive.cond).All(ctx, &docs); nil != err {
+				blog.Errorf("request(%s): it is failed to find the documents of the model (%s) on the table (%s), error info is %s", ctx.ReqID, objID, archive.table, err.Error())
+				return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+			}
+			item.Archive(archive.table, docs...)
+		}
+		items = append(items, item)
+	}
+
+	return m.dependent.ArchiveDeleted(ctx, items...)
+}
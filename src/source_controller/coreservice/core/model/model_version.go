@@ -0,0 +1,265 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package model
+
+import (
+	"strconv"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (m *modelManager) SearchModelVersion(ctx core.ContextParams, objID string, inputParam metadata.QueryCondition) (*metadata.QueryModelVersionResult, error) {
+
+	cond := util.SetQueryOwner(inputParam.Condition.ToMapInterface(), ctx.SupplierAccount)
+	cond[metadata.ModelVersionFieldObjectID] = objID
+
+	cnt, err := m.countVersions(ctx, cond)
+	if nil != err {
+		return &metadata.QueryModelVersionResult{}, err
+	}
+
+	versions, err := m.searchVersions(ctx, cond, inputParam)
+	if nil != err {
+		return &metadata.QueryModelVersionResult{}, err
+	}
+
+	return &metadata.QueryModelVersionResult{Count: int64(cnt), Info: versions}, nil
+}
+
+func (m *modelManager) DiffModelVersion(ctx core.ContextParams, objID string, inputParam metadata.DiffModelVersionOption) (*metadata.ModelSchemaDiff, error) {
+
+	from, err := m.mustGetVersion(ctx, objID, inputParam.From)
+	if nil != err {
+		return nil, err
+	}
+
+	to, err := m.mustGetVersion(ctx, objID, inputParam.To)
+	if nil != err {
+		return nil, err
+	}
+
+	diff := metadata.DiffModelSchema(from.Schema, to.Schema)
+	return &diff, nil
+}
+
+func (m *modelManager) ExportModelVersion(ctx core.ContextParams, objID string, version int64) (*metadata.ModelVersionDocument, error) {
+
+	modelVersion, err := m.mustGetVersion(ctx, objID, version)
+	if nil != err {
+		return nil, err
+	}
+
+	document := modelVersion.Document()
+	return &document, nil
+}
+
+func (m *modelManager) RollbackModelVersion(ctx core.ContextParams, objID string, inputParam metadata.RollbackModelVersionOption) (*metadata.ModelVersionRollbackResult, error) {
+
+	if err := m.isValid(ctx, objID); nil != err {
+		blog.Errorf("request(%s): it is failed to check if the model(%s) is valid, error info is %s", ctx.ReqID, objID, err.Error())
+		return nil, err
+	}
+
+	target, err := m.mustGetVersion(ctx, objID, inputParam.Version)
+	if nil != err {
+		return nil, err
+	}
+
+	current, err := m.snapshot(ctx, objID, nil, "")
+	if nil != err {
+		return nil, err
+	}
+
+	// the object, attributes, groups and uniques are restored, the changes from the current schema to the version
+	// are applied, the associations are shared with the other models, and they're not restored.
+	diff := metadata.DiffModelSchema(*current, target.Schema)
+	diff.Object = rollbackObjectFields(diff.Object)
+	affected, err := m.rollbackAffected(ctx, objID, current, &target.Schema, diff.Attributes)
+	if nil != err {
+		return nil, err
+	}
+
+	result := &metadata.ModelVersionRollbackResult{
+		Object:     diff.Object,
+		Attributes: diff.Attributes,
+		Groups:     diff.Groups,
+		Uniques:    diff.Uniques,
+		Affected:   affected,
+		Safe:       true,
+	}
+	for _, item := range affected {
+		if item.Unsafe {
+			result.Safe = false
+		}
+	}
+
+	diff.Associations = metadata.SchemaItemsDiff{}
+	if inputParam.DryRun || diff.IsEmpty() || (!result.Safe && !inputParam.Force) {
+		return result, nil
+	}
+
+	// the changes are applied in the transaction of the request, so all of them are rolled back if one is failed
+	if err := m.rollbackSchema(ctx, objID, &target.Schema, diff); nil != err {
+		return nil, err
+	}
+	result.Applied = true
+
+	result.Version, err = m.saveVersion(ctx, objID, metadata.ModelVersionActionRollback, false)
+	if nil != err {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (m *modelManager) RecordModelVersion(ctx core.ContextParams, action string, objIDs []string) error {
+
+	for _, objID := range util.StrArrayUnique(objIDs) {
+		// only the parts of the schema changed by the action are searched, the others are taken from the latest version
+		if _, err := m.saveVersion(ctx, objID, action, true); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *modelManager) mustGetVersion(ctx core.ContextParams, objID string, version int64) (*metadata.ModelVersion, error) {
+	modelVersion, exists, err := m.getVersion(ctx, objID, version)
+	if nil != err {
+		return nil, err
+	}
+	if !exists || 0 == version {
+		blog.Warnf("request(%s): the version (%d) of the model (%s) is not found", ctx.ReqID, version, objID)
+		return nil, ctx.Error.Errorf(common.CCErrCoreServiceModelVersionNotFound, objID, strconv.FormatInt(version, 10))
+	}
+	return modelVersion, nil
+}
+
+// ATTENTION: the methods below record the versions of the models after the schemas are changed
+
+func (m *modelManager) CreateModelAttributeGroup(ctx core.ContextParams, objID string, inputParam metadata.CreateModelAttributeGroup) (*metadata.CreateOneDataResult, error) {
+	result, err := m.modelAttributeGroup.CreateModelAttributeGroup(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) SetModelAttributeGroup(ctx core.ContextParams, objID string, inputParam metadata.SetModelAttributeGroup) (*metadata.SetDataResult, error) {
+	result, err := m.modelAttributeGroup.SetModelAttributeGroup(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) UpdateModelAttributeGroup(ctx core.ContextParams, objID string, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	result, err := m.modelAttributeGroup.UpdateModelAttributeGroup(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) UpdateModelAttributeGroupByCondition(ctx core.ContextParams, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	result, err := m.modelAttributeGroup.UpdateModelAttributeGroupByCondition(ctx, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, m.searchObjIDs(ctx, common.BKTableNamePropertyGroup, inputParam.Condition)...)
+	}
+	return result, err
+}
+
+func (m *modelManager) DeleteModelAttributeGroup(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
+	result, err := m.modelAttributeGroup.DeleteModelAttributeGroup(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) DeleteModelAttributeGroupByCondition(ctx core.ContextParams, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
+	objIDs := m.searchObjIDs(ctx, common.BKTableNamePropertyGroup, inputParam.Condition)
+	result, err := m.modelAttributeGroup.DeleteModelAttributeGroupByCondition(ctx, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionGroup, objIDs...)
+	}
+	return result, err
+}
+
+func (m *modelManager) CreateModelAttributes(ctx core.ContextParams, objID string, inputParam metadata.CreateModelAttributes) (*metadata.CreateManyDataResult, error) {
+	result, err := m.modelAttribute.CreateModelAttributes(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionAttribute, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) SetModelAttributes(ctx core.ContextParams, objID string, inputParam metadata.SetModelAttributes) (*metadata.SetDataResult, error) {
+	result, err := m.modelAttribute.SetModelAttributes(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionAttribute, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) UpdateModelAttributes(ctx core.ContextParams, objID string, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	result, err := m.modelAttribute.UpdateModelAttributes(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionAttribute, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) UpdateModelAttributesByCondition(ctx core.ContextParams, inputParam metadata.UpdateOption) (*metadata.UpdatedCount, error) {
+	result, err := m.modelAttribute.UpdateModelAttributesByCondition(ctx, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionAttribute, m.searchObjIDs(ctx, common.BKTableNameObjAttDes, inputParam.Condition)...)
+	}
+	return result, err
+}
+
+func (m *modelManager) DeleteModelAttributes(ctx core.ContextParams, objID string, inputParam metadata.DeleteOption) (*metadata.DeletedCount, error) {
+	result, err := m.modelAttribute.DeleteModelAttributes(ctx, objID, inputParam)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionAttribute, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) CreateModelAttrUnique(ctx core.ContextParams, objID string, data metadata.CreateModelAttrUnique) (*metadata.CreateOneDataResult, error) {
+	result, err := m.modelAttrUnique.CreateModelAttrUnique(ctx, objID, data)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionUnique, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) UpdateModelAttrUnique(ctx core.ContextParams, objID string, id uint64, data metadata.UpdateModelAttrUnique) (*metadata.UpdatedCount, error) {
+	result, err := m.modelAttrUnique.UpdateModelAttrUnique(ctx, objID, id, data)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionUnique, objID)
+	}
+	return result, err
+}
+
+func (m *modelManager) DeleteModelAttrUnique(ctx core.ContextParams, objID string, id uint64, meta metadata.DeleteModelAttrUnique) (*metadata.DeletedCount, error) {
+	result, err := m.modelAttrUnique.DeleteModelAttrUnique(ctx, objID, id, meta)
+	if nil == err {
+		err = m.record(ctx, metadata.ModelVersionActionUnique, objID)
+	}
+	return result, err
+}
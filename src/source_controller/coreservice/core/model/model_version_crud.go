@@ -0,0 +1,135 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package model
+
+import (
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// versionSaveRetry the times to retry when the version number is taken by another request
+const versionSaveRetry = 3
+
+func (m *modelManager) searchVersions(ctx core.ContextParams, cond mapstr.MapStr, inputParam metadata.QueryCondition) ([]metadata.ModelVersion, error) {
+	results := make([]metadata.ModelVersion, 0)
+	finder := m.dbProxy.Table(common.BKTableNameObjVersion).Find(cond)
+	for _, sort := range inputParam.SortArr {
+		field := sort.Field
+		if sort.IsDsc {
+			field = "-" + field
+		}
+		finder = finder.Sort(field)
+	}
+	if 0 == len(inputParam.SortArr) {
+		finder = finder.Sort("-" + metadata.ModelVersionFieldVersion)
+	}
+	err := finder.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &results)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to search the model versions by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return results, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	return results, nil
+}
+
+func (m *modelManager) countVersions(ctx core.ContextParams, cond mapstr.MapStr) (uint64, error) {
+	cnt, err := m.dbProxy.Table(common.BKTableNameObjVersion).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the model versions by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return 0, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	return cnt, nil
+}
+
+// getVersion returns the version of the model, the latest version is returned if the version is 0
+func (m *modelManager) getVersion(ctx core.ContextParams, objID string, version int64) (*metadata.ModelVersion, bool, error) {
+	cond := mapstr.MapStr{
+		metadata.ModelVersionFieldObjectID: objID,
+		metadata.ModelVersionFieldOwnerID:  ctx.SupplierAccount,
+	}
+	if 0 != version {
+		cond.Set(metadata.ModelVersionFieldVersion, version)
+	}
+
+	// One ignores the sort, so the latest version is searched by All with the limit
+	versions, err := m.searchVersions(ctx, cond, metadata.QueryCondition{Limit: metadata.SearchLimit{Limit: 1}})
+	if nil != err {
+		return nil, false, err
+	}
+	if 0 == len(versions) {
+		return nil, false, nil
+	}
+	return &versions[0], true, nil
+}
+
+// saveVersion snapshot the schema of the model and save it as the next version, the unchanged parts are taken from
+// the latest version if incremental is set. the snapshot is taken again against the new latest version if the version
+// number is taken by another request, otherwise the parts changed by that request are lost.
+func (m *modelManager) saveVersion(ctx core.ContextParams, objID, action string, incremental bool) (int64, error) {
+	for retry := 0; ; retry++ {
+		latest, exists, err := m.getVersion(ctx, objID, 0)
+		if nil != err {
+			return 0, err
+		}
+
+		var base *metadata.ModelSchema
+		if exists && incremental {
+			base = &latest.Schema
+		}
+		schema, err := m.snapshot(ctx, objID, base, action)
+		if nil != err {
+			return 0, err
+		}
+
+		if exists && metadata.DiffModelSchema(latest.Schema, *schema).IsEmpty() {
+			return latest.Version, nil
+		}
+		if !exists && nil == schema.Object {
+			return 0, nil
+		}
+
+		id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameObjVersion)
+		if nil != err {
+			blog.Errorf("request(%s): it is failed to make the sequence id on the table (%s), error info is %s", ctx.ReqID, common.BKTableNameObjVersion, err.Error())
+			return 0, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+
+		version := metadata.ModelVersion{
+			ID:         int64(id),
+			ObjectID:   objID,
+			Version:    1,
+			Action:     action,
+			Operator:   ctx.User,
+			OwnerID:    ctx.SupplierAccount,
+			CreateTime: time.Now(),
+			Schema:     *schema,
+		}
+		if exists {
+			version.Version = latest.Version + 1
+		}
+
+		err = m.dbProxy.Table(common.BKTableNameObjVersion).Insert(ctx, version)
+		if nil == err {
+			return version.Version, nil
+		}
+		// the version number is taken by another request which changes the model at the same time
+		if m.dbProxy.IsDuplicatedError(err) && retry < versionSaveRetry {
+			continue
+		}
+		blog.Errorf("request(%s): it is failed to save the version of the model (%s), error info is %s", ctx.ReqID, objID, err.Error())
+		return 0, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+}
@@ -0,0 +1,435 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package model
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql/mongo"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// record records the versions of the models after they are changed, the change is failed if it is failed to record
+// the versions, so that it's rolled back by the transaction of the request, otherwise the next version which takes
+// the unchanged parts from the latest one misses the change.
+func (m *modelManager) record(ctx core.ContextParams, action string, objIDs ...string) error {
+	if err := m.RecordModelVersion(ctx, action, objIDs); nil != err {
+		blog.Errorf("request(%s): it is failed to record the versions of the models (%#v), error info is %s", ctx.ReqID, objIDs, err.Error())
+		return err
+	}
+	return nil
+}
+
+// searchObjIDs returns the models of the items in the table which are matched by the condition
+func (m *modelManager) searchObjIDs(ctx core.ContextParams, tableName string, cond mapstr.MapStr) []string {
+	items := make([]mapstr.MapStr, 0)
+	if err := m.dbProxy.Table(tableName).Find(util.SetModOwner(cond.ToMapInterface(), ctx.SupplierAccount)).Fields(common.BKObjIDField).All(ctx, &items); nil != err {
+		blog.Errorf("request(%s): it is failed to search the models on the table (%s) by the condition (%#v), error info is %s", ctx.ReqID, tableName, cond, err.Error())
+		return []string{}
+	}
+
+	objIDs := make([]string, 0)
+	for _, item := range items {
+		if objID, ok := item[common.BKObjIDField].(string); ok {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	return util.StrArrayUnique(objIDs)
+}
+
+// the parts of the schema
+const (
+	schemaPartObject       = "object"
+	schemaPartAttributes   = "attributes"
+	schemaPartGroups       = "groups"
+	schemaPartUniques      = "uniques"
+	schemaPartAssociations = "associations"
+)
+
+// actionSchemaParts the parts of the schema which are changed by the actions, the uniques are searched along with
+// the attributes, for the attributes of the unique keys may be deleted.
+var actionSchemaParts = map[string][]string{
+	metadata.ModelVersionActionModel:       {schemaPartObject},
+	metadata.ModelVersionActionAttribute:   {schemaPartAttributes, schemaPartUniques},
+	metadata.ModelVersionActionGroup:       {schemaPartGroups},
+	metadata.ModelVersionActionUnique:      {schemaPartUniques},
+	metadata.ModelVersionActionAssociation: {schemaPartAssociations},
+}
+
+// snapshot returns the current schema of the model, if the base schema is given, only the parts changed by the action
+// are searched and the others are taken from the base, otherwise the whole schema is searched.
+func (m *modelManager) snapshot(ctx core.ContextParams, objID string, base *metadata.ModelSchema, action string) (*metadata.ModelSchema, error) {
+	parts, ok := actionSchemaParts[action]
+	if nil == base || !ok {
+		base = &metadata.ModelSchema{}
+		parts = []string{schemaPartObject, schemaPartAttributes, schemaPartGroups, schemaPartUniques, schemaPartAssociations}
+	}
+	schema := &metadata.ModelSchema{
+		Object:       base.Object,
+		Attributes:   base.Attributes,
+		Groups:       base.Groups,
+		Uniques:      base.Uniques,
+		Associations: base.Associations,
+	}
+	cond := mapstr.MapStr{common.BKObjIDField: objID, common.BKOwnerIDField: ctx.SupplierAccount}
+
+	for _, part := range parts {
+		var err error
+		switch part {
+		case schemaPartObject:
+			objects := make([]metadata.Object, 0)
+			err = m.dbProxy.Table(common.BKTableNameObjDes).Find(cond).All(ctx, &objects)
+			schema.Object = nil
+			if 0 != len(objects) {
+				schema.Object = &objects[0]
+			}
+		case schemaPartAttributes:
+			schema.Attributes = make([]metadata.Attribute, 0)
+			err = m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).Sort(metadata.AttributeFieldID).All(ctx, &schema.Attributes)
+		case schemaPartGroups:
+			schema.Groups = make([]metadata.Group, 0)
+			err = m.dbProxy.Table(common.BKTableNamePropertyGroup).Find(cond).All(ctx, &schema.Groups)
+		case schemaPartUniques:
+			uniques := make([]metadata.ObjectUnique, 0)
+			err = m.dbProxy.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques)
+			schema.Uniques = make([]metadata.SchemaUnique, 0)
+			for _, unique := range uniques {
+				schema.Uniques = append(schema.Uniques, metadata.NewSchemaUnique(unique, schema.Attributes))
+			}
+		case schemaPartAssociations:
+			asstCond := mapstr.MapStr{
+				common.BKOwnerIDField: ctx.SupplierAccount,
+				common.BKDBOR: []mapstr.MapStr{
+					{metadata.AssociationFieldObjectID: objID},
+					{metadata.AssociationFieldAssociationObjectID: objID},
+				},
+			}
+			schema.Associations = make([]metadata.Association, 0)
+			err = m.dbProxy.Table(common.BKTableNameObjAsst).Find(asstCond).All(ctx, &schema.Associations)
+		}
+		if nil != err {
+			blog.Errorf("request(%s): it is failed to search the %s of the model (%s), error info is %s", ctx.ReqID, part, objID, err.Error())
+			return nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+	}
+
+	return schema, nil
+}
+
+// countInstances returns the count of the instances of the model matched by the condition, and some of their ids
+func (m *modelManager) countInstances(ctx core.ContextParams, objID string, cond mapstr.MapStr) (uint64, []int64, error) {
+	tableName := common.GetInstTableName(objID)
+	instIDField := common.GetInstIDField(objID)
+	cond.Set(common.BKOwnerIDField, ctx.SupplierAccount)
+	if tableName == common.BKTableNameBaseInst {
+		cond.Set(common.BKObjIDField, objID)
+	}
+
+	cnt, err := m.dbProxy.Table(tableName).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the instances of the model (%s) by the condition (%#v), error info is %s", ctx.ReqID, objID, cond, err.Error())
+		return 0, nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	insts := make([]mapstr.MapStr, 0)
+	if 0 != cnt {
+		err = m.dbProxy.Table(tableName).Find(cond).Fields(instIDField).Limit(metadata.RollbackMaxAffectedInstances).All(ctx, &insts)
+		if nil != err {
+			blog.Errorf("request(%s): it is failed to search the instances of the model (%s) by the condition (%#v), error info is %s", ctx.ReqID, objID, cond, err.Error())
+			return 0, nil, ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+		}
+	}
+
+	instIDs := make([]int64, 0)
+	for _, inst := range insts {
+		if instID, err := util.GetInt64ByInterface(inst[instIDField]); nil == err {
+			instIDs = append(instIDs, instID)
+		}
+	}
+	return cnt, instIDs, nil
+}
+
+// rollbackAffected check how the attributes and their instances are affected if the schema is changed to the target
+func (m *modelManager) rollbackAffected(ctx core.ContextParams, objID string, current, target *metadata.ModelSchema, diff metadata.SchemaItemsDiff) ([]metadata.RollbackAffected, error) {
+	affected := make([]metadata.RollbackAffected, 0)
+	addAffected := func(propertyID, reason string, unsafe bool, cond mapstr.MapStr) error {
+		cnt, instIDs, err := m.countInstances(ctx, objID, cond)
+		if nil != err {
+			return err
+		}
+		if 0 == cnt && !unsafe {
+			return nil
+		}
+		affected = append(affected, metadata.RollbackAffected{
+			PropertyID:    propertyID,
+			Reason:        reason,
+			Unsafe:        unsafe || (0 != cnt && reason != metadata.RollbackReasonRequired),
+			InstanceCount: cnt,
+			InstanceIDs:   instIDs,
+		})
+		return nil
+	}
+	hasValue := func(propertyID string) mapstr.MapStr {
+		return mapstr.MapStr{propertyID: mapstr.MapStr{common.BKDBNIN: []interface{}{nil, ""}}}
+	}
+	noValue := func(propertyID string) mapstr.MapStr {
+		return mapstr.MapStr{propertyID: mapstr.MapStr{common.BKDBIN: []interface{}{nil, ""}}}
+	}
+
+	currentAttrs := make(map[string]metadata.Attribute)
+	for _, attr := range current.Attributes {
+		currentAttrs[attr.PropertyID] = attr
+	}
+	uniqueKeys := make(map[string]bool)
+	for _, unique := range current.Uniques {
+		for _, key := range unique.Keys {
+			uniqueKeys[key] = true
+		}
+	}
+
+	// the attributes created after the version are deleted
+	for _, propertyID := range diff.Removed {
+		reason := metadata.RollbackReasonRemoved
+		unsafe := false
+		if currentAttrs[propertyID].IsPre {
+			reason, unsafe = metadata.RollbackReasonPredefined, true
+		} else if uniqueKeys[propertyID] {
+			reason, unsafe = metadata.RollbackReasonUnique, true
+		}
+		if err := addAffected(propertyID, reason, unsafe, hasValue(propertyID)); nil != err {
+			return nil, err
+		}
+	}
+
+	// the attributes deleted after the version are created again, the instances have no values of the required ones
+	for _, propertyID := range diff.Added {
+		for _, attr := range target.Attributes {
+			if attr.PropertyID == propertyID && attr.IsRequired {
+				if err := addAffected(propertyID, metadata.RollbackReasonRequired, false, noValue(propertyID)); nil != err {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	for _, change := range diff.Changed {
+		for _, field := range change.Fields {
+			switch field.Field {
+			case metadata.AttributeFieldPropertyType:
+				if err := addAffected(change.Key, metadata.RollbackReasonTypeChanged, false, hasValue(change.Key)); nil != err {
+					return nil, err
+				}
+			case metadata.AttributeFieldIsRequired:
+				if required, _ := field.To.(bool); required {
+					if err := addAffected(change.Key, metadata.RollbackReasonRequired, false, noValue(change.Key)); nil != err {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	return affected, nil
+}
+
+// rollbackObjectFields pick out the fields of the object which are restored by the rollback
+func rollbackObjectFields(fields []metadata.FieldDiff) []metadata.FieldDiff {
+	results := make([]metadata.FieldDiff, 0)
+	for _, field := range fields {
+		switch field.Field {
+		case common.BKObjIDField, metadata.ModelFieldIsPre:
+			continue
+		}
+		results = append(results, field)
+	}
+	return results
+}
+
+// rollbackSchema change the object, groups, attributes and uniques of the model to the ones of the target schema,
+// the groups are created before the attributes in them and deleted after the attributes are moved out, the uniques
+// are deleted before their attributes are deleted and created after their attributes are created.
+func (m *modelManager) rollbackSchema(ctx core.ContextParams, objID string, target *metadata.ModelSchema, diff metadata.ModelSchemaDiff) error {
+	if 0 != len(diff.Object) && nil != target.Object {
+		data := mapstr.New()
+		for _, field := range diff.Object {
+			data.Set(field.Field, field.To)
+		}
+		cond := mongo.NewCondition()
+		cond.Element(&mongo.Eq{Key: metadata.ModelFieldObjectID, Val: objID}, &mongo.Eq{Key: metadata.ModelFieldOwnerID, Val: ctx.SupplierAccount})
+		if _, err := m.update(ctx, data, cond); nil != err {
+			blog.Errorf("request(%s): it is failed to update the model (%s) for the rollback, error info is %s", ctx.ReqID, objID, err.Error())
+			return err
+		}
+	}
+
+	if err := m.rollbackGroups(ctx, objID, target, diff.Groups, false); nil != err {
+		return err
+	}
+	if err := m.rollbackUniques(ctx, objID, target, diff.Uniques, false); nil != err {
+		return err
+	}
+	if err := m.rollbackAttributes(ctx, objID, target, diff.Attributes); nil != err {
+		return err
+	}
+	if err := m.rollbackUniques(ctx, objID, target, diff.Uniques, true); nil != err {
+		return err
+	}
+	return m.rollbackGroups(ctx, objID, target, diff.Groups, true)
+}
+
+// rollbackGroups change the groups of the model to the ones of the target schema, the groups created after the version
+// are deleted if remove is set, otherwise the groups changed or deleted after the version are restored.
+func (m *modelManager) rollbackGroups(ctx core.ContextParams, objID string, target *metadata.ModelSchema, diff metadata.SchemaItemsDiff, remove bool) error {
+	if remove {
+		for _, groupID := range diff.Removed {
+			cond := mapstr.MapStr{metadata.GroupFieldGroupID: groupID}
+			if _, err := m.modelAttributeGroup.DeleteModelAttributeGroup(ctx, objID, metadata.DeleteOption{Condition: cond}); nil != err {
+				blog.Errorf("request(%s): it is failed to delete the group (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, groupID, err.Error())
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, change := range diff.Changed {
+		data := mapstr.New()
+		for _, field := range change.Fields {
+			data.Set(field.Field, field.To)
+		}
+		cond := mapstr.MapStr{metadata.GroupFieldGroupID: change.Key}
+		if _, err := m.modelAttributeGroup.UpdateModelAttributeGroup(ctx, objID, metadata.UpdateOption{Condition: cond, Data: data}); nil != err {
+			blog.Errorf("request(%s): it is failed to update the group (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, change.Key, err.Error())
+			return err
+		}
+	}
+	for _, groupID := range diff.Added {
+		for _, group := range target.Groups {
+			if group.GroupID != groupID {
+				continue
+			}
+			group.ID = 0
+			if _, err := m.modelAttributeGroup.CreateModelAttributeGroup(ctx, objID, metadata.CreateModelAttributeGroup{Data: group}); nil != err {
+				blog.Errorf("request(%s): it is failed to create the group (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, groupID, err.Error())
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rollbackUniques change the uniques of the model to the ones of the target schema, the uniques deleted or changed
+// after the version are created again if create is set, otherwise the uniques created or changed after the version
+// are deleted, the predefined uniques are kept.
+func (m *modelManager) rollbackUniques(ctx core.ContextParams, objID string, target *metadata.ModelSchema, diff metadata.SchemaItemsDiff, create bool) error {
+	// the keys of the uniques are the ids of the attributes, which are changed when the attributes are created again
+	cond := mapstr.MapStr{common.BKObjIDField: objID, common.BKOwnerIDField: ctx.SupplierAccount}
+	attrs := make([]metadata.Attribute, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjAttDes).Find(cond).All(ctx, &attrs); nil != err {
+		blog.Errorf("request(%s): it is failed to search the attributes of the model (%s), error info is %s", ctx.ReqID, objID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+	uniques := make([]metadata.ObjectUnique, 0)
+	if err := m.dbProxy.Table(common.BKTableNameObjUnique).Find(cond).All(ctx, &uniques); nil != err {
+		blog.Errorf("request(%s): it is failed to search the uniques of the model (%s), error info is %s", ctx.ReqID, objID, err.Error())
+		return ctx.Error.New(common.CCErrObjectDBOpErrno, err.Error())
+	}
+
+	changed := make(map[string]bool)
+	for _, change := range diff.Changed {
+		changed[change.Key] = true
+	}
+
+	if !create {
+		removed := make(map[string]bool)
+		for _, key := range diff.Removed {
+			removed[key] = true
+		}
+		for _, unique := range uniques {
+			key := metadata.NewSchemaUnique(unique, attrs).Key()
+			if unique.Ispre || (!removed[key] && !changed[key]) {
+				continue
+			}
+			if _, err := m.modelAttrUnique.DeleteModelAttrUnique(ctx, objID, unique.ID, metadata.DeleteModelAttrUnique{Metadata: unique.Metadata}); nil != err {
+				blog.Errorf("request(%s): it is failed to delete the unique (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, key, err.Error())
+				return err
+			}
+		}
+		return nil
+	}
+
+	attrIDs := make(map[string]uint64)
+	for _, attr := range attrs {
+		attrIDs[attr.PropertyID] = uint64(attr.ID)
+	}
+	for _, schemaUnique := range target.Uniques {
+		key := schemaUnique.Key()
+		if schemaUnique.IsPre || (!util.InStrArr(diff.Added, key) && !changed[key]) {
+			continue
+		}
+		unique := metadata.ObjectUnique{ObjID: objID, MustCheck: schemaUnique.MustCheck, Keys: make([]metadata.UniqueKey, 0)}
+		for _, propertyID := range schemaUnique.Keys {
+			unique.Keys = append(unique.Keys, metadata.UniqueKey{Kind: metadata.UniqueKeyKindProperty, ID: attrIDs[propertyID]})
+		}
+		if _, err := m.modelAttrUnique.CreateModelAttrUnique(ctx, objID, metadata.CreateModelAttrUnique{Data: unique}); nil != err {
+			blog.Errorf("request(%s): it is failed to create the unique (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, key, err.Error())
+			return err
+		}
+	}
+	return nil
+}
+
+// rollbackAttributes change the attributes of the model to the ones of the target schema
+func (m *modelManager) rollbackAttributes(ctx core.ContextParams, objID string, target *metadata.ModelSchema, diff metadata.SchemaItemsDiff) error {
+	for _, propertyID := range diff.Removed {
+		cond := mapstr.MapStr{metadata.AttributeFieldObjectID: objID, metadata.AttributeFieldPropertyID: propertyID}
+		if _, err := m.modelAttribute.DeleteModelAttributes(ctx, objID, metadata.DeleteOption{Condition: cond}); nil != err {
+			blog.Errorf("request(%s): it is failed to delete the attribute (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, propertyID, err.Error())
+			return err
+		}
+	}
+
+	for _, change := range diff.Changed {
+		data := mapstr.New()
+		for _, field := range change.Fields {
+			data.Set(field.Field, field.To)
+		}
+		cond := mapstr.MapStr{metadata.AttributeFieldObjectID: objID, metadata.AttributeFieldPropertyID: change.Key}
+		if _, err := m.modelAttribute.UpdateModelAttributes(ctx, objID, metadata.UpdateOption{Condition: cond, Data: data}); nil != err {
+			blog.Errorf("request(%s): it is failed to update the attribute (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, change.Key, err.Error())
+			return err
+		}
+	}
+
+	for _, propertyID := range diff.Added {
+		for _, attr := range target.Attributes {
+			if attr.PropertyID != propertyID {
+				continue
+			}
+
+			attr.ID = 0
+			result, err := m.modelAttribute.CreateModelAttributes(ctx, objID, metadata.CreateModelAttributes{Attributes: []metadata.Attribute{attr}})
+			if nil != err {
+				blog.Errorf("request(%s): it is failed to create the attribute (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, propertyID, err.Error())
+				return err
+			}
+			for _, exception := range result.Exceptions {
+				blog.Errorf("request(%s): it is failed to create the attribute (%s.%s) for the rollback, error info is %s", ctx.ReqID, objID, propertyID, exception.Message)
+				return ctx.Error.New(int(exception.Code), exception.Message)
+			}
+		}
+	}
+
+	return nil
+}
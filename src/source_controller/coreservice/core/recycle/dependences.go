@@ -0,0 +1,30 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// ATTENTIONS: the dependent methods of the other module
+
+// OperationDependences methods definition
+type OperationDependences interface {
+
+	// ValidInstanceUnique used to check the restored instance against the unique constraints of the model
+	ValidInstanceUnique(ctx core.ContextParams, objID string, instanceData mapstr.MapStr) error
+
+	// RestoreInstanceAssociation used to insert the restored instance association with the mapping keys of it
+	RestoreInstanceAssociation(ctx core.ContextParams, asstInst metadata.InstAsst) (bool, error)
+}
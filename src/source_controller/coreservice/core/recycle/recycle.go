@@ -0,0 +1,184 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"time"
+
+	redis "gopkg.in/redis.v5"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+	"configcenter/src/storage/dal"
+)
+
+var _ core.RecycleOperation = (*recycleManager)(nil)
+
+type recycleManager struct {
+	dbProxy   dal.RDB
+	dependent OperationDependences
+	eventC    eventclient.Client
+}
+
+// New create a new recycle bin manager instance
+func New(dbProxy dal.RDB, dependent OperationDependences, cache *redis.Client) core.RecycleOperation {
+	return &recycleManager{
+		dbProxy:   dbProxy,
+		dependent: dependent,
+		eventC:    eventclient.NewClientViaRedis(cache, dbProxy),
+	}
+}
+
+// ArchiveDeleted save the deleted resources into the recycle bin, the items deleted by the same request
+// are in the same batch.
+func (m *recycleManager) ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error {
+	if 0 == len(items) {
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]interface{}, 0)
+	for _, item := range items {
+		id, err := m.dbProxy.NextSequence(ctx, common.BKTableNameRecycleBin)
+		if nil != err {
+			blog.Errorf("request(%s): it is failed to make the sequence id on the table (%s), error info is %s", ctx.ReqID, common.BKTableNameRecycleBin, err.Error())
+			return ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+		item.ID = int64(id)
+		item.BatchID = ctx.ReqID
+		item.OwnerID = ctx.SupplierAccount
+		item.Operator = ctx.User
+		item.DeleteTime = now
+		rows = append(rows, item)
+	}
+
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Insert(ctx, rows); nil != err {
+		blog.Errorf("request(%s): it is failed to archive the deleted resources, error info is %s", ctx.ReqID, err.Error())
+		return ctx.Error.Error(common.CCErrCommDBInsertFailed)
+	}
+	return nil
+}
+
+func (m *recycleManager) SearchRecycleItem(ctx core.ContextParams, inputParam metadata.QueryCondition) (*metadata.QueryRecycleItemResult, error) {
+
+	cond := util.SetQueryOwner(inputParam.Condition.ToMapInterface(), ctx.SupplierAccount)
+	cnt, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the recycle items by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return &metadata.QueryRecycleItemResult{}, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+
+	items, err := m.search(ctx, cond, inputParam)
+	if nil != err {
+		return &metadata.QueryRecycleItemResult{}, err
+	}
+	// the archived documents keep the address ranges of the ip attributes to be restored with them
+	for _, item := range items {
+		for _, doc := range item.Documents {
+			util.RemoveIPRange(doc.Data)
+		}
+	}
+
+	return &metadata.QueryRecycleItemResult{Count: int64(cnt), Info: items}, nil
+}
+
+// RestoreRecycleItem restore the items, all of them are checked before anything is restored, and the items
+// of the same batch should be restored together, such as the set and its modules.
+func (m *recycleManager) RestoreRecycleItem(ctx core.ContextParams, inputParam metadata.RecycleOption) (*metadata.RecycleRestoreResult, error) {
+
+	items, err := m.find(ctx, inputParam)
+	if nil != err {
+		return nil, err
+	}
+	sortRestoreItems(items)
+
+	restoring := make(map[string]bool)
+	for _, item := range items {
+		if err := m.validRestore(ctx, item, restoring); nil != err {
+			blog.Errorf("request(%s): the recycle item (%d) can not be restored, error info is %s", ctx.ReqID, item.ID, err.Error())
+			return nil, err
+		}
+		restoring[itemKey(item)] = true
+	}
+
+	ids := make([]int64, 0)
+	docs := make(map[int64][]metadata.RecycleDocument)
+	for _, item := range items {
+		restored, err := m.restore(ctx, item)
+		if nil != err {
+			return nil, err
+		}
+		docs[item.ID] = restored
+		ids = append(ids, item.ID)
+	}
+	// the associations are restored after all the instances and models, so that both sides of them exist
+	assts := make([]metadata.InstAsst, 0)
+	for _, item := range items {
+		restored, err := m.restoreAssociations(ctx, item)
+		if nil != err {
+			return nil, err
+		}
+		assts = append(assts, restored...)
+	}
+
+	// the items are deleted from the recycle bin in the transaction of the request along with the restoring
+	if err := m.delete(ctx, mapstr.MapStr{metadata.RecycleFieldID: mapstr.MapStr{common.BKDBIN: ids}}); nil != err {
+		return nil, err
+	}
+
+	if err := m.eventC.Push(ctx, restoreEvents(ctx, items, docs, assts)...); nil != err {
+		blog.Errorf("request(%s): it is failed to push the events of the restored items, error info is %s", ctx.ReqID, err.Error())
+		return nil, ctx.Error.Error(common.CCErrEventPushEventFailed)
+	}
+	return &metadata.RecycleRestoreResult{Restored: items}, nil
+}
+
+func (m *recycleManager) PurgeRecycleItem(ctx core.ContextParams, inputParam metadata.RecycleOption) (*metadata.DeletedCount, error) {
+
+	cond, err := optionCondition(ctx, inputParam)
+	if nil != err {
+		return nil, err
+	}
+
+	cnt, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the recycle items by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if err := m.delete(ctx, cond); nil != err {
+		return nil, err
+	}
+	return &metadata.DeletedCount{Count: cnt}, nil
+}
+
+// PurgeExpiredRecycleItem purge the items of all the supplier accounts which are deleted before the time
+func (m *recycleManager) PurgeExpiredRecycleItem(ctx core.ContextParams, before time.Time) (*metadata.DeletedCount, error) {
+
+	cond := mapstr.MapStr{metadata.RecycleFieldDeleteTime: mapstr.MapStr{common.BKDBLT: before}}
+	cnt, err := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the expired recycle items, error info is %s", ctx.ReqID, err.Error())
+		return nil, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	if 0 == cnt {
+		return &metadata.DeletedCount{}, nil
+	}
+	if err := m.delete(ctx, cond); nil != err {
+		return nil, err
+	}
+	return &metadata.DeletedCount{Count: cnt}, nil
+}
@@ -0,0 +1,82 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (m *recycleManager) search(ctx core.ContextParams, cond mapstr.MapStr, inputParam metadata.QueryCondition) ([]metadata.RecycleItem, error) {
+	results := make([]metadata.RecycleItem, 0)
+	finder := m.dbProxy.Table(common.BKTableNameRecycleBin).Find(cond)
+	for _, sort := range inputParam.SortArr {
+		field := sort.Field
+		if sort.IsDsc {
+			field = "-" + field
+		}
+		finder = finder.Sort(field)
+	}
+	if 0 == len(inputParam.SortArr) {
+		finder = finder.Sort("-" + metadata.RecycleFieldDeleteTime)
+	}
+	err := finder.Start(uint64(inputParam.Limit.Offset)).Limit(uint64(inputParam.Limit.Limit)).All(ctx, &results)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to search the recycle items by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return results, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return results, nil
+}
+
+// find returns the items of the option, it's failed if there is not any item
+func (m *recycleManager) find(ctx core.ContextParams, inputParam metadata.RecycleOption) ([]metadata.RecycleItem, error) {
+	cond, err := optionCondition(ctx, inputParam)
+	if nil != err {
+		return nil, err
+	}
+
+	items, err := m.search(ctx, cond, metadata.QueryCondition{})
+	if nil != err {
+		return nil, err
+	}
+	if 0 == len(items) {
+		blog.Errorf("request(%s): there is not any recycle item by the condition (%#v)", ctx.ReqID, cond)
+		return nil, ctx.Error.Error(common.CCErrCommNotFound)
+	}
+	return items, nil
+}
+
+func (m *recycleManager) delete(ctx core.ContextParams, cond mapstr.MapStr) error {
+	if err := m.dbProxy.Table(common.BKTableNameRecycleBin).Delete(ctx, cond); nil != err {
+		blog.Errorf("request(%s): it is failed to delete the recycle items by the condition (%#v), error info is %s", ctx.ReqID, cond, err.Error())
+		return ctx.Error.Error(common.CCErrCommDBDeleteFailed)
+	}
+	return nil
+}
+
+func optionCondition(ctx core.ContextParams, inputParam metadata.RecycleOption) (mapstr.MapStr, error) {
+	if 0 == len(inputParam.IDs) && 0 == len(inputParam.BatchID) {
+		return nil, ctx.Error.Errorf(common.CCErrCommParamsNeedSet, "ids")
+	}
+
+	cond := mapstr.MapStr{metadata.RecycleFieldOwnerID: ctx.SupplierAccount}
+	if 0 != len(inputParam.IDs) {
+		cond.Set(metadata.RecycleFieldID, mapstr.MapStr{common.BKDBIN: inputParam.IDs})
+	}
+	if 0 != len(inputParam.BatchID) {
+		cond.Set(metadata.RecycleFieldBatchID, inputParam.BatchID)
+	}
+	return cond, nil
+}
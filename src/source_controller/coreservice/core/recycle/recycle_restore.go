@@ -0,0 +1,321 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"fmt"
+	"sort"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/eventclient"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+// restorePriority the models are restored first, then the mainline instances from the top down,
+// and the hosts are restored at last because they are related to the modules.
+func restorePriority(item metadata.RecycleItem) int {
+	switch item.Kind {
+	case metadata.RecycleKindModel:
+		return 0
+	case metadata.RecycleKindHost:
+		return 5
+	}
+	switch item.ObjectID {
+	case common.BKInnerObjIDApp:
+		return 1
+	case common.BKInnerObjIDSet:
+		return 2
+	case common.BKInnerObjIDModule:
+		return 3
+	}
+	return 4
+}
+
+func sortRestoreItems(items []metadata.RecycleItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		pi, pj := restorePriority(items[i]), restorePriority(items[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return items[i].ID < items[j].ID
+	})
+}
+
+func itemKey(item metadata.RecycleItem) string {
+	if item.Kind == metadata.RecycleKindModel {
+		return modelKey(item.ObjectID)
+	}
+	return instKey(item.ObjectID, item.InstID)
+}
+
+func modelKey(objID string) string {
+	return "model:" + objID
+}
+
+func instKey(objID string, instID int64) string {
+	return fmt.Sprintf("%s:%d", objID, instID)
+}
+
+func isAssociationTable(table string) bool {
+	return table == common.BKTableNameInstAsst || table == common.BKTableNameObjAsst
+}
+
+func conflictError(ctx core.ContextParams, item metadata.RecycleItem, reason string) error {
+	return ctx.Error.Errorf(common.CCErrCoreServiceRecycleRestoreConflict, fmt.Sprintf("%s %s", item.ObjectID, item.InstName), reason)
+}
+
+// validRestore check the item can be restored, the restoring has the items which are restored with it
+func (m *recycleManager) validRestore(ctx core.ContextParams, item metadata.RecycleItem, restoring map[string]bool) error {
+	if 0 == len(item.Documents) {
+		return conflictError(ctx, item, "the item has no document")
+	}
+	data := item.Documents[0].Data
+
+	if item.Kind == metadata.RecycleKindModel {
+		exists, err := m.exists(ctx, common.BKTableNameObjDes, mapstr.MapStr{common.BKDBOR: []mapstr.MapStr{
+			{common.BKObjIDField: item.ObjectID},
+			{common.BKObjNameField: item.InstName},
+		}})
+		if nil != err {
+			return err
+		}
+		if exists {
+			return conflictError(ctx, item, fmt.Sprintf("the model %s or %s already exists", item.ObjectID, item.InstName))
+		}
+		clsID, _ := data.String(common.BKClassificationIDField)
+		exists, err = m.exists(ctx, common.BKTableNameObjClassifiction, mapstr.MapStr{common.BKClassificationIDField: clsID})
+		if nil != err {
+			return err
+		}
+		if !exists {
+			return conflictError(ctx, item, fmt.Sprintf("the classification %s does not exist", clsID))
+		}
+		return nil
+	}
+
+	// the model of the instance is restored with it, so there is no other instance to conflict with
+	if restoring[modelKey(item.ObjectID)] {
+		return nil
+	}
+	if !util.IsInnerObject(item.ObjectID) {
+		exists, err := m.exists(ctx, common.BKTableNameObjDes, mapstr.MapStr{common.BKObjIDField: item.ObjectID})
+		if nil != err {
+			return err
+		}
+		if !exists {
+			return conflictError(ctx, item, fmt.Sprintf("the model %s does not exist", item.ObjectID))
+		}
+	}
+
+	// the parent of the set and module, and the modules of the host must exist
+	parents := make(map[string][]int64)
+	switch item.ObjectID {
+	case common.BKInnerObjIDSet:
+		if bizID, err := data.Int64(common.BKAppIDField); nil == err {
+			parents[common.BKInnerObjIDApp] = append(parents[common.BKInnerObjIDApp], bizID)
+		}
+	case common.BKInnerObjIDModule:
+		if setID, err := data.Int64(common.BKSetIDField); nil == err {
+			parents[common.BKInnerObjIDSet] = append(parents[common.BKInnerObjIDSet], setID)
+		}
+	case common.BKInnerObjIDHost:
+		for _, doc := range item.Documents[1:] {
+			if doc.Table != common.BKTableNameModuleHostConfig {
+				continue
+			}
+			if moduleID, err := doc.Data.Int64(common.BKModuleIDField); nil == err {
+				parents[common.BKInnerObjIDModule] = append(parents[common.BKInnerObjIDModule], moduleID)
+			}
+		}
+	}
+	for objID, instIDs := range parents {
+		for _, instID := range instIDs {
+			if restoring[instKey(objID, instID)] {
+				continue
+			}
+			exists, err := m.instanceExists(ctx, objID, instID)
+			if nil != err {
+				return err
+			}
+			if !exists {
+				return conflictError(ctx, item, fmt.Sprintf("the %s %d does not exist", objID, instID))
+			}
+		}
+	}
+
+	return m.dependent.ValidInstanceUnique(ctx, item.ObjectID, data)
+}
+
+// restore insert the documents of the item back except the associations, and returns the restored documents,
+// the documents which have been there, such as the groups of the model which are not deleted, are skipped.
+func (m *recycleManager) restore(ctx core.ContextParams, item metadata.RecycleItem) ([]metadata.RecycleDocument, error) {
+	restored := make([]metadata.RecycleDocument, 0)
+	for idx, doc := range item.Documents {
+		if isAssociationTable(doc.Table) {
+			continue
+		}
+		if idx > 0 {
+			exists, err := m.exists(ctx, doc.Table, documentCond(doc))
+			if nil != err {
+				return nil, err
+			}
+			if exists {
+				continue
+			}
+		}
+		if err := m.dbProxy.Table(doc.Table).Insert(ctx, doc.Data); nil != err {
+			blog.Errorf("request(%s): it is failed to restore the document of the recycle item (%d) into the table (%s), error info is %s", ctx.ReqID, item.ID, doc.Table, err.Error())
+			return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+		}
+		restored = append(restored, doc)
+	}
+	return restored, nil
+}
+
+// restoreAssociations insert the associations of the item back if both sides of them exist, and returns the restored
+// instance associations. the instance associations are made again with the mapping keys, and they are skipped if the
+// mapping of the model association is exceeded.
+func (m *recycleManager) restoreAssociations(ctx core.ContextParams, item metadata.RecycleItem) ([]metadata.InstAsst, error) {
+	restored := make([]metadata.InstAsst, 0)
+	for _, doc := range item.Documents {
+		if !isAssociationTable(doc.Table) {
+			continue
+		}
+
+		objID, _ := doc.Data.String(common.BKObjIDField)
+		asstObjID, _ := doc.Data.String(common.BKAsstObjIDField)
+		var sides [][2]interface{}
+		if doc.Table == common.BKTableNameInstAsst {
+			instID, _ := doc.Data.Int64(common.BKInstIDField)
+			asstInstID, _ := doc.Data.Int64(common.BKAsstInstIDField)
+			sides = [][2]interface{}{{objID, instID}, {asstObjID, asstInstID}}
+		} else {
+			sides = [][2]interface{}{{objID, nil}, {asstObjID, nil}}
+		}
+
+		restorable := true
+		for _, side := range sides {
+			var exists bool
+			var err error
+			if instID, ok := side[1].(int64); ok {
+				exists, err = m.instanceExists(ctx, side[0].(string), instID)
+			} else {
+				exists, err = m.exists(ctx, common.BKTableNameObjDes, mapstr.MapStr{common.BKObjIDField: side[0]})
+			}
+			if nil != err {
+				return nil, err
+			}
+			restorable = restorable && exists
+		}
+		if !restorable {
+			blog.Warnf("request(%s): skip the association (%#v) of the recycle item (%d), one side of it does not exist", ctx.ReqID, doc.Data, item.ID)
+			continue
+		}
+
+		exists, err := m.exists(ctx, doc.Table, documentCond(doc))
+		if nil != err {
+			return nil, err
+		}
+		if exists {
+			continue
+		}
+
+		if doc.Table == common.BKTableNameObjAsst {
+			if err := m.dbProxy.Table(doc.Table).Insert(ctx, doc.Data); nil != err {
+				blog.Errorf("request(%s): it is failed to restore the association of the recycle item (%d) into the table (%s), error info is %s", ctx.ReqID, item.ID, doc.Table, err.Error())
+				return nil, ctx.Error.Error(common.CCErrCommDBInsertFailed)
+			}
+			continue
+		}
+
+		asst := metadata.InstAsst{}
+		if err := doc.Data.MarshalJSONInto(&asst); nil != err {
+			blog.Errorf("request(%s): the association (%#v) of the recycle item (%d) is invalid, error info is %s", ctx.ReqID, doc.Data, item.ID, err.Error())
+			return nil, ctx.Error.Errorf(common.CCErrCommParamsIsInvalid, common.BKTableNameInstAsst)
+		}
+		ok, err := m.dependent.RestoreInstanceAssociation(ctx, asst)
+		if nil != err {
+			return nil, err
+		}
+		if !ok {
+			blog.Warnf("request(%s): skip the association (%#v) of the recycle item (%d), it can not be associated again", ctx.ReqID, doc.Data, item.ID)
+			continue
+		}
+		restored = append(restored, asst)
+	}
+	return restored, nil
+}
+
+// restoreEvents returns the events of the restored instances, host relations and instance associations
+func restoreEvents(ctx core.ContextParams, items []metadata.RecycleItem, docs map[int64][]metadata.RecycleDocument, assts []metadata.InstAsst) []*metadata.EventInst {
+	events := make([]*metadata.EventInst, 0)
+	newEvent := func(eventType, objType string, data interface{}) {
+		event := eventclient.NewEventWithHeader(ctx.Header)
+		event.EventType = eventType
+		event.ObjType = objType
+		event.Action = metadata.EventActionCreate
+		event.Data = []metadata.EventData{{CurData: data}}
+		events = append(events, event)
+	}
+
+	for _, item := range items {
+		if item.Kind == metadata.RecycleKindModel {
+			continue
+		}
+		for _, doc := range docs[item.ID] {
+			switch doc.Table {
+			case common.GetInstTableName(item.ObjectID):
+				newEvent(metadata.EventTypeInstData, item.ObjectID, doc.Data)
+			case common.BKTableNameModuleHostConfig:
+				newEvent(metadata.EventTypeRelation, "moduletransfer", doc.Data)
+			}
+		}
+	}
+	for _, asst := range assts {
+		newEvent(metadata.EventTypeAssociation, asst.ObjectID, asst)
+		newEvent(metadata.EventTypeAssociation, asst.AsstObjectID, asst)
+	}
+	return events
+}
+
+// documentCond returns the condition which identifies the document in its table
+func documentCond(doc metadata.RecycleDocument) mapstr.MapStr {
+	if doc.Table == common.BKTableNameModuleHostConfig {
+		return mapstr.MapStr{
+			common.BKHostIDField:   doc.Data[common.BKHostIDField],
+			common.BKModuleIDField: doc.Data[common.BKModuleIDField],
+		}
+	}
+	return mapstr.MapStr{common.BKFieldID: doc.Data[common.BKFieldID]}
+}
+
+func (m *recycleManager) instanceExists(ctx core.ContextParams, objID string, instID int64) (bool, error) {
+	cond := mapstr.MapStr{common.GetInstIDField(objID): instID}
+	if common.GetInstTableName(objID) == common.BKTableNameBaseInst {
+		cond.Set(common.BKObjIDField, objID)
+	}
+	return m.exists(ctx, common.GetInstTableName(objID), cond)
+}
+
+func (m *recycleManager) exists(ctx core.ContextParams, table string, cond mapstr.MapStr) (bool, error) {
+	cond = util.SetQueryOwner(cond, ctx.SupplierAccount)
+	cnt, err := m.dbProxy.Table(table).Find(cond).Count(ctx)
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to count the documents of the table (%s) by the condition (%#v), error info is %s", ctx.ReqID, table, cond, err.Error())
+		return false, ctx.Error.Error(common.CCErrCommDBSelectFailed)
+	}
+	return cnt > 0, nil
+}
@@ -0,0 +1,72 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package recycle
+
+import (
+	"net/http"
+	"testing"
+
+	"configcenter/src/common"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func TestSortRestoreItems(t *testing.T) {
+	items := []metadata.RecycleItem{
+		{ID: 1, Kind: metadata.RecycleKindHost, ObjectID: common.BKInnerObjIDHost},
+		{ID: 2, Kind: metadata.RecycleKindInstance, ObjectID: common.BKInnerObjIDModule},
+		{ID: 3, Kind: metadata.RecycleKindInstance, ObjectID: "switch"},
+		{ID: 4, Kind: metadata.RecycleKindInstance, ObjectID: common.BKInnerObjIDSet},
+		{ID: 5, Kind: metadata.RecycleKindModel, ObjectID: "switch"},
+		{ID: 6, Kind: metadata.RecycleKindInstance, ObjectID: common.BKInnerObjIDModule},
+	}
+	sortRestoreItems(items)
+
+	expect := []int64{5, 4, 2, 6, 3, 1}
+	for idx, item := range items {
+		if item.ID != expect[idx] {
+			t.Fatalf("the item at %d should be %d, but got %d", idx, expect[idx], item.ID)
+		}
+	}
+}
+
+func TestRestoreEvents(t *testing.T) {
+	items := []metadata.RecycleItem{
+		{ID: 1, Kind: metadata.RecycleKindModel, ObjectID: "switch"},
+		{ID: 2, Kind: metadata.RecycleKindHost, ObjectID: common.BKInnerObjIDHost},
+	}
+	docs := map[int64][]metadata.RecycleDocument{
+		1: {{Table: common.BKTableNameObjDes, Data: mapstr.MapStr{common.BKObjIDField: "switch"}}},
+		2: {
+			{Table: common.BKTableNameBaseHost, Data: mapstr.MapStr{common.BKHostIDField: 1}},
+			{Table: common.BKTableNameModuleHostConfig, Data: mapstr.MapStr{common.BKHostIDField: 1, common.BKModuleIDField: 2}},
+		},
+	}
+	assts := []metadata.InstAsst{{ID: 3, ObjectID: "switch", InstID: 4, AsstObjectID: common.BKInnerObjIDHost, AsstInstID: 1}}
+
+	events := restoreEvents(core.ContextParams{Header: http.Header{}}, items, docs, assts)
+	expect := []string{
+		metadata.EventTypeInstData + ":" + common.BKInnerObjIDHost,
+		metadata.EventTypeRelation + ":moduletransfer",
+		metadata.EventTypeAssociation + ":switch",
+		metadata.EventTypeAssociation + ":" + common.BKInnerObjIDHost,
+	}
+	if len(events) != len(expect) {
+		t.Fatalf("restoreEvents returns %d events, want %d", len(events), len(expect))
+	}
+	for idx, event := range events {
+		if got := event.EventType + ":" + event.ObjType; got != expect[idx] || event.Action != metadata.EventActionCreate {
+			t.Errorf("the event at %d is %s %s, want %s create", idx, got, event.Action, expect[idx])
+		}
+	}
+}
@@ -188,3 +188,12 @@ func (s *coreService) DeleteInstanceAssociation(params core.ContextParams, pathP
 	}
 	return s.core.AssociationOperation().DeleteInstanceAssociation(params, inputData)
 }
+
+func (s *coreService) SearchInstanceAssociationMappingViolation(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+
+	inputData := metadata.SearchAsstMappingViolationRequest{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AssociationOperation().SearchInstanceAssociationMappingViolation(params, inputData)
+}
@@ -35,3 +35,18 @@ func (s *coreService) IsInstanceExist(ctx core.ContextParams, objID string, inst
 	}
 	return true, nil
 }
+
+// RecomputeInstances evaluate the computed attributes of the instances again
+func (s *coreService) RecomputeInstances(ctx core.ContextParams, objID string, instIDs []int64) error {
+	err := s.core.InstanceOperation().RecomputeInstances(ctx, objID, instIDs)
+	if nil != err {
+		blog.Errorf("recompute the instances of model %s error %v", objID, err)
+		return err
+	}
+	return nil
+}
+
+// RecordModelVersion record the versions of the models whose associations are changed
+func (s *coreService) RecordModelVersion(ctx core.ContextParams, objIDs []string) error {
+	return s.core.ModelOperation().RecordModelVersion(ctx, metadata.ModelVersionActionAssociation, objIDs)
+}
@@ -42,3 +42,11 @@ func (s *coreService) SearchAuditLog(ctx core.ContextParams, pathParams, queryPa
 		Info:  auditlogs,
 	}, err
 }
+
+func (s *coreService) SearchInstanceHistory(ctx core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.InstanceHistoryOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.AuditOperation().SearchInstanceHistory(ctx, pathParams("bk_obj_id"), inputData)
+}
@@ -84,3 +84,20 @@ func (s *coreService) CascadeDeleteModelInstances(params core.ContextParams, pat
 	}
 	return s.core.InstanceOperation().CascadeDeleteModelInstance(params, pathParams("bk_obj_id"), inputData)
 }
+
+// DeleteModelInstanceImpact returns the impact of deleting the instances, nothing is deleted.
+func (s *coreService) DeleteModelInstanceImpact(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.DeleteOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().DeleteModelInstanceImpact(params, pathParams("bk_obj_id"), inputData)
+}
+
+func (s *coreService) RecomputeModelInstances(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.RecomputeOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+	return s.core.InstanceOperation().RecomputeModelInstance(params, pathParams("bk_obj_id"), inputData)
+}
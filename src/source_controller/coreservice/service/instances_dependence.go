@@ -17,33 +17,86 @@ import (
 	"configcenter/src/common/blog"
 	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
+	"configcenter/src/common/universalsql"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/source_controller/coreservice/core"
 )
 
-// IsInstanceExist used to check if the  instances  asst exist
-func (s *coreService) IsInstAsstExist(ctx core.ContextParams, objID string, instID uint64) (exists bool, err error) {
+// SearchInstAsst used to search the inst asst which the instance is the source or destination of
+func (s *coreService) SearchInstAsst(ctx core.ContextParams, objID string, instID uint64) (assts []metadata.InstAsst, err error) {
+	assts = make([]metadata.InstAsst, 0)
+	conds := []universalsql.Condition{
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID}, &mongo.Eq{Key: common.BKInstIDField, Val: instID}),
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKAsstObjIDField, Val: objID}, &mongo.Eq{Key: common.BKAsstInstIDField, Val: instID}),
+	}
+	for _, cond := range conds {
+		queryCond := metadata.QueryCondition{Condition: cond.ToMapStr()}
+		result, err := s.core.AssociationOperation().SearchInstanceAssociation(ctx, queryCond)
+		if nil != err {
+			blog.Errorf("search instance association error %v", err)
+			return nil, err
+		}
+		for _, item := range result.Info {
+			asst := metadata.InstAsst{}
+			if err := item.MarshalJSONInto(&asst); nil != err {
+				blog.Errorf("parse instance association %v error %v", item, err)
+				return nil, err
+			}
+			assts = append(assts, asst)
+		}
+	}
+	return assts, nil
+}
+
+// SearchInstAssts used to search the inst assts which the instances are the source or destination of
+func (s *coreService) SearchInstAssts(ctx core.ContextParams, objID string, instIDs []int64) (assts []metadata.InstAsst, err error) {
+	assts = make([]metadata.InstAsst, 0)
+	conds := []universalsql.Condition{
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID}, &mongo.In{Key: common.BKInstIDField, Val: instIDs}),
+		mongo.NewCondition().Element(&mongo.Eq{Key: common.BKAsstObjIDField, Val: objID}, &mongo.In{Key: common.BKAsstInstIDField, Val: instIDs}),
+	}
+	for _, cond := range conds {
+		queryCond := metadata.QueryCondition{Condition: cond.ToMapStr()}
+		result, err := s.core.AssociationOperation().SearchInstanceAssociation(ctx, queryCond)
+		if nil != err {
+			blog.Errorf("search instance association error %v", err)
+			return nil, err
+		}
+		for _, item := range result.Info {
+			asst := metadata.InstAsst{}
+			if err := item.MarshalJSONInto(&asst); nil != err {
+				blog.Errorf("parse instance association %v error %v", item, err)
+				return nil, err
+			}
+			assts = append(assts, asst)
+		}
+	}
+	return assts, nil
+}
+
+// SearchModelAsst used to search the model asst by the bk_obj_asst_id
+func (s *coreService) SearchModelAsst(ctx core.ContextParams, objAsstIDs []string) (assts []metadata.Association, err error) {
+	assts = make([]metadata.Association, 0)
+	if len(objAsstIDs) == 0 {
+		return assts, nil
+	}
 	cond := mongo.NewCondition()
-	cond.Element(&mongo.Eq{Key: common.BKObjIDField, Val: objID}, &mongo.Eq{Key: common.BKInstIDField, Val: instID})
+	cond.Element(&mongo.In{Key: common.AssociationObjAsstIDField, Val: objAsstIDs})
 	queryCond := metadata.QueryCondition{Condition: cond.ToMapStr()}
-	objInsts, err := s.core.AssociationOperation().SearchInstanceAssociation(ctx, queryCond)
-	if nil != err {
-		blog.Errorf("search instance association error %v", err)
-		return false, err
-	}
-	cond = mongo.NewCondition()
-	cond.Element(&mongo.Eq{Key: common.BKAsstObjIDField, Val: objID}, &mongo.Eq{Key: common.BKAsstInstIDField, Val: instID})
-	queryCond = metadata.QueryCondition{Condition: cond.ToMapStr()}
-	objAsstInsts, err := s.core.AssociationOperation().SearchInstanceAssociation(ctx, queryCond)
+	result, err := s.core.AssociationOperation().SearchModelAssociation(ctx, queryCond)
 	if nil != err {
-		blog.Errorf("search instance to association error %v", err)
-		return false, err
+		blog.Errorf("search model association error %v", err)
+		return nil, err
 	}
-	if 0 < objInsts.Count && 0 < objAsstInsts.Count {
-		return true, nil
+	for _, item := range result.Info {
+		asst := metadata.Association{}
+		if err := item.MarshalJSONInto(&asst); nil != err {
+			blog.Errorf("parse model association %v error %v", item, err)
+			return nil, err
+		}
+		assts = append(assts, asst)
 	}
-	return false, nil
-
+	return assts, nil
 }
 
 // DeleteInstAsst used to delete inst asst
@@ -98,3 +151,41 @@ func (s *coreService) SearchUnique(ctx core.ContextParams, objID string) (unique
 	result, err := s.core.ModelOperation().SearchModelAttrUnique(ctx, queryCond)
 	return result.Info, err
 }
+
+// SearchComputedAttributes search the computed attributes of all the models
+func (s *coreService) SearchComputedAttributes(ctx core.ContextParams) (attributeArr []metadata.Attribute, err error) {
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: metadata.AttributeFieldPropertyType, Val: common.FieldTypeComputed})
+	queryCond := metadata.QueryCondition{Condition: cond.ToMapStr()}
+	result, err := s.core.ModelOperation().SearchModelAttributesByCondition(ctx, queryCond)
+	if nil != err {
+		blog.Errorf("search the computed attributes error %v", err)
+		return nil, err
+	}
+	return result.Info, nil
+}
+
+// SearchHostModuleRelation search the host module relations
+func (s *coreService) SearchHostModuleRelation(ctx core.ContextParams, input *metadata.HostModuleRelationRequest) (relations []metadata.ModuleHost, err error) {
+	return s.core.HostOperation().GetHostModuleRelation(ctx, input)
+}
+
+// ValidInstanceUnique check the instance data which will be restored is unique
+func (s *coreService) ValidInstanceUnique(ctx core.ContextParams, objID string, instanceData mapstr.MapStr) error {
+	return s.core.InstanceOperation().ValidModelInstanceUnique(ctx, objID, instanceData)
+}
+
+// RestoreInstanceAssociation insert the instance association restored from the recycle bin
+func (s *coreService) RestoreInstanceAssociation(ctx core.ContextParams, asstInst metadata.InstAsst) (bool, error) {
+	return s.core.AssociationOperation().RestoreInstanceAssociation(ctx, asstInst)
+}
+
+// SaveAuditLog save the audit logs of the changes made by core service itself
+func (s *coreService) SaveAuditLog(ctx core.ContextParams, logs ...metadata.SaveAuditLogParams) error {
+	return s.core.AuditOperation().CreateAuditLog(ctx, logs...)
+}
+
+// ArchiveDeleted save the deleted resources into the recycle bin
+func (s *coreService) ArchiveDeleted(ctx core.ContextParams, items ...metadata.RecycleItem) error {
+	return s.core.RecycleOperation().ArchiveDeleted(ctx, items...)
+}
@@ -395,3 +395,66 @@ func (s *coreService) DeleteModelAttrUnique(params core.ContextParams, pathParam
 
 	return s.core.ModelOperation().DeleteModelAttrUnique(params, pathParams("bk_obj_id"), id, metadata.DeleteModelAttrUnique{Metadata: inputDatas.Metadata})
 }
+
+func (s *coreService) SearchModelVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().SearchModelVersion(params, pathParams("bk_obj_id"), inputData)
+}
+
+func (s *coreService) DiffModelVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.DiffModelVersionOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().DiffModelVersion(params, pathParams("bk_obj_id"), inputData)
+}
+
+func (s *coreService) ExportModelVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	version, err := strconv.ParseInt(pathParams("version"), 10, 64)
+	if err != nil {
+		return nil, params.Error.Errorf(common.CCErrCommParamsNeedInt, "version")
+	}
+
+	return s.core.ModelOperation().ExportModelVersion(params, pathParams("bk_obj_id"), version)
+}
+
+func (s *coreService) RollbackModelVersion(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.RollbackModelVersionOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().RollbackModelVersion(params, pathParams("bk_obj_id"), inputData)
+}
+
+func (s *coreService) ExportModelBundle(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.ExportModelBundleOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().ExportModelBundle(params, inputData)
+}
+
+func (s *coreService) PlanModelBundle(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.ModelBundleOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().PlanModelBundle(params, inputData)
+}
+
+func (s *coreService) ApplyModelBundle(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.ModelBundleOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.ModelOperation().ApplyModelBundle(params, inputData)
+}
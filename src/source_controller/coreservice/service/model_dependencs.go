@@ -14,6 +14,7 @@ package service
 
 import (
 	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
 	"configcenter/src/common/metadata"
 	"configcenter/src/common/universalsql/mongo"
 	"configcenter/src/source_controller/coreservice/core"
@@ -95,3 +96,27 @@ func (s *coreService) CascadeDeleteInstances(ctx core.ContextParams, objIDS []st
 
 	return nil
 }
+
+// ApplyBundleAssociation create, update or delete the association of the model bundle by the action
+func (s *coreService) ApplyBundleAssociation(ctx core.ContextParams, action string, asst metadata.Association) error {
+
+	cond := mongo.NewCondition()
+	cond.Element(&mongo.Eq{Key: metadata.AssociationFieldAsstID, Val: asst.AssociationName})
+
+	var err error
+	switch action {
+	case metadata.BundleActionCreate:
+		_, err = s.core.AssociationOperation().CreateModelAssociation(ctx, metadata.CreateModelAssociation{Spec: asst})
+	case metadata.BundleActionUpdate:
+		data := mapstr.MapStr{"bk_obj_asst_name": asst.AssociationAliasName}
+		_, err = s.core.AssociationOperation().UpdateModelAssociation(ctx, metadata.UpdateOption{Condition: cond.ToMapStr(), Data: data})
+	case metadata.BundleActionDelete:
+		_, err = s.core.AssociationOperation().DeleteModelAssociation(ctx, metadata.DeleteOption{Condition: cond.ToMapStr()})
+	}
+	if nil != err {
+		blog.Errorf("request(%s): it is failed to %s the association (%s) of the bundle, error info is %s", ctx.ReqID, action, asst.AssociationName, err.Error())
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+/*
+ * Tencent is pleased to support the open source community by making 蓝鲸 available.
+ * Copyright (C) 2017-2018 THL A29 Limited, a Tencent company. All rights reserved.
+ * Licensed under the MIT License (the "License"); you may not use this file except
+ * in compliance with the License. You may obtain a copy of the License at
+ * http://opensource.org/licenses/MIT
+ * Unless required by applicable law or agreed to in writing, software distributed under
+ * the License is distributed on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND,
+ * either express or implied. See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package service
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"configcenter/src/common"
+	"configcenter/src/common/blog"
+	"configcenter/src/common/mapstr"
+	"configcenter/src/common/metadata"
+	"configcenter/src/common/util"
+	"configcenter/src/source_controller/coreservice/core"
+)
+
+func (s *coreService) SearchRecycleItem(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.QueryCondition{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.RecycleOperation().SearchRecycleItem(params, inputData)
+}
+
+func (s *coreService) RestoreRecycleItem(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.RecycleOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.RecycleOperation().RestoreRecycleItem(params, inputData)
+}
+
+func (s *coreService) PurgeRecycleItem(params core.ContextParams, pathParams, queryParams ParamsGetter, data mapstr.MapStr) (interface{}, error) {
+	inputData := metadata.RecycleOption{}
+	if err := data.MarshalJSONInto(&inputData); nil != err {
+		return nil, err
+	}
+
+	return s.core.RecycleOperation().PurgeRecycleItem(params, inputData)
+}
+
+// purgeExpiredRecycleItem purge the items which are kept longer than the retention days every hour
+func (s *coreService) purgeExpiredRecycleItem() {
+	for {
+		header := make(http.Header)
+		header.Add(common.BKHTTPOwnerID, common.BKSuperOwnerID)
+		header.Add(common.BKHTTPHeaderUser, common.CCSystemOperatorUserName)
+		params := core.ContextParams{
+			Context:         context.Background(),
+			Header:          header,
+			SupplierAccount: common.BKSuperOwnerID,
+			User:            common.CCSystemOperatorUserName,
+			ReqID:           util.GenerateRID(),
+			Error:           s.err.CreateDefaultCCErrorIf("en"),
+			Lang:            s.language.CreateDefaultCCLanguageIf("en"),
+		}
+
+		before := time.Now().AddDate(0, 0, -s.cfg.Recycle.RetentionDays)
+		result, err := s.core.RecycleOperation().PurgeExpiredRecycleItem(params, before)
+		if nil != err {
+			blog.Errorf("request(%s): purge the recycle items deleted before %s failed, err: %v", params.ReqID, before, err)
+		} else if result.Count > 0 {
+			blog.Infof("request(%s): purged %d recycle items deleted before %s", params.ReqID, result.Count, before)
+		}
+
+		time.Sleep(time.Hour)
+	}
+}
@@ -43,6 +43,7 @@ import (
 	"configcenter/src/source_controller/coreservice/core/instances"
 	"configcenter/src/source_controller/coreservice/core/mainline"
 	"configcenter/src/source_controller/coreservice/core/model"
+	"configcenter/src/source_controller/coreservice/core/recycle"
 	"configcenter/src/storage/dal"
 	"configcenter/src/storage/dal/mongo/local"
 	"configcenter/src/storage/dal/mongo/remote"
@@ -117,9 +118,12 @@ func (s *coreService) SetConfig(cfg options.Config, engin *backbone.Engine, err
 		association.New(db, s),
 		datasynchronize.New(db, s),
 		mainline.New(db),
-		host.New(db, cache),
+		host.New(db, cache, s),
 		auditlog.New(db),
+		recycle.New(db, s, cache),
 	)
+
+	go s.purgeExpiredRecycleItem()
 	return nil
 }
 
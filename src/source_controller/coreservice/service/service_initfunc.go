@@ -63,6 +63,19 @@ func (s *coreService) initAttrUnique() {
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/attributes/unique/{id}", s.DeleteModelAttrUnique, nil)
 }
 
+func (s *coreService) initModelVersion() {
+	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/versions", s.SearchModelVersion, nil)
+	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/versions/diff", s.DiffModelVersion, nil)
+	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/versions/{version}/export", s.ExportModelVersion, nil)
+	s.addAction(http.MethodPost, "/update/model/{bk_obj_id}/versions/rollback", s.RollbackModelVersion, nil)
+}
+
+func (s *coreService) initModelBundle() {
+	s.addAction(http.MethodPost, "/read/model/bundle/export", s.ExportModelBundle, nil)
+	s.addAction(http.MethodPost, "/read/model/bundle/plan", s.PlanModelBundle, nil)
+	s.addAction(http.MethodPost, "/update/model/bundle/apply", s.ApplyModelBundle, nil)
+}
+
 func (s *coreService) initModelInstances() {
 	s.addAction(http.MethodPost, "/create/model/{bk_obj_id}/instance", s.CreateOneModelInstance, nil)
 	s.addAction(http.MethodPost, "/createmany/model/{bk_obj_id}/instance", s.CreateManyModelInstances, nil)
@@ -70,6 +83,8 @@ func (s *coreService) initModelInstances() {
 	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/instances", s.SearchModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance", s.DeleteModelInstances, nil)
 	s.addAction(http.MethodDelete, "/delete/model/{bk_obj_id}/instance/cascade", s.CascadeDeleteModelInstances, nil)
+	s.addAction(http.MethodPost, "/read/model/{bk_obj_id}/instance/delete_impact", s.DeleteModelInstanceImpact, nil)
+	s.addAction(http.MethodPost, "/update/model/{bk_obj_id}/instance/computed", s.RecomputeModelInstances, nil)
 }
 
 func (s *coreService) initAssociationKind() {
@@ -101,6 +116,7 @@ func (s *coreService) initInstanceAssociation() {
 	s.addAction(http.MethodPost, "/create/instanceassociation", s.CreateOneInstanceAssociation, nil)
 	s.addAction(http.MethodPost, "/createmany/instanceassociation", s.CreateManyInstanceAssociation, nil)
 	s.addAction(http.MethodPost, "/read/instanceassociation", s.SearchInstanceAssociation, nil)
+	s.addAction(http.MethodPost, "/read/instanceassociation/mapping_violation", s.SearchInstanceAssociationMappingViolation, nil)
 	s.addAction(http.MethodDelete, "/delete/instanceassociation", s.DeleteInstanceAssociation, nil)
 }
 
@@ -122,6 +138,13 @@ func (s *coreService) host() {
 func (s *coreService) audit() {
 	s.addAction(http.MethodPost, "/create/auditlog", s.CreateAuditLog, nil)
 	s.addAction(http.MethodPost, "/read/auditlog", s.SearchAuditLog, nil)
+	s.addAction(http.MethodPost, "/read/auditlog/history/object/{bk_obj_id}", s.SearchInstanceHistory, nil)
+}
+
+func (s *coreService) initRecycle() {
+	s.addAction(http.MethodPost, "/read/recycle/item", s.SearchRecycleItem, nil)
+	s.addAction(http.MethodPut, "/update/recycle/restore", s.RestoreRecycleItem, nil)
+	s.addAction(http.MethodDelete, "/delete/recycle/purge", s.PurgeRecycleItem, nil)
 }
 
 func (s *coreService) initService() {
@@ -129,6 +152,8 @@ func (s *coreService) initService() {
 	s.initModel()
 	s.initAssociationKind()
 	s.initAttrUnique()
+	s.initModelVersion()
+	s.initModelBundle()
 	s.initModelAssociation()
 	s.initModelInstances()
 	s.initInstanceAssociation()
@@ -136,4 +161,5 @@ func (s *coreService) initService() {
 	s.initMainline()
 	s.host()
 	s.audit()
+	s.initRecycle()
 }
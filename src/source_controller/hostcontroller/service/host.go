@@ -59,6 +59,7 @@ func (s *Service) GetHostByID(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrCommDBSelectFailed)})
 		return
 	}
+	util.RemoveIPRange(result)
 
 	resp.WriteEntity(meta.HostInstanceResult{
 		BaseResp: meta.SuccessBaseResp,
@@ -96,6 +97,9 @@ func (s *Service) GetHosts(req *restful.Request, resp *restful.Response) {
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(common.CCErrHostSelectInst)})
 		return
 	}
+	for _, host := range result {
+		util.RemoveIPRange(host)
+	}
 
 	resp.WriteEntity(meta.GetHostsResult{
 		BaseResp: meta.SuccessBaseResp,
@@ -61,6 +61,9 @@ func (cli *Service) GetObjectByCondition(ctx context.Context, db dal.RDB, defLan
 		blog.Errorf("failed to query the inst , error info %s", err.Error())
 		return err
 	}
+	if results, ok := result.(*[]map[string]interface{}); ok {
+		util.RemoveIPRange(*results...)
+	}
 
 	// translate language for default name
 	if m, ok := defaultNameLanguagePkg[objType]; nil != defLang && ok {
@@ -123,6 +126,9 @@ func (cli *Service) GetObjectByID(ctx context.Context, db dal.RDB, objType strin
 		condition[common.BKObjIDField] = objType
 	}
 	err := db.Table(tName).Find(condition).Fields(fields...).One(ctx, result)
+	if inst, ok := result.(*map[string]interface{}); ok && nil == err {
+		util.RemoveIPRange(*inst)
+	}
 	return err
 }
 
@@ -156,8 +156,20 @@ func (cli *Service) UpdateInstObject(req *restful.Request, resp *restful.Respons
 		return
 	}
 
+	// the addresses of the host ips are stored along with them to be searched by the cidr and ip ranges
+	updateData := data
+	if common.BKInnerObjIDHost == objType {
+		updateData = make(map[string]interface{}, len(data))
+		for key, val := range data {
+			updateData[key] = val
+		}
+		for key, addresses := range util.HostIPRanges(data) {
+			updateData[common.BKIPRangeField+"."+key] = addresses
+		}
+	}
+
 	blog.Infof("update object type:%s,data:%v,condition:%v", objType, data, condition)
-	err = cli.UpdateObjByCondition(ctx, db, objType, data, condition)
+	err = cli.UpdateObjByCondition(ctx, db, objType, updateData, condition)
 	if err != nil {
 		blog.Errorf("update object type:%s,data:%v,condition:%v,error:%v", objType, data, condition, err)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrObjectDBOpErrno, err.Error())})
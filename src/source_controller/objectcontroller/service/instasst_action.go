@@ -96,7 +96,16 @@ func (cli *Service) CreateInstAssociation(req *restful.Request, resp *restful.Re
 		OwnerID:           ownerID,
 	}
 
-	err = db.Table(common.BKTableNameInstAsst).Insert(ctx, data)
+	err = db.Table(common.BKTableNameInstAsst).Insert(ctx, meta.NewInstAsstWithMappingKey(*data, objResult.Mapping))
+	if nil != err && db.IsDuplicatedError(err) {
+		blog.Errorf("create instance association, but exceeds the mapping %s, err: %v", objResult.Mapping, err)
+		errCode := common.CCErrorTopoCreateMultipleInstancesForOneToManyAssociation
+		if objResult.Mapping == meta.OneToOneMapping {
+			errCode = common.CCErrorTopoCreateMultipleInstancesForOneToOneAssociation
+		}
+		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.Error(errCode)})
+		return
+	}
 	if nil != err {
 		blog.Errorf("search object association error :%v", err)
 		resp.WriteError(http.StatusBadRequest, &meta.RespError{Msg: defErr.New(common.CCErrCommDBInsertFailed, err.Error())})
@@ -13,6 +13,7 @@
 package logics
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -147,6 +148,20 @@ func setExcelRowDataByIndex(rowMap mapstr.MapStr, sheet *xlsx.Sheet, rowIndex in
 				cell.SetFloat(floatVal)
 			}
 
+		case common.FieldTypeList:
+			if cellVal := getListText(val, property.Option); "" != cellVal {
+				cell.SetString(cellVal)
+			}
+
+		case common.FieldTypeTable:
+			// the rows of the table are exported as json
+			if rows, ok := val.([]interface{}); ok && 0 != len(rows) {
+				js, err := json.Marshal(rows)
+				if nil == err {
+					cell.SetString(string(js))
+				}
+			}
+
 		default:
 			switch val.(type) {
 			case string:
@@ -255,6 +270,24 @@ func getDataFromByExcelRow(row *xlsx.Row, rowIndex int, fields map[string]Proper
 			} else {
 				blog.Debug("get excel cell value error, field:%s, value:%s, error:%s", fieldName, host[fieldName], err.Error())
 			}
+		case common.FieldTypeList:
+			listVal, err := getListValue(cell.Value, field.Option)
+			if nil != err {
+				errMsg = append(errMsg, defLang.Languagef("web_excel_row_handle_error", fieldName, (cellIndex+1)))
+				blog.Errorf("%d row %s column get list content error:%s", rowIndex+1, fieldName, err.Error())
+				continue
+			}
+			host[fieldName] = listVal
+		case common.FieldTypeTable:
+			rows := make([]interface{}, 0)
+			if err := json.Unmarshal([]byte(cell.Value), &rows); nil != err {
+				errMsg = append(errMsg, defLang.Languagef("web_excel_row_handle_error", fieldName, (cellIndex+1)))
+				blog.Errorf("%d row %s column get table content error:%s", rowIndex+1, fieldName, err.Error())
+				continue
+			}
+			host[fieldName] = rows
+		case common.FieldTypeIP:
+			host[fieldName] = strings.TrimSpace(cell.Value)
 		default:
 			if util.IsStrProperty(field.PropertyType) {
 				host[fieldName] = cell.Value
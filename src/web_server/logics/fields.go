@@ -259,6 +259,9 @@ func getPropertyTypeAliasName(propertyType string, defLang lang.DefaultCCLanguag
 	case common.FieldTypeMultiAsst:
 	case common.FieldTypeBool:
 	case common.FieldTypeTimeZone:
+	case common.FieldTypeList:
+	case common.FieldTypeTable:
+	case common.FieldTypeIP:
 
 	}
 	if "" == name {
@@ -17,6 +17,7 @@ import (
 	"strings"
 
 	"configcenter/src/common"
+	"configcenter/src/common/metadata"
 
 	"github.com/rentiansheng/xlsx"
 )
@@ -115,6 +116,44 @@ func getEnumNames(items []interface{}) []string {
 	return names
 }
 
+// getListText get the text of the list value, the items are separated by commas and the enum items are shown by name
+func getListText(val interface{}, option interface{}) string {
+	items, ok := val.([]interface{})
+	if !ok {
+		return ""
+	}
+	listOption, err := metadata.ParseListOption(option)
+	if nil != err {
+		return ""
+	}
+	enumItems, _ := listOption.ItemOption.([]interface{})
+	texts := make([]string, 0)
+	for _, item := range items {
+		if listOption.ItemType == common.FieldTypeEnum {
+			texts = append(texts, getEnumNameByID(fmt.Sprintf("%v", item), enumItems))
+			continue
+		}
+		texts = append(texts, fmt.Sprintf("%v", item))
+	}
+	return strings.Join(texts, common.ListValueSeparator)
+}
+
+// getListValue get the list value from the text, the enum items are converted from name to id
+func getListValue(text string, option interface{}) ([]interface{}, error) {
+	listOption, err := metadata.ParseListOption(option)
+	if nil != err {
+		return nil, err
+	}
+	items := listOption.ParseText(text)
+	if listOption.ItemType == common.FieldTypeEnum {
+		enumItems, _ := listOption.ItemOption.([]interface{})
+		for index, item := range items {
+			items[index] = getEnumIDByName(fmt.Sprintf("%v", item), enumItems)
+		}
+	}
+	return items, nil
+}
+
 // getHeaderCellGeneralStyle get excel header general style by C6EFCE,000000
 func getHeaderCellGeneralStyle() *xlsx.Style {
 	return getCellStyle(common.ExcelHeaderOtherRowColor, common.ExcelHeaderOtherRowFontColor)